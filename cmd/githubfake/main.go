@@ -0,0 +1,107 @@
+// cmd/githubfake/main.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github-data-fetcher/internal/githubfake"
+)
+
+// githubfake serves a fake GitHub REST API seeded with synthetic repositories, so the service
+// can run end-to-end without network access or a token: point GITHUB_BASE_URL at it.
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	repos := flag.String("repos", "octo-org/hello-world", "comma-separated owner/name repositories to serve")
+	commits := flag.Int("commits", 150, "number of historical commits per repository")
+	pushInterval := flag.Duration("push-interval", 0, "if set, push a new commit to every repository at this interval")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	if err := run(logger, *addr, strings.Split(*repos, ","), *commits, *pushInterval); err != nil {
+		logger.Error("Fake GitHub API stopped with error", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(logger *slog.Logger, addr string, repos []string, commits int, pushInterval time.Duration) error {
+	fake := githubfake.New()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var seeded [][2]string
+	for _, r := range repos {
+		owner, name, ok := strings.Cut(strings.TrimSpace(r), "/")
+		if !ok || owner == "" || name == "" {
+			return fmt.Errorf("invalid repository %q, expected 'owner/name'", r)
+		}
+		fake.AddRepository(githubfake.Repository{Owner: owner, Name: name, Language: "Go", CreatedAt: now.AddDate(-1, 0, 0)})
+		for i := 0; i < commits; i++ {
+			if err := fake.AddCommits(owner, name, syntheticCommit(i, now.Add(-time.Duration(commits-i)*time.Hour))); err != nil {
+				return err
+			}
+		}
+		seeded = append(seeded, [2]string{owner, name})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if pushInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(pushInterval)
+			defer ticker.Stop()
+			for n := commits; ; n++ {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					for _, r := range seeded {
+						_ = fake.AddCommits(r[0], r[1], syntheticCommit(n, time.Time{}))
+					}
+					logger.Info("Pushed new commits", "repositories", len(seeded))
+				}
+			}
+		}()
+	}
+
+	server := &http.Server{Addr: addr, Handler: fake}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("Fake GitHub API listening", "address", addr, "repositories", len(seeded), "commits_per_repository", commits)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// syntheticCommit returns the i-th commit of a seeded repository; a zero date means now.
+func syntheticCommit(i int, date time.Time) githubfake.Commit {
+	authors := []struct{ name, email, login string }{
+		{"Mona Lisa", "mona@example.com", "octocat"},
+		{"Hubot", "hubot@example.com", "hubot"},
+		{"Jane Doe", "jane@example.com", ""},
+	}
+	a := authors[i%len(authors)]
+	return githubfake.Commit{
+		Message:     fmt.Sprintf("Synthetic commit #%d", i+1),
+		AuthorName:  a.name,
+		AuthorEmail: a.email,
+		AuthorLogin: a.login,
+		Date:        date,
+		Verified:    i%2 == 0,
+	}
+}
@@ -6,8 +6,6 @@ package main
 import (
 	"context"
 	"log/slog"
-	"net/http"
-	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -23,6 +21,8 @@ import (
 
 	"github-data-fetcher/internal/database"
 	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/githubfake"
+	"github-data-fetcher/internal/model"
 	"github-data-fetcher/internal/syncer"
 )
 
@@ -74,43 +74,34 @@ func TestSyncer_Integration(t *testing.T) {
 	dbpool, teardown := setupTestDatabase(ctx, t)
 	defer teardown()
 
-	// Setup a mock GitHub API server
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/repos/test-owner/test-repo":
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"id": 123, "owner": {"login": "test-owner"}, "name": "test-repo"}`))
-		case "/repos/test-owner/test-repo/commits":
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`[
-				{"sha": "abc", "commit": {"author": {"name": "tester", "email": "t@t.com", "date": "2024-01-01T12:00:00Z"}, "message": "feat: new feature"}, "html_url": "url1"},
-				{"sha": "def", "commit": {"author": {"name": "tester", "email": "t@t.com", "date": "2024-01-02T12:00:00Z"}, "message": "fix: a bug"}, "html_url": "url2"}
-			]`))
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
-	})
-	server := httptest.NewServer(handler)
+	// Setup a fake GitHub API server
+	fake, server := githubfake.NewServer()
 	defer server.Close()
+	fake.AddRepository(githubfake.Repository{ID: 123, Owner: "test-owner", Name: "test-repo"})
+	err := fake.AddCommits("test-owner", "test-repo",
+		githubfake.Commit{SHA: "abc", AuthorName: "tester", AuthorEmail: "t@t.com", Message: "feat: new feature", Date: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		githubfake.Commit{SHA: "def", AuthorName: "tester", AuthorEmail: "t@t.com", Message: "fix: a bug", Date: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)},
+	)
+	require.NoError(t, err)
 
-	// Create a github client pointing to the mock server
+	// Create a github client pointing to the fake server
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	ghClient := github.NewClient("", logger)
-	ghClient.OverrideBaseURL(server.URL) // Simplified for test; real one is more complex
+	ghClient, err := github.NewClient("", logger).WithBaseURL(server.URL)
+	require.NoError(t, err)
 
 	// Create the syncer with the REAL database pool and mock GitHub client
-	appSyncer, err := syncer.NewSyncer(dbpool, ghClient, logger, []string{"test-owner/test-repo"}, time.Hour, time.Time{})
+	appSyncer, err := syncer.NewSyncer(dbpool, map[string]syncer.Source{github.DefaultHost: ghClient}, logger, []string{"test-owner/test-repo"}, time.Hour, time.Time{})
 	require.NoError(t, err)
 
 	// --- ACT ---
 	// Run a single sync cycle. We call the internal method directly for this test.
-	err = appSyncer.SyncRepoInTransaction(ctx, syncer.RepoIdentifier{Owner: "test-owner", Name: "test-repo"})
+	err = appSyncer.SyncRepoInTransaction(ctx, syncer.RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"})
 	require.NoError(t, err)
 
 	// --- ASSERT ---
 	// Query the database directly to verify the data was inserted correctly.
 	dbQuerier := database.New(dbpool)
-	repo, err := dbQuerier.GetRepositoryByOwnerAndName(ctx, database.GetRepositoryByOwnerAndNameParams{Owner: "test-owner", Name: "test-repo"})
+	repo, err := dbQuerier.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"})
 	require.NoError(t, err)
 	assert.Equal(t, int64(123), repo.GithubRepoID)
 	assert.Equal(t, "test-repo", repo.Name)
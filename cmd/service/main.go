@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -22,6 +24,8 @@ import (
 	"github-data-fetcher/internal/config"
 	"github-data-fetcher/internal/database"
 	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/gitlab"
+	"github-data-fetcher/internal/gitmirror"
 	"github-data-fetcher/internal/syncer"
 )
 
@@ -65,13 +69,39 @@ func run() error {
 	}
 	logger.Info("Database migrations applied successfully")
 
+	ghClients, err := newGithubClients(cfg, dbpool, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create github clients: %w", err)
+	}
+	glClient, err := gitlab.NewClient(cfg.GitlabBaseURL, cfg.GitlabToken, logger)
+	if err != nil {
+		return fmt.Errorf("invalid GITLAB_BASE_URL: %w", err)
+	}
+	if _, ok := ghClients[glClient.Host()]; ok {
+		return fmt.Errorf("GitLab host %q is also configured as a GitHub host", glClient.Host())
+	}
+
+	sources := make(map[string]syncer.Source, len(ghClients)+1)
+	for host, client := range ghClients {
+		sources[host] = client
+	}
+	if cfg.GithubCommitsBackend == config.CommitsBackendGit {
+		for host, mirror := range newGitMirrors(cfg, logger) {
+			sources[host] = syncer.WithCommitSource(sources[host], mirror)
+		}
+	}
+	sources[glClient.Host()] = glClient
+	appSyncer, err := syncer.NewSyncer(dbpool, sources, logger, cfg.ReposToSync, cfg.SyncInterval, cfg.DefaultSyncSinceTime, syncer.WithBranches(cfg.BranchPatterns), syncer.WithCommitFiles(cfg.CommitFilesBudget), syncer.WithPullRequestDetails(cfg.PullRequestDetailsBudget), syncer.WithIssueComments(cfg.IssueCommentsBudget), syncer.WithStargazers(cfg.StargazerBackfill), syncer.WithDiscoveryFilter(syncer.DiscoveryFilter{
+		Archived: cfg.DiscoverArchived,
+		Forks:    cfg.DiscoverForks,
+		Private:  cfg.DiscoverPrivate,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to create syncer: %w", err)
+	}
+
 	// --- Service 1: The Syncer ---
 	g.Go(func() error {
-		ghClient := github.NewClient(cfg.GithubToken, logger)
-		appSyncer, err := syncer.NewSyncer(dbpool, ghClient, logger, cfg.ReposToSync, cfg.SyncInterval, cfg.DefaultSyncSinceTime)
-		if err != nil {
-			return fmt.Errorf("failed to create syncer: %w", err)
-		}
 		appSyncer.Start(ctx)
 		logger.Info("Syncer service has stopped.")
 		return nil
@@ -80,7 +110,7 @@ func run() error {
 	// --- Service 2: The API Server ---
 	g.Go(func() error {
 		dbQuerier := database.New(dbpool)
-		router := api.NewRouter(dbQuerier, logger)
+		router := api.NewRouter(dbQuerier, ghClients[github.DefaultHost], appSyncer, appSyncer, cfg.AdminToken, logger)
 		server := &http.Server{
 			Addr:         ":8080",
 			Handler:      router,
@@ -114,6 +144,85 @@ func run() error {
 	return g.Wait()
 }
 
+// newGithubClients builds one GitHub client per host: github.com, authenticated using the mode
+// selected in cfg, and every configured GitHub Enterprise host.
+func newGithubClients(cfg *config.Config, dbpool *pgxpool.Pool, logger *slog.Logger) (map[string]*github.Client, error) {
+	validators := syncer.NewValidatorStore(database.New(dbpool))
+	common := []github.Option{github.WithValidatorStore(validators)}
+	if cfg.GithubCommitsBackend == config.CommitsBackendGraphQL {
+		common = append(common, github.WithGraphQLCommits())
+		logger.Info("Fetching commit history through the GitHub GraphQL API")
+	}
+	opts := append([]github.Option{}, common...)
+
+	if cfg.GithubAuthMode == config.AuthModeApp {
+		key, err := os.ReadFile(cfg.GithubAppPrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+		baseURL := github.DefaultBaseURL
+		if cfg.GithubBaseURL != "" {
+			baseURL = cfg.GithubBaseURL
+		}
+		ts, err := github.NewAppTokenSource(cfg.GithubAppID, cfg.GithubAppInstallationID, key, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, github.WithTokenSource(ts))
+		logger.Info("Authenticating to GitHub as an App installation", "app_id", cfg.GithubAppID, "installation_id", cfg.GithubAppInstallationID)
+	} else if len(cfg.GithubTokens) > 0 {
+		opts = append(opts, github.WithTokenPool(cfg.GithubTokens))
+		logger.Info("Authenticating to GitHub with a token pool", "tokens", len(cfg.GithubTokens))
+	}
+
+	client := github.NewClient(cfg.GithubToken, logger, opts...)
+	if cfg.GithubBaseURL != "" {
+		if _, err := client.WithBaseURL(cfg.GithubBaseURL); err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_BASE_URL: %w", err)
+		}
+		logger.Info("Using custom GitHub API base URL", "base_url", cfg.GithubBaseURL)
+	}
+	clients := map[string]*github.Client{github.DefaultHost: client}
+
+	for _, eh := range cfg.EnterpriseHosts {
+		client, err := github.NewClient(eh.Token, logger, common...).WithEnterpriseURLs(eh.BaseURL, eh.UploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URLs for GitHub Enterprise host %q: %w", eh.Host, err)
+		}
+		clients[eh.Host] = client
+		logger.Info("Configured GitHub Enterprise host", "host", eh.Host, "base_url", eh.BaseURL)
+	}
+
+	return clients, nil
+}
+
+// newGitMirrors builds one git mirror per GitHub host, cloning into a directory per host under
+// GIT_MIRROR_DIR. The github.com remote can be overridden with GIT_MIRROR_REMOTE_URL.
+func newGitMirrors(cfg *config.Config, logger *slog.Logger) map[string]*gitmirror.Mirror {
+	remote := "https://github.com/"
+	if cfg.GitMirrorRemoteURL != "" {
+		remote = cfg.GitMirrorRemoteURL
+	}
+	token := cfg.GithubToken
+	if token == "" && len(cfg.GithubTokens) > 0 {
+		token = cfg.GithubTokens[0]
+	}
+	mirrors := map[string]*gitmirror.Mirror{
+		github.DefaultHost: gitmirror.New(filepath.Join(cfg.GitMirrorDir, github.DefaultHost), remote, logger, gitmirror.WithToken(token)),
+	}
+
+	for _, eh := range cfg.EnterpriseHosts {
+		remote := eh.BaseURL
+		if u, err := url.Parse(eh.BaseURL); err == nil && u.Host != "" {
+			remote = u.Scheme + "://" + u.Host + "/"
+		}
+		mirrors[eh.Host] = gitmirror.New(filepath.Join(cfg.GitMirrorDir, eh.Host), remote, logger, gitmirror.WithToken(eh.Token))
+	}
+
+	logger.Info("Fetching commit history from local git mirrors", "dir", cfg.GitMirrorDir)
+	return mirrors
+}
+
 func runMigrations(dbURL string) error {
 	m, err := migrate.New("file://migrations", dbURL)
 	if err != nil {
@@ -2,30 +2,67 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 
 	"github-data-fetcher/internal/database"
+	custom_errors "github-data-fetcher/internal/errors"
+	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/gitlab"
+	"github-data-fetcher/internal/model"
 )
 
+// QuotaReporter exposes the rate limit quota of the GitHub tokens in use.
+type QuotaReporter interface {
+	Quotas() []github.TokenQuota
+}
+
+// RepoValidator checks entries added through the admin API the way REPOS_TO_SYNC is checked.
+type RepoValidator interface {
+	// ValidateEntry returns the entry in the form it is tracked in, or why it cannot be synced.
+	ValidateEntry(entry string) (string, error)
+}
+
+// SyncScheduler queues on-demand syncs of tracked repositories.
+type SyncScheduler interface {
+	// EnqueueSync queues a sync of the repository a REPOS_TO_SYNC entry names and returns its job.
+	EnqueueSync(ctx context.Context, entry string) (database.SyncJob, error)
+}
+
 // Handler is the container for API dependencies.
 type Handler struct {
-	db     database.Querier
-	logger *slog.Logger
+	db         database.Querier
+	quotas     QuotaReporter
+	repos      RepoValidator
+	syncs      SyncScheduler
+	adminToken string
+	logger     *slog.Logger
 }
 
-// NewRouter creates and configures a new chi router with all API routes.
-func NewRouter(db database.Querier, logger *slog.Logger) http.Handler {
+// NewRouter creates and configures a new chi router with all API routes. The admin routes are
+// only served if adminToken is set, to requests that carry it as a bearer token. On-demand syncs,
+// which spend API quota, and the token quotas then require the token too.
+func NewRouter(db database.Querier, quotas QuotaReporter, repos RepoValidator, syncs SyncScheduler, adminToken string, logger *slog.Logger) http.Handler {
 	h := &Handler{
-		db:     db,
-		logger: logger,
+		db:         db,
+		quotas:     quotas,
+		repos:      repos,
+		syncs:      syncs,
+		adminToken: adminToken,
+		logger:     logger,
 	}
 
 	r := chi.NewRouter()
@@ -40,8 +77,29 @@ func NewRouter(db database.Querier, logger *slog.Logger) http.Handler {
 	// API Routes
 	r.Get("/health", h.healthCheck)
 	r.Route("/v1", func(r chi.Router) {
+		r.Get("/repos", h.listRepositories)
+		r.Get("/repos/{owner}/{name}", h.getRepository)
+		r.Get("/repos/{owner}/{name}/branches", h.getBranches)
 		r.Get("/repos/{owner}/{name}/commits", h.getCommits)
+		r.Get("/repos/{owner}/{name}/history-rewrites", h.getHistoryRewrites)
+		r.Get("/repos/{owner}/{name}/issues", h.getIssues)
+		r.Get("/repos/{owner}/{name}/labels", h.getLabels)
+		r.Get("/repos/{owner}/{name}/pulls", h.getPullRequests)
+		r.Get("/repos/{owner}/{name}/releases", h.getReleases)
+		r.Get("/repos/{owner}/{name}/stats/growth", h.getGrowth)
 		r.Get("/repos/{owner}/{name}/stats/top-committers", h.getTopCommitters)
+		r.Get("/repos/{owner}/{name}/stats/workflows", h.getWorkflowStats)
+		r.With(h.adminOnly).Post("/repos/{owner}/{name}/sync", h.syncRepository)
+		r.Get("/sync-jobs/{id}", h.getSyncJob)
+		r.With(h.adminOnly).Get("/github/quotas", h.getTokenQuotas)
+		if adminToken != "" {
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(h.requireAdmin)
+				r.Get("/repos", h.listTrackedRepositories)
+				r.Post("/repos", h.trackRepository)
+				r.Delete("/repos", h.untrackRepository)
+			})
+		}
 	})
 
 	return r
@@ -52,27 +110,253 @@ func (h *Handler) healthCheck(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-// getCommits handles the request to retrieve commits for a repository.
-// GET /v1/repos/{owner}/{name}/commits
-func (h *Handler) getCommits(w http.ResponseWriter, r *http.Request) {
-	owner := chi.URLParam(r, "owner")
-	name := chi.URLParam(r, "name")
+// lookupRepository resolves the {owner}/{name} route parameters and the optional 'provider'
+// (default github) and 'host' (default github.com, or gitlab.com for GitLab) query parameters
+// to a stored repository. GitLab owners containing subgroups are passed URL-encoded, e.g.
+// group%2Fsubgroup. On failure it writes the error response and returns false.
+func (h *Handler) lookupRepository(w http.ResponseWriter, r *http.Request) (database.Repository, bool) {
+	arg, ok := repositoryParams(w, r)
+	if !ok {
+		return database.Repository{}, false
+	}
 
-	repo, err := h.db.GetRepositoryByOwnerAndName(r.Context(), database.GetRepositoryByOwnerAndNameParams{
-		Owner: owner,
-		Name:  name,
-	})
+	repo, err := h.db.GetRepositoryByProviderHostOwnerAndName(r.Context(), arg)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			respondWithError(w, http.StatusNotFound, "Repository not found")
-			return
+			return database.Repository{}, false
 		}
 		h.logger.Error("Failed to get repository", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return database.Repository{}, false
+	}
+	return repo, true
+}
+
+// repositoryParams resolves the parameters lookupRepository describes. On failure it writes the
+// error response and returns false.
+func repositoryParams(w http.ResponseWriter, r *http.Request) (database.GetRepositoryByProviderHostOwnerAndNameParams, bool) {
+	provider := r.URL.Query().Get("provider")
+	host := r.URL.Query().Get("host")
+	switch provider {
+	case "", model.ProviderGitHub:
+		provider = model.ProviderGitHub
+		if host == "" {
+			host = github.DefaultHost
+		}
+	case model.ProviderGitLab:
+		if host == "" {
+			host = gitlab.DefaultHost
+		}
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid 'provider' parameter. Must be 'github' or 'gitlab'.")
+		return database.GetRepositoryByProviderHostOwnerAndNameParams{}, false
+	}
+
+	owner, err := url.PathUnescape(chi.URLParam(r, "owner"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid repository owner")
+		return database.GetRepositoryByProviderHostOwnerAndNameParams{}, false
+	}
+	return database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: provider,
+		Host:     host,
+		Owner:    owner,
+		Name:     chi.URLParam(r, "name"),
+	}, true
+}
+
+// repositoryResponse is a stored repository and the progress of its initial commit backfill.
+// Complete is false while a backfill is under way or was interrupted, i.e. the stored history
+// is partial. Backfill is null for repositories synced from sources that cannot resume.
+type repositoryResponse struct {
+	database.Repository
+	Complete  bool                                          `json:"complete"`
+	Backfill  *database.SyncCheckpoint                      `json:"backfill"`
+	Languages []database.GetRepositoryLanguagesByRepoIDsRow `json:"languages"`
+	Topics    []string                                      `json:"topics"`
+}
+
+// repositorySummary is a stored repository with its languages, largest first, and its topics.
+type repositorySummary struct {
+	database.Repository
+	Languages []database.GetRepositoryLanguagesByRepoIDsRow `json:"languages"`
+	Topics    []string                                      `json:"topics"`
+}
+
+// listRepositories handles the request to list the stored repositories, optionally only those
+// tagged with a topic or with code in a language.
+// GET /v1/repos?topic=T&language=L
+func (h *Handler) listRepositories(w http.ResponseWriter, r *http.Request) {
+	repos, err := h.db.ListRepositories(r.Context(), database.ListRepositoriesParams{
+		Topic:    r.URL.Query().Get("topic"),
+		Language: r.URL.Query().Get("language"),
+	})
+	if err != nil {
+		h.logger.Error("Failed to list repositories", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	ids := make([]int64, len(repos))
+	for i, repo := range repos {
+		ids[i] = repo.ID
+	}
+	languages, topics, ok := h.getLanguagesAndTopics(w, r, ids)
+	if !ok {
+		return
+	}
+
+	resp := make([]repositorySummary, len(repos))
+	for i, repo := range repos {
+		resp[i] = repositorySummary{Repository: repo, Languages: languages[repo.ID], Topics: topics[repo.ID]}
+		if resp[i].Languages == nil {
+			resp[i].Languages = []database.GetRepositoryLanguagesByRepoIDsRow{}
+		}
+		if resp[i].Topics == nil {
+			resp[i].Topics = []string{}
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// getLanguagesAndTopics returns the languages and topics of repositories, keyed by repository ID.
+// On failure it writes the error response and returns false.
+func (h *Handler) getLanguagesAndTopics(w http.ResponseWriter, r *http.Request, repoIDs []int64) (map[int64][]database.GetRepositoryLanguagesByRepoIDsRow, map[int64][]string, bool) {
+	languages, err := h.db.GetRepositoryLanguagesByRepoIDs(r.Context(), repoIDs)
+	if err != nil {
+		h.logger.Error("Failed to get repository languages", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return nil, nil, false
+	}
+	topics, err := h.db.GetRepositoryTopicsByRepoIDs(r.Context(), repoIDs)
+	if err != nil {
+		h.logger.Error("Failed to get repository topics", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return nil, nil, false
+	}
+
+	languagesByRepo := make(map[int64][]database.GetRepositoryLanguagesByRepoIDsRow)
+	for _, language := range languages {
+		languagesByRepo[language.RepositoryID] = append(languagesByRepo[language.RepositoryID], language)
+	}
+	topicsByRepo := make(map[int64][]string)
+	for _, topic := range topics {
+		topicsByRepo[topic.RepositoryID] = append(topicsByRepo[topic.RepositoryID], topic.Topic)
+	}
+	return languagesByRepo, topicsByRepo, true
+}
+
+// getRepository handles the request to retrieve a repository.
+// GET /v1/repos/{owner}/{name}?provider=P&host=H
+func (h *Handler) getRepository(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
 		return
 	}
 
-	commits, err := h.db.GetCommitsByRepoID(r.Context(), repo.ID)
+	resp := repositoryResponse{Repository: repo, Complete: true}
+	checkpoint, err := h.db.GetSyncCheckpoint(r.Context(), repo.ID)
+	if err == nil {
+		resp.Complete = checkpoint.CompletedAt.Valid
+		resp.Backfill = &checkpoint
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		h.logger.Error("Failed to get sync checkpoint", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	languages, topics, ok := h.getLanguagesAndTopics(w, r, []int64{repo.ID})
+	if !ok {
+		return
+	}
+	resp.Languages, resp.Topics = languages[repo.ID], topics[repo.ID]
+	if resp.Languages == nil {
+		resp.Languages = []database.GetRepositoryLanguagesByRepoIDsRow{}
+	}
+	if resp.Topics == nil {
+		resp.Topics = []string{}
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// flagIncompleteHistory sets the X-Backfill-Incomplete header when the repository's commit
+// history is still being backfilled, so results computed from it are partial.
+func (h *Handler) flagIncompleteHistory(w http.ResponseWriter, r *http.Request, repoID int64) {
+	checkpoint, err := h.db.GetSyncCheckpoint(r.Context(), repoID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			h.logger.Warn("Failed to get sync checkpoint", "error", err)
+		}
+		return
+	}
+	if !checkpoint.CompletedAt.Valid {
+		w.Header().Set("X-Backfill-Incomplete", "true")
+	}
+}
+
+// getBranches handles the request to list the tracked branches of a repository with their heads.
+// GET /v1/repos/{owner}/{name}/branches?provider=P&host=H
+func (h *Handler) getBranches(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+
+	branches, err := h.db.GetBranchesByRepoID(r.Context(), repo.ID)
+	if err != nil {
+		h.logger.Error("Failed to get branches", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if branches == nil {
+		branches = []database.Branch{}
+	}
+
+	respondWithJSON(w, http.StatusOK, branches)
+}
+
+// getCommits handles the request to retrieve commits for a repository. Commits a force-push
+// removed from the default branch are only included with include_unreachable=true. With
+// branch=NAME, only the commits on that tracked branch are returned, wherever else they are.
+// GET /v1/repos/{owner}/{name}/commits?branch=NAME&include_unreachable=B&provider=P&host=H
+func (h *Handler) getCommits(w http.ResponseWriter, r *http.Request) {
+	includeUnreachable := false
+	if v := r.URL.Query().Get("include_unreachable"); v != "" {
+		var err error
+		includeUnreachable, err = strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid 'include_unreachable' parameter. Must be 'true' or 'false'.")
+			return
+		}
+	}
+
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+	h.flagIncompleteHistory(w, r, repo.ID)
+
+	var commits []database.Commit
+	var err error
+	if name := r.URL.Query().Get("branch"); name != "" {
+		var branch database.Branch
+		branch, err = h.db.GetBranch(r.Context(), database.GetBranchParams{RepositoryID: repo.ID, Name: name})
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Branch not tracked")
+			return
+		} else if err != nil {
+			h.logger.Error("Failed to get branch", "error", err)
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		commits, err = h.db.GetCommitsByBranchID(r.Context(), branch.ID)
+	} else {
+		commits, err = h.db.GetCommitsByRepoID(r.Context(), database.GetCommitsByRepoIDParams{
+			RepositoryID:       repo.ID,
+			IncludeUnreachable: includeUnreachable,
+		})
+	}
 	if err != nil {
 		h.logger.Error("Failed to get commits", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Internal server error")
@@ -82,12 +366,311 @@ func (h *Handler) getCommits(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, commits)
 }
 
+// getHistoryRewrites handles the request to list the force-pushes detected on a repository's
+// default branch, newest first.
+// GET /v1/repos/{owner}/{name}/history-rewrites?provider=P&host=H
+func (h *Handler) getHistoryRewrites(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+
+	rewrites, err := h.db.GetHistoryRewritesByRepoID(r.Context(), repo.ID)
+	if err != nil {
+		h.logger.Error("Failed to get history rewrites", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if rewrites == nil {
+		rewrites = []database.HistoryRewrite{}
+	}
+
+	respondWithJSON(w, http.StatusOK, rewrites)
+}
+
+// parseTimeParam parses the optional RFC3339 query parameter name, which is NULL if unset. On
+// failure it writes the error response and returns false.
+func parseTimeParam(w http.ResponseWriter, r *http.Request, name string) (pgtype.Timestamptz, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return pgtype.Timestamptz{}, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid '"+name+"' parameter. Must be an RFC3339 time, e.g. 2024-01-01T00:00:00Z.")
+		return pgtype.Timestamptz{}, false
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}, true
+}
+
+// pullRequestResponse is a stored pull request with its reviews, oldest first.
+type pullRequestResponse struct {
+	database.PullRequest
+	Reviews []database.PullRequestReview `json:"reviews"`
+}
+
+// getPullRequests handles the request to list the pull requests of a repository, newest first.
+// state is open, closed (without merging), merged or all (the default). since and until are
+// RFC3339 times bounding the timestamp selected by date: created (the default), updated, merged
+// or closed.
+// GET /v1/repos/{owner}/{name}/pulls?state=S&since=T&until=T&date=D&provider=P&host=H
+func (h *Handler) getPullRequests(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	arg := database.GetPullRequestsByRepoIDParams{DateField: query.Get("date")}
+	switch state := query.Get("state"); state {
+	case "", "all":
+	case model.PullRequestOpen, model.PullRequestClosed, model.PullRequestMerged:
+		arg.State = state
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid 'state' parameter. Must be 'open', 'closed', 'merged' or 'all'.")
+		return
+	}
+	switch arg.DateField {
+	case "", "created", "updated", "merged", "closed":
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid 'date' parameter. Must be 'created', 'updated', 'merged' or 'closed'.")
+		return
+	}
+	var ok bool
+	if arg.Since, ok = parseTimeParam(w, r, "since"); !ok {
+		return
+	}
+	if arg.Until, ok = parseTimeParam(w, r, "until"); !ok {
+		return
+	}
+
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+	arg.RepositoryID = repo.ID
+
+	pulls, err := h.db.GetPullRequestsByRepoID(r.Context(), arg)
+	if err != nil {
+		h.logger.Error("Failed to get pull requests", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	ids := make([]int64, len(pulls))
+	for i, pr := range pulls {
+		ids[i] = pr.ID
+	}
+	reviews, err := h.db.GetPullRequestReviewsByPullRequestIDs(r.Context(), ids)
+	if err != nil {
+		h.logger.Error("Failed to get pull request reviews", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	reviewsByPull := make(map[int64][]database.PullRequestReview)
+	for _, review := range reviews {
+		reviewsByPull[review.PullRequestID] = append(reviewsByPull[review.PullRequestID], review)
+	}
+
+	resp := make([]pullRequestResponse, len(pulls))
+	for i, pr := range pulls {
+		resp[i] = pullRequestResponse{PullRequest: pr, Reviews: reviewsByPull[pr.ID]}
+		if resp[i].Reviews == nil {
+			resp[i].Reviews = []database.PullRequestReview{}
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// getIssues handles the request to list the issues of a repository, newest first. Pull requests
+// are not issues here. state is open, closed or all (the default), and label restricts the list to
+// issues carrying that label. since and until are RFC3339 times bounding the timestamp selected by
+// date: created (the default), updated or closed.
+// GET /v1/repos/{owner}/{name}/issues?state=S&label=L&since=T&until=T&date=D&provider=P&host=H
+func (h *Handler) getIssues(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	arg := database.GetIssuesByRepoIDParams{Label: query.Get("label"), DateField: query.Get("date")}
+	switch state := query.Get("state"); state {
+	case "", "all":
+	case "open", "closed":
+		arg.State = state
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid 'state' parameter. Must be 'open', 'closed' or 'all'.")
+		return
+	}
+	switch arg.DateField {
+	case "", "created", "updated", "closed":
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid 'date' parameter. Must be 'created', 'updated' or 'closed'.")
+		return
+	}
+	var ok bool
+	if arg.Since, ok = parseTimeParam(w, r, "since"); !ok {
+		return
+	}
+	if arg.Until, ok = parseTimeParam(w, r, "until"); !ok {
+		return
+	}
+
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+	arg.RepositoryID = repo.ID
+
+	issues, err := h.db.GetIssuesByRepoID(r.Context(), arg)
+	if err != nil {
+		h.logger.Error("Failed to get issues", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if issues == nil {
+		issues = []database.Issue{}
+	}
+
+	respondWithJSON(w, http.StatusOK, issues)
+}
+
+// getLabels handles the request to list the labels defined in a repository, sorted by name.
+// GET /v1/repos/{owner}/{name}/labels?provider=P&host=H
+func (h *Handler) getLabels(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+
+	labels, err := h.db.GetLabelsByRepoID(r.Context(), repo.ID)
+	if err != nil {
+		h.logger.Error("Failed to get labels", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if labels == nil {
+		labels = []database.Label{}
+	}
+
+	respondWithJSON(w, http.StatusOK, labels)
+}
+
+// releaseResponse is a stored release with its assets, sorted by name, and their total downloads.
+type releaseResponse struct {
+	database.GetReleasesByRepoIDRow
+	DownloadCount int64                   `json:"download_count"`
+	Assets        []database.ReleaseAsset `json:"assets"`
+}
+
+// getReleases handles the request to list the releases of a repository, newest first, including
+// drafts and prereleases.
+// GET /v1/repos/{owner}/{name}/releases?provider=P&host=H
+func (h *Handler) getReleases(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+
+	releases, err := h.db.GetReleasesByRepoID(r.Context(), repo.ID)
+	if err != nil {
+		h.logger.Error("Failed to get releases", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	ids := make([]int64, len(releases))
+	for i, release := range releases {
+		ids[i] = release.ID
+	}
+	assets, err := h.db.GetReleaseAssetsByReleaseIDs(r.Context(), ids)
+	if err != nil {
+		h.logger.Error("Failed to get release assets", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	assetsByRelease := make(map[int64][]database.ReleaseAsset)
+	for _, asset := range assets {
+		assetsByRelease[asset.ReleaseID] = append(assetsByRelease[asset.ReleaseID], asset)
+	}
+
+	resp := make([]releaseResponse, len(releases))
+	for i, release := range releases {
+		resp[i] = releaseResponse{GetReleasesByRepoIDRow: release, Assets: assetsByRelease[release.ID]}
+		if resp[i].Assets == nil {
+			resp[i].Assets = []database.ReleaseAsset{}
+		}
+		for _, asset := range resp[i].Assets {
+			resp[i].DownloadCount += asset.DownloadCount
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// growthResponse is the growth series of a repository: the counters it had at the end of each
+// interval, as recorded by the syncs, and the stars given in each interval according to the
+// backfilled stargazers, which go back further.
+type growthResponse struct {
+	Interval   string                            `json:"interval"`
+	Snapshots  []database.GetRepositoryGrowthRow `json:"snapshots"`
+	Stargazers []database.GetStarHistoryRow      `json:"stargazers"`
+}
+
+// getGrowth handles the request for the star, fork, watcher and open issue counts of a
+// repository over time, per day (the default) or per week.
+// GET /v1/repos/{owner}/{name}/stats/growth?interval=I&since=T&until=T&provider=P&host=H
+func (h *Handler) getGrowth(w http.ResponseWriter, r *http.Request) {
+	interval := r.URL.Query().Get("interval")
+	switch interval {
+	case "":
+		interval = "day"
+	case "day", "week":
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid 'interval' parameter. Must be 'day' or 'week'.")
+		return
+	}
+	since, ok := parseTimeParam(w, r, "since")
+	if !ok {
+		return
+	}
+	until, ok := parseTimeParam(w, r, "until")
+	if !ok {
+		return
+	}
+
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+
+	snapshots, err := h.db.GetRepositoryGrowth(r.Context(), database.GetRepositoryGrowthParams{
+		Interval:     interval,
+		RepositoryID: repo.ID,
+		Since:        since,
+		Until:        until,
+	})
+	if err != nil {
+		h.logger.Error("Failed to get repository growth", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	stars, err := h.db.GetStarHistory(r.Context(), database.GetStarHistoryParams{
+		Interval:     interval,
+		RepositoryID: repo.ID,
+		Since:        since,
+		Until:        until,
+	})
+	if err != nil {
+		h.logger.Error("Failed to get star history", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	resp := growthResponse{Interval: interval, Snapshots: snapshots, Stargazers: stars}
+	if resp.Snapshots == nil {
+		resp.Snapshots = []database.GetRepositoryGrowthRow{}
+	}
+	if resp.Stargazers == nil {
+		resp.Stargazers = []database.GetStarHistoryRow{}
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
 // getTopCommitters handles the request for top commit authors.
-// GET /v1/repos/{owner}/{name}/stats/top-committers?limit=N
+// GET /v1/repos/{owner}/{name}/stats/top-committers?limit=N&provider=P&host=H
 func (h *Handler) getTopCommitters(w http.ResponseWriter, r *http.Request) {
-	owner := chi.URLParam(r, "owner")
-	name := chi.URLParam(r, "name")
-
 	limitStr := r.URL.Query().Get("limit")
 	if limitStr == "" {
 		limitStr = "10" // Default limit
@@ -98,29 +681,259 @@ func (h *Handler) getTopCommitters(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repo, err := h.db.GetRepositoryByOwnerAndName(r.Context(), database.GetRepositoryByOwnerAndNameParams{
-		Owner: owner,
-		Name:  name,
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+	h.flagIncompleteHistory(w, r, repo.ID)
+
+	authors, err := h.db.GetTopNCommitAuthors(r.Context(), database.GetTopNCommitAuthorsParams{
+		RepositoryID: repo.ID,
+		Limit:        int32(limit),
 	})
+	if err != nil {
+		h.logger.Error("Failed to get top commit authors", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, authors)
+}
+
+// getWorkflowStats handles the request for the success rate and p50/p95 run duration of each
+// GitHub Actions workflow of a repository. since and until are RFC3339 times bounding when runs
+// were created; without them all stored runs count.
+// GET /v1/repos/{owner}/{name}/stats/workflows?since=T&until=T&provider=P&host=H
+func (h *Handler) getWorkflowStats(w http.ResponseWriter, r *http.Request) {
+	var arg database.GetWorkflowStatsParams
+	var ok bool
+	if arg.Since, ok = parseTimeParam(w, r, "since"); !ok {
+		return
+	}
+	if arg.Until, ok = parseTimeParam(w, r, "until"); !ok {
+		return
+	}
+
+	repo, ok := h.lookupRepository(w, r)
+	if !ok {
+		return
+	}
+	arg.RepositoryID = repo.ID
+
+	stats, err := h.db.GetWorkflowStats(r.Context(), arg)
+	if err != nil {
+		h.logger.Error("Failed to get workflow stats", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if stats == nil {
+		stats = []database.GetWorkflowStatsRow{}
+	}
+
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+// getTokenQuotas reports the last known rate limit quota of each pooled GitHub token.
+// GET /v1/github/quotas
+func (h *Handler) getTokenQuotas(w http.ResponseWriter, r *http.Request) {
+	quotas := []github.TokenQuota{}
+	if h.quotas != nil {
+		quotas = append(quotas, h.quotas.Quotas()...)
+	}
+	respondWithJSON(w, http.StatusOK, quotas)
+}
+
+// syncRepository handles the request to sync a tracked repository now rather than at the next
+// cycle. It accepts the same 'provider' and 'host' parameters as the other repository routes and
+// answers with the queued job, or the one already queued for the repository.
+// POST /v1/repos/{owner}/{name}/sync
+func (h *Handler) syncRepository(w http.ResponseWriter, r *http.Request) {
+	arg, ok := repositoryParams(w, r)
+	if !ok {
+		return
+	}
+	if strings.ContainsAny(arg.Owner+arg.Name, "*?[") {
+		respondWithError(w, http.StatusBadRequest, "Invalid repository. Must name a single repository.")
+		return
+	}
+	// The entry is written the way REPOS_TO_SYNC names the repository.
+	entry := arg.Owner + "/" + arg.Name
+	switch {
+	case arg.Provider == model.ProviderGitLab:
+		entry = model.ProviderGitLab + ":" + entry
+	case arg.Host != github.DefaultHost:
+		entry = arg.Host + "/" + entry
+	}
+
+	job, err := h.syncs.EnqueueSync(r.Context(), entry)
+	var invalidRepo *custom_errors.ErrInvalidRepoFormat
+	var unknownHost *custom_errors.ErrUnknownHost
+	switch {
+	case err == nil:
+	case errors.As(err, &invalidRepo), errors.As(err, &unknownHost):
+		respondWithError(w, http.StatusBadRequest, "Invalid repository: "+err.Error())
+		return
+	case errors.Is(err, custom_errors.ErrNotTracked):
+		respondWithError(w, http.StatusNotFound, "Repository not tracked")
+		return
+	case errors.Is(err, custom_errors.ErrPaused):
+		respondWithError(w, http.StatusConflict, "Repository is paused")
+		return
+	case errors.Is(err, custom_errors.ErrSyncQueueFull):
+		respondWithError(w, http.StatusServiceUnavailable, "Too many syncs are queued, try again later")
+		return
+	default:
+		h.logger.Error("Failed to queue sync", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Location", "/v1/sync-jobs/"+strconv.FormatInt(job.ID, 10))
+	respondWithJSON(w, http.StatusAccepted, newSyncJobResponse(job))
+}
+
+// syncJobResponse is a sync job and how long it ran, or has been running so far. Duration is null
+// while the job is queued.
+type syncJobResponse struct {
+	database.SyncJob
+	DurationSeconds *float64 `json:"duration_seconds"`
+}
+
+func newSyncJobResponse(job database.SyncJob) syncJobResponse {
+	resp := syncJobResponse{SyncJob: job}
+	if job.StartedAt.Valid {
+		end := time.Now()
+		if job.FinishedAt.Valid {
+			end = job.FinishedAt.Time
+		}
+		d := end.Sub(job.StartedAt.Time).Seconds()
+		resp.DurationSeconds = &d
+	}
+	return resp
+}
+
+// getSyncJob handles the request for the state of a sync job, the number of commits it inserted
+// and, if it failed, why.
+// GET /v1/sync-jobs/{id}
+func (h *Handler) getSyncJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		respondWithError(w, http.StatusBadRequest, "Invalid 'id' parameter. Must be a positive integer.")
+		return
+	}
+
+	job, err := h.db.GetSyncJob(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "Repository not found")
+			respondWithError(w, http.StatusNotFound, "Sync job not found")
 			return
 		}
-		h.logger.Error("Failed to get repository", "error", err)
+		h.logger.Error("Failed to get sync job", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
+	respondWithJSON(w, http.StatusOK, newSyncJobResponse(job))
+}
 
-	authors, err := h.db.GetTopNCommitAuthors(r.Context(), database.GetTopNCommitAuthorsParams{
-		RepositoryID: repo.ID,
-		Limit:        int32(limit),
+// requireAdmin rejects requests that do not carry the admin token as a bearer token.
+func (h *Handler) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
+}
+
+// adminOnly is requireAdmin if an admin token is set, and lets every request through otherwise.
+func (h *Handler) adminOnly(next http.Handler) http.Handler {
+	if h.adminToken == "" {
+		return next
+	}
+	return h.requireAdmin(next)
+}
+
+// listTrackedRepositories handles the request to list the REPOS_TO_SYNC entries the syncer
+// works through, including the paused ones.
+// GET /v1/admin/repos
+func (h *Handler) listTrackedRepositories(w http.ResponseWriter, r *http.Request) {
+	tracked, err := h.db.ListTrackedRepositories(r.Context())
 	if err != nil {
-		h.logger.Error("Failed to get top commit authors", "error", err)
+		h.logger.Error("Failed to list tracked repositories", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
+	if tracked == nil {
+		tracked = []database.TrackedRepository{}
+	}
+	respondWithJSON(w, http.StatusOK, tracked)
+}
 
-	respondWithJSON(w, http.StatusOK, authors)
+// trackRequest is the body of a request to track, pause or resume a repository.
+type trackRequest struct {
+	Repo   string `json:"repo"`
+	Paused bool   `json:"paused"`
+}
+
+// trackRepository handles the request to track a REPOS_TO_SYNC entry, or to pause or resume a
+// tracked one. The syncer picks the change up at its next cycle.
+// POST /v1/admin/repos {"repo": "owner/name", "paused": false}
+func (h *Handler) trackRepository(w http.ResponseWriter, r *http.Request) {
+	var req trackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	entry, err := h.repos.ValidateEntry(req.Repo)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid 'repo' field: "+err.Error())
+		return
+	}
+
+	row, err := h.db.UpsertTrackedRepository(r.Context(), database.UpsertTrackedRepositoryParams{Entry: entry, Paused: req.Paused})
+	if err != nil {
+		h.logger.Error("Failed to track repository", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	status := http.StatusOK
+	if row.Created {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, database.TrackedRepository{
+		Entry:     row.Entry,
+		Paused:    row.Paused,
+		AddedBy:   row.AddedBy,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	})
+}
+
+// untrackRepository handles the request to stop tracking a REPOS_TO_SYNC entry. The data synced
+// for it is kept. Entries still in REPOS_TO_SYNC are tracked again on the next start.
+// DELETE /v1/admin/repos?repo=owner/name
+func (h *Handler) untrackRepository(w http.ResponseWriter, r *http.Request) {
+	entry := r.URL.Query().Get("repo")
+	if entry == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid 'repo' parameter. Must be a tracked entry.")
+		return
+	}
+	// Entries whose host is no longer configured cannot be validated, but can still be removed.
+	if canonical, err := h.repos.ValidateEntry(entry); err == nil {
+		entry = canonical
+	}
+
+	n, err := h.db.DeleteTrackedRepository(r.Context(), entry)
+	if err != nil {
+		h.logger.Error("Failed to untrack repository", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Repository not tracked")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,112 @@
+// internal/api/handler_test.go
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github-data-fetcher/internal/database"
+	"github-data-fetcher/internal/github"
+)
+
+// fakeScheduler records the entries it is asked to sync.
+type fakeScheduler struct {
+	entries []string
+}
+
+func (f *fakeScheduler) EnqueueSync(ctx context.Context, entry string) (database.SyncJob, error) {
+	f.entries = append(f.entries, entry)
+	return database.SyncJob{ID: 42, Owner: "golang", Name: "go", State: "queued"}, nil
+}
+
+func TestRouter_SyncRepository(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	sync := func(router http.Handler, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/repos/golang/go/sync", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("rejects requests without the admin token when one is set", func(t *testing.T) {
+		syncs := &fakeScheduler{}
+		router := NewRouter(nil, nil, nil, syncs, "secret", logger)
+
+		for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+			rec := sync(router, authorization)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, authorization)
+		}
+		assert.Empty(t, syncs.entries)
+	})
+
+	t.Run("queues syncs requested with the admin token", func(t *testing.T) {
+		syncs := &fakeScheduler{}
+		router := NewRouter(nil, nil, nil, syncs, "secret", logger)
+
+		rec := sync(router, "Bearer secret")
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, "/v1/sync-jobs/42", rec.Header().Get("Location"))
+		assert.Equal(t, []string{"golang/go"}, syncs.entries)
+	})
+
+	t.Run("queues syncs from anyone without an admin token", func(t *testing.T) {
+		syncs := &fakeScheduler{}
+		router := NewRouter(nil, nil, nil, syncs, "", logger)
+
+		rec := sync(router, "")
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, []string{"golang/go"}, syncs.entries)
+	})
+}
+
+// fakeQuotas reports a fixed quota.
+type fakeQuotas struct{}
+
+func (fakeQuotas) Quotas() []github.TokenQuota {
+	return []github.TokenQuota{{Token: "token-1", Resource: "core", Limit: 5000, Remaining: 4210}}
+}
+
+func TestRouter_GetTokenQuotas(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	get := func(router http.Handler, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/github/quotas", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("rejects requests without the admin token when one is set", func(t *testing.T) {
+		router := NewRouter(nil, fakeQuotas{}, nil, nil, "secret", logger)
+
+		rec := get(router, "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "token-1")
+	})
+
+	t.Run("reports the quotas to requests with the admin token", func(t *testing.T) {
+		router := NewRouter(nil, fakeQuotas{}, nil, nil, "secret", logger)
+
+		rec := get(router, "Bearer secret")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[{"token": "token-1", "resource": "core", "limit": 5000, "remaining": 4210, "reset": "0001-01-01T00:00:00Z"}]`, rec.Body.String())
+	})
+}
@@ -3,28 +3,96 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// Supported values of GITHUB_AUTH_MODE.
+const (
+	AuthModeToken = "token"
+	AuthModeApp   = "app"
+)
+
+// Supported values of GITHUB_COMMITS_BACKEND.
+const (
+	CommitsBackendREST    = "rest"
+	CommitsBackendGraphQL = "graphql"
+	CommitsBackendGit     = "git"
+)
+
 // Config holds all configuration for the application.
 type Config struct {
-	LogLevel             string        `mapstructure:"LOG_LEVEL"`
-	DBURL                string        `mapstructure:"DB_URL"`
-	GithubToken          string        `mapstructure:"GITHUB_TOKEN"`
-	ReposToSync          []string      `mapstructure:"REPOS_TO_SYNC"`
-	SyncInterval         time.Duration `mapstructure:"SYNC_INTERVAL"`
-	DefaultSyncSinceDate string        `mapstructure:"DEFAULT_SYNC_SINCE_DATE"`
-	DefaultSyncSinceTime time.Time     `mapstructure:"-"`
+	LogLevel                 string              `mapstructure:"LOG_LEVEL"`
+	DBURL                    string              `mapstructure:"DB_URL"`
+	GithubAuthMode           string              `mapstructure:"GITHUB_AUTH_MODE"`
+	GithubBaseURL            string              `mapstructure:"GITHUB_BASE_URL"`
+	GithubToken              string              `mapstructure:"GITHUB_TOKEN"`
+	GithubTokens             []string            `mapstructure:"GITHUB_TOKENS"`
+	GithubAppID              int64               `mapstructure:"GITHUB_APP_ID"`
+	GithubAppInstallationID  int64               `mapstructure:"GITHUB_APP_INSTALLATION_ID"`
+	GithubAppPrivateKeyPath  string              `mapstructure:"GITHUB_APP_PRIVATE_KEY_PATH"`
+	GithubCommitsBackend     string              `mapstructure:"GITHUB_COMMITS_BACKEND"`
+	GithubEnterpriseHosts    []string            `mapstructure:"GITHUB_ENTERPRISE_HOSTS"`
+	GithubEnterpriseTokens   []string            `mapstructure:"GITHUB_ENTERPRISE_TOKENS"`
+	GitMirrorDir             string              `mapstructure:"GIT_MIRROR_DIR"`
+	GitMirrorRemoteURL       string              `mapstructure:"GIT_MIRROR_REMOTE_URL"`
+	GitlabBaseURL            string              `mapstructure:"GITLAB_BASE_URL"`
+	GitlabToken              string              `mapstructure:"GITLAB_TOKEN"`
+	EnterpriseHosts          []EnterpriseHost    `mapstructure:"-"`
+	ReposToSync              []string            `mapstructure:"REPOS_TO_SYNC"`
+	AdminToken               string              `mapstructure:"ADMIN_TOKEN"`
+	DiscoverArchived         bool                `mapstructure:"DISCOVER_ARCHIVED"`
+	DiscoverForks            bool                `mapstructure:"DISCOVER_FORKS"`
+	DiscoverPrivate          bool                `mapstructure:"DISCOVER_PRIVATE"`
+	RepoBranches             []string            `mapstructure:"REPO_BRANCHES"`
+	BranchPatterns           map[string][]string `mapstructure:"-"`
+	SyncInterval             time.Duration       `mapstructure:"SYNC_INTERVAL"`
+	CommitFilesBudget        int                 `mapstructure:"COMMIT_FILES_BUDGET"`
+	PullRequestDetailsBudget int                 `mapstructure:"PULL_REQUEST_DETAILS_BUDGET"`
+	IssueCommentsBudget      int                 `mapstructure:"ISSUE_COMMENTS_BUDGET"`
+	StargazerBackfill        bool                `mapstructure:"STARGAZER_BACKFILL"`
+	DefaultSyncSinceDate     string              `mapstructure:"DEFAULT_SYNC_SINCE_DATE"`
+	DefaultSyncSinceTime     time.Time           `mapstructure:"-"`
+}
+
+// EnterpriseHost is a GitHub Enterprise Server instance repositories can be synced from.
+type EnterpriseHost struct {
+	Host      string // Name used in repository identifiers, e.g. "ghe.corp" in "ghe.corp/owner/name".
+	BaseURL   string
+	UploadURL string
+	Token     string
 }
 
 // LoadConfig reads configuration from file and/or environment variables.
 func LoadConfig() (*Config, error) {
 	// Set default values
 	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("GITHUB_BASE_URL", "")
+	viper.SetDefault("GITHUB_AUTH_MODE", AuthModeToken)
+	viper.SetDefault("GITHUB_TOKENS", []string{})
+	viper.SetDefault("GITHUB_APP_ID", 0)
+	viper.SetDefault("GITHUB_APP_INSTALLATION_ID", 0)
+	viper.SetDefault("GITHUB_APP_PRIVATE_KEY_PATH", "")
+	viper.SetDefault("GITHUB_COMMITS_BACKEND", CommitsBackendREST)
+	viper.SetDefault("GITHUB_ENTERPRISE_HOSTS", []string{})
+	viper.SetDefault("GITHUB_ENTERPRISE_TOKENS", []string{})
+	viper.SetDefault("GIT_MIRROR_DIR", "mirrors")
+	viper.SetDefault("GIT_MIRROR_REMOTE_URL", "")
+	viper.SetDefault("GITLAB_BASE_URL", "https://gitlab.com/")
+	viper.SetDefault("GITLAB_TOKEN", "")
+	viper.SetDefault("ADMIN_TOKEN", "")
+	viper.SetDefault("DISCOVER_ARCHIVED", false)
+	viper.SetDefault("DISCOVER_FORKS", false)
+	viper.SetDefault("DISCOVER_PRIVATE", true)
+	viper.SetDefault("REPO_BRANCHES", []string{})
 	viper.SetDefault("SYNC_INTERVAL", "1h")
+	viper.SetDefault("COMMIT_FILES_BUDGET", 0)
+	viper.SetDefault("PULL_REQUEST_DETAILS_BUDGET", 1000)
+	viper.SetDefault("ISSUE_COMMENTS_BUDGET", 1000)
+	viper.SetDefault("STARGAZER_BACKFILL", false)
 	viper.SetDefault("DEFAULT_SYNC_SINCE_DATE", "2023-01-01T00:00:00Z")
 
 	// Load from .env file if it exists
@@ -49,16 +117,106 @@ func LoadConfig() (*Config, error) {
 	}
 	cfg.DefaultSyncSinceTime = parsedTime
 
+	enterpriseHosts, err := parseEnterpriseHosts(cfg.GithubEnterpriseHosts, cfg.GithubEnterpriseTokens)
+	if err != nil {
+		return nil, err
+	}
+	cfg.EnterpriseHosts = enterpriseHosts
+
+	branchPatterns, err := parseRepoBranches(cfg.RepoBranches)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BranchPatterns = branchPatterns
+
 	// Validate required fields
 	if cfg.DBURL == "" {
 		return nil, errors.New("DB_URL is a required configuration field")
 	}
-	if cfg.GithubToken == "" {
-		return nil, errors.New("GITHUB_TOKEN is a required configuration field")
+	switch cfg.GithubAuthMode {
+	case AuthModeToken:
+		// A custom base URL, e.g. a local githubfake server, may not need authentication.
+		if cfg.GithubToken == "" && len(cfg.GithubTokens) == 0 && cfg.GithubBaseURL == "" {
+			return nil, errors.New("GITHUB_TOKEN or GITHUB_TOKENS is a required configuration field")
+		}
+	case AuthModeApp:
+		if cfg.GithubAppID == 0 || cfg.GithubAppInstallationID == 0 || cfg.GithubAppPrivateKeyPath == "" {
+			return nil, errors.New("GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PRIVATE_KEY_PATH are required when GITHUB_AUTH_MODE is 'app'")
+		}
+	default:
+		return nil, errors.New("GITHUB_AUTH_MODE must be either 'token' or 'app'")
+	}
+	switch cfg.GithubCommitsBackend {
+	case CommitsBackendREST, CommitsBackendGraphQL, CommitsBackendGit:
+	default:
+		return nil, errors.New("GITHUB_COMMITS_BACKEND must be one of 'rest', 'graphql' or 'git'")
+	}
+	if cfg.CommitFilesBudget < 0 {
+		return nil, errors.New("COMMIT_FILES_BUDGET must not be negative")
+	}
+	if cfg.PullRequestDetailsBudget < 0 {
+		return nil, errors.New("PULL_REQUEST_DETAILS_BUDGET must not be negative")
 	}
-	if len(cfg.ReposToSync) == 0 {
-		return nil, errors.New("REPOS_TO_SYNC must contain at least one repository")
+	if cfg.IssueCommentsBudget < 0 {
+		return nil, errors.New("ISSUE_COMMENTS_BUDGET must not be negative")
+	}
+	// Without the admin API, REPOS_TO_SYNC is the only way to track repositories.
+	if len(cfg.ReposToSync) == 0 && cfg.AdminToken == "" {
+		return nil, errors.New("REPOS_TO_SYNC must contain at least one repository unless ADMIN_TOKEN is set")
 	}
 
 	return &cfg, nil
 }
+
+// parseEnterpriseHosts combines GITHUB_ENTERPRISE_HOSTS entries of the form
+// 'host=baseURL' or 'host=baseURL|uploadURL' with the 'host=token' entries of GITHUB_ENTERPRISE_TOKENS.
+func parseEnterpriseHosts(hosts, tokens []string) ([]EnterpriseHost, error) {
+	tokenByHost := make(map[string]string, len(tokens))
+	for _, entry := range tokens {
+		host, token, ok := strings.Cut(entry, "=")
+		if !ok || host == "" || token == "" {
+			return nil, errors.New("GITHUB_ENTERPRISE_TOKENS entries must be in 'host=token' format")
+		}
+		tokenByHost[host] = token
+	}
+
+	var parsed []EnterpriseHost
+	for _, entry := range hosts {
+		host, urls, ok := strings.Cut(entry, "=")
+		if !ok || host == "" || urls == "" {
+			return nil, fmt.Errorf("invalid GITHUB_ENTERPRISE_HOSTS entry %q, expected 'host=baseURL' or 'host=baseURL|uploadURL'", entry)
+		}
+		if host == "github.com" {
+			return nil, errors.New("GITHUB_ENTERPRISE_HOSTS must not redefine github.com")
+		}
+		baseURL, uploadURL, _ := strings.Cut(urls, "|")
+		if uploadURL == "" {
+			uploadURL = baseURL
+		}
+		token, ok := tokenByHost[host]
+		if !ok {
+			return nil, fmt.Errorf("no token configured in GITHUB_ENTERPRISE_TOKENS for enterprise host %q", host)
+		}
+		parsed = append(parsed, EnterpriseHost{Host: host, BaseURL: baseURL, UploadURL: uploadURL, Token: token})
+	}
+	return parsed, nil
+}
+
+// parseRepoBranches parses REPO_BRANCHES entries of the form 'repo=pattern|pattern', where repo
+// is written as in REPOS_TO_SYNC, into the patterns of each repository.
+func parseRepoBranches(entries []string) (map[string][]string, error) {
+	patterns := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		repo, list, ok := strings.Cut(entry, "=")
+		if !ok || repo == "" || list == "" {
+			return nil, fmt.Errorf("invalid REPO_BRANCHES entry %q, expected 'repo=pattern' or 'repo=pattern|pattern'", entry)
+		}
+		for _, p := range strings.Split(list, "|") {
+			if p == "" {
+				return nil, fmt.Errorf("invalid REPO_BRANCHES entry %q, branch patterns must not be empty", entry)
+			}
+			patterns[repo] = append(patterns[repo], p)
+		}
+	}
+	return patterns, nil
+}
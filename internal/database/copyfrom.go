@@ -9,6 +9,43 @@ import (
 	"context"
 )
 
+// iteratorForCreateCommitFiles implements pgx.CopyFromSource.
+type iteratorForCreateCommitFiles struct {
+	rows                 []CreateCommitFilesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateCommitFiles) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateCommitFiles) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].RepositoryID,
+		r.rows[0].Sha,
+		r.rows[0].Path,
+		r.rows[0].Status,
+		r.rows[0].Additions,
+		r.rows[0].Deletions,
+	}, nil
+}
+
+func (r iteratorForCreateCommitFiles) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateCommitFiles(ctx context.Context, arg []CreateCommitFilesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"commit_files"}, []string{"repository_id", "sha", "path", "status", "additions", "deletions"}, &iteratorForCreateCommitFiles{rows: arg})
+}
+
 // iteratorForCreateCommits implements pgx.CopyFromSource.
 type iteratorForCreateCommits struct {
 	rows                 []CreateCommitsParams
@@ -36,6 +73,15 @@ func (r iteratorForCreateCommits) Values() ([]interface{}, error) {
 		r.rows[0].Message,
 		r.rows[0].Url,
 		r.rows[0].CommitDate,
+		r.rows[0].AuthorLogin,
+		r.rows[0].Additions,
+		r.rows[0].Deletions,
+		r.rows[0].Verified,
+		r.rows[0].Parents,
+		r.rows[0].CommitterName,
+		r.rows[0].CommitterEmail,
+		r.rows[0].CommitterDate,
+		r.rows[0].OnDefaultBranch,
 	}, nil
 }
 
@@ -44,5 +90,39 @@ func (r iteratorForCreateCommits) Err() error {
 }
 
 func (q *Queries) CreateCommits(ctx context.Context, arg []CreateCommitsParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"commits"}, []string{"sha", "repository_id", "author_name", "author_email", "message", "url", "commit_date"}, &iteratorForCreateCommits{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"commits"}, []string{"sha", "repository_id", "author_name", "author_email", "message", "url", "commit_date", "author_login", "additions", "deletions", "verified", "parents", "committer_name", "committer_email", "committer_date", "on_default_branch"}, &iteratorForCreateCommits{rows: arg})
+}
+
+// iteratorForCreateStargazers implements pgx.CopyFromSource.
+type iteratorForCreateStargazers struct {
+	rows                 []CreateStargazersParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateStargazers) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateStargazers) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].RepositoryID,
+		r.rows[0].Login,
+		r.rows[0].StarredAt,
+	}, nil
+}
+
+func (r iteratorForCreateStargazers) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateStargazers(ctx context.Context, arg []CreateStargazersParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"stargazers"}, []string{"repository_id", "login", "starred_at"}, &iteratorForCreateStargazers{rows: arg})
 }
@@ -10,15 +10,163 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Commit struct {
-	Sha          string    `json:"sha"`
+type Branch struct {
+	ID           int64     `json:"id"`
 	RepositoryID int64     `json:"repository_id"`
-	AuthorName   string    `json:"author_name"`
-	AuthorEmail  string    `json:"author_email"`
-	Message      string    `json:"message"`
+	Name         string    `json:"name"`
+	HeadSha      string    `json:"head_sha"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Commit struct {
+	Sha             string             `json:"sha"`
+	RepositoryID    int64              `json:"repository_id"`
+	AuthorName      string             `json:"author_name"`
+	AuthorEmail     string             `json:"author_email"`
+	Message         string             `json:"message"`
+	Url             string             `json:"url"`
+	CommitDate      time.Time          `json:"commit_date"`
+	CreatedAt       time.Time          `json:"created_at"`
+	AuthorLogin     string             `json:"author_login"`
+	Additions       pgtype.Int4        `json:"additions"`
+	Deletions       pgtype.Int4        `json:"deletions"`
+	Verified        bool               `json:"verified"`
+	Parents         []string           `json:"parents"`
+	CommitterName   string             `json:"committer_name"`
+	CommitterEmail  string             `json:"committer_email"`
+	CommitterDate   pgtype.Timestamptz `json:"committer_date"`
+	UnreachableAt   pgtype.Timestamptz `json:"unreachable_at"`
+	OnDefaultBranch bool               `json:"on_default_branch"`
+	FirstRelease    string             `json:"first_release"`
+	FilesSyncedAt   pgtype.Timestamptz `json:"files_synced_at"`
+}
+
+type CommitBranch struct {
+	BranchID     int64  `json:"branch_id"`
+	RepositoryID int64  `json:"repository_id"`
+	Sha          string `json:"sha"`
+}
+
+type CommitFile struct {
+	RepositoryID int64  `json:"repository_id"`
+	Sha          string `json:"sha"`
+	Path         string `json:"path"`
+	Status       string `json:"status"`
+	Additions    int32  `json:"additions"`
+	Deletions    int32  `json:"deletions"`
+}
+
+type HistoryRewrite struct {
+	ID                 int64     `json:"id"`
+	RepositoryID       int64     `json:"repository_id"`
+	OldHeadSha         string    `json:"old_head_sha"`
+	NewHeadSha         string    `json:"new_head_sha"`
+	MergeBaseSha       string    `json:"merge_base_sha"`
+	UnreachableCommits int64     `json:"unreachable_commits"`
+	DetectedAt         time.Time `json:"detected_at"`
+}
+
+type HttpValidator struct {
 	Url          string    `json:"url"`
-	CommitDate   time.Time `json:"commit_date"`
+	Etag         string    `json:"etag"`
+	LastModified string    `json:"last_modified"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Issue struct {
+	ID                int64              `json:"id"`
+	RepositoryID      int64              `json:"repository_id"`
+	Number            int32              `json:"number"`
+	GithubIssueID     int64              `json:"github_issue_id"`
+	Title             string             `json:"title"`
+	State             string             `json:"state"`
+	AuthorLogin       string             `json:"author_login"`
+	Labels            []string           `json:"labels"`
+	Assignees         []string           `json:"assignees"`
+	Milestone         string             `json:"milestone"`
+	CommentsCount     int32              `json:"comments_count"`
+	Url               string             `json:"url"`
+	IssueCreatedAt    time.Time          `json:"issue_created_at"`
+	IssueUpdatedAt    time.Time          `json:"issue_updated_at"`
+	ClosedAt          pgtype.Timestamptz `json:"closed_at"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	CommentsUpdatedAt pgtype.Timestamptz `json:"comments_updated_at"`
+}
+
+type IssueComment struct {
+	IssueID          int64     `json:"issue_id"`
+	GithubCommentID  int64     `json:"github_comment_id"`
+	AuthorLogin      string    `json:"author_login"`
+	CommentCreatedAt time.Time `json:"comment_created_at"`
+	CommentUpdatedAt time.Time `json:"comment_updated_at"`
+}
+
+type Label struct {
+	ID           int64     `json:"id"`
+	RepositoryID int64     `json:"repository_id"`
+	Name         string    `json:"name"`
+	Color        string    `json:"color"`
+	Description  string    `json:"description"`
 	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type PullRequest struct {
+	ID               int64              `json:"id"`
+	RepositoryID     int64              `json:"repository_id"`
+	Number           int32              `json:"number"`
+	GithubPrID       int64              `json:"github_pr_id"`
+	Title            string             `json:"title"`
+	State            string             `json:"state"`
+	AuthorLogin      string             `json:"author_login"`
+	BaseRef          string             `json:"base_ref"`
+	HeadRef          string             `json:"head_ref"`
+	Additions        int32              `json:"additions"`
+	Deletions        int32              `json:"deletions"`
+	MergedByLogin    string             `json:"merged_by_login"`
+	Url              string             `json:"url"`
+	PrCreatedAt      time.Time          `json:"pr_created_at"`
+	PrUpdatedAt      time.Time          `json:"pr_updated_at"`
+	MergedAt         pgtype.Timestamptz `json:"merged_at"`
+	ClosedAt         pgtype.Timestamptz `json:"closed_at"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	DetailsUpdatedAt pgtype.Timestamptz `json:"details_updated_at"`
+}
+
+type PullRequestReview struct {
+	PullRequestID  int64     `json:"pull_request_id"`
+	GithubReviewID int64     `json:"github_review_id"`
+	ReviewerLogin  string    `json:"reviewer_login"`
+	State          string    `json:"state"`
+	CommitSha      string    `json:"commit_sha"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+}
+
+type Release struct {
+	ID               int64              `json:"id"`
+	RepositoryID     int64              `json:"repository_id"`
+	GithubReleaseID  int64              `json:"github_release_id"`
+	TagName          string             `json:"tag_name"`
+	Name             string             `json:"name"`
+	Draft            bool               `json:"draft"`
+	Prerelease       bool               `json:"prerelease"`
+	AuthorLogin      string             `json:"author_login"`
+	Url              string             `json:"url"`
+	ReleaseCreatedAt time.Time          `json:"release_created_at"`
+	PublishedAt      pgtype.Timestamptz `json:"published_at"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+type ReleaseAsset struct {
+	ReleaseID     int64  `json:"release_id"`
+	GithubAssetID int64  `json:"github_asset_id"`
+	Name          string `json:"name"`
+	Size          int64  `json:"size"`
+	DownloadCount int64  `json:"download_count"`
 }
 
 type Repository struct {
@@ -38,4 +186,111 @@ type Repository struct {
 	LastSyncedAt    pgtype.Timestamptz `json:"last_synced_at"`
 	CreatedAt       time.Time          `json:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at"`
+	Host            string             `json:"host"`
+	Provider        string             `json:"provider"`
+	HeadSha         string             `json:"head_sha"`
+}
+
+type RepositoryLanguage struct {
+	RepositoryID int64  `json:"repository_id"`
+	Language     string `json:"language"`
+	Bytes        int64  `json:"bytes"`
+}
+
+type RepositorySnapshot struct {
+	RepositoryID    int64     `json:"repository_id"`
+	CapturedAt      time.Time `json:"captured_at"`
+	StarsCount      int32     `json:"stars_count"`
+	ForksCount      int32     `json:"forks_count"`
+	WatchersCount   int32     `json:"watchers_count"`
+	OpenIssuesCount int32     `json:"open_issues_count"`
+}
+
+type RepositorySnapshotLanguage struct {
+	RepositoryID int64     `json:"repository_id"`
+	CapturedAt   time.Time `json:"captured_at"`
+	Language     string    `json:"language"`
+	Bytes        int64     `json:"bytes"`
+}
+
+type RepositoryTopic struct {
+	RepositoryID int64  `json:"repository_id"`
+	Topic        string `json:"topic"`
+}
+
+type Stargazer struct {
+	RepositoryID int64     `json:"repository_id"`
+	Login        string    `json:"login"`
+	StarredAt    time.Time `json:"starred_at"`
+}
+
+type SyncCheckpoint struct {
+	RepositoryID     int64              `json:"repository_id"`
+	Since            time.Time          `json:"since"`
+	PageCursor       string             `json:"page_cursor"`
+	OldestSha        string             `json:"oldest_sha"`
+	OldestCommitDate pgtype.Timestamptz `json:"oldest_commit_date"`
+	CommitsImported  int64              `json:"commits_imported"`
+	StartedAt        time.Time          `json:"started_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	CompletedAt      pgtype.Timestamptz `json:"completed_at"`
+}
+
+type SyncJob struct {
+	ID              int64              `json:"id"`
+	Provider        string             `json:"provider"`
+	Host            string             `json:"host"`
+	Owner           string             `json:"owner"`
+	Name            string             `json:"name"`
+	State           string             `json:"state"`
+	CommitsInserted int64              `json:"commits_inserted"`
+	Error           string             `json:"error"`
+	CreatedAt       time.Time          `json:"created_at"`
+	StartedAt       pgtype.Timestamptz `json:"started_at"`
+	FinishedAt      pgtype.Timestamptz `json:"finished_at"`
+}
+
+type Tag struct {
+	RepositoryID int64     `json:"repository_id"`
+	Name         string    `json:"name"`
+	Sha          string    `json:"sha"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type TrackedRepository struct {
+	Entry     string    `json:"entry"`
+	Paused    bool      `json:"paused"`
+	AddedBy   string    `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Workflow struct {
+	RepositoryID     int64     `json:"repository_id"`
+	GithubWorkflowID int64     `json:"github_workflow_id"`
+	Name             string    `json:"name"`
+	Path             string    `json:"path"`
+	State            string    `json:"state"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type WorkflowRun struct {
+	RepositoryID int64     `json:"repository_id"`
+	GithubRunID  int64     `json:"github_run_id"`
+	WorkflowID   int64     `json:"workflow_id"`
+	Name         string    `json:"name"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	HeadSha      string    `json:"head_sha"`
+	HeadBranch   string    `json:"head_branch"`
+	RunNumber    int32     `json:"run_number"`
+	RunAttempt   int32     `json:"run_attempt"`
+	RunCreatedAt time.Time `json:"run_created_at"`
+	RunStartedAt time.Time `json:"run_started_at"`
+	RunUpdatedAt time.Time `json:"run_updated_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -11,14 +11,138 @@ import (
 )
 
 type Querier interface {
+	AddCommitsToBranch(ctx context.Context, arg AddCommitsToBranchParams) error
+	AddRepositoryTopics(ctx context.Context, arg AddRepositoryTopicsParams) error
+	AdvanceSyncCheckpoint(ctx context.Context, arg AdvanceSyncCheckpointParams) error
+	// Sets the first release of the commit sha and its stored ancestors that have none yet. Tags
+	// must be assigned oldest first: the walk stops at commits an older tag already contains, as
+	// their ancestors are contained in it too.
+	AssignFirstRelease(ctx context.Context, arg AssignFirstReleaseParams) (int64, error)
+	ClearBranchCommits(ctx context.Context, branchID int64) error
+	ClearFirstReleases(ctx context.Context, repositoryID int64) error
+	CompleteSyncCheckpoint(ctx context.Context, repositoryID int64) error
+	CountStargazers(ctx context.Context, repositoryID int64) (int64, error)
+	CreateCommitFiles(ctx context.Context, arg []CreateCommitFilesParams) (int64, error)
 	CreateCommits(ctx context.Context, arg []CreateCommitsParams) (int64, error)
+	CreateHistoryRewrite(ctx context.Context, arg CreateHistoryRewriteParams) (HistoryRewrite, error)
 	CreateRepository(ctx context.Context, arg CreateRepositoryParams) (Repository, error)
-	GetCommitsByRepoID(ctx context.Context, repositoryID int64) ([]Commit, error)
+	// Records the counters currently stored for a repository.
+	CreateRepositorySnapshot(ctx context.Context, id int64) error
+	// Records the languages currently stored for a repository with its latest snapshot.
+	CreateSnapshotLanguages(ctx context.Context, repositoryID int64) error
+	CreateStargazers(ctx context.Context, arg []CreateStargazersParams) (int64, error)
+	CreateSyncJob(ctx context.Context, arg CreateSyncJobParams) (SyncJob, error)
+	DeleteBranchesNotIn(ctx context.Context, arg DeleteBranchesNotInParams) error
+	// The URL of a repository and those below it. starts_with rather than LIKE, as
+	// repository names may contain '_', which LIKE matches to any character.
+	DeleteHTTPValidators(ctx context.Context, url string) error
+	DeleteIssueCommentsNotIn(ctx context.Context, arg DeleteIssueCommentsNotInParams) error
+	DeleteLabelsNotIn(ctx context.Context, arg DeleteLabelsNotInParams) error
+	DeleteReleaseAssetsNotIn(ctx context.Context, arg DeleteReleaseAssetsNotInParams) error
+	DeleteReleasesNotIn(ctx context.Context, arg DeleteReleasesNotInParams) error
+	DeleteRepositoryLanguagesNotIn(ctx context.Context, arg DeleteRepositoryLanguagesNotInParams) error
+	DeleteRepositoryTopicsNotIn(ctx context.Context, arg DeleteRepositoryTopicsNotInParams) error
+	DeleteTagsNotIn(ctx context.Context, arg DeleteTagsNotInParams) error
+	DeleteTrackedRepository(ctx context.Context, entry string) (int64, error)
+	// Fails the jobs that are queued or running but not among keep, i.e. those a previous process
+	// did not finish.
+	FailUnfinishedSyncJobs(ctx context.Context, arg FailUnfinishedSyncJobsParams) (int64, error)
+	FinishSyncJob(ctx context.Context, arg FinishSyncJobParams) error
+	GetBranch(ctx context.Context, arg GetBranchParams) (Branch, error)
+	GetBranchesByRepoID(ctx context.Context, repositoryID int64) ([]Branch, error)
+	GetCommitsByBranchID(ctx context.Context, branchID int64) ([]Commit, error)
+	GetCommitsByRepoID(ctx context.Context, arg GetCommitsByRepoIDParams) ([]Commit, error)
+	// Reachable commits whose changed files are not stored yet, newest first.
+	GetCommitsWithoutFiles(ctx context.Context, arg GetCommitsWithoutFilesParams) ([]string, error)
+	GetHTTPValidators(ctx context.Context, url string) (HttpValidator, error)
+	GetHistoryRewritesByRepoID(ctx context.Context, repositoryID int64) ([]HistoryRewrite, error)
+	// date_field selects the timestamp since and until bound: created (the default), updated or
+	// closed. Open issues are left out when closed is bounded. An empty label matches every issue.
+	GetIssuesByRepoID(ctx context.Context, arg GetIssuesByRepoIDParams) ([]Issue, error)
+	// Issues with comments updated since their comments were last listed, most recently updated first.
+	GetIssuesWithStaleComments(ctx context.Context, arg GetIssuesWithStaleCommentsParams) ([]int32, error)
+	GetLabelsByRepoID(ctx context.Context, repositoryID int64) ([]Label, error)
+	GetLatestCommitDateForBranch(ctx context.Context, branchID int64) (pgtype.Timestamp, error)
+	// Commit listings filter on the committer date, which rebases and cherry-picks reset, so the
+	// author date is only used for commits whose committer date is unknown.
+	// Commits only seen on other tracked branches are left out, as the default branch's listing
+	// has not necessarily reached their dates.
 	GetLatestCommitDateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamp, error)
+	GetLatestCommitSHAForRepo(ctx context.Context, repositoryID int64) (string, error)
+	GetLatestIssueUpdateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error)
+	GetLatestPullRequestUpdateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error)
+	GetPullRequestReviewsByPullRequestIDs(ctx context.Context, pullRequestIds []int64) ([]PullRequestReview, error)
+	// date_field selects the timestamp since and until bound: created (the default), updated,
+	// merged or closed. Pull requests without that timestamp, e.g. open ones for merged, are left
+	// out when a bound is given.
+	GetPullRequestsByRepoID(ctx context.Context, arg GetPullRequestsByRepoIDParams) ([]PullRequest, error)
+	// Pull requests updated since their line stats, merger and reviews were last fetched, most
+	// recently updated first.
+	GetPullRequestsWithoutDetails(ctx context.Context, arg GetPullRequestsWithoutDetailsParams) ([]int32, error)
+	GetReleaseAssetsByReleaseIDs(ctx context.Context, releaseIds []int64) ([]ReleaseAsset, error)
+	// tag_sha is the commit the release's tag points to, empty if the tag is unknown, e.g. for drafts.
+	GetReleasesByRepoID(ctx context.Context, repositoryID int64) ([]GetReleasesByRepoIDRow, error)
 	// internal/database/query.sql
-	GetRepositoryByOwnerAndName(ctx context.Context, arg GetRepositoryByOwnerAndNameParams) (Repository, error)
+	GetRepositoryByProviderHostOwnerAndName(ctx context.Context, arg GetRepositoryByProviderHostOwnerAndNameParams) (Repository, error)
+	// The last snapshot taken in each interval, which is 'day' or 'week'. Weeks start on Monday.
+	GetRepositoryGrowth(ctx context.Context, arg GetRepositoryGrowthParams) ([]GetRepositoryGrowthRow, error)
+	// share is the fraction of a repository's code that is in the language.
+	GetRepositoryLanguagesByRepoIDs(ctx context.Context, repositoryIds []int64) ([]GetRepositoryLanguagesByRepoIDsRow, error)
+	GetRepositoryTopicsByRepoIDs(ctx context.Context, repositoryIds []int64) ([]RepositoryTopic, error)
+	// The stars given in each interval according to the stored stargazers, with the running total.
+	// The interval since falls in is included in full.
+	GetStarHistory(ctx context.Context, arg GetStarHistoryParams) ([]GetStarHistoryRow, error)
+	GetSyncCheckpoint(ctx context.Context, repositoryID int64) (SyncCheckpoint, error)
+	GetSyncJob(ctx context.Context, id int64) (SyncJob, error)
+	// Tags whose commit is not stored are left out. first_release is that of the tagged commit, empty
+	// when it was not mapped yet, e.g. because the tag is new or the commit was stored after it.
+	GetTagsByCommitDate(ctx context.Context, repositoryID int64) ([]GetTagsByCommitDateRow, error)
+	GetTagsByRepoID(ctx context.Context, repositoryID int64) ([]Tag, error)
 	GetTopNCommitAuthors(ctx context.Context, arg GetTopNCommitAuthorsParams) ([]GetTopNCommitAuthorsRow, error)
+	// The creation time of the oldest run that has not completed yet, or else of the newest run.
+	GetWorkflowRunSyncStart(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error)
+	// Runs are bounded by their creation time. The success rate is the share of successful runs
+	// among those that completed with a verdict, leaving out cancelled, skipped and neutral runs.
+	// Durations are from the start of a run's latest attempt to its completion.
+	GetWorkflowStats(ctx context.Context, arg GetWorkflowStatsParams) ([]GetWorkflowStatsRow, error)
+	// Repositories tagged with topic and with code in language, if given. Languages are compared
+	// case-insensitively; repositories whose languages are not stored match by their main language.
+	ListRepositories(ctx context.Context, arg ListRepositoriesParams) ([]Repository, error)
+	ListTrackedRepositories(ctx context.Context) ([]TrackedRepository, error)
+	// additions and deletions are only filled in if the commits backend did not report them.
+	MarkCommitFilesSynced(ctx context.Context, arg MarkCommitFilesSyncedParams) error
+	MarkCommitsUnreachable(ctx context.Context, arg MarkCommitsUnreachableParams) (int64, error)
+	// The comments are as of the stored update of the issue, so an update listed after they were
+	// fetched has them fetched again.
+	MarkIssueCommentsSynced(ctx context.Context, arg MarkIssueCommentsSyncedParams) (int64, error)
+	MarkRepositorySynced(ctx context.Context, id int64) error
+	RemoveCommitsFromBranch(ctx context.Context, arg RemoveCommitsFromBranchParams) (int64, error)
+	// Tracks the entries that are not tracked yet. Entries that were paused stay paused.
+	SeedTrackedRepositories(ctx context.Context, entries []string) error
+	StartSyncCheckpoint(ctx context.Context, arg StartSyncCheckpointParams) (SyncCheckpoint, error)
+	StartSyncJob(ctx context.Context, id int64) error
+	// details_updated_at is the update the details were fetched at, so they are fetched again once the
+	// pull request is listed with a later one.
+	UpdatePullRequestDetails(ctx context.Context, arg UpdatePullRequestDetailsParams) (int64, error)
+	UpdateRepositoryHead(ctx context.Context, arg UpdateRepositoryHeadParams) error
 	UpdateRepositorySyncData(ctx context.Context, arg UpdateRepositorySyncDataParams) (Repository, error)
+	UpsertBranch(ctx context.Context, arg UpsertBranchParams) (Branch, error)
+	UpsertHTTPValidators(ctx context.Context, arg UpsertHTTPValidatorsParams) error
+	UpsertIssue(ctx context.Context, arg UpsertIssueParams) (Issue, error)
+	UpsertIssueComment(ctx context.Context, arg UpsertIssueCommentParams) error
+	UpsertLabel(ctx context.Context, arg UpsertLabelParams) error
+	// Listings lack line stats and who merged a pull request, so those are only set by
+	// UpdatePullRequestDetails.
+	UpsertPullRequest(ctx context.Context, arg UpsertPullRequestParams) (PullRequest, error)
+	UpsertPullRequestReview(ctx context.Context, arg UpsertPullRequestReviewParams) error
+	UpsertRelease(ctx context.Context, arg UpsertReleaseParams) (Release, error)
+	UpsertReleaseAsset(ctx context.Context, arg UpsertReleaseAssetParams) error
+	UpsertRepositoryLanguages(ctx context.Context, arg UpsertRepositoryLanguagesParams) error
+	UpsertTag(ctx context.Context, arg UpsertTagParams) error
+	// Tracks an entry, or pauses or resumes it if it is tracked. created is false in the latter case.
+	UpsertTrackedRepository(ctx context.Context, arg UpsertTrackedRepositoryParams) (UpsertTrackedRepositoryRow, error)
+	UpsertWorkflow(ctx context.Context, arg UpsertWorkflowParams) error
+	UpsertWorkflowRun(ctx context.Context, arg UpsertWorkflowRunParams) error
 }
 
 var _ Querier = (*Queries)(nil)
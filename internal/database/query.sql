@@ -1,17 +1,17 @@
 -- internal/database/query.sql
 
--- name: GetRepositoryByOwnerAndName :one
+-- name: GetRepositoryByProviderHostOwnerAndName :one
 SELECT * FROM repositories
-WHERE owner = $1 AND name = $2
+WHERE provider = $1 AND host = $2 AND owner = $3 AND name = $4
 LIMIT 1;
 
 -- name: CreateRepository :one
 INSERT INTO repositories (
     github_repo_id, owner, name, description, url, language,
     forks_count, stars_count, open_issues_count, watchers_count,
-    repo_created_at, repo_updated_at
+    repo_created_at, repo_updated_at, host, provider
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
          )
     RETURNING *;
 
@@ -32,14 +32,26 @@ WHERE id = $1
 
 
 -- name: GetLatestCommitDateForRepo :one
-SELECT MAX(commit_date)::timestamp AS max_date FROM commits
-WHERE repository_id = $1;
+-- Commit listings filter on the committer date, which rebases and cherry-picks reset, so the
+-- author date is only used for commits whose committer date is unknown.
+-- Commits only seen on other tracked branches are left out, as the default branch's listing
+-- has not necessarily reached their dates.
+SELECT MAX(COALESCE(committer_date, commit_date))::timestamp AS max_date FROM commits
+WHERE repository_id = $1 AND unreachable_at IS NULL AND on_default_branch;
+
+-- name: GetLatestCommitSHAForRepo :one
+SELECT sha FROM commits
+WHERE repository_id = $1 AND unreachable_at IS NULL AND on_default_branch
+ORDER BY COALESCE(committer_date, commit_date) DESC
+LIMIT 1;
 
 -- name: CreateCommits :copyfrom
 INSERT INTO commits (
-    sha, repository_id, author_name, author_email, message, url, commit_date
+    sha, repository_id, author_name, author_email, message, url, commit_date,
+    author_login, additions, deletions, verified,
+    parents, committer_name, committer_email, committer_date, on_default_branch
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
          );
 
 -- name: GetTopNCommitAuthors :many
@@ -48,12 +60,653 @@ SELECT
     author_email,
     COUNT(*) as commit_count
 FROM commits
-WHERE repository_id = $1
+WHERE repository_id = $1 AND unreachable_at IS NULL
 GROUP BY author_name, author_email
 ORDER BY commit_count DESC
 LIMIT $2;
 
 -- name: GetCommitsByRepoID :many
 SELECT * FROM commits
+WHERE repository_id = @repository_id AND (unreachable_at IS NULL OR @include_unreachable::boolean)
+ORDER BY commit_date DESC;
+
+-- name: MarkCommitsUnreachable :execrows
+UPDATE commits
+SET unreachable_at = NOW()
+WHERE repository_id = @repository_id AND sha = ANY(@shas::text[]) AND unreachable_at IS NULL;
+
+-- name: MarkRepositorySynced :exec
+UPDATE repositories
+SET
+    last_synced_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateRepositoryHead :exec
+UPDATE repositories
+SET
+    head_sha = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateHistoryRewrite :one
+INSERT INTO history_rewrites (
+    repository_id, old_head_sha, new_head_sha, merge_base_sha, unreachable_commits
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+    RETURNING *;
+
+-- name: GetHistoryRewritesByRepoID :many
+SELECT * FROM history_rewrites
+WHERE repository_id = $1
+ORDER BY detected_at DESC, id DESC;
+
+-- name: GetBranchesByRepoID :many
+SELECT * FROM branches
+WHERE repository_id = $1
+ORDER BY name;
+
+-- name: GetBranch :one
+SELECT * FROM branches
+WHERE repository_id = $1 AND name = $2;
+
+-- name: UpsertBranch :one
+INSERT INTO branches (repository_id, name, head_sha)
+VALUES ($1, $2, $3)
+ON CONFLICT (repository_id, name) DO UPDATE
+SET
+    head_sha = EXCLUDED.head_sha,
+    updated_at = NOW()
+    RETURNING *;
+
+-- name: DeleteBranchesNotIn :exec
+DELETE FROM branches
+WHERE repository_id = @repository_id AND NOT (name = ANY(@names::text[]));
+
+-- name: AddCommitsToBranch :exec
+INSERT INTO commit_branches (branch_id, repository_id, sha)
+SELECT @branch_id::bigint, @repository_id::bigint, unnest(@shas::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveCommitsFromBranch :execrows
+DELETE FROM commit_branches
+WHERE branch_id = @branch_id AND sha = ANY(@shas::text[]);
+
+-- name: ClearBranchCommits :exec
+DELETE FROM commit_branches
+WHERE branch_id = $1;
+
+-- name: GetLatestCommitDateForBranch :one
+SELECT MAX(COALESCE(c.committer_date, c.commit_date))::timestamp AS max_date FROM commits c
+JOIN commit_branches cb ON cb.repository_id = c.repository_id AND cb.sha = c.sha
+WHERE cb.branch_id = $1;
+
+-- name: GetCommitsByBranchID :many
+SELECT c.* FROM commits c
+JOIN commit_branches cb ON cb.repository_id = c.repository_id AND cb.sha = c.sha
+WHERE cb.branch_id = $1
+ORDER BY c.commit_date DESC;
+
+-- name: GetLatestPullRequestUpdateForRepo :one
+SELECT MAX(pr_updated_at)::timestamptz AS max_updated_at FROM pull_requests
+WHERE repository_id = $1;
+
+-- name: UpsertPullRequest :one
+-- Listings lack line stats and who merged a pull request, so those are only set by
+-- UpdatePullRequestDetails.
+INSERT INTO pull_requests (
+    repository_id, number, github_pr_id, title, state, author_login, base_ref, head_ref,
+    url, pr_created_at, pr_updated_at, merged_at, closed_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+         )
+ON CONFLICT (repository_id, number) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    state = EXCLUDED.state,
+    base_ref = EXCLUDED.base_ref,
+    head_ref = EXCLUDED.head_ref,
+    pr_updated_at = EXCLUDED.pr_updated_at,
+    merged_at = EXCLUDED.merged_at,
+    closed_at = EXCLUDED.closed_at,
+    updated_at = NOW()
+    RETURNING *;
+
+-- name: UpsertPullRequestReview :exec
+INSERT INTO pull_request_reviews (
+    pull_request_id, github_review_id, reviewer_login, state, commit_sha, submitted_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         )
+ON CONFLICT (pull_request_id, github_review_id) DO UPDATE
+SET
+    state = EXCLUDED.state;
+
+-- name: GetPullRequestsWithoutDetails :many
+-- Pull requests updated since their line stats, merger and reviews were last fetched, most
+-- recently updated first.
+SELECT number FROM pull_requests
+WHERE repository_id = $1 AND (details_updated_at IS NULL OR details_updated_at < pr_updated_at)
+ORDER BY pr_updated_at DESC
+LIMIT $2;
+
+-- name: UpdatePullRequestDetails :one
+-- details_updated_at is the update the details were fetched at, so they are fetched again once the
+-- pull request is listed with a later one.
+UPDATE pull_requests
+SET additions = $3,
+    deletions = $4,
+    merged_by_login = $5,
+    details_updated_at = $6,
+    updated_at = NOW()
+WHERE repository_id = $1 AND number = $2
+RETURNING id;
+
+-- name: GetPullRequestsByRepoID :many
+-- date_field selects the timestamp since and until bound: created (the default), updated,
+-- merged or closed. Pull requests without that timestamp, e.g. open ones for merged, are left
+-- out when a bound is given.
+SELECT * FROM pull_requests
+WHERE repository_id = @repository_id
+  AND (@state::text = '' OR state = @state::text)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR CASE @date_field::text
+          WHEN 'updated' THEN pr_updated_at
+          WHEN 'merged' THEN merged_at
+          WHEN 'closed' THEN closed_at
+          ELSE pr_created_at
+      END >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR CASE @date_field::text
+          WHEN 'updated' THEN pr_updated_at
+          WHEN 'merged' THEN merged_at
+          WHEN 'closed' THEN closed_at
+          ELSE pr_created_at
+      END < sqlc.narg(until)::timestamptz)
+ORDER BY pr_created_at DESC, number DESC;
+
+-- name: GetPullRequestReviewsByPullRequestIDs :many
+SELECT * FROM pull_request_reviews
+WHERE pull_request_id = ANY(@pull_request_ids::bigint[])
+ORDER BY pull_request_id, submitted_at;
+
+-- name: GetLabelsByRepoID :many
+SELECT * FROM labels
+WHERE repository_id = $1
+ORDER BY name;
+
+-- name: UpsertLabel :exec
+INSERT INTO labels (repository_id, name, color, description)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (repository_id, name) DO UPDATE
+SET
+    color = EXCLUDED.color,
+    description = EXCLUDED.description,
+    updated_at = NOW();
+
+-- name: DeleteLabelsNotIn :exec
+DELETE FROM labels
+WHERE repository_id = @repository_id AND NOT (name = ANY(@names::text[]));
+
+-- name: GetLatestIssueUpdateForRepo :one
+SELECT MAX(issue_updated_at)::timestamptz AS max_updated_at FROM issues
+WHERE repository_id = $1;
+
+-- name: UpsertIssue :one
+INSERT INTO issues (
+    repository_id, number, github_issue_id, title, state, author_login, labels, assignees,
+    milestone, comments_count, url, issue_created_at, issue_updated_at, closed_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+         )
+ON CONFLICT (repository_id, number) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    state = EXCLUDED.state,
+    labels = EXCLUDED.labels,
+    assignees = EXCLUDED.assignees,
+    milestone = EXCLUDED.milestone,
+    comments_count = EXCLUDED.comments_count,
+    issue_updated_at = EXCLUDED.issue_updated_at,
+    closed_at = EXCLUDED.closed_at,
+    updated_at = NOW()
+    RETURNING *;
+
+-- name: UpsertIssueComment :exec
+INSERT INTO issue_comments (
+    issue_id, github_comment_id, author_login, comment_created_at, comment_updated_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+ON CONFLICT (issue_id, github_comment_id) DO UPDATE
+SET
+    comment_updated_at = EXCLUDED.comment_updated_at;
+
+-- name: DeleteIssueCommentsNotIn :exec
+DELETE FROM issue_comments
+WHERE issue_id = @issue_id AND NOT (github_comment_id = ANY(@github_comment_ids::bigint[]));
+
+-- name: GetIssuesWithStaleComments :many
+-- Issues with comments updated since their comments were last listed, most recently updated first.
+SELECT number FROM issues
+WHERE repository_id = $1 AND comments_count > 0
+  AND (comments_updated_at IS NULL OR comments_updated_at < issue_updated_at)
+ORDER BY issue_updated_at DESC
+LIMIT $2;
+
+-- name: MarkIssueCommentsSynced :one
+-- The comments are as of the stored update of the issue, so an update listed after they were
+-- fetched has them fetched again.
+UPDATE issues
+SET comments_updated_at = issue_updated_at
+WHERE repository_id = $1 AND number = $2
+RETURNING id;
+
+-- name: GetIssuesByRepoID :many
+-- date_field selects the timestamp since and until bound: created (the default), updated or
+-- closed. Open issues are left out when closed is bounded. An empty label matches every issue.
+SELECT * FROM issues
+WHERE repository_id = @repository_id
+  AND (@state::text = '' OR state = @state::text)
+  AND (@label::text = '' OR @label::text = ANY(labels))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR CASE @date_field::text
+          WHEN 'updated' THEN issue_updated_at
+          WHEN 'closed' THEN closed_at
+          ELSE issue_created_at
+      END >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR CASE @date_field::text
+          WHEN 'updated' THEN issue_updated_at
+          WHEN 'closed' THEN closed_at
+          ELSE issue_created_at
+      END < sqlc.narg(until)::timestamptz)
+ORDER BY issue_created_at DESC, number DESC;
+
+-- name: GetTagsByRepoID :many
+SELECT * FROM tags
 WHERE repository_id = $1
-ORDER BY commit_date DESC;
\ No newline at end of file
+ORDER BY name;
+
+-- name: UpsertTag :exec
+INSERT INTO tags (repository_id, name, sha)
+VALUES ($1, $2, $3)
+ON CONFLICT (repository_id, name) DO UPDATE
+SET
+    sha = EXCLUDED.sha,
+    updated_at = NOW();
+
+-- name: DeleteTagsNotIn :exec
+DELETE FROM tags
+WHERE repository_id = @repository_id AND NOT (name = ANY(@names::text[]));
+
+-- name: GetTagsByCommitDate :many
+-- Tags whose commit is not stored are left out. first_release is that of the tagged commit, empty
+-- when it was not mapped yet, e.g. because the tag is new or the commit was stored after it.
+SELECT t.name, t.sha, c.first_release FROM tags t
+JOIN commits c ON c.repository_id = t.repository_id AND c.sha = t.sha
+WHERE t.repository_id = $1
+ORDER BY COALESCE(c.committer_date, c.commit_date), t.name;
+
+-- name: ClearFirstReleases :exec
+UPDATE commits
+SET first_release = ''
+WHERE repository_id = $1 AND first_release <> '';
+
+-- name: AssignFirstRelease :execrows
+-- Sets the first release of the commit sha and its stored ancestors that have none yet. Tags
+-- must be assigned oldest first: the walk stops at commits an older tag already contains, as
+-- their ancestors are contained in it too.
+WITH RECURSIVE contained(sha) AS (
+    SELECT c.sha FROM commits c
+    WHERE c.repository_id = @repository_id AND c.sha = @sha AND c.first_release = ''
+    UNION
+    SELECT p.sha FROM contained
+    JOIN commits c ON c.repository_id = @repository_id AND c.sha = contained.sha
+    JOIN commits p ON p.repository_id = @repository_id AND p.sha = ANY(c.parents)
+    WHERE p.first_release = ''
+)
+UPDATE commits
+SET first_release = @tag::text
+WHERE repository_id = @repository_id AND sha IN (SELECT sha FROM contained);
+
+-- name: UpsertRelease :one
+INSERT INTO releases (
+    repository_id, github_release_id, tag_name, name, draft, prerelease, author_login, url,
+    release_created_at, published_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+         )
+ON CONFLICT (repository_id, github_release_id) DO UPDATE
+SET
+    tag_name = EXCLUDED.tag_name,
+    name = EXCLUDED.name,
+    draft = EXCLUDED.draft,
+    prerelease = EXCLUDED.prerelease,
+    url = EXCLUDED.url,
+    published_at = EXCLUDED.published_at,
+    updated_at = NOW()
+    RETURNING *;
+
+-- name: DeleteReleasesNotIn :exec
+DELETE FROM releases
+WHERE repository_id = @repository_id AND NOT (github_release_id = ANY(@github_release_ids::bigint[]));
+
+-- name: UpsertReleaseAsset :exec
+INSERT INTO release_assets (release_id, github_asset_id, name, size, download_count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (release_id, github_asset_id) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    size = EXCLUDED.size,
+    download_count = EXCLUDED.download_count;
+
+-- name: DeleteReleaseAssetsNotIn :exec
+DELETE FROM release_assets
+WHERE release_id = @release_id AND NOT (github_asset_id = ANY(@github_asset_ids::bigint[]));
+
+-- name: GetReleasesByRepoID :many
+-- tag_sha is the commit the release's tag points to, empty if the tag is unknown, e.g. for drafts.
+SELECT r.*, COALESCE(t.sha, '')::text AS tag_sha FROM releases r
+LEFT JOIN tags t ON t.repository_id = r.repository_id AND t.name = r.tag_name
+WHERE r.repository_id = $1
+ORDER BY r.release_created_at DESC, r.id DESC;
+
+-- name: GetReleaseAssetsByReleaseIDs :many
+SELECT * FROM release_assets
+WHERE release_id = ANY(@release_ids::bigint[])
+ORDER BY release_id, name;
+
+-- name: GetCommitsWithoutFiles :many
+-- Reachable commits whose changed files are not stored yet, newest first.
+SELECT sha FROM commits
+WHERE repository_id = $1 AND files_synced_at IS NULL AND unreachable_at IS NULL
+ORDER BY commit_date DESC
+LIMIT $2;
+
+-- name: CreateCommitFiles :copyfrom
+INSERT INTO commit_files (repository_id, sha, path, status, additions, deletions) VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: MarkCommitFilesSynced :exec
+-- additions and deletions are only filled in if the commits backend did not report them.
+UPDATE commits
+SET files_synced_at = NOW(),
+    additions = COALESCE(additions, @additions::int),
+    deletions = COALESCE(deletions, @deletions::int)
+WHERE repository_id = @repository_id AND sha = @sha;
+
+-- name: UpsertWorkflow :exec
+INSERT INTO workflows (repository_id, github_workflow_id, name, path, state)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (repository_id, github_workflow_id) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    path = EXCLUDED.path,
+    state = EXCLUDED.state,
+    updated_at = NOW();
+
+-- name: GetWorkflowRunSyncStart :one
+-- The creation time of the oldest run that has not completed yet, or else of the newest run.
+SELECT COALESCE(MIN(run_created_at) FILTER (WHERE status <> 'completed'), MAX(run_created_at))::timestamptz AS sync_start
+FROM workflow_runs
+WHERE repository_id = $1;
+
+-- name: UpsertWorkflowRun :exec
+INSERT INTO workflow_runs (
+    repository_id, github_run_id, workflow_id, name, event, status, conclusion, head_sha,
+    head_branch, run_number, run_attempt, run_created_at, run_started_at, run_updated_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+         )
+ON CONFLICT (repository_id, github_run_id) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    status = EXCLUDED.status,
+    conclusion = EXCLUDED.conclusion,
+    run_attempt = EXCLUDED.run_attempt,
+    run_started_at = EXCLUDED.run_started_at,
+    run_updated_at = EXCLUDED.run_updated_at,
+    updated_at = NOW();
+
+-- name: GetWorkflowStats :many
+-- Runs are bounded by their creation time. The success rate is the share of successful runs
+-- among those that completed with a verdict, leaving out cancelled, skipped and neutral runs.
+-- Durations are from the start of a run's latest attempt to its completion.
+SELECT
+    w.github_workflow_id,
+    w.name,
+    w.path,
+    COUNT(*) AS total_runs,
+    COUNT(*) FILTER (WHERE r.status = 'completed') AS completed_runs,
+    COUNT(*) FILTER (WHERE r.conclusion = 'success') AS successful_runs,
+    COALESCE(
+        COUNT(*) FILTER (WHERE r.conclusion = 'success')::float8
+            / NULLIF(COUNT(*) FILTER (WHERE r.status = 'completed' AND r.conclusion NOT IN ('cancelled', 'skipped', 'neutral')), 0),
+        0
+    )::float8 AS success_rate,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM r.run_updated_at - r.run_started_at))
+        FILTER (WHERE r.status = 'completed'), 0)::float8 AS p50_duration_seconds,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM r.run_updated_at - r.run_started_at))
+        FILTER (WHERE r.status = 'completed'), 0)::float8 AS p95_duration_seconds
+FROM workflows w
+JOIN workflow_runs r ON r.repository_id = w.repository_id AND r.workflow_id = w.github_workflow_id
+WHERE w.repository_id = @repository_id
+  AND (sqlc.narg(since)::timestamptz IS NULL OR r.run_created_at >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR r.run_created_at < sqlc.narg(until)::timestamptz)
+GROUP BY w.github_workflow_id, w.name, w.path
+ORDER BY w.name, w.github_workflow_id;
+
+-- name: CreateRepositorySnapshot :exec
+-- Records the counters currently stored for a repository.
+INSERT INTO repository_snapshots (repository_id, stars_count, forks_count, watchers_count, open_issues_count)
+SELECT id, stars_count, forks_count, watchers_count, open_issues_count
+FROM repositories
+WHERE id = $1;
+
+-- name: GetRepositoryGrowth :many
+-- The last snapshot taken in each interval, which is 'day' or 'week'. Weeks start on Monday.
+SELECT DISTINCT ON (period)
+    date_trunc(@interval::text, captured_at)::timestamptz AS period,
+    stars_count,
+    forks_count,
+    watchers_count,
+    open_issues_count
+FROM repository_snapshots
+WHERE repository_id = @repository_id
+  AND (sqlc.narg(since)::timestamptz IS NULL OR captured_at >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR captured_at < sqlc.narg(until)::timestamptz)
+ORDER BY period, captured_at DESC;
+
+-- name: CountStargazers :one
+SELECT COUNT(*) FROM stargazers
+WHERE repository_id = $1;
+
+-- name: CreateStargazers :copyfrom
+INSERT INTO stargazers (repository_id, login, starred_at) VALUES ($1, $2, $3);
+
+-- name: GetStarHistory :many
+-- The stars given in each interval according to the stored stargazers, with the running total.
+-- The interval since falls in is included in full.
+WITH buckets AS (
+    SELECT date_trunc(@interval::text, starred_at)::timestamptz AS period, COUNT(*) AS new_stars
+    FROM stargazers
+    WHERE repository_id = @repository_id
+    GROUP BY period
+), totals AS (
+    SELECT period, new_stars, SUM(new_stars) OVER (ORDER BY period)::bigint AS total_stars
+    FROM buckets
+)
+SELECT period, new_stars, total_stars
+FROM totals
+WHERE (sqlc.narg(since)::timestamptz IS NULL OR period >= date_trunc(@interval::text, sqlc.narg(since)::timestamptz))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR period < sqlc.narg(until)::timestamptz)
+ORDER BY period;
+
+-- name: UpsertRepositoryLanguages :exec
+INSERT INTO repository_languages (repository_id, language, bytes)
+SELECT @repository_id::bigint, unnest(@languages::text[]), unnest(@bytes::bigint[])
+ON CONFLICT (repository_id, language) DO UPDATE
+SET bytes = EXCLUDED.bytes;
+
+-- name: DeleteRepositoryLanguagesNotIn :exec
+DELETE FROM repository_languages
+WHERE repository_id = @repository_id AND NOT (language = ANY(@languages::text[]));
+
+-- name: AddRepositoryTopics :exec
+INSERT INTO repository_topics (repository_id, topic)
+SELECT @repository_id::bigint, unnest(@topics::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteRepositoryTopicsNotIn :exec
+DELETE FROM repository_topics
+WHERE repository_id = @repository_id AND NOT (topic = ANY(@topics::text[]));
+
+-- name: CreateSnapshotLanguages :exec
+-- Records the languages currently stored for a repository with its latest snapshot.
+INSERT INTO repository_snapshot_languages (repository_id, captured_at, language, bytes)
+SELECT l.repository_id, s.captured_at, l.language, l.bytes
+FROM repository_languages l
+JOIN (
+    SELECT repository_id, MAX(captured_at) AS captured_at FROM repository_snapshots
+    WHERE repository_id = $1
+    GROUP BY repository_id
+) s ON s.repository_id = l.repository_id
+ON CONFLICT DO NOTHING;
+
+-- name: GetRepositoryLanguagesByRepoIDs :many
+-- share is the fraction of a repository's code that is in the language.
+SELECT
+    repository_id,
+    language,
+    bytes,
+    COALESCE(bytes::float8 / NULLIF(SUM(bytes) OVER (PARTITION BY repository_id), 0), 0)::float8 AS share
+FROM repository_languages
+WHERE repository_id = ANY(@repository_ids::bigint[])
+ORDER BY repository_id, bytes DESC, language;
+
+-- name: GetRepositoryTopicsByRepoIDs :many
+SELECT * FROM repository_topics
+WHERE repository_id = ANY(@repository_ids::bigint[])
+ORDER BY repository_id, topic;
+
+-- name: ListRepositories :many
+-- Repositories tagged with topic and with code in language, if given. Languages are compared
+-- case-insensitively; repositories whose languages are not stored match by their main language.
+SELECT r.* FROM repositories r
+WHERE (@topic::text = '' OR EXISTS (
+        SELECT 1 FROM repository_topics t WHERE t.repository_id = r.id AND t.topic = lower(@topic::text)))
+  AND (@language::text = '' OR lower(r.language) = lower(@language::text) OR EXISTS (
+        SELECT 1 FROM repository_languages l WHERE l.repository_id = r.id AND lower(l.language) = lower(@language::text)))
+ORDER BY r.provider, r.host, r.owner, r.name;
+
+-- name: SeedTrackedRepositories :exec
+-- Tracks the entries that are not tracked yet. Entries that were paused stay paused.
+INSERT INTO tracked_repositories (entry, added_by)
+SELECT unnest(@entries::text[]), 'config'
+ON CONFLICT (entry) DO NOTHING;
+
+-- name: ListTrackedRepositories :many
+SELECT * FROM tracked_repositories
+ORDER BY entry;
+
+-- name: UpsertTrackedRepository :one
+-- Tracks an entry, or pauses or resumes it if it is tracked. created is false in the latter case.
+INSERT INTO tracked_repositories (entry, paused, added_by)
+VALUES (@entry, @paused, 'api')
+ON CONFLICT (entry) DO UPDATE
+SET
+    paused = EXCLUDED.paused,
+    updated_at = NOW()
+RETURNING *, (xmax = 0)::boolean AS created;
+
+-- name: DeleteTrackedRepository :execrows
+DELETE FROM tracked_repositories
+WHERE entry = $1;
+
+-- name: CreateSyncJob :one
+INSERT INTO sync_jobs (provider, host, owner, name)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetSyncJob :one
+SELECT * FROM sync_jobs
+WHERE id = $1;
+
+-- name: StartSyncJob :exec
+UPDATE sync_jobs
+SET
+    state = 'running',
+    started_at = NOW()
+WHERE id = $1;
+
+-- name: FinishSyncJob :exec
+UPDATE sync_jobs
+SET
+    state = @state,
+    commits_inserted = @commits_inserted,
+    error = @error,
+    finished_at = NOW()
+WHERE id = @id;
+
+-- name: FailUnfinishedSyncJobs :execrows
+-- Fails the jobs that are queued or running but not among keep, i.e. those a previous process
+-- did not finish.
+UPDATE sync_jobs
+SET
+    state = 'failed',
+    error = @message::text,
+    finished_at = NOW()
+WHERE state IN ('queued', 'running') AND NOT (id = ANY(@keep::bigint[]));
+
+-- name: GetHTTPValidators :one
+SELECT * FROM http_validators
+WHERE url = $1;
+
+-- name: UpsertHTTPValidators :exec
+INSERT INTO http_validators (url, etag, last_modified)
+VALUES ($1, $2, $3)
+ON CONFLICT (url) DO UPDATE
+SET
+    etag = EXCLUDED.etag,
+    last_modified = EXCLUDED.last_modified,
+    updated_at = NOW();
+
+-- name: DeleteHTTPValidators :exec
+-- The URL of a repository and those below it. starts_with rather than LIKE, as
+-- repository names may contain '_', which LIKE matches to any character.
+DELETE FROM http_validators
+WHERE url = @url::text OR starts_with(url, @url::text || '/');
+
+-- name: GetSyncCheckpoint :one
+SELECT * FROM sync_checkpoints
+WHERE repository_id = $1;
+
+-- name: StartSyncCheckpoint :one
+INSERT INTO sync_checkpoints (repository_id, since)
+VALUES ($1, $2)
+ON CONFLICT (repository_id) DO UPDATE
+SET
+    since = EXCLUDED.since,
+    page_cursor = '',
+    oldest_sha = '',
+    oldest_commit_date = NULL,
+    commits_imported = 0,
+    started_at = NOW(),
+    updated_at = NOW(),
+    completed_at = NULL
+    RETURNING *;
+
+-- name: AdvanceSyncCheckpoint :exec
+UPDATE sync_checkpoints
+SET
+    page_cursor = $2,
+    oldest_sha = $3,
+    oldest_commit_date = $4,
+    commits_imported = commits_imported + $5,
+    updated_at = NOW()
+WHERE repository_id = $1;
+
+-- name: CompleteSyncCheckpoint :exec
+UPDATE sync_checkpoints
+SET
+    page_cursor = '',
+    completed_at = NOW(),
+    updated_at = NOW()
+WHERE repository_id = $1;
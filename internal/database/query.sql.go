@@ -12,25 +12,223 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addCommitsToBranch = `-- name: AddCommitsToBranch :exec
+INSERT INTO commit_branches (branch_id, repository_id, sha)
+SELECT $1::bigint, $2::bigint, unnest($3::text[])
+ON CONFLICT DO NOTHING
+`
+
+type AddCommitsToBranchParams struct {
+	BranchID     int64    `json:"branch_id"`
+	RepositoryID int64    `json:"repository_id"`
+	Shas         []string `json:"shas"`
+}
+
+func (q *Queries) AddCommitsToBranch(ctx context.Context, arg AddCommitsToBranchParams) error {
+	_, err := q.db.Exec(ctx, addCommitsToBranch, arg.BranchID, arg.RepositoryID, arg.Shas)
+	return err
+}
+
+const addRepositoryTopics = `-- name: AddRepositoryTopics :exec
+INSERT INTO repository_topics (repository_id, topic)
+SELECT $1::bigint, unnest($2::text[])
+ON CONFLICT DO NOTHING
+`
+
+type AddRepositoryTopicsParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Topics       []string `json:"topics"`
+}
+
+func (q *Queries) AddRepositoryTopics(ctx context.Context, arg AddRepositoryTopicsParams) error {
+	_, err := q.db.Exec(ctx, addRepositoryTopics, arg.RepositoryID, arg.Topics)
+	return err
+}
+
+const advanceSyncCheckpoint = `-- name: AdvanceSyncCheckpoint :exec
+UPDATE sync_checkpoints
+SET
+    page_cursor = $2,
+    oldest_sha = $3,
+    oldest_commit_date = $4,
+    commits_imported = commits_imported + $5,
+    updated_at = NOW()
+WHERE repository_id = $1
+`
+
+type AdvanceSyncCheckpointParams struct {
+	RepositoryID     int64              `json:"repository_id"`
+	PageCursor       string             `json:"page_cursor"`
+	OldestSha        string             `json:"oldest_sha"`
+	OldestCommitDate pgtype.Timestamptz `json:"oldest_commit_date"`
+	CommitsImported  int64              `json:"commits_imported"`
+}
+
+func (q *Queries) AdvanceSyncCheckpoint(ctx context.Context, arg AdvanceSyncCheckpointParams) error {
+	_, err := q.db.Exec(ctx, advanceSyncCheckpoint,
+		arg.RepositoryID,
+		arg.PageCursor,
+		arg.OldestSha,
+		arg.OldestCommitDate,
+		arg.CommitsImported,
+	)
+	return err
+}
+
+const assignFirstRelease = `-- name: AssignFirstRelease :execrows
+WITH RECURSIVE contained(sha) AS (
+    SELECT c.sha FROM commits c
+    WHERE c.repository_id = $1 AND c.sha = $2 AND c.first_release = ''
+    UNION
+    SELECT p.sha FROM contained
+    JOIN commits c ON c.repository_id = $1 AND c.sha = contained.sha
+    JOIN commits p ON p.repository_id = $1 AND p.sha = ANY(c.parents)
+    WHERE p.first_release = ''
+)
+UPDATE commits
+SET first_release = $3::text
+WHERE repository_id = $1 AND sha IN (SELECT sha FROM contained)
+`
+
+type AssignFirstReleaseParams struct {
+	RepositoryID int64  `json:"repository_id"`
+	Sha          string `json:"sha"`
+	Tag          string `json:"tag"`
+}
+
+// Sets the first release of the commit sha and its stored ancestors that have none yet. Tags
+// must be assigned oldest first: the walk stops at commits an older tag already contains, as
+// their ancestors are contained in it too.
+func (q *Queries) AssignFirstRelease(ctx context.Context, arg AssignFirstReleaseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, assignFirstRelease, arg.RepositoryID, arg.Sha, arg.Tag)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const clearBranchCommits = `-- name: ClearBranchCommits :exec
+DELETE FROM commit_branches
+WHERE branch_id = $1
+`
+
+func (q *Queries) ClearBranchCommits(ctx context.Context, branchID int64) error {
+	_, err := q.db.Exec(ctx, clearBranchCommits, branchID)
+	return err
+}
+
+const clearFirstReleases = `-- name: ClearFirstReleases :exec
+UPDATE commits
+SET first_release = ''
+WHERE repository_id = $1 AND first_release <> ''
+`
+
+func (q *Queries) ClearFirstReleases(ctx context.Context, repositoryID int64) error {
+	_, err := q.db.Exec(ctx, clearFirstReleases, repositoryID)
+	return err
+}
+
+const completeSyncCheckpoint = `-- name: CompleteSyncCheckpoint :exec
+UPDATE sync_checkpoints
+SET
+    page_cursor = '',
+    completed_at = NOW(),
+    updated_at = NOW()
+WHERE repository_id = $1
+`
+
+func (q *Queries) CompleteSyncCheckpoint(ctx context.Context, repositoryID int64) error {
+	_, err := q.db.Exec(ctx, completeSyncCheckpoint, repositoryID)
+	return err
+}
+
+type CreateCommitFilesParams struct {
+	RepositoryID int64  `json:"repository_id"`
+	Sha          string `json:"sha"`
+	Path         string `json:"path"`
+	Status       string `json:"status"`
+	Additions    int32  `json:"additions"`
+	Deletions    int32  `json:"deletions"`
+}
+
 type CreateCommitsParams struct {
-	Sha          string    `json:"sha"`
-	RepositoryID int64     `json:"repository_id"`
-	AuthorName   string    `json:"author_name"`
-	AuthorEmail  string    `json:"author_email"`
-	Message      string    `json:"message"`
-	Url          string    `json:"url"`
-	CommitDate   time.Time `json:"commit_date"`
+	Sha             string             `json:"sha"`
+	RepositoryID    int64              `json:"repository_id"`
+	AuthorName      string             `json:"author_name"`
+	AuthorEmail     string             `json:"author_email"`
+	Message         string             `json:"message"`
+	Url             string             `json:"url"`
+	CommitDate      time.Time          `json:"commit_date"`
+	AuthorLogin     string             `json:"author_login"`
+	Additions       pgtype.Int4        `json:"additions"`
+	Deletions       pgtype.Int4        `json:"deletions"`
+	Verified        bool               `json:"verified"`
+	Parents         []string           `json:"parents"`
+	CommitterName   string             `json:"committer_name"`
+	CommitterEmail  string             `json:"committer_email"`
+	CommitterDate   pgtype.Timestamptz `json:"committer_date"`
+	OnDefaultBranch bool               `json:"on_default_branch"`
+}
+
+const countStargazers = `-- name: CountStargazers :one
+SELECT COUNT(*) FROM stargazers
+WHERE repository_id = $1
+`
+
+func (q *Queries) CountStargazers(ctx context.Context, repositoryID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countStargazers, repositoryID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createHistoryRewrite = `-- name: CreateHistoryRewrite :one
+INSERT INTO history_rewrites (
+    repository_id, old_head_sha, new_head_sha, merge_base_sha, unreachable_commits
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+    RETURNING id, repository_id, old_head_sha, new_head_sha, merge_base_sha, unreachable_commits, detected_at
+`
+
+type CreateHistoryRewriteParams struct {
+	RepositoryID       int64  `json:"repository_id"`
+	OldHeadSha         string `json:"old_head_sha"`
+	NewHeadSha         string `json:"new_head_sha"`
+	MergeBaseSha       string `json:"merge_base_sha"`
+	UnreachableCommits int64  `json:"unreachable_commits"`
+}
+
+func (q *Queries) CreateHistoryRewrite(ctx context.Context, arg CreateHistoryRewriteParams) (HistoryRewrite, error) {
+	row := q.db.QueryRow(ctx, createHistoryRewrite,
+		arg.RepositoryID,
+		arg.OldHeadSha,
+		arg.NewHeadSha,
+		arg.MergeBaseSha,
+		arg.UnreachableCommits,
+	)
+	var i HistoryRewrite
+	err := row.Scan(
+		&i.ID,
+		&i.RepositoryID,
+		&i.OldHeadSha,
+		&i.NewHeadSha,
+		&i.MergeBaseSha,
+		&i.UnreachableCommits,
+		&i.DetectedAt,
+	)
+	return i, err
 }
 
 const createRepository = `-- name: CreateRepository :one
 INSERT INTO repositories (
     github_repo_id, owner, name, description, url, language,
     forks_count, stars_count, open_issues_count, watchers_count,
-    repo_created_at, repo_updated_at
+    repo_created_at, repo_updated_at, host, provider
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
          )
-    RETURNING id, github_repo_id, owner, name, description, url, language, forks_count, stars_count, open_issues_count, watchers_count, repo_created_at, repo_updated_at, last_synced_at, created_at, updated_at
+    RETURNING id, github_repo_id, owner, name, description, url, language, forks_count, stars_count, open_issues_count, watchers_count, repo_created_at, repo_updated_at, last_synced_at, created_at, updated_at, host, provider, head_sha
 `
 
 type CreateRepositoryParams struct {
@@ -46,6 +244,8 @@ type CreateRepositoryParams struct {
 	WatchersCount   int32     `json:"watchers_count"`
 	RepoCreatedAt   time.Time `json:"repo_created_at"`
 	RepoUpdatedAt   time.Time `json:"repo_updated_at"`
+	Host            string    `json:"host"`
+	Provider        string    `json:"provider"`
 }
 
 func (q *Queries) CreateRepository(ctx context.Context, arg CreateRepositoryParams) (Repository, error) {
@@ -62,6 +262,8 @@ func (q *Queries) CreateRepository(ctx context.Context, arg CreateRepositoryPara
 		arg.WatchersCount,
 		arg.RepoCreatedAt,
 		arg.RepoUpdatedAt,
+		arg.Host,
+		arg.Provider,
 	)
 	var i Repository
 	err := row.Scan(
@@ -81,127 +283,330 @@ func (q *Queries) CreateRepository(ctx context.Context, arg CreateRepositoryPara
 		&i.LastSyncedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Host,
+		&i.Provider,
+		&i.HeadSha,
 	)
 	return i, err
 }
 
-const getCommitsByRepoID = `-- name: GetCommitsByRepoID :many
-SELECT sha, repository_id, author_name, author_email, message, url, commit_date, created_at FROM commits
-WHERE repository_id = $1
-ORDER BY commit_date DESC
+const createRepositorySnapshot = `-- name: CreateRepositorySnapshot :exec
+INSERT INTO repository_snapshots (repository_id, stars_count, forks_count, watchers_count, open_issues_count)
+SELECT id, stars_count, forks_count, watchers_count, open_issues_count
+FROM repositories
+WHERE id = $1
+`
+
+// Records the counters currently stored for a repository.
+func (q *Queries) CreateRepositorySnapshot(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, createRepositorySnapshot, id)
+	return err
+}
+
+type CreateStargazersParams struct {
+	RepositoryID int64     `json:"repository_id"`
+	Login        string    `json:"login"`
+	StarredAt    time.Time `json:"starred_at"`
+}
+
+const createSnapshotLanguages = `-- name: CreateSnapshotLanguages :exec
+INSERT INTO repository_snapshot_languages (repository_id, captured_at, language, bytes)
+SELECT l.repository_id, s.captured_at, l.language, l.bytes
+FROM repository_languages l
+JOIN (
+    SELECT repository_id, MAX(captured_at) AS captured_at FROM repository_snapshots
+    WHERE repository_id = $1
+    GROUP BY repository_id
+) s ON s.repository_id = l.repository_id
+ON CONFLICT DO NOTHING
+`
+
+// Records the languages currently stored for a repository with its latest snapshot.
+func (q *Queries) CreateSnapshotLanguages(ctx context.Context, repositoryID int64) error {
+	_, err := q.db.Exec(ctx, createSnapshotLanguages, repositoryID)
+	return err
+}
+
+const createSyncJob = `-- name: CreateSyncJob :one
+INSERT INTO sync_jobs (provider, host, owner, name)
+VALUES ($1, $2, $3, $4)
+RETURNING id, provider, host, owner, name, state, commits_inserted, error, created_at, started_at, finished_at
+`
+
+type CreateSyncJobParams struct {
+	Provider string `json:"provider"`
+	Host     string `json:"host"`
+	Owner    string `json:"owner"`
+	Name     string `json:"name"`
+}
+
+func (q *Queries) CreateSyncJob(ctx context.Context, arg CreateSyncJobParams) (SyncJob, error) {
+	row := q.db.QueryRow(ctx, createSyncJob,
+		arg.Provider,
+		arg.Host,
+		arg.Owner,
+		arg.Name,
+	)
+	var i SyncJob
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.Host,
+		&i.Owner,
+		&i.Name,
+		&i.State,
+		&i.CommitsInserted,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const deleteBranchesNotIn = `-- name: DeleteBranchesNotIn :exec
+DELETE FROM branches
+WHERE repository_id = $1 AND NOT (name = ANY($2::text[]))
+`
+
+type DeleteBranchesNotInParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Names        []string `json:"names"`
+}
+
+func (q *Queries) DeleteBranchesNotIn(ctx context.Context, arg DeleteBranchesNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteBranchesNotIn, arg.RepositoryID, arg.Names)
+	return err
+}
+
+const deleteHTTPValidators = `-- name: DeleteHTTPValidators :exec
+DELETE FROM http_validators
+WHERE url = $1::text OR starts_with(url, $1::text || '/')
+`
+
+// The URL of a repository and those below it. starts_with rather than LIKE, as
+// repository names may contain '_', which LIKE matches to any character.
+func (q *Queries) DeleteHTTPValidators(ctx context.Context, url string) error {
+	_, err := q.db.Exec(ctx, deleteHTTPValidators, url)
+	return err
+}
+
+const deleteIssueCommentsNotIn = `-- name: DeleteIssueCommentsNotIn :exec
+DELETE FROM issue_comments
+WHERE issue_id = $1 AND NOT (github_comment_id = ANY($2::bigint[]))
+`
+
+type DeleteIssueCommentsNotInParams struct {
+	IssueID          int64   `json:"issue_id"`
+	GithubCommentIds []int64 `json:"github_comment_ids"`
+}
+
+func (q *Queries) DeleteIssueCommentsNotIn(ctx context.Context, arg DeleteIssueCommentsNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteIssueCommentsNotIn, arg.IssueID, arg.GithubCommentIds)
+	return err
+}
+
+const deleteLabelsNotIn = `-- name: DeleteLabelsNotIn :exec
+DELETE FROM labels
+WHERE repository_id = $1 AND NOT (name = ANY($2::text[]))
+`
+
+type DeleteLabelsNotInParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Names        []string `json:"names"`
+}
+
+func (q *Queries) DeleteLabelsNotIn(ctx context.Context, arg DeleteLabelsNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteLabelsNotIn, arg.RepositoryID, arg.Names)
+	return err
+}
+
+const deleteReleaseAssetsNotIn = `-- name: DeleteReleaseAssetsNotIn :exec
+DELETE FROM release_assets
+WHERE release_id = $1 AND NOT (github_asset_id = ANY($2::bigint[]))
+`
+
+type DeleteReleaseAssetsNotInParams struct {
+	ReleaseID      int64   `json:"release_id"`
+	GithubAssetIds []int64 `json:"github_asset_ids"`
+}
+
+func (q *Queries) DeleteReleaseAssetsNotIn(ctx context.Context, arg DeleteReleaseAssetsNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteReleaseAssetsNotIn, arg.ReleaseID, arg.GithubAssetIds)
+	return err
+}
+
+const deleteReleasesNotIn = `-- name: DeleteReleasesNotIn :exec
+DELETE FROM releases
+WHERE repository_id = $1 AND NOT (github_release_id = ANY($2::bigint[]))
+`
+
+type DeleteReleasesNotInParams struct {
+	RepositoryID     int64   `json:"repository_id"`
+	GithubReleaseIds []int64 `json:"github_release_ids"`
+}
+
+func (q *Queries) DeleteReleasesNotIn(ctx context.Context, arg DeleteReleasesNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteReleasesNotIn, arg.RepositoryID, arg.GithubReleaseIds)
+	return err
+}
+
+const deleteRepositoryLanguagesNotIn = `-- name: DeleteRepositoryLanguagesNotIn :exec
+DELETE FROM repository_languages
+WHERE repository_id = $1 AND NOT (language = ANY($2::text[]))
+`
+
+type DeleteRepositoryLanguagesNotInParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Languages    []string `json:"languages"`
+}
+
+func (q *Queries) DeleteRepositoryLanguagesNotIn(ctx context.Context, arg DeleteRepositoryLanguagesNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteRepositoryLanguagesNotIn, arg.RepositoryID, arg.Languages)
+	return err
+}
+
+const deleteRepositoryTopicsNotIn = `-- name: DeleteRepositoryTopicsNotIn :exec
+DELETE FROM repository_topics
+WHERE repository_id = $1 AND NOT (topic = ANY($2::text[]))
+`
+
+type DeleteRepositoryTopicsNotInParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Topics       []string `json:"topics"`
+}
+
+func (q *Queries) DeleteRepositoryTopicsNotIn(ctx context.Context, arg DeleteRepositoryTopicsNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteRepositoryTopicsNotIn, arg.RepositoryID, arg.Topics)
+	return err
+}
+
+const deleteTagsNotIn = `-- name: DeleteTagsNotIn :exec
+DELETE FROM tags
+WHERE repository_id = $1 AND NOT (name = ANY($2::text[]))
 `
 
-func (q *Queries) GetCommitsByRepoID(ctx context.Context, repositoryID int64) ([]Commit, error) {
-	rows, err := q.db.Query(ctx, getCommitsByRepoID, repositoryID)
+type DeleteTagsNotInParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Names        []string `json:"names"`
+}
+
+func (q *Queries) DeleteTagsNotIn(ctx context.Context, arg DeleteTagsNotInParams) error {
+	_, err := q.db.Exec(ctx, deleteTagsNotIn, arg.RepositoryID, arg.Names)
+	return err
+}
+
+const deleteTrackedRepository = `-- name: DeleteTrackedRepository :execrows
+DELETE FROM tracked_repositories
+WHERE entry = $1
+`
+
+func (q *Queries) DeleteTrackedRepository(ctx context.Context, entry string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTrackedRepository, entry)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Commit
-	for rows.Next() {
-		var i Commit
-		if err := rows.Scan(
-			&i.Sha,
-			&i.RepositoryID,
-			&i.AuthorName,
-			&i.AuthorEmail,
-			&i.Message,
-			&i.Url,
-			&i.CommitDate,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected(), nil
+}
+
+const failUnfinishedSyncJobs = `-- name: FailUnfinishedSyncJobs :execrows
+UPDATE sync_jobs
+SET
+    state = 'failed',
+    error = $1::text,
+    finished_at = NOW()
+WHERE state IN ('queued', 'running') AND NOT (id = ANY($2::bigint[]))
+`
+
+type FailUnfinishedSyncJobsParams struct {
+	Message string  `json:"message"`
+	Keep    []int64 `json:"keep"`
+}
+
+// Fails the jobs that are queued or running but not among keep, i.e. those a previous process
+// did not finish.
+func (q *Queries) FailUnfinishedSyncJobs(ctx context.Context, arg FailUnfinishedSyncJobsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, failUnfinishedSyncJobs, arg.Message, arg.Keep)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getLatestCommitDateForRepo = `-- name: GetLatestCommitDateForRepo :one
-SELECT MAX(commit_date)::timestamp AS max_date FROM commits
-WHERE repository_id = $1
+const finishSyncJob = `-- name: FinishSyncJob :exec
+UPDATE sync_jobs
+SET
+    state = $1,
+    commits_inserted = $2,
+    error = $3,
+    finished_at = NOW()
+WHERE id = $4
 `
 
-func (q *Queries) GetLatestCommitDateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamp, error) {
-	row := q.db.QueryRow(ctx, getLatestCommitDateForRepo, repositoryID)
-	var max_date pgtype.Timestamp
-	err := row.Scan(&max_date)
-	return max_date, err
+type FinishSyncJobParams struct {
+	State           string `json:"state"`
+	CommitsInserted int64  `json:"commits_inserted"`
+	Error           string `json:"error"`
+	ID              int64  `json:"id"`
 }
 
-const getRepositoryByOwnerAndName = `-- name: GetRepositoryByOwnerAndName :one
+func (q *Queries) FinishSyncJob(ctx context.Context, arg FinishSyncJobParams) error {
+	_, err := q.db.Exec(ctx, finishSyncJob,
+		arg.State,
+		arg.CommitsInserted,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
 
-SELECT id, github_repo_id, owner, name, description, url, language, forks_count, stars_count, open_issues_count, watchers_count, repo_created_at, repo_updated_at, last_synced_at, created_at, updated_at FROM repositories
-WHERE owner = $1 AND name = $2
-LIMIT 1
+const getBranch = `-- name: GetBranch :one
+SELECT id, repository_id, name, head_sha, created_at, updated_at FROM branches
+WHERE repository_id = $1 AND name = $2
 `
 
-type GetRepositoryByOwnerAndNameParams struct {
-	Owner string `json:"owner"`
-	Name  string `json:"name"`
+type GetBranchParams struct {
+	RepositoryID int64  `json:"repository_id"`
+	Name         string `json:"name"`
 }
 
-// internal/database/query.sql
-func (q *Queries) GetRepositoryByOwnerAndName(ctx context.Context, arg GetRepositoryByOwnerAndNameParams) (Repository, error) {
-	row := q.db.QueryRow(ctx, getRepositoryByOwnerAndName, arg.Owner, arg.Name)
-	var i Repository
+func (q *Queries) GetBranch(ctx context.Context, arg GetBranchParams) (Branch, error) {
+	row := q.db.QueryRow(ctx, getBranch, arg.RepositoryID, arg.Name)
+	var i Branch
 	err := row.Scan(
 		&i.ID,
-		&i.GithubRepoID,
-		&i.Owner,
+		&i.RepositoryID,
 		&i.Name,
-		&i.Description,
-		&i.Url,
-		&i.Language,
-		&i.ForksCount,
-		&i.StarsCount,
-		&i.OpenIssuesCount,
-		&i.WatchersCount,
-		&i.RepoCreatedAt,
-		&i.RepoUpdatedAt,
-		&i.LastSyncedAt,
+		&i.HeadSha,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getTopNCommitAuthors = `-- name: GetTopNCommitAuthors :many
-SELECT
-    author_name,
-    author_email,
-    COUNT(*) as commit_count
-FROM commits
+const getBranchesByRepoID = `-- name: GetBranchesByRepoID :many
+SELECT id, repository_id, name, head_sha, created_at, updated_at FROM branches
 WHERE repository_id = $1
-GROUP BY author_name, author_email
-ORDER BY commit_count DESC
-LIMIT $2
+ORDER BY name
 `
 
-type GetTopNCommitAuthorsParams struct {
-	RepositoryID int64 `json:"repository_id"`
-	Limit        int32 `json:"limit"`
-}
-
-type GetTopNCommitAuthorsRow struct {
-	AuthorName  string `json:"author_name"`
-	AuthorEmail string `json:"author_email"`
-	CommitCount int64  `json:"commit_count"`
-}
-
-func (q *Queries) GetTopNCommitAuthors(ctx context.Context, arg GetTopNCommitAuthorsParams) ([]GetTopNCommitAuthorsRow, error) {
-	rows, err := q.db.Query(ctx, getTopNCommitAuthors, arg.RepositoryID, arg.Limit)
+func (q *Queries) GetBranchesByRepoID(ctx context.Context, repositoryID int64) ([]Branch, error) {
+	rows, err := q.db.Query(ctx, getBranchesByRepoID, repositoryID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetTopNCommitAuthorsRow
+	var items []Branch
 	for rows.Next() {
-		var i GetTopNCommitAuthorsRow
-		if err := rows.Scan(&i.AuthorName, &i.AuthorEmail, &i.CommitCount); err != nil {
+		var i Branch
+		if err := rows.Scan(
+			&i.ID,
+			&i.RepositoryID,
+			&i.Name,
+			&i.HeadSha,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -212,30 +617,1443 @@ func (q *Queries) GetTopNCommitAuthors(ctx context.Context, arg GetTopNCommitAut
 	return items, nil
 }
 
-const updateRepositorySyncData = `-- name: UpdateRepositorySyncData :one
-UPDATE repositories
-SET
-    description = $2,
-    language = $3,
-    forks_count = $4,
-    stars_count = $5,
-    open_issues_count = $6,
-    watchers_count = $7,
-    repo_updated_at = $8,
-    last_synced_at = NOW(),
-    updated_at = NOW()
-WHERE id = $1
-    RETURNING id, github_repo_id, owner, name, description, url, language, forks_count, stars_count, open_issues_count, watchers_count, repo_created_at, repo_updated_at, last_synced_at, created_at, updated_at
+const getCommitsByBranchID = `-- name: GetCommitsByBranchID :many
+SELECT c.sha, c.repository_id, c.author_name, c.author_email, c.message, c.url, c.commit_date, c.created_at, c.author_login, c.additions, c.deletions, c.verified, c.parents, c.committer_name, c.committer_email, c.committer_date, c.unreachable_at, c.on_default_branch, c.first_release, c.files_synced_at FROM commits c
+JOIN commit_branches cb ON cb.repository_id = c.repository_id AND cb.sha = c.sha
+WHERE cb.branch_id = $1
+ORDER BY c.commit_date DESC
 `
 
-type UpdateRepositorySyncDataParams struct {
-	ID              int64     `json:"id"`
-	Description     string    `json:"description"`
-	Language        string    `json:"language"`
-	ForksCount      int32     `json:"forks_count"`
-	StarsCount      int32     `json:"stars_count"`
-	OpenIssuesCount int32     `json:"open_issues_count"`
-	WatchersCount   int32     `json:"watchers_count"`
+func (q *Queries) GetCommitsByBranchID(ctx context.Context, branchID int64) ([]Commit, error) {
+	rows, err := q.db.Query(ctx, getCommitsByBranchID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Commit
+	for rows.Next() {
+		var i Commit
+		if err := rows.Scan(
+			&i.Sha,
+			&i.RepositoryID,
+			&i.AuthorName,
+			&i.AuthorEmail,
+			&i.Message,
+			&i.Url,
+			&i.CommitDate,
+			&i.CreatedAt,
+			&i.AuthorLogin,
+			&i.Additions,
+			&i.Deletions,
+			&i.Verified,
+			&i.Parents,
+			&i.CommitterName,
+			&i.CommitterEmail,
+			&i.CommitterDate,
+			&i.UnreachableAt,
+			&i.OnDefaultBranch,
+			&i.FirstRelease,
+			&i.FilesSyncedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommitsByRepoID = `-- name: GetCommitsByRepoID :many
+SELECT sha, repository_id, author_name, author_email, message, url, commit_date, created_at, author_login, additions, deletions, verified, parents, committer_name, committer_email, committer_date, unreachable_at, on_default_branch, first_release, files_synced_at FROM commits
+WHERE repository_id = $1 AND (unreachable_at IS NULL OR $2::boolean)
+ORDER BY commit_date DESC
+`
+
+type GetCommitsByRepoIDParams struct {
+	RepositoryID       int64 `json:"repository_id"`
+	IncludeUnreachable bool  `json:"include_unreachable"`
+}
+
+func (q *Queries) GetCommitsByRepoID(ctx context.Context, arg GetCommitsByRepoIDParams) ([]Commit, error) {
+	rows, err := q.db.Query(ctx, getCommitsByRepoID, arg.RepositoryID, arg.IncludeUnreachable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Commit
+	for rows.Next() {
+		var i Commit
+		if err := rows.Scan(
+			&i.Sha,
+			&i.RepositoryID,
+			&i.AuthorName,
+			&i.AuthorEmail,
+			&i.Message,
+			&i.Url,
+			&i.CommitDate,
+			&i.CreatedAt,
+			&i.AuthorLogin,
+			&i.Additions,
+			&i.Deletions,
+			&i.Verified,
+			&i.Parents,
+			&i.CommitterName,
+			&i.CommitterEmail,
+			&i.CommitterDate,
+			&i.UnreachableAt,
+			&i.OnDefaultBranch,
+			&i.FirstRelease,
+			&i.FilesSyncedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommitsWithoutFiles = `-- name: GetCommitsWithoutFiles :many
+SELECT sha FROM commits
+WHERE repository_id = $1 AND files_synced_at IS NULL AND unreachable_at IS NULL
+ORDER BY commit_date DESC
+LIMIT $2
+`
+
+type GetCommitsWithoutFilesParams struct {
+	RepositoryID int64 `json:"repository_id"`
+	Limit        int32 `json:"limit"`
+}
+
+// Reachable commits whose changed files are not stored yet, newest first.
+func (q *Queries) GetCommitsWithoutFiles(ctx context.Context, arg GetCommitsWithoutFilesParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, getCommitsWithoutFiles, arg.RepositoryID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var sha string
+		if err := rows.Scan(&sha); err != nil {
+			return nil, err
+		}
+		items = append(items, sha)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHTTPValidators = `-- name: GetHTTPValidators :one
+SELECT url, etag, last_modified, updated_at FROM http_validators
+WHERE url = $1
+`
+
+func (q *Queries) GetHTTPValidators(ctx context.Context, url string) (HttpValidator, error) {
+	row := q.db.QueryRow(ctx, getHTTPValidators, url)
+	var i HttpValidator
+	err := row.Scan(
+		&i.Url,
+		&i.Etag,
+		&i.LastModified,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getHistoryRewritesByRepoID = `-- name: GetHistoryRewritesByRepoID :many
+SELECT id, repository_id, old_head_sha, new_head_sha, merge_base_sha, unreachable_commits, detected_at FROM history_rewrites
+WHERE repository_id = $1
+ORDER BY detected_at DESC, id DESC
+`
+
+func (q *Queries) GetHistoryRewritesByRepoID(ctx context.Context, repositoryID int64) ([]HistoryRewrite, error) {
+	rows, err := q.db.Query(ctx, getHistoryRewritesByRepoID, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HistoryRewrite
+	for rows.Next() {
+		var i HistoryRewrite
+		if err := rows.Scan(
+			&i.ID,
+			&i.RepositoryID,
+			&i.OldHeadSha,
+			&i.NewHeadSha,
+			&i.MergeBaseSha,
+			&i.UnreachableCommits,
+			&i.DetectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIssuesByRepoID = `-- name: GetIssuesByRepoID :many
+SELECT id, repository_id, number, github_issue_id, title, state, author_login, labels, assignees, milestone, comments_count, url, issue_created_at, issue_updated_at, closed_at, created_at, updated_at, comments_updated_at FROM issues
+WHERE repository_id = $1
+  AND ($2::text = '' OR state = $2::text)
+  AND ($3::text = '' OR $3::text = ANY(labels))
+  AND ($4::timestamptz IS NULL OR CASE $5::text
+          WHEN 'updated' THEN issue_updated_at
+          WHEN 'closed' THEN closed_at
+          ELSE issue_created_at
+      END >= $4::timestamptz)
+  AND ($6::timestamptz IS NULL OR CASE $5::text
+          WHEN 'updated' THEN issue_updated_at
+          WHEN 'closed' THEN closed_at
+          ELSE issue_created_at
+      END < $6::timestamptz)
+ORDER BY issue_created_at DESC, number DESC
+`
+
+type GetIssuesByRepoIDParams struct {
+	RepositoryID int64              `json:"repository_id"`
+	State        string             `json:"state"`
+	Label        string             `json:"label"`
+	Since        pgtype.Timestamptz `json:"since"`
+	DateField    string             `json:"date_field"`
+	Until        pgtype.Timestamptz `json:"until"`
+}
+
+// date_field selects the timestamp since and until bound: created (the default), updated or
+// closed. Open issues are left out when closed is bounded. An empty label matches every issue.
+func (q *Queries) GetIssuesByRepoID(ctx context.Context, arg GetIssuesByRepoIDParams) ([]Issue, error) {
+	rows, err := q.db.Query(ctx, getIssuesByRepoID,
+		arg.RepositoryID,
+		arg.State,
+		arg.Label,
+		arg.Since,
+		arg.DateField,
+		arg.Until,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Issue
+	for rows.Next() {
+		var i Issue
+		if err := rows.Scan(
+			&i.ID,
+			&i.RepositoryID,
+			&i.Number,
+			&i.GithubIssueID,
+			&i.Title,
+			&i.State,
+			&i.AuthorLogin,
+			&i.Labels,
+			&i.Assignees,
+			&i.Milestone,
+			&i.CommentsCount,
+			&i.Url,
+			&i.IssueCreatedAt,
+			&i.IssueUpdatedAt,
+			&i.ClosedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CommentsUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIssuesWithStaleComments = `-- name: GetIssuesWithStaleComments :many
+SELECT number FROM issues
+WHERE repository_id = $1 AND comments_count > 0
+  AND (comments_updated_at IS NULL OR comments_updated_at < issue_updated_at)
+ORDER BY issue_updated_at DESC
+LIMIT $2
+`
+
+type GetIssuesWithStaleCommentsParams struct {
+	RepositoryID int64 `json:"repository_id"`
+	Limit        int32 `json:"limit"`
+}
+
+// Issues with comments updated since their comments were last listed, most recently updated first.
+func (q *Queries) GetIssuesWithStaleComments(ctx context.Context, arg GetIssuesWithStaleCommentsParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getIssuesWithStaleComments, arg.RepositoryID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var number int32
+		if err := rows.Scan(&number); err != nil {
+			return nil, err
+		}
+		items = append(items, number)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLabelsByRepoID = `-- name: GetLabelsByRepoID :many
+SELECT id, repository_id, name, color, description, created_at, updated_at FROM labels
+WHERE repository_id = $1
+ORDER BY name
+`
+
+func (q *Queries) GetLabelsByRepoID(ctx context.Context, repositoryID int64) ([]Label, error) {
+	rows, err := q.db.Query(ctx, getLabelsByRepoID, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Label
+	for rows.Next() {
+		var i Label
+		if err := rows.Scan(
+			&i.ID,
+			&i.RepositoryID,
+			&i.Name,
+			&i.Color,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestCommitDateForBranch = `-- name: GetLatestCommitDateForBranch :one
+SELECT MAX(COALESCE(c.committer_date, c.commit_date))::timestamp AS max_date FROM commits c
+JOIN commit_branches cb ON cb.repository_id = c.repository_id AND cb.sha = c.sha
+WHERE cb.branch_id = $1
+`
+
+func (q *Queries) GetLatestCommitDateForBranch(ctx context.Context, branchID int64) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, getLatestCommitDateForBranch, branchID)
+	var max_date pgtype.Timestamp
+	err := row.Scan(&max_date)
+	return max_date, err
+}
+
+const getLatestCommitDateForRepo = `-- name: GetLatestCommitDateForRepo :one
+SELECT MAX(COALESCE(committer_date, commit_date))::timestamp AS max_date FROM commits
+WHERE repository_id = $1 AND unreachable_at IS NULL AND on_default_branch
+`
+
+// Commit listings filter on the committer date, which rebases and cherry-picks reset, so the
+// author date is only used for commits whose committer date is unknown.
+// Commits only seen on other tracked branches are left out, as the default branch's listing
+// has not necessarily reached their dates.
+func (q *Queries) GetLatestCommitDateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, getLatestCommitDateForRepo, repositoryID)
+	var max_date pgtype.Timestamp
+	err := row.Scan(&max_date)
+	return max_date, err
+}
+
+const getLatestCommitSHAForRepo = `-- name: GetLatestCommitSHAForRepo :one
+SELECT sha FROM commits
+WHERE repository_id = $1 AND unreachable_at IS NULL AND on_default_branch
+ORDER BY COALESCE(committer_date, commit_date) DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestCommitSHAForRepo(ctx context.Context, repositoryID int64) (string, error) {
+	row := q.db.QueryRow(ctx, getLatestCommitSHAForRepo, repositoryID)
+	var sha string
+	err := row.Scan(&sha)
+	return sha, err
+}
+
+const getLatestIssueUpdateForRepo = `-- name: GetLatestIssueUpdateForRepo :one
+SELECT MAX(issue_updated_at)::timestamptz AS max_updated_at FROM issues
+WHERE repository_id = $1
+`
+
+func (q *Queries) GetLatestIssueUpdateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, getLatestIssueUpdateForRepo, repositoryID)
+	var max_updated_at pgtype.Timestamptz
+	err := row.Scan(&max_updated_at)
+	return max_updated_at, err
+}
+
+const getLatestPullRequestUpdateForRepo = `-- name: GetLatestPullRequestUpdateForRepo :one
+SELECT MAX(pr_updated_at)::timestamptz AS max_updated_at FROM pull_requests
+WHERE repository_id = $1
+`
+
+func (q *Queries) GetLatestPullRequestUpdateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, getLatestPullRequestUpdateForRepo, repositoryID)
+	var max_updated_at pgtype.Timestamptz
+	err := row.Scan(&max_updated_at)
+	return max_updated_at, err
+}
+
+const getPullRequestReviewsByPullRequestIDs = `-- name: GetPullRequestReviewsByPullRequestIDs :many
+SELECT pull_request_id, github_review_id, reviewer_login, state, commit_sha, submitted_at FROM pull_request_reviews
+WHERE pull_request_id = ANY($1::bigint[])
+ORDER BY pull_request_id, submitted_at
+`
+
+func (q *Queries) GetPullRequestReviewsByPullRequestIDs(ctx context.Context, pullRequestIds []int64) ([]PullRequestReview, error) {
+	rows, err := q.db.Query(ctx, getPullRequestReviewsByPullRequestIDs, pullRequestIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PullRequestReview
+	for rows.Next() {
+		var i PullRequestReview
+		if err := rows.Scan(
+			&i.PullRequestID,
+			&i.GithubReviewID,
+			&i.ReviewerLogin,
+			&i.State,
+			&i.CommitSha,
+			&i.SubmittedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPullRequestsByRepoID = `-- name: GetPullRequestsByRepoID :many
+SELECT id, repository_id, number, github_pr_id, title, state, author_login, base_ref, head_ref, additions, deletions, merged_by_login, url, pr_created_at, pr_updated_at, merged_at, closed_at, created_at, updated_at, details_updated_at FROM pull_requests
+WHERE repository_id = $1
+  AND ($2::text = '' OR state = $2::text)
+  AND ($3::timestamptz IS NULL OR CASE $4::text
+          WHEN 'updated' THEN pr_updated_at
+          WHEN 'merged' THEN merged_at
+          WHEN 'closed' THEN closed_at
+          ELSE pr_created_at
+      END >= $3::timestamptz)
+  AND ($5::timestamptz IS NULL OR CASE $4::text
+          WHEN 'updated' THEN pr_updated_at
+          WHEN 'merged' THEN merged_at
+          WHEN 'closed' THEN closed_at
+          ELSE pr_created_at
+      END < $5::timestamptz)
+ORDER BY pr_created_at DESC, number DESC
+`
+
+type GetPullRequestsByRepoIDParams struct {
+	RepositoryID int64              `json:"repository_id"`
+	State        string             `json:"state"`
+	Since        pgtype.Timestamptz `json:"since"`
+	DateField    string             `json:"date_field"`
+	Until        pgtype.Timestamptz `json:"until"`
+}
+
+// date_field selects the timestamp since and until bound: created (the default), updated,
+// merged or closed. Pull requests without that timestamp, e.g. open ones for merged, are left
+// out when a bound is given.
+func (q *Queries) GetPullRequestsByRepoID(ctx context.Context, arg GetPullRequestsByRepoIDParams) ([]PullRequest, error) {
+	rows, err := q.db.Query(ctx, getPullRequestsByRepoID,
+		arg.RepositoryID,
+		arg.State,
+		arg.Since,
+		arg.DateField,
+		arg.Until,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PullRequest
+	for rows.Next() {
+		var i PullRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.RepositoryID,
+			&i.Number,
+			&i.GithubPrID,
+			&i.Title,
+			&i.State,
+			&i.AuthorLogin,
+			&i.BaseRef,
+			&i.HeadRef,
+			&i.Additions,
+			&i.Deletions,
+			&i.MergedByLogin,
+			&i.Url,
+			&i.PrCreatedAt,
+			&i.PrUpdatedAt,
+			&i.MergedAt,
+			&i.ClosedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DetailsUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPullRequestsWithoutDetails = `-- name: GetPullRequestsWithoutDetails :many
+SELECT number FROM pull_requests
+WHERE repository_id = $1 AND (details_updated_at IS NULL OR details_updated_at < pr_updated_at)
+ORDER BY pr_updated_at DESC
+LIMIT $2
+`
+
+type GetPullRequestsWithoutDetailsParams struct {
+	RepositoryID int64 `json:"repository_id"`
+	Limit        int32 `json:"limit"`
+}
+
+// Pull requests updated since their line stats, merger and reviews were last fetched, most
+// recently updated first.
+func (q *Queries) GetPullRequestsWithoutDetails(ctx context.Context, arg GetPullRequestsWithoutDetailsParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getPullRequestsWithoutDetails, arg.RepositoryID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var number int32
+		if err := rows.Scan(&number); err != nil {
+			return nil, err
+		}
+		items = append(items, number)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReleaseAssetsByReleaseIDs = `-- name: GetReleaseAssetsByReleaseIDs :many
+SELECT release_id, github_asset_id, name, size, download_count FROM release_assets
+WHERE release_id = ANY($1::bigint[])
+ORDER BY release_id, name
+`
+
+func (q *Queries) GetReleaseAssetsByReleaseIDs(ctx context.Context, releaseIds []int64) ([]ReleaseAsset, error) {
+	rows, err := q.db.Query(ctx, getReleaseAssetsByReleaseIDs, releaseIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReleaseAsset
+	for rows.Next() {
+		var i ReleaseAsset
+		if err := rows.Scan(
+			&i.ReleaseID,
+			&i.GithubAssetID,
+			&i.Name,
+			&i.Size,
+			&i.DownloadCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReleasesByRepoID = `-- name: GetReleasesByRepoID :many
+SELECT r.id, r.repository_id, r.github_release_id, r.tag_name, r.name, r.draft, r.prerelease, r.author_login, r.url, r.release_created_at, r.published_at, r.created_at, r.updated_at, COALESCE(t.sha, '')::text AS tag_sha FROM releases r
+LEFT JOIN tags t ON t.repository_id = r.repository_id AND t.name = r.tag_name
+WHERE r.repository_id = $1
+ORDER BY r.release_created_at DESC, r.id DESC
+`
+
+type GetReleasesByRepoIDRow struct {
+	ID               int64              `json:"id"`
+	RepositoryID     int64              `json:"repository_id"`
+	GithubReleaseID  int64              `json:"github_release_id"`
+	TagName          string             `json:"tag_name"`
+	Name             string             `json:"name"`
+	Draft            bool               `json:"draft"`
+	Prerelease       bool               `json:"prerelease"`
+	AuthorLogin      string             `json:"author_login"`
+	Url              string             `json:"url"`
+	ReleaseCreatedAt time.Time          `json:"release_created_at"`
+	PublishedAt      pgtype.Timestamptz `json:"published_at"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	TagSha           string             `json:"tag_sha"`
+}
+
+// tag_sha is the commit the release's tag points to, empty if the tag is unknown, e.g. for drafts.
+func (q *Queries) GetReleasesByRepoID(ctx context.Context, repositoryID int64) ([]GetReleasesByRepoIDRow, error) {
+	rows, err := q.db.Query(ctx, getReleasesByRepoID, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReleasesByRepoIDRow
+	for rows.Next() {
+		var i GetReleasesByRepoIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RepositoryID,
+			&i.GithubReleaseID,
+			&i.TagName,
+			&i.Name,
+			&i.Draft,
+			&i.Prerelease,
+			&i.AuthorLogin,
+			&i.Url,
+			&i.ReleaseCreatedAt,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TagSha,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepositoryByProviderHostOwnerAndName = `-- name: GetRepositoryByProviderHostOwnerAndName :one
+
+SELECT id, github_repo_id, owner, name, description, url, language, forks_count, stars_count, open_issues_count, watchers_count, repo_created_at, repo_updated_at, last_synced_at, created_at, updated_at, host, provider, head_sha FROM repositories
+WHERE provider = $1 AND host = $2 AND owner = $3 AND name = $4
+LIMIT 1
+`
+
+type GetRepositoryByProviderHostOwnerAndNameParams struct {
+	Provider string `json:"provider"`
+	Host     string `json:"host"`
+	Owner    string `json:"owner"`
+	Name     string `json:"name"`
+}
+
+// internal/database/query.sql
+func (q *Queries) GetRepositoryByProviderHostOwnerAndName(ctx context.Context, arg GetRepositoryByProviderHostOwnerAndNameParams) (Repository, error) {
+	row := q.db.QueryRow(ctx, getRepositoryByProviderHostOwnerAndName,
+		arg.Provider,
+		arg.Host,
+		arg.Owner,
+		arg.Name,
+	)
+	var i Repository
+	err := row.Scan(
+		&i.ID,
+		&i.GithubRepoID,
+		&i.Owner,
+		&i.Name,
+		&i.Description,
+		&i.Url,
+		&i.Language,
+		&i.ForksCount,
+		&i.StarsCount,
+		&i.OpenIssuesCount,
+		&i.WatchersCount,
+		&i.RepoCreatedAt,
+		&i.RepoUpdatedAt,
+		&i.LastSyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Host,
+		&i.Provider,
+		&i.HeadSha,
+	)
+	return i, err
+}
+
+const getRepositoryGrowth = `-- name: GetRepositoryGrowth :many
+SELECT DISTINCT ON (period)
+    date_trunc($1::text, captured_at)::timestamptz AS period,
+    stars_count,
+    forks_count,
+    watchers_count,
+    open_issues_count
+FROM repository_snapshots
+WHERE repository_id = $2
+  AND ($3::timestamptz IS NULL OR captured_at >= $3::timestamptz)
+  AND ($4::timestamptz IS NULL OR captured_at < $4::timestamptz)
+ORDER BY period, captured_at DESC
+`
+
+type GetRepositoryGrowthParams struct {
+	Interval     string             `json:"interval"`
+	RepositoryID int64              `json:"repository_id"`
+	Since        pgtype.Timestamptz `json:"since"`
+	Until        pgtype.Timestamptz `json:"until"`
+}
+
+type GetRepositoryGrowthRow struct {
+	Period          pgtype.Timestamptz `json:"period"`
+	StarsCount      int32              `json:"stars_count"`
+	ForksCount      int32              `json:"forks_count"`
+	WatchersCount   int32              `json:"watchers_count"`
+	OpenIssuesCount int32              `json:"open_issues_count"`
+}
+
+// The last snapshot taken in each interval, which is 'day' or 'week'. Weeks start on Monday.
+func (q *Queries) GetRepositoryGrowth(ctx context.Context, arg GetRepositoryGrowthParams) ([]GetRepositoryGrowthRow, error) {
+	rows, err := q.db.Query(ctx, getRepositoryGrowth,
+		arg.Interval,
+		arg.RepositoryID,
+		arg.Since,
+		arg.Until,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRepositoryGrowthRow
+	for rows.Next() {
+		var i GetRepositoryGrowthRow
+		if err := rows.Scan(
+			&i.Period,
+			&i.StarsCount,
+			&i.ForksCount,
+			&i.WatchersCount,
+			&i.OpenIssuesCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepositoryLanguagesByRepoIDs = `-- name: GetRepositoryLanguagesByRepoIDs :many
+SELECT
+    repository_id,
+    language,
+    bytes,
+    COALESCE(bytes::float8 / NULLIF(SUM(bytes) OVER (PARTITION BY repository_id), 0), 0)::float8 AS share
+FROM repository_languages
+WHERE repository_id = ANY($1::bigint[])
+ORDER BY repository_id, bytes DESC, language
+`
+
+type GetRepositoryLanguagesByRepoIDsRow struct {
+	RepositoryID int64   `json:"repository_id"`
+	Language     string  `json:"language"`
+	Bytes        int64   `json:"bytes"`
+	Share        float64 `json:"share"`
+}
+
+// share is the fraction of a repository's code that is in the language.
+func (q *Queries) GetRepositoryLanguagesByRepoIDs(ctx context.Context, repositoryIds []int64) ([]GetRepositoryLanguagesByRepoIDsRow, error) {
+	rows, err := q.db.Query(ctx, getRepositoryLanguagesByRepoIDs, repositoryIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRepositoryLanguagesByRepoIDsRow
+	for rows.Next() {
+		var i GetRepositoryLanguagesByRepoIDsRow
+		if err := rows.Scan(
+			&i.RepositoryID,
+			&i.Language,
+			&i.Bytes,
+			&i.Share,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepositoryTopicsByRepoIDs = `-- name: GetRepositoryTopicsByRepoIDs :many
+SELECT repository_id, topic FROM repository_topics
+WHERE repository_id = ANY($1::bigint[])
+ORDER BY repository_id, topic
+`
+
+func (q *Queries) GetRepositoryTopicsByRepoIDs(ctx context.Context, repositoryIds []int64) ([]RepositoryTopic, error) {
+	rows, err := q.db.Query(ctx, getRepositoryTopicsByRepoIDs, repositoryIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RepositoryTopic
+	for rows.Next() {
+		var i RepositoryTopic
+		if err := rows.Scan(&i.RepositoryID, &i.Topic); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStarHistory = `-- name: GetStarHistory :many
+WITH buckets AS (
+    SELECT date_trunc($1::text, starred_at)::timestamptz AS period, COUNT(*) AS new_stars
+    FROM stargazers
+    WHERE repository_id = $2
+    GROUP BY period
+), totals AS (
+    SELECT period, new_stars, SUM(new_stars) OVER (ORDER BY period)::bigint AS total_stars
+    FROM buckets
+)
+SELECT period, new_stars, total_stars
+FROM totals
+WHERE ($3::timestamptz IS NULL OR period >= date_trunc($1::text, $3::timestamptz))
+  AND ($4::timestamptz IS NULL OR period < $4::timestamptz)
+ORDER BY period
+`
+
+type GetStarHistoryParams struct {
+	Interval     string             `json:"interval"`
+	RepositoryID int64              `json:"repository_id"`
+	Since        pgtype.Timestamptz `json:"since"`
+	Until        pgtype.Timestamptz `json:"until"`
+}
+
+type GetStarHistoryRow struct {
+	Period     pgtype.Timestamptz `json:"period"`
+	NewStars   int64              `json:"new_stars"`
+	TotalStars int64              `json:"total_stars"`
+}
+
+// The stars given in each interval according to the stored stargazers, with the running total.
+// The interval since falls in is included in full.
+func (q *Queries) GetStarHistory(ctx context.Context, arg GetStarHistoryParams) ([]GetStarHistoryRow, error) {
+	rows, err := q.db.Query(ctx, getStarHistory,
+		arg.Interval,
+		arg.RepositoryID,
+		arg.Since,
+		arg.Until,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStarHistoryRow
+	for rows.Next() {
+		var i GetStarHistoryRow
+		if err := rows.Scan(&i.Period, &i.NewStars, &i.TotalStars); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSyncCheckpoint = `-- name: GetSyncCheckpoint :one
+SELECT repository_id, since, page_cursor, oldest_sha, oldest_commit_date, commits_imported, started_at, updated_at, completed_at FROM sync_checkpoints
+WHERE repository_id = $1
+`
+
+func (q *Queries) GetSyncCheckpoint(ctx context.Context, repositoryID int64) (SyncCheckpoint, error) {
+	row := q.db.QueryRow(ctx, getSyncCheckpoint, repositoryID)
+	var i SyncCheckpoint
+	err := row.Scan(
+		&i.RepositoryID,
+		&i.Since,
+		&i.PageCursor,
+		&i.OldestSha,
+		&i.OldestCommitDate,
+		&i.CommitsImported,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getSyncJob = `-- name: GetSyncJob :one
+SELECT id, provider, host, owner, name, state, commits_inserted, error, created_at, started_at, finished_at FROM sync_jobs
+WHERE id = $1
+`
+
+func (q *Queries) GetSyncJob(ctx context.Context, id int64) (SyncJob, error) {
+	row := q.db.QueryRow(ctx, getSyncJob, id)
+	var i SyncJob
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.Host,
+		&i.Owner,
+		&i.Name,
+		&i.State,
+		&i.CommitsInserted,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getTagsByCommitDate = `-- name: GetTagsByCommitDate :many
+SELECT t.name, t.sha, c.first_release FROM tags t
+JOIN commits c ON c.repository_id = t.repository_id AND c.sha = t.sha
+WHERE t.repository_id = $1
+ORDER BY COALESCE(c.committer_date, c.commit_date), t.name
+`
+
+type GetTagsByCommitDateRow struct {
+	Name         string `json:"name"`
+	Sha          string `json:"sha"`
+	FirstRelease string `json:"first_release"`
+}
+
+// Tags whose commit is not stored are left out. first_release is that of the tagged commit, empty
+// when it was not mapped yet, e.g. because the tag is new or the commit was stored after it.
+func (q *Queries) GetTagsByCommitDate(ctx context.Context, repositoryID int64) ([]GetTagsByCommitDateRow, error) {
+	rows, err := q.db.Query(ctx, getTagsByCommitDate, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagsByCommitDateRow
+	for rows.Next() {
+		var i GetTagsByCommitDateRow
+		if err := rows.Scan(&i.Name, &i.Sha, &i.FirstRelease); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagsByRepoID = `-- name: GetTagsByRepoID :many
+SELECT repository_id, name, sha, created_at, updated_at FROM tags
+WHERE repository_id = $1
+ORDER BY name
+`
+
+func (q *Queries) GetTagsByRepoID(ctx context.Context, repositoryID int64) ([]Tag, error) {
+	rows, err := q.db.Query(ctx, getTagsByRepoID, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(
+			&i.RepositoryID,
+			&i.Name,
+			&i.Sha,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopNCommitAuthors = `-- name: GetTopNCommitAuthors :many
+SELECT
+    author_name,
+    author_email,
+    COUNT(*) as commit_count
+FROM commits
+WHERE repository_id = $1 AND unreachable_at IS NULL
+GROUP BY author_name, author_email
+ORDER BY commit_count DESC
+LIMIT $2
+`
+
+type GetTopNCommitAuthorsParams struct {
+	RepositoryID int64 `json:"repository_id"`
+	Limit        int32 `json:"limit"`
+}
+
+type GetTopNCommitAuthorsRow struct {
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	CommitCount int64  `json:"commit_count"`
+}
+
+func (q *Queries) GetTopNCommitAuthors(ctx context.Context, arg GetTopNCommitAuthorsParams) ([]GetTopNCommitAuthorsRow, error) {
+	rows, err := q.db.Query(ctx, getTopNCommitAuthors, arg.RepositoryID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopNCommitAuthorsRow
+	for rows.Next() {
+		var i GetTopNCommitAuthorsRow
+		if err := rows.Scan(&i.AuthorName, &i.AuthorEmail, &i.CommitCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkflowRunSyncStart = `-- name: GetWorkflowRunSyncStart :one
+SELECT COALESCE(MIN(run_created_at) FILTER (WHERE status <> 'completed'), MAX(run_created_at))::timestamptz AS sync_start
+FROM workflow_runs
+WHERE repository_id = $1
+`
+
+// The creation time of the oldest run that has not completed yet, or else of the newest run.
+func (q *Queries) GetWorkflowRunSyncStart(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, getWorkflowRunSyncStart, repositoryID)
+	var sync_start pgtype.Timestamptz
+	err := row.Scan(&sync_start)
+	return sync_start, err
+}
+
+const getWorkflowStats = `-- name: GetWorkflowStats :many
+SELECT
+    w.github_workflow_id,
+    w.name,
+    w.path,
+    COUNT(*) AS total_runs,
+    COUNT(*) FILTER (WHERE r.status = 'completed') AS completed_runs,
+    COUNT(*) FILTER (WHERE r.conclusion = 'success') AS successful_runs,
+    COALESCE(
+        COUNT(*) FILTER (WHERE r.conclusion = 'success')::float8
+            / NULLIF(COUNT(*) FILTER (WHERE r.status = 'completed' AND r.conclusion NOT IN ('cancelled', 'skipped', 'neutral')), 0),
+        0
+    )::float8 AS success_rate,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM r.run_updated_at - r.run_started_at))
+        FILTER (WHERE r.status = 'completed'), 0)::float8 AS p50_duration_seconds,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM r.run_updated_at - r.run_started_at))
+        FILTER (WHERE r.status = 'completed'), 0)::float8 AS p95_duration_seconds
+FROM workflows w
+JOIN workflow_runs r ON r.repository_id = w.repository_id AND r.workflow_id = w.github_workflow_id
+WHERE w.repository_id = $1
+  AND ($2::timestamptz IS NULL OR r.run_created_at >= $2::timestamptz)
+  AND ($3::timestamptz IS NULL OR r.run_created_at < $3::timestamptz)
+GROUP BY w.github_workflow_id, w.name, w.path
+ORDER BY w.name, w.github_workflow_id
+`
+
+type GetWorkflowStatsParams struct {
+	RepositoryID int64              `json:"repository_id"`
+	Since        pgtype.Timestamptz `json:"since"`
+	Until        pgtype.Timestamptz `json:"until"`
+}
+
+type GetWorkflowStatsRow struct {
+	GithubWorkflowID   int64   `json:"github_workflow_id"`
+	Name               string  `json:"name"`
+	Path               string  `json:"path"`
+	TotalRuns          int64   `json:"total_runs"`
+	CompletedRuns      int64   `json:"completed_runs"`
+	SuccessfulRuns     int64   `json:"successful_runs"`
+	SuccessRate        float64 `json:"success_rate"`
+	P50DurationSeconds float64 `json:"p50_duration_seconds"`
+	P95DurationSeconds float64 `json:"p95_duration_seconds"`
+}
+
+// Runs are bounded by their creation time. The success rate is the share of successful runs
+// among those that completed with a verdict, leaving out cancelled, skipped and neutral runs.
+// Durations are from the start of a run's latest attempt to its completion.
+func (q *Queries) GetWorkflowStats(ctx context.Context, arg GetWorkflowStatsParams) ([]GetWorkflowStatsRow, error) {
+	rows, err := q.db.Query(ctx, getWorkflowStats, arg.RepositoryID, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkflowStatsRow
+	for rows.Next() {
+		var i GetWorkflowStatsRow
+		if err := rows.Scan(
+			&i.GithubWorkflowID,
+			&i.Name,
+			&i.Path,
+			&i.TotalRuns,
+			&i.CompletedRuns,
+			&i.SuccessfulRuns,
+			&i.SuccessRate,
+			&i.P50DurationSeconds,
+			&i.P95DurationSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRepositories = `-- name: ListRepositories :many
+SELECT r.id, r.github_repo_id, r.owner, r.name, r.description, r.url, r.language, r.forks_count, r.stars_count, r.open_issues_count, r.watchers_count, r.repo_created_at, r.repo_updated_at, r.last_synced_at, r.created_at, r.updated_at, r.host, r.provider, r.head_sha FROM repositories r
+WHERE ($1::text = '' OR EXISTS (
+        SELECT 1 FROM repository_topics t WHERE t.repository_id = r.id AND t.topic = lower($1::text)))
+  AND ($2::text = '' OR lower(r.language) = lower($2::text) OR EXISTS (
+        SELECT 1 FROM repository_languages l WHERE l.repository_id = r.id AND lower(l.language) = lower($2::text)))
+ORDER BY r.provider, r.host, r.owner, r.name
+`
+
+type ListRepositoriesParams struct {
+	Topic    string `json:"topic"`
+	Language string `json:"language"`
+}
+
+// Repositories tagged with topic and with code in language, if given. Languages are compared
+// case-insensitively; repositories whose languages are not stored match by their main language.
+func (q *Queries) ListRepositories(ctx context.Context, arg ListRepositoriesParams) ([]Repository, error) {
+	rows, err := q.db.Query(ctx, listRepositories, arg.Topic, arg.Language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Repository
+	for rows.Next() {
+		var i Repository
+		if err := rows.Scan(
+			&i.ID,
+			&i.GithubRepoID,
+			&i.Owner,
+			&i.Name,
+			&i.Description,
+			&i.Url,
+			&i.Language,
+			&i.ForksCount,
+			&i.StarsCount,
+			&i.OpenIssuesCount,
+			&i.WatchersCount,
+			&i.RepoCreatedAt,
+			&i.RepoUpdatedAt,
+			&i.LastSyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Host,
+			&i.Provider,
+			&i.HeadSha,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrackedRepositories = `-- name: ListTrackedRepositories :many
+SELECT entry, paused, added_by, created_at, updated_at FROM tracked_repositories
+ORDER BY entry
+`
+
+func (q *Queries) ListTrackedRepositories(ctx context.Context) ([]TrackedRepository, error) {
+	rows, err := q.db.Query(ctx, listTrackedRepositories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrackedRepository
+	for rows.Next() {
+		var i TrackedRepository
+		if err := rows.Scan(
+			&i.Entry,
+			&i.Paused,
+			&i.AddedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markCommitFilesSynced = `-- name: MarkCommitFilesSynced :exec
+UPDATE commits
+SET files_synced_at = NOW(),
+    additions = COALESCE(additions, $1::int),
+    deletions = COALESCE(deletions, $2::int)
+WHERE repository_id = $3 AND sha = $4
+`
+
+type MarkCommitFilesSyncedParams struct {
+	Additions    int32  `json:"additions"`
+	Deletions    int32  `json:"deletions"`
+	RepositoryID int64  `json:"repository_id"`
+	Sha          string `json:"sha"`
+}
+
+// additions and deletions are only filled in if the commits backend did not report them.
+func (q *Queries) MarkCommitFilesSynced(ctx context.Context, arg MarkCommitFilesSyncedParams) error {
+	_, err := q.db.Exec(ctx, markCommitFilesSynced,
+		arg.Additions,
+		arg.Deletions,
+		arg.RepositoryID,
+		arg.Sha,
+	)
+	return err
+}
+
+const markCommitsUnreachable = `-- name: MarkCommitsUnreachable :execrows
+UPDATE commits
+SET unreachable_at = NOW()
+WHERE repository_id = $1 AND sha = ANY($2::text[]) AND unreachable_at IS NULL
+`
+
+type MarkCommitsUnreachableParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Shas         []string `json:"shas"`
+}
+
+func (q *Queries) MarkCommitsUnreachable(ctx context.Context, arg MarkCommitsUnreachableParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markCommitsUnreachable, arg.RepositoryID, arg.Shas)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markIssueCommentsSynced = `-- name: MarkIssueCommentsSynced :one
+UPDATE issues
+SET comments_updated_at = issue_updated_at
+WHERE repository_id = $1 AND number = $2
+RETURNING id
+`
+
+type MarkIssueCommentsSyncedParams struct {
+	RepositoryID int64 `json:"repository_id"`
+	Number       int32 `json:"number"`
+}
+
+// The comments are as of the stored update of the issue, so an update listed after they were
+// fetched has them fetched again.
+func (q *Queries) MarkIssueCommentsSynced(ctx context.Context, arg MarkIssueCommentsSyncedParams) (int64, error) {
+	row := q.db.QueryRow(ctx, markIssueCommentsSynced, arg.RepositoryID, arg.Number)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const markRepositorySynced = `-- name: MarkRepositorySynced :exec
+UPDATE repositories
+SET
+    last_synced_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkRepositorySynced(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markRepositorySynced, id)
+	return err
+}
+
+const removeCommitsFromBranch = `-- name: RemoveCommitsFromBranch :execrows
+DELETE FROM commit_branches
+WHERE branch_id = $1 AND sha = ANY($2::text[])
+`
+
+type RemoveCommitsFromBranchParams struct {
+	BranchID int64    `json:"branch_id"`
+	Shas     []string `json:"shas"`
+}
+
+func (q *Queries) RemoveCommitsFromBranch(ctx context.Context, arg RemoveCommitsFromBranchParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeCommitsFromBranch, arg.BranchID, arg.Shas)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const seedTrackedRepositories = `-- name: SeedTrackedRepositories :exec
+INSERT INTO tracked_repositories (entry, added_by)
+SELECT unnest($1::text[]), 'config'
+ON CONFLICT (entry) DO NOTHING
+`
+
+// Tracks the entries that are not tracked yet. Entries that were paused stay paused.
+func (q *Queries) SeedTrackedRepositories(ctx context.Context, entries []string) error {
+	_, err := q.db.Exec(ctx, seedTrackedRepositories, entries)
+	return err
+}
+
+const startSyncCheckpoint = `-- name: StartSyncCheckpoint :one
+INSERT INTO sync_checkpoints (repository_id, since)
+VALUES ($1, $2)
+ON CONFLICT (repository_id) DO UPDATE
+SET
+    since = EXCLUDED.since,
+    page_cursor = '',
+    oldest_sha = '',
+    oldest_commit_date = NULL,
+    commits_imported = 0,
+    started_at = NOW(),
+    updated_at = NOW(),
+    completed_at = NULL
+    RETURNING repository_id, since, page_cursor, oldest_sha, oldest_commit_date, commits_imported, started_at, updated_at, completed_at
+`
+
+type StartSyncCheckpointParams struct {
+	RepositoryID int64     `json:"repository_id"`
+	Since        time.Time `json:"since"`
+}
+
+func (q *Queries) StartSyncCheckpoint(ctx context.Context, arg StartSyncCheckpointParams) (SyncCheckpoint, error) {
+	row := q.db.QueryRow(ctx, startSyncCheckpoint, arg.RepositoryID, arg.Since)
+	var i SyncCheckpoint
+	err := row.Scan(
+		&i.RepositoryID,
+		&i.Since,
+		&i.PageCursor,
+		&i.OldestSha,
+		&i.OldestCommitDate,
+		&i.CommitsImported,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const startSyncJob = `-- name: StartSyncJob :exec
+UPDATE sync_jobs
+SET
+    state = 'running',
+    started_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) StartSyncJob(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, startSyncJob, id)
+	return err
+}
+
+const updatePullRequestDetails = `-- name: UpdatePullRequestDetails :one
+UPDATE pull_requests
+SET additions = $3,
+    deletions = $4,
+    merged_by_login = $5,
+    details_updated_at = $6,
+    updated_at = NOW()
+WHERE repository_id = $1 AND number = $2
+RETURNING id
+`
+
+type UpdatePullRequestDetailsParams struct {
+	RepositoryID     int64              `json:"repository_id"`
+	Number           int32              `json:"number"`
+	Additions        int32              `json:"additions"`
+	Deletions        int32              `json:"deletions"`
+	MergedByLogin    string             `json:"merged_by_login"`
+	DetailsUpdatedAt pgtype.Timestamptz `json:"details_updated_at"`
+}
+
+// details_updated_at is the update the details were fetched at, so they are fetched again once the
+// pull request is listed with a later one.
+func (q *Queries) UpdatePullRequestDetails(ctx context.Context, arg UpdatePullRequestDetailsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, updatePullRequestDetails,
+		arg.RepositoryID,
+		arg.Number,
+		arg.Additions,
+		arg.Deletions,
+		arg.MergedByLogin,
+		arg.DetailsUpdatedAt,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const updateRepositoryHead = `-- name: UpdateRepositoryHead :exec
+UPDATE repositories
+SET
+    head_sha = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateRepositoryHeadParams struct {
+	ID      int64  `json:"id"`
+	HeadSha string `json:"head_sha"`
+}
+
+func (q *Queries) UpdateRepositoryHead(ctx context.Context, arg UpdateRepositoryHeadParams) error {
+	_, err := q.db.Exec(ctx, updateRepositoryHead, arg.ID, arg.HeadSha)
+	return err
+}
+
+const updateRepositorySyncData = `-- name: UpdateRepositorySyncData :one
+UPDATE repositories
+SET
+    description = $2,
+    language = $3,
+    forks_count = $4,
+    stars_count = $5,
+    open_issues_count = $6,
+    watchers_count = $7,
+    repo_updated_at = $8,
+    last_synced_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    RETURNING id, github_repo_id, owner, name, description, url, language, forks_count, stars_count, open_issues_count, watchers_count, repo_created_at, repo_updated_at, last_synced_at, created_at, updated_at, host, provider, head_sha
+`
+
+type UpdateRepositorySyncDataParams struct {
+	ID              int64     `json:"id"`
+	Description     string    `json:"description"`
+	Language        string    `json:"language"`
+	ForksCount      int32     `json:"forks_count"`
+	StarsCount      int32     `json:"stars_count"`
+	OpenIssuesCount int32     `json:"open_issues_count"`
+	WatchersCount   int32     `json:"watchers_count"`
 	RepoUpdatedAt   time.Time `json:"repo_updated_at"`
 }
 
@@ -268,6 +2086,563 @@ func (q *Queries) UpdateRepositorySyncData(ctx context.Context, arg UpdateReposi
 		&i.LastSyncedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Host,
+		&i.Provider,
+		&i.HeadSha,
+	)
+	return i, err
+}
+
+const upsertBranch = `-- name: UpsertBranch :one
+INSERT INTO branches (repository_id, name, head_sha)
+VALUES ($1, $2, $3)
+ON CONFLICT (repository_id, name) DO UPDATE
+SET
+    head_sha = EXCLUDED.head_sha,
+    updated_at = NOW()
+    RETURNING id, repository_id, name, head_sha, created_at, updated_at
+`
+
+type UpsertBranchParams struct {
+	RepositoryID int64  `json:"repository_id"`
+	Name         string `json:"name"`
+	HeadSha      string `json:"head_sha"`
+}
+
+func (q *Queries) UpsertBranch(ctx context.Context, arg UpsertBranchParams) (Branch, error) {
+	row := q.db.QueryRow(ctx, upsertBranch, arg.RepositoryID, arg.Name, arg.HeadSha)
+	var i Branch
+	err := row.Scan(
+		&i.ID,
+		&i.RepositoryID,
+		&i.Name,
+		&i.HeadSha,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertHTTPValidators = `-- name: UpsertHTTPValidators :exec
+INSERT INTO http_validators (url, etag, last_modified)
+VALUES ($1, $2, $3)
+ON CONFLICT (url) DO UPDATE
+SET
+    etag = EXCLUDED.etag,
+    last_modified = EXCLUDED.last_modified,
+    updated_at = NOW()
+`
+
+type UpsertHTTPValidatorsParams struct {
+	Url          string `json:"url"`
+	Etag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+func (q *Queries) UpsertHTTPValidators(ctx context.Context, arg UpsertHTTPValidatorsParams) error {
+	_, err := q.db.Exec(ctx, upsertHTTPValidators, arg.Url, arg.Etag, arg.LastModified)
+	return err
+}
+
+const upsertIssue = `-- name: UpsertIssue :one
+INSERT INTO issues (
+    repository_id, number, github_issue_id, title, state, author_login, labels, assignees,
+    milestone, comments_count, url, issue_created_at, issue_updated_at, closed_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+         )
+ON CONFLICT (repository_id, number) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    state = EXCLUDED.state,
+    labels = EXCLUDED.labels,
+    assignees = EXCLUDED.assignees,
+    milestone = EXCLUDED.milestone,
+    comments_count = EXCLUDED.comments_count,
+    issue_updated_at = EXCLUDED.issue_updated_at,
+    closed_at = EXCLUDED.closed_at,
+    updated_at = NOW()
+    RETURNING id, repository_id, number, github_issue_id, title, state, author_login, labels, assignees, milestone, comments_count, url, issue_created_at, issue_updated_at, closed_at, created_at, updated_at, comments_updated_at
+`
+
+type UpsertIssueParams struct {
+	RepositoryID   int64              `json:"repository_id"`
+	Number         int32              `json:"number"`
+	GithubIssueID  int64              `json:"github_issue_id"`
+	Title          string             `json:"title"`
+	State          string             `json:"state"`
+	AuthorLogin    string             `json:"author_login"`
+	Labels         []string           `json:"labels"`
+	Assignees      []string           `json:"assignees"`
+	Milestone      string             `json:"milestone"`
+	CommentsCount  int32              `json:"comments_count"`
+	Url            string             `json:"url"`
+	IssueCreatedAt time.Time          `json:"issue_created_at"`
+	IssueUpdatedAt time.Time          `json:"issue_updated_at"`
+	ClosedAt       pgtype.Timestamptz `json:"closed_at"`
+}
+
+func (q *Queries) UpsertIssue(ctx context.Context, arg UpsertIssueParams) (Issue, error) {
+	row := q.db.QueryRow(ctx, upsertIssue,
+		arg.RepositoryID,
+		arg.Number,
+		arg.GithubIssueID,
+		arg.Title,
+		arg.State,
+		arg.AuthorLogin,
+		arg.Labels,
+		arg.Assignees,
+		arg.Milestone,
+		arg.CommentsCount,
+		arg.Url,
+		arg.IssueCreatedAt,
+		arg.IssueUpdatedAt,
+		arg.ClosedAt,
+	)
+	var i Issue
+	err := row.Scan(
+		&i.ID,
+		&i.RepositoryID,
+		&i.Number,
+		&i.GithubIssueID,
+		&i.Title,
+		&i.State,
+		&i.AuthorLogin,
+		&i.Labels,
+		&i.Assignees,
+		&i.Milestone,
+		&i.CommentsCount,
+		&i.Url,
+		&i.IssueCreatedAt,
+		&i.IssueUpdatedAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CommentsUpdatedAt,
+	)
+	return i, err
+}
+
+const upsertIssueComment = `-- name: UpsertIssueComment :exec
+INSERT INTO issue_comments (
+    issue_id, github_comment_id, author_login, comment_created_at, comment_updated_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+ON CONFLICT (issue_id, github_comment_id) DO UPDATE
+SET
+    comment_updated_at = EXCLUDED.comment_updated_at
+`
+
+type UpsertIssueCommentParams struct {
+	IssueID          int64     `json:"issue_id"`
+	GithubCommentID  int64     `json:"github_comment_id"`
+	AuthorLogin      string    `json:"author_login"`
+	CommentCreatedAt time.Time `json:"comment_created_at"`
+	CommentUpdatedAt time.Time `json:"comment_updated_at"`
+}
+
+func (q *Queries) UpsertIssueComment(ctx context.Context, arg UpsertIssueCommentParams) error {
+	_, err := q.db.Exec(ctx, upsertIssueComment,
+		arg.IssueID,
+		arg.GithubCommentID,
+		arg.AuthorLogin,
+		arg.CommentCreatedAt,
+		arg.CommentUpdatedAt,
+	)
+	return err
+}
+
+const upsertLabel = `-- name: UpsertLabel :exec
+INSERT INTO labels (repository_id, name, color, description)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (repository_id, name) DO UPDATE
+SET
+    color = EXCLUDED.color,
+    description = EXCLUDED.description,
+    updated_at = NOW()
+`
+
+type UpsertLabelParams struct {
+	RepositoryID int64  `json:"repository_id"`
+	Name         string `json:"name"`
+	Color        string `json:"color"`
+	Description  string `json:"description"`
+}
+
+func (q *Queries) UpsertLabel(ctx context.Context, arg UpsertLabelParams) error {
+	_, err := q.db.Exec(ctx, upsertLabel,
+		arg.RepositoryID,
+		arg.Name,
+		arg.Color,
+		arg.Description,
+	)
+	return err
+}
+
+const upsertPullRequest = `-- name: UpsertPullRequest :one
+INSERT INTO pull_requests (
+    repository_id, number, github_pr_id, title, state, author_login, base_ref, head_ref,
+    url, pr_created_at, pr_updated_at, merged_at, closed_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+         )
+ON CONFLICT (repository_id, number) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    state = EXCLUDED.state,
+    base_ref = EXCLUDED.base_ref,
+    head_ref = EXCLUDED.head_ref,
+    pr_updated_at = EXCLUDED.pr_updated_at,
+    merged_at = EXCLUDED.merged_at,
+    closed_at = EXCLUDED.closed_at,
+    updated_at = NOW()
+    RETURNING id, repository_id, number, github_pr_id, title, state, author_login, base_ref, head_ref, additions, deletions, merged_by_login, url, pr_created_at, pr_updated_at, merged_at, closed_at, created_at, updated_at, details_updated_at
+`
+
+type UpsertPullRequestParams struct {
+	RepositoryID int64              `json:"repository_id"`
+	Number       int32              `json:"number"`
+	GithubPrID   int64              `json:"github_pr_id"`
+	Title        string             `json:"title"`
+	State        string             `json:"state"`
+	AuthorLogin  string             `json:"author_login"`
+	BaseRef      string             `json:"base_ref"`
+	HeadRef      string             `json:"head_ref"`
+	Url          string             `json:"url"`
+	PrCreatedAt  time.Time          `json:"pr_created_at"`
+	PrUpdatedAt  time.Time          `json:"pr_updated_at"`
+	MergedAt     pgtype.Timestamptz `json:"merged_at"`
+	ClosedAt     pgtype.Timestamptz `json:"closed_at"`
+}
+
+// Listings lack line stats and who merged a pull request, so those are only set by
+// UpdatePullRequestDetails.
+func (q *Queries) UpsertPullRequest(ctx context.Context, arg UpsertPullRequestParams) (PullRequest, error) {
+	row := q.db.QueryRow(ctx, upsertPullRequest,
+		arg.RepositoryID,
+		arg.Number,
+		arg.GithubPrID,
+		arg.Title,
+		arg.State,
+		arg.AuthorLogin,
+		arg.BaseRef,
+		arg.HeadRef,
+		arg.Url,
+		arg.PrCreatedAt,
+		arg.PrUpdatedAt,
+		arg.MergedAt,
+		arg.ClosedAt,
+	)
+	var i PullRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RepositoryID,
+		&i.Number,
+		&i.GithubPrID,
+		&i.Title,
+		&i.State,
+		&i.AuthorLogin,
+		&i.BaseRef,
+		&i.HeadRef,
+		&i.Additions,
+		&i.Deletions,
+		&i.MergedByLogin,
+		&i.Url,
+		&i.PrCreatedAt,
+		&i.PrUpdatedAt,
+		&i.MergedAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DetailsUpdatedAt,
+	)
+	return i, err
+}
+
+const upsertPullRequestReview = `-- name: UpsertPullRequestReview :exec
+INSERT INTO pull_request_reviews (
+    pull_request_id, github_review_id, reviewer_login, state, commit_sha, submitted_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         )
+ON CONFLICT (pull_request_id, github_review_id) DO UPDATE
+SET
+    state = EXCLUDED.state
+`
+
+type UpsertPullRequestReviewParams struct {
+	PullRequestID  int64     `json:"pull_request_id"`
+	GithubReviewID int64     `json:"github_review_id"`
+	ReviewerLogin  string    `json:"reviewer_login"`
+	State          string    `json:"state"`
+	CommitSha      string    `json:"commit_sha"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+}
+
+func (q *Queries) UpsertPullRequestReview(ctx context.Context, arg UpsertPullRequestReviewParams) error {
+	_, err := q.db.Exec(ctx, upsertPullRequestReview,
+		arg.PullRequestID,
+		arg.GithubReviewID,
+		arg.ReviewerLogin,
+		arg.State,
+		arg.CommitSha,
+		arg.SubmittedAt,
+	)
+	return err
+}
+
+const upsertRelease = `-- name: UpsertRelease :one
+INSERT INTO releases (
+    repository_id, github_release_id, tag_name, name, draft, prerelease, author_login, url,
+    release_created_at, published_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+         )
+ON CONFLICT (repository_id, github_release_id) DO UPDATE
+SET
+    tag_name = EXCLUDED.tag_name,
+    name = EXCLUDED.name,
+    draft = EXCLUDED.draft,
+    prerelease = EXCLUDED.prerelease,
+    url = EXCLUDED.url,
+    published_at = EXCLUDED.published_at,
+    updated_at = NOW()
+    RETURNING id, repository_id, github_release_id, tag_name, name, draft, prerelease, author_login, url, release_created_at, published_at, created_at, updated_at
+`
+
+type UpsertReleaseParams struct {
+	RepositoryID     int64              `json:"repository_id"`
+	GithubReleaseID  int64              `json:"github_release_id"`
+	TagName          string             `json:"tag_name"`
+	Name             string             `json:"name"`
+	Draft            bool               `json:"draft"`
+	Prerelease       bool               `json:"prerelease"`
+	AuthorLogin      string             `json:"author_login"`
+	Url              string             `json:"url"`
+	ReleaseCreatedAt time.Time          `json:"release_created_at"`
+	PublishedAt      pgtype.Timestamptz `json:"published_at"`
+}
+
+func (q *Queries) UpsertRelease(ctx context.Context, arg UpsertReleaseParams) (Release, error) {
+	row := q.db.QueryRow(ctx, upsertRelease,
+		arg.RepositoryID,
+		arg.GithubReleaseID,
+		arg.TagName,
+		arg.Name,
+		arg.Draft,
+		arg.Prerelease,
+		arg.AuthorLogin,
+		arg.Url,
+		arg.ReleaseCreatedAt,
+		arg.PublishedAt,
+	)
+	var i Release
+	err := row.Scan(
+		&i.ID,
+		&i.RepositoryID,
+		&i.GithubReleaseID,
+		&i.TagName,
+		&i.Name,
+		&i.Draft,
+		&i.Prerelease,
+		&i.AuthorLogin,
+		&i.Url,
+		&i.ReleaseCreatedAt,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertReleaseAsset = `-- name: UpsertReleaseAsset :exec
+INSERT INTO release_assets (release_id, github_asset_id, name, size, download_count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (release_id, github_asset_id) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    size = EXCLUDED.size,
+    download_count = EXCLUDED.download_count
+`
+
+type UpsertReleaseAssetParams struct {
+	ReleaseID     int64  `json:"release_id"`
+	GithubAssetID int64  `json:"github_asset_id"`
+	Name          string `json:"name"`
+	Size          int64  `json:"size"`
+	DownloadCount int64  `json:"download_count"`
+}
+
+func (q *Queries) UpsertReleaseAsset(ctx context.Context, arg UpsertReleaseAssetParams) error {
+	_, err := q.db.Exec(ctx, upsertReleaseAsset,
+		arg.ReleaseID,
+		arg.GithubAssetID,
+		arg.Name,
+		arg.Size,
+		arg.DownloadCount,
+	)
+	return err
+}
+
+const upsertRepositoryLanguages = `-- name: UpsertRepositoryLanguages :exec
+INSERT INTO repository_languages (repository_id, language, bytes)
+SELECT $1::bigint, unnest($2::text[]), unnest($3::bigint[])
+ON CONFLICT (repository_id, language) DO UPDATE
+SET bytes = EXCLUDED.bytes
+`
+
+type UpsertRepositoryLanguagesParams struct {
+	RepositoryID int64    `json:"repository_id"`
+	Languages    []string `json:"languages"`
+	Bytes        []int64  `json:"bytes"`
+}
+
+func (q *Queries) UpsertRepositoryLanguages(ctx context.Context, arg UpsertRepositoryLanguagesParams) error {
+	_, err := q.db.Exec(ctx, upsertRepositoryLanguages, arg.RepositoryID, arg.Languages, arg.Bytes)
+	return err
+}
+
+const upsertTag = `-- name: UpsertTag :exec
+INSERT INTO tags (repository_id, name, sha)
+VALUES ($1, $2, $3)
+ON CONFLICT (repository_id, name) DO UPDATE
+SET
+    sha = EXCLUDED.sha,
+    updated_at = NOW()
+`
+
+type UpsertTagParams struct {
+	RepositoryID int64  `json:"repository_id"`
+	Name         string `json:"name"`
+	Sha          string `json:"sha"`
+}
+
+func (q *Queries) UpsertTag(ctx context.Context, arg UpsertTagParams) error {
+	_, err := q.db.Exec(ctx, upsertTag, arg.RepositoryID, arg.Name, arg.Sha)
+	return err
+}
+
+const upsertTrackedRepository = `-- name: UpsertTrackedRepository :one
+INSERT INTO tracked_repositories (entry, paused, added_by)
+VALUES ($1, $2, 'api')
+ON CONFLICT (entry) DO UPDATE
+SET
+    paused = EXCLUDED.paused,
+    updated_at = NOW()
+RETURNING entry, paused, added_by, created_at, updated_at, (xmax = 0)::boolean AS created
+`
+
+type UpsertTrackedRepositoryParams struct {
+	Entry  string `json:"entry"`
+	Paused bool   `json:"paused"`
+}
+
+type UpsertTrackedRepositoryRow struct {
+	Entry     string    `json:"entry"`
+	Paused    bool      `json:"paused"`
+	AddedBy   string    `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Created   bool      `json:"created"`
+}
+
+// Tracks an entry, or pauses or resumes it if it is tracked. created is false in the latter case.
+func (q *Queries) UpsertTrackedRepository(ctx context.Context, arg UpsertTrackedRepositoryParams) (UpsertTrackedRepositoryRow, error) {
+	row := q.db.QueryRow(ctx, upsertTrackedRepository, arg.Entry, arg.Paused)
+	var i UpsertTrackedRepositoryRow
+	err := row.Scan(
+		&i.Entry,
+		&i.Paused,
+		&i.AddedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Created,
 	)
 	return i, err
 }
+
+const upsertWorkflow = `-- name: UpsertWorkflow :exec
+INSERT INTO workflows (repository_id, github_workflow_id, name, path, state)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (repository_id, github_workflow_id) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    path = EXCLUDED.path,
+    state = EXCLUDED.state,
+    updated_at = NOW()
+`
+
+type UpsertWorkflowParams struct {
+	RepositoryID     int64  `json:"repository_id"`
+	GithubWorkflowID int64  `json:"github_workflow_id"`
+	Name             string `json:"name"`
+	Path             string `json:"path"`
+	State            string `json:"state"`
+}
+
+func (q *Queries) UpsertWorkflow(ctx context.Context, arg UpsertWorkflowParams) error {
+	_, err := q.db.Exec(ctx, upsertWorkflow,
+		arg.RepositoryID,
+		arg.GithubWorkflowID,
+		arg.Name,
+		arg.Path,
+		arg.State,
+	)
+	return err
+}
+
+const upsertWorkflowRun = `-- name: UpsertWorkflowRun :exec
+INSERT INTO workflow_runs (
+    repository_id, github_run_id, workflow_id, name, event, status, conclusion, head_sha,
+    head_branch, run_number, run_attempt, run_created_at, run_started_at, run_updated_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+         )
+ON CONFLICT (repository_id, github_run_id) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    status = EXCLUDED.status,
+    conclusion = EXCLUDED.conclusion,
+    run_attempt = EXCLUDED.run_attempt,
+    run_started_at = EXCLUDED.run_started_at,
+    run_updated_at = EXCLUDED.run_updated_at,
+    updated_at = NOW()
+`
+
+type UpsertWorkflowRunParams struct {
+	RepositoryID int64     `json:"repository_id"`
+	GithubRunID  int64     `json:"github_run_id"`
+	WorkflowID   int64     `json:"workflow_id"`
+	Name         string    `json:"name"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	HeadSha      string    `json:"head_sha"`
+	HeadBranch   string    `json:"head_branch"`
+	RunNumber    int32     `json:"run_number"`
+	RunAttempt   int32     `json:"run_attempt"`
+	RunCreatedAt time.Time `json:"run_created_at"`
+	RunStartedAt time.Time `json:"run_started_at"`
+	RunUpdatedAt time.Time `json:"run_updated_at"`
+}
+
+func (q *Queries) UpsertWorkflowRun(ctx context.Context, arg UpsertWorkflowRunParams) error {
+	_, err := q.db.Exec(ctx, upsertWorkflowRun,
+		arg.RepositoryID,
+		arg.GithubRunID,
+		arg.WorkflowID,
+		arg.Name,
+		arg.Event,
+		arg.Status,
+		arg.Conclusion,
+		arg.HeadSha,
+		arg.HeadBranch,
+		arg.RunNumber,
+		arg.RunAttempt,
+		arg.RunCreatedAt,
+		arg.RunStartedAt,
+		arg.RunUpdatedAt,
+	)
+	return err
+}
@@ -0,0 +1,66 @@
+// internal/database/upsert.go
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Store is a Querier that also offers the hand-written queries below, which sqlc cannot
+// generate because they go through a temporary table.
+type Store interface {
+	Querier
+	UpsertCommits(ctx context.Context, arg []CreateCommitsParams) (int64, error)
+}
+
+var _ Store = (*Queries)(nil)
+
+const createCommitsStaging = `CREATE TEMP TABLE commits_staging (LIKE commits INCLUDING DEFAULTS) ON COMMIT DROP`
+
+const insertStagedCommits = `INSERT INTO commits (
+    sha, repository_id, author_name, author_email, message, url, commit_date,
+    author_login, additions, deletions, verified,
+    parents, committer_name, committer_email, committer_date, on_default_branch
+)
+SELECT DISTINCT ON (repository_id, sha)
+    sha, repository_id, author_name, author_email, message, url, commit_date,
+    author_login, additions, deletions, verified,
+    parents, committer_name, committer_email, committer_date, on_default_branch
+FROM commits_staging
+ON CONFLICT (repository_id, sha) DO UPDATE
+SET
+    unreachable_at = NULL,
+    on_default_branch = TRUE
+WHERE EXCLUDED.on_default_branch
+  AND (commits.unreachable_at IS NOT NULL OR NOT commits.on_default_branch)`
+
+const dropCommitsStaging = `DROP TABLE commits_staging`
+
+// UpsertCommits stores commits and returns how many were inserted. Commits already stored, or
+// repeated within arg, are skipped and left as they are, so unlike CreateCommits, whose COPY
+// aborts the transaction on the first duplicate, it is safe to call with overlapping pages.
+// When a commit listed on the default branch was marked unreachable, or was so far only seen
+// on other branches, it is updated and counted as inserted, as it is now on the default
+// branch, e.g. after a force-push was reverted or a release branch was merged. Commits listed
+// on other branches never change stored rows.
+// The rows are copied into a temporary table first, which only lives until the end of the
+// transaction, so it must be called inside one.
+func (q *Queries) UpsertCommits(ctx context.Context, arg []CreateCommitsParams) (int64, error) {
+	if _, err := q.db.Exec(ctx, createCommitsStaging); err != nil {
+		return 0, err
+	}
+	_, err := q.db.CopyFrom(ctx, pgx.Identifier{"commits_staging"}, []string{"sha", "repository_id", "author_name", "author_email", "message", "url", "commit_date", "author_login", "additions", "deletions", "verified", "parents", "committer_name", "committer_email", "committer_date", "on_default_branch"}, &iteratorForCreateCommits{rows: arg})
+	if err != nil {
+		return 0, err
+	}
+	tag, err := q.db.Exec(ctx, insertStagedCommits)
+	if err != nil {
+		return 0, err
+	}
+	// Dropped right away so the next page in the same transaction can create it again.
+	if _, err := q.db.Exec(ctx, dropCommitsStaging); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -1,13 +1,41 @@
 // internal/errors/errors.go
 package errors
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
-// ErrInvalidRepoFormat is returned when a repository string in the config is not in 'owner/name' format.
+// ErrInvalidRepoFormat is returned when a repository string in the config is not in 'owner/name', 'host/owner/name',
+// 'gitlab:group/project', 'org:owner' or 'user:owner' format.
 type ErrInvalidRepoFormat struct {
 	Repo string
 }
 
 func (e *ErrInvalidRepoFormat) Error() string {
-	return fmt.Sprintf("invalid repository format: %q, expected 'owner/name'", e.Repo)
+	return fmt.Sprintf("invalid repository format: %q, expected 'owner/name', 'host/owner/name', 'gitlab:group/project', 'org:owner' or 'user:owner'", e.Repo)
 }
+
+// ErrUnknownHost is returned when a configured repository lives on a host that has no client configured.
+type ErrUnknownHost struct {
+	Host string
+	Repo string
+}
+
+func (e *ErrUnknownHost) Error() string {
+	return fmt.Sprintf("repository %q is on unknown host %q, add it to GITHUB_ENTERPRISE_HOSTS or set GITLAB_BASE_URL", e.Repo, e.Host)
+}
+
+// ErrNotModified is returned by the GitHub client when a conditional request reports
+// that the resource has not changed since it was last fetched.
+var ErrNotModified = errors.New("resource not modified")
+
+// ErrNotTracked is returned when a sync is requested for a repository that is neither tracked by
+// name nor matched by an org:, user: or wildcard entry.
+var ErrNotTracked = errors.New("repository is not tracked")
+
+// ErrPaused is returned when a sync is requested for a repository whose tracking is paused.
+var ErrPaused = errors.New("repository is paused")
+
+// ErrSyncQueueFull is returned when too many on-demand syncs are waiting to run.
+var ErrSyncQueueFull = errors.New("too many syncs are queued")
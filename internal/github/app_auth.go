@@ -0,0 +1,161 @@
+// internal/github/app_auth.go
+package github
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTLifetime is how long the JWT used to request installation tokens is valid.
+	// GitHub rejects JWTs valid for more than 10 minutes.
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockSkew backdates the JWT's issue time to tolerate clock drift.
+	appJWTClockSkew = 60 * time.Second
+	// installationTokenLeeway refreshes installation tokens this long before they expire.
+	installationTokenLeeway = 5 * time.Minute
+)
+
+// appTokenSource exchanges a GitHub App JWT for installation access tokens.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	tokenURL       string
+	httpClient     *http.Client
+	now            func() time.Time
+}
+
+// NewAppTokenSource returns a token source that authenticates as a GitHub App installation.
+// Each token is obtained by signing a JWT with the App's private key and exchanging it at
+// baseURL for an installation access token, which is reused until shortly before it expires.
+func NewAppTokenSource(appID, installationID int64, privateKeyPEM []byte, baseURL string) (oauth2.TokenSource, error) {
+	key, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	src := &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		tokenURL:       fmt.Sprintf("%sapp/installations/%d/access_tokens", baseURL, installationID),
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+		now:            time.Now,
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, src, installationTokenLeeway), nil
+}
+
+// Token implements oauth2.TokenSource.
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.signJWT()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.tokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		var body struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return nil, fmt.Errorf("failed to request installation token: %s: %s", resp.Status, body.Message)
+	}
+
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode installation token: %w", err)
+	}
+	if out.Token == "" {
+		return nil, errors.New("installation token response did not contain a token")
+	}
+
+	return &oauth2.Token{
+		AccessToken: out.Token,
+		Expiry:      out.ExpiresAt,
+	}, nil
+}
+
+// signJWT builds the RS256-signed JWT that identifies the GitHub App.
+func (s *appTokenSource) signJWT() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+
+	digest := sha256.Sum256(buf.Bytes())
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(sig))
+	return buf.String(), nil
+}
+
+// parseRSAPrivateKey accepts the PKCS#1 PEM keys GitHub issues as well as PKCS#8.
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
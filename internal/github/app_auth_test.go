@@ -0,0 +1,140 @@
+// internal/github/app_auth_test.go
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenEndpoint serves installation tokens and verifies the App JWT it receives.
+func fakeTokenEndpoint(t *testing.T, key *rsa.PrivateKey, expiresIn time.Duration, issued *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/app/installations/99/access_tokens", r.URL.Path)
+
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		require.Len(t, parts, 3)
+
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig), "JWT must be signed with the App key")
+
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "42", claims.Iss)
+		assert.LessOrEqual(t, claims.Exp-claims.Iat, int64(10*60), "GitHub rejects JWTs valid for more than 10 minutes")
+
+		n := atomic.AddInt32(issued, 1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_token%d", "expires_at": %q}`, n, time.Now().Add(expiresIn).Format(time.RFC3339))
+	}))
+}
+
+func generateTestKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return key, keyPEM
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key, keyPEM := generateTestKey(t)
+
+	t.Run("exchanges the App JWT for an installation token and reuses it", func(t *testing.T) {
+		var issued int32
+		server := fakeTokenEndpoint(t, key, time.Hour, &issued)
+		defer server.Close()
+
+		ts, err := NewAppTokenSource(42, 99, keyPEM, server.URL)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			tok, err := ts.Token()
+			require.NoError(t, err)
+			assert.Equal(t, "ghs_token1", tok.AccessToken)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&issued))
+	})
+
+	t.Run("refreshes tokens that are about to expire", func(t *testing.T) {
+		var issued int32
+		server := fakeTokenEndpoint(t, key, installationTokenLeeway/2, &issued)
+		defer server.Close()
+
+		ts, err := NewAppTokenSource(42, 99, keyPEM, server.URL)
+		require.NoError(t, err)
+
+		first, err := ts.Token()
+		require.NoError(t, err)
+		second, err := ts.Token()
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first.AccessToken, second.AccessToken)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&issued))
+	})
+
+	t.Run("surfaces token endpoint errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, `{"message": "A JSON web token could not be decoded"}`)
+		}))
+		defer server.Close()
+
+		ts, err := NewAppTokenSource(42, 99, keyPEM, server.URL)
+		require.NoError(t, err)
+
+		_, err = ts.Token()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "A JSON web token could not be decoded")
+	})
+
+	t.Run("rejects keys that are not PEM encoded", func(t *testing.T) {
+		_, err := NewAppTokenSource(42, 99, []byte("not a key"), DefaultBaseURL)
+		assert.Error(t, err)
+	})
+
+	t.Run("client authenticates with the installation token", func(t *testing.T) {
+		var issued int32
+		tokenServer := fakeTokenEndpoint(t, key, time.Hour, &issued)
+		defer tokenServer.Close()
+
+		ts, err := NewAppTokenSource(42, 99, keyPEM, tokenServer.URL)
+		require.NoError(t, err)
+
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer ghs_token1", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `{"id": 1, "name": "repo", "owner": {"login": "test"}}`)
+		})
+		client, server := setupTestClient(t, handler, WithTokenSource(ts))
+		defer server.Close()
+
+		_, err = client.GetRepository(context.Background(), "test", "repo")
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,163 @@
+// internal/github/cache.go
+package github
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Validators are the HTTP cache validators GitHub returned for a request URL.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// ValidatorStore persists cache validators between sync cycles so that
+// unchanged resources can be requested conditionally.
+type ValidatorStore interface {
+	// GetValidators returns the stored validators for url. The boolean is false if none are stored.
+	GetValidators(ctx context.Context, url string) (Validators, bool, error)
+	SaveValidators(ctx context.Context, url string, v Validators) error
+	// DeleteValidators removes the validators for url and every URL below it.
+	DeleteValidators(ctx context.Context, url string) error
+}
+
+// conditionalTransport sends If-None-Match/If-Modified-Since headers for GET requests
+// it has validators for, and records the validators of successful responses.
+// GitHub answers such requests with 304 Not Modified, which does not count against the rate limit.
+type conditionalTransport struct {
+	base   http.RoundTripper
+	store  ValidatorStore
+	logger *slog.Logger
+}
+
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isConditional(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	key := req.URL.String()
+
+	v, ok, err := t.store.GetValidators(ctx, key)
+	if err != nil {
+		t.logger.Warn("Failed to load cache validators, sending unconditional request", "url", key, "error", err)
+	}
+	if ok {
+		req = req.Clone(ctx)
+		if v.ETag != "" {
+			req.Header.Set("If-None-Match", v.ETag)
+		}
+		if v.LastModified != "" {
+			req.Header.Set("If-Modified-Since", v.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		fresh := Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if fresh != (Validators{}) {
+			if err := t.store.SaveValidators(ctx, key, fresh); err != nil {
+				t.logger.Warn("Failed to save cache validators", "url", key, "error", err)
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// unconditionalPrefixes start the paths, below /repos/{owner}/{name}, of requests that are never
+// sent conditionally, because a 304 would leave the caller without data it needs.
+var unconditionalPrefixes = []string{
+	"compare/", // only requested when there is something to compare
+	"pulls/",   // single pull requests and their reviews, only requested when they were updated
+	"issues/",  // issue comments, only requested when the listing reported the issue as updated
+}
+
+// unconditionalListings are the paths, below /repos/{owner}/{name}, of listings that are never
+// sent conditionally, although their first page could be.
+var unconditionalListings = []string{
+	"branches",          // matched against patterns that may have changed since the last request
+	"labels",            // not ordered newest first, so a later page may change while the first does not
+	"tags",              // not ordered newest first, like labels
+	"releases",          // older releases change as their assets are downloaded
+	"actions/workflows", // listed along with their runs
+	"actions/runs",      // runs on later pages change status when they complete
+	"stargazers",        // read once, so validators would only pile up
+}
+
+// isConditional reports whether req may be sent conditionally. Only the first page of a listing is
+// cached: a 304 on a later page would leave a hole in the results, because validators are stored
+// without the response body. Requests matching unconditionalPrefixes or unconditionalListings,
+// commits fetched by SHA, which are each read once, and owner repository listings, which are not
+// ordered newest first, are never sent conditionally.
+func isConditional(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	sub, ok := repoSubPath(req.URL.Path)
+	switch {
+	case !ok && strings.HasSuffix(req.URL.Path, "/repos"):
+		return false
+	case slices.Contains(unconditionalListings, sub):
+		return false
+	case slices.ContainsFunc(unconditionalPrefixes, func(prefix string) bool { return strings.HasPrefix(sub, prefix) }):
+		return false
+	}
+	if ref, ok := strings.CutPrefix(sub, "commits/"); ok && isSHA(ref) {
+		return false
+	}
+	page := req.URL.Query().Get("page")
+	return page == "" || page == "1"
+}
+
+// repoSubPath returns the part of path below /repos/{owner}/{name}, such as "pulls/1", or "" for
+// the repository itself. ok is false for paths outside a repository, such as /orgs/{org}/repos.
+func repoSubPath(path string) (sub string, ok bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	i := slices.Index(segments, "repos") // after the /api/v3 prefix of GitHub Enterprise Server
+	if i < 0 || len(segments) < i+3 {
+		return "", false
+	}
+	return strings.Join(segments[i+3:], "/"), true
+}
+
+// isSHA reports whether ref is a full commit SHA rather than a branch name or HEAD.
+func isSHA(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	for _, r := range ref {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// repositoryURL returns the API URL of a repository, which prefixes every URL of its sub-resources.
+func (c *Client) repositoryURL(owner, name string) string {
+	u := c.gh.BaseURL.ResolveReference(&url.URL{Path: "repos/" + owner + "/" + name})
+	return u.String()
+}
+
+// InvalidateRepository forgets the cache validators of a repository and all of its
+// sub-resources, so the next sync downloads full payloads again. The syncer calls it
+// when stored data and validators may have drifted apart, e.g. after a rolled back sync.
+func (c *Client) InvalidateRepository(ctx context.Context, owner, name string) error {
+	if c.validators == nil {
+		return nil
+	}
+	return c.validators.DeleteValidators(ctx, c.repositoryURL(owner, name))
+}
@@ -0,0 +1,52 @@
+// internal/github/cache_test.go
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConditional(t *testing.T) {
+	tests := []struct {
+		method, target string
+		want           bool
+	}{
+		{http.MethodGet, "/repos/o/n", true},
+		{http.MethodGet, "/repos/o/n/commits?page=1", true},
+		{http.MethodGet, "/repos/o/n/commits?page=2", false},
+		{http.MethodGet, "/repos/o/n/commits/main", true},
+		{http.MethodGet, "/repos/o/n/commits/0123456789abcdef0123456789abcdef01234567", false},
+		{http.MethodGet, "/repos/o/n/pulls", true},
+		{http.MethodGet, "/repos/o/n/pulls/1/reviews", false},
+		{http.MethodGet, "/repos/o/n/issues", true},
+		{http.MethodGet, "/repos/o/n/issues/1/comments", false},
+		{http.MethodGet, "/repos/o/n/compare/a...b", false},
+		{http.MethodGet, "/repos/o/n/tags", false},
+		{http.MethodGet, "/repos/o/n/actions/runs", false},
+		{http.MethodGet, "/orgs/o/repos", false},
+		{http.MethodGet, "/users/u/repos", false},
+		{http.MethodPost, "/graphql", false},
+
+		// Repositories and owners named after sub-resources.
+		{http.MethodGet, "/repos/o/runs", true},
+		{http.MethodGet, "/repos/o/tags", true},
+		{http.MethodGet, "/repos/o/repos", true},
+		{http.MethodGet, "/repos/o/runs/tags", false},
+		{http.MethodGet, "/repos/o/tags/commits", true},
+		{http.MethodGet, "/repos/issues/pulls/commits", true},
+		{http.MethodGet, "/repos/pulls/issues", true},
+		{http.MethodGet, "/orgs/repos/repos", false},
+
+		// GitHub Enterprise Server serves the API below /api/v3.
+		{http.MethodGet, "/api/v3/repos/o/runs", true},
+		{http.MethodGet, "/api/v3/repos/o/n/tags", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+
+		assert.Equal(t, tt.want, isConditional(req), "%s %s", tt.method, tt.target)
+	}
+}
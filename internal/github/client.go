@@ -4,10 +4,16 @@ package github
 import (
 	"context"
 	"errors"
+	"fmt"
+	custom_errors "github-data-fetcher/internal/errors"
 	"github-data-fetcher/internal/model"
 	"log/slog"
 	"math"
 	"math/rand" // Import math/rand
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v62/github"
@@ -15,6 +21,11 @@ import (
 )
 
 const (
+	// DefaultHost identifies public GitHub in repository identifiers.
+	DefaultHost = "github.com"
+	// DefaultBaseURL is the REST API root of public GitHub.
+	DefaultBaseURL = "https://api.github.com/"
+
 	maxRetries    = 5
 	retryMinDelay = 1 * time.Second
 	retryMaxDelay = 120 * time.Second
@@ -22,28 +33,137 @@ const (
 
 // Client is a wrapper around the go-github client that adds resilience.
 type Client struct {
-	gh     *github.Client
-	logger *slog.Logger
-	r      *rand.Rand // Add a random source for jitter
+	gh          *github.Client
+	logger      *slog.Logger
+	r           *rand.Rand // Add a random source for jitter
+	validators  ValidatorStore
+	tokenSource oauth2.TokenSource
+	tokens      []string
+	pool        *tokenPool
+	useGraphQL  bool
+	transport   http.RoundTripper
+	minDelay    time.Duration
+	maxDelay    time.Duration
+}
+
+// Option configures optional behaviour of a Client.
+type Option func(*Client)
+
+// WithValidatorStore enables conditional requests, persisting ETag/Last-Modified validators in store.
+func WithValidatorStore(store ValidatorStore) Option {
+	return func(c *Client) {
+		c.validators = store
+	}
+}
+
+// WithTokenSource authenticates requests with tokens from ts instead of the static token
+// passed to NewClient, e.g. a GitHub App installation token source.
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = ts
+	}
+}
+
+// WithTokenPool spreads requests over several personal access tokens, routing each request to
+// the token with the most remaining quota. It takes precedence over WithTokenSource.
+func WithTokenPool(tokens []string) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// WithGraphQLCommits makes ForEachCommitPage page through the GraphQL API instead of REST ListCommits.
+// GraphQL also reports commit stats, which REST only returns by fetching each commit individually.
+func WithGraphQLCommits() Option {
+	return func(c *Client) {
+		c.useGraphQL = true
+	}
+}
+
+// WithTransport sends requests through rt instead of http.DefaultTransport. Authentication and
+// conditional requests are layered on top of it, e.g. to record or replay API interactions in tests.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
+
+// WithRetryBackoff overrides the exponential backoff bounds used when retrying server errors.
+func WithRetryBackoff(minDelay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.minDelay = minDelay
+		c.maxDelay = maxDelay
+	}
 }
 
 // NewClient creates and configures a new Client instance.
-func NewClient(token string, logger *slog.Logger) *Client {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-
-	return &Client{
-		gh:     github.NewClient(tc),
+func NewClient(token string, logger *slog.Logger, opts ...Option) *Client {
+	c := &Client{
 		logger: logger,
 		// Create a non-global random source to be concurrency-safe.
-		r: rand.New(rand.NewSource(time.Now().UnixNano())),
+		r:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		transport: http.DefaultTransport,
+		minDelay:  retryMinDelay,
+		maxDelay:  retryMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	var transport http.RoundTripper
+	if len(c.tokens) > 0 {
+		c.pool = newTokenPool(c.tokens, c.transport, logger)
+		transport = c.pool
+	} else {
+		ts := c.tokenSource
+		if ts == nil {
+			ts = oauth2.StaticTokenSource(
+				&oauth2.Token{AccessToken: token},
+			)
+		}
+		transport = &oauth2.Transport{Source: ts, Base: c.transport}
+	}
+	if c.validators != nil {
+		transport = &conditionalTransport{base: transport, store: c.validators, logger: logger}
+	}
+
+	c.gh = github.NewClient(&http.Client{Transport: transport})
+	return c
+}
+
+// WithEnterpriseURLs points the client at a GitHub Enterprise Server instance instead of
+// api.github.com. The URLs are normalized as by go-github, so 'https://ghe.corp/' is enough.
+// It must be called before the client is used.
+func (c *Client) WithEnterpriseURLs(baseURL, uploadURL string) (*Client, error) {
+	gh, err := c.gh.WithEnterpriseURLs(baseURL, uploadURL)
+	if err != nil {
+		return nil, err
+	}
+	c.gh = gh
+	return c, nil
+}
+
+// WithBaseURL sends REST requests to baseURL instead of api.github.com, e.g. a githubfake server.
+// Unlike WithEnterpriseURLs the URL is used as is. It must be called before the client is used.
+func (c *Client) WithBaseURL(baseURL string) (*Client, error) {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	c.gh.BaseURL = u
+	return c, nil
+}
+
+// Provider returns model.ProviderGitHub; GitHub Enterprise hosts are the same provider.
+func (c *Client) Provider() string {
+	return model.ProviderGitHub
 }
 
 // GetRepository fetches repository details with retry logic.
+// It returns custom_errors.ErrNotModified if the repository is unchanged since the last conditional request.
 func (c *Client) GetRepository(ctx context.Context, owner, name string) (*model.Repository, error) {
 	var repo *github.Repository
 	var resp *github.Response
@@ -54,15 +174,123 @@ func (c *Client) GetRepository(ctx context.Context, owner, name string) (*model.
 		return resp, err
 	})
 
+	if notModified(resp) {
+		return nil, custom_errors.ErrNotModified
+	}
 	if err != nil {
 		return nil, err
 	}
 	return toInternalRepository(repo), nil
 }
 
+// ListOwnerRepositories returns the repositories of an organization or, if user is true or there
+// is no organization called owner, of a user. GitHub only lists the public repositories of a
+// user; those of an organization include the private ones the credentials can see.
+func (c *Client) ListOwnerRepositories(ctx context.Context, owner string, user bool) ([]model.Repository, error) {
+	var result []model.Repository
+	opts := github.ListOptions{PerPage: 100}
+	for {
+		var repos []*github.Repository
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			if user {
+				repos, resp, err = c.gh.Repositories.ListByUser(ctx, owner, &github.RepositoryListByUserOptions{Type: "owner", Sort: "full_name", ListOptions: opts})
+			} else {
+				repos, resp, err = c.gh.Repositories.ListByOrg(ctx, owner, &github.RepositoryListByOrgOptions{Type: "all", Sort: "full_name", ListOptions: opts})
+			}
+			return resp, err
+		})
+		if !user && opts.Page == 0 && resp != nil && resp.StatusCode == http.StatusNotFound {
+			user = true // owner is a user, or does not exist
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range repos {
+			result = append(result, *toInternalRepository(r))
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// GetLanguages returns the number of bytes of code in each language of a repository. It returns
+// custom_errors.ErrNotModified if they are unchanged since the last conditional request.
+func (c *Client) GetLanguages(ctx context.Context, owner, name string) (map[string]int64, error) {
+	var languages map[string]int
+	var resp *github.Response
+	var err error
+
+	err = c.retry(ctx, func() (*github.Response, error) {
+		languages, resp, err = c.gh.Repositories.ListLanguages(ctx, owner, name)
+		return resp, err
+	})
+
+	if notModified(resp) {
+		return nil, custom_errors.ErrNotModified
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int64, len(languages))
+	for language, bytes := range languages {
+		result[language] = int64(bytes)
+	}
+	return result, nil
+}
+
+// ListTopics returns the topics of a repository. It returns custom_errors.ErrNotModified if they
+// are unchanged since the last conditional request.
+func (c *Client) ListTopics(ctx context.Context, owner, name string) ([]string, error) {
+	var topics []string
+	var resp *github.Response
+	var err error
+
+	err = c.retry(ctx, func() (*github.Response, error) {
+		topics, resp, err = c.gh.Repositories.ListAllTopics(ctx, owner, name)
+		return resp, err
+	})
+
+	if notModified(resp) {
+		return nil, custom_errors.ErrNotModified
+	}
+	if err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
 // GetCommits fetches all commits for a repository since a given time, with retries and pagination.
+// It returns custom_errors.ErrNotModified if the first page is unchanged since the last conditional request.
+// Prefer ForEachCommitPage for large histories, which does not hold every commit in memory.
 func (c *Client) GetCommits(ctx context.Context, owner, name string, since time.Time) ([]model.Commit, error) {
 	var allCommits []model.Commit
+	err := c.ForEachCommitPage(ctx, owner, name, since, func(page []model.Commit) error {
+		allCommits = append(allCommits, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allCommits, nil
+}
+
+// ForEachCommitPage fetches the commits for a repository since a given time, newest first, calling fn
+// with each page as it arrives. It stops at the first error returned by fn and returns it.
+// It returns custom_errors.ErrNotModified, without calling fn, if the first page is unchanged since the
+// last conditional request.
+func (c *Client) ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error {
+	if c.useGraphQL {
+		return c.forEachCommitPageGraphQL(ctx, owner, name, since, "", "", func(page []model.Commit, _, _ string) error {
+			return fn(page)
+		})
+	}
 
 	opts := &github.CommitsListOptions{
 		Since: since,
@@ -70,7 +298,56 @@ func (c *Client) GetCommits(ctx context.Context, owner, name string, since time.
 			PerPage: 100,
 		},
 	}
+	return c.forEachCommitPageREST(ctx, owner, name, opts, func(page []model.Commit, _ int) error {
+		return fn(page)
+	})
+}
+
+// ForEachCommitPageFrom is like ForEachCommitPage, but fn also receives a cursor from which a later
+// call resumes the listing after that page, or "" after the last page. An empty cursor starts at
+// the newest commit. The listing is anchored at the head commit seen on the first page, so
+// commits pushed in the meantime do not shift the pages of a resumed listing.
+func (c *Client) ForEachCommitPageFrom(ctx context.Context, owner, name string, since time.Time, cursor string, fn func(page []model.Commit, next string) error) error {
+	head, pos, _ := strings.Cut(cursor, " ")
 
+	if c.useGraphQL {
+		return c.forEachCommitPageGraphQL(ctx, owner, name, since, head, pos, func(page []model.Commit, head, next string) error {
+			if next != "" {
+				next = head + " " + next
+			}
+			return fn(page, next)
+		})
+	}
+
+	opts := &github.CommitsListOptions{
+		SHA:   head,
+		Since: since,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	if pos != "" {
+		page, err := strconv.Atoi(pos)
+		if err != nil {
+			return fmt.Errorf("invalid commit cursor %q: %w", cursor, err)
+		}
+		opts.Page = page
+	}
+	return c.forEachCommitPageREST(ctx, owner, name, opts, func(page []model.Commit, nextPage int) error {
+		if opts.SHA == "" && len(page) > 0 {
+			opts.SHA = page[0].SHA // Later pages list history from the first page's head.
+		}
+		next := ""
+		if nextPage != 0 {
+			next = opts.SHA + " " + strconv.Itoa(nextPage)
+		}
+		return fn(page, next)
+	})
+}
+
+// forEachCommitPageREST pages through REST ListCommits with opts, passing fn each page and the
+// number of the page after it, 0 after the last one.
+func (c *Client) forEachCommitPageREST(ctx context.Context, owner, name string, opts *github.CommitsListOptions, fn func(page []model.Commit, nextPage int) error) error {
 	for {
 		var commits []*github.RepositoryCommit
 		var resp *github.Response
@@ -81,21 +358,544 @@ func (c *Client) GetCommits(ctx context.Context, owner, name string, since time.
 			commits, resp, err = c.gh.Repositories.ListCommits(ctx, owner, name, opts)
 			return resp, err
 		})
+		if opts.Page == 0 && notModified(resp) {
+			return custom_errors.ErrNotModified
+		}
 		if err != nil {
-			return nil, err
+			return err
 		}
 
+		page := make([]model.Commit, 0, len(commits))
 		for _, commit := range commits {
-			allCommits = append(allCommits, toInternalCommit(commit))
+			page = append(page, toInternalCommit(commit))
+		}
+		if err := fn(page, resp.NextPage); err != nil {
+			return err
 		}
 
 		if resp.NextPage == 0 {
-			break
+			return nil
 		}
 		opts.Page = resp.NextPage
 	}
+}
 
-	return allCommits, nil
+// ListBranches returns the branches of a repository with the commits they point to.
+func (c *Client) ListBranches(ctx context.Context, owner, name string) ([]model.Branch, error) {
+	var branches []model.Branch
+	opts := &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		var page []*github.Branch
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			page, resp, err = c.gh.Repositories.ListBranches(ctx, owner, name, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range page {
+			branches = append(branches, model.Branch{Name: b.GetName(), HeadSHA: b.GetCommit().GetSHA()})
+		}
+		if resp.NextPage == 0 {
+			return branches, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ForEachBranchCommitPage is like ForEachCommitPage, but lists the history of branch instead of
+// the default branch. It always pages through the REST API.
+func (c *Client) ForEachBranchCommitPage(ctx context.Context, owner, name, branch string, since time.Time, fn func(page []model.Commit) error) error {
+	opts := &github.CommitsListOptions{
+		SHA:   branch,
+		Since: since,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	return c.forEachCommitPageREST(ctx, owner, name, opts, func(page []model.Commit, _ int) error {
+		return fn(page)
+	})
+}
+
+// GetHeadSHA returns the SHA the default branch points to, or "" if the repository is empty.
+// It returns custom_errors.ErrNotModified if the head is unchanged since the last conditional request.
+func (c *Client) GetHeadSHA(ctx context.Context, owner, name string) (string, error) {
+	var sha string
+	var resp *github.Response
+	var err error
+
+	err = c.retry(ctx, func() (*github.Response, error) {
+		sha, resp, err = c.gh.Repositories.GetCommitSHA1(ctx, owner, name, "HEAD", "")
+		return resp, err
+	})
+
+	if notModified(resp) {
+		return "", custom_errors.ErrNotModified
+	}
+	if resp != nil && resp.StatusCode == http.StatusConflict {
+		return "", nil // GitHub answers 409 Conflict for repositories without commits.
+	}
+	if err != nil {
+		return "", err
+	}
+	return sha, nil
+}
+
+// CompareHeads reports how a branch got from oldHead to newHead. The commits reachable
+// from oldHead but not from newHead are listed by comparing the heads the other way round.
+func (c *Client) CompareHeads(ctx context.Context, owner, name, oldHead, newHead string) (*model.HeadComparison, error) {
+	cmp := &model.HeadComparison{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var comparison *github.CommitsComparison
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			comparison, resp, err = c.gh.Repositories.CompareCommits(ctx, owner, name, newHead, oldHead, opts)
+			return resp, err
+		})
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			cmp.OldHeadMissing = true
+			return cmp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cmp.MergeBase = comparison.GetMergeBaseCommit().GetSHA()
+		for _, commit := range comparison.Commits {
+			cmp.Orphaned = append(cmp.Orphaned, commit.GetSHA())
+		}
+		if resp.NextPage == 0 {
+			return cmp, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// GetCommitFiles returns the files a commit changed with their line stats. GitHub lists at most
+// 3000 files per commit. Commits GitHub no longer has or no longer serves are reported as changing
+// no files, so they are not asked for again.
+func (c *Client) GetCommitFiles(ctx context.Context, owner, name, sha string) ([]model.CommitFile, error) {
+	var result []model.CommitFile
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var commit *github.RepositoryCommit
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			commit, resp, err = c.gh.Repositories.GetCommit(ctx, owner, name, sha, opts)
+			return resp, err
+		})
+		if resp != nil && (resp.StatusCode == http.StatusUnprocessableEntity || resp.StatusCode == http.StatusNotFound) {
+			return nil, nil // The commit is gone, e.g. garbage collected after a force-push.
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range commit.Files {
+			result = append(result, model.CommitFile{
+				Filename:  f.GetFilename(),
+				Status:    f.GetStatus(),
+				Additions: f.GetAdditions(),
+				Deletions: f.GetDeletions(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ForEachPullRequestPage calls fn with each page of the pull requests updated at or after since,
+// most recently updated first. Listings lack line stats, who merged a pull request and its
+// reviews; GetPullRequest fetches those. It returns custom_errors.ErrNotModified, without calling
+// fn, if the first page is unchanged since the last conditional request.
+func (c *Client) ForEachPullRequestPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.PullRequest) error) error {
+	opts := &github.PullRequestListOptions{
+		State:     "all",
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		var prs []*github.PullRequest
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			c.logger.Debug("Fetching pull requests page", "owner", owner, "repo", name, "page", opts.Page)
+			prs, resp, err = c.gh.PullRequests.List(ctx, owner, name, opts)
+			return resp, err
+		})
+		if opts.Page == 0 && notModified(resp) {
+			return custom_errors.ErrNotModified
+		}
+		if err != nil {
+			return err
+		}
+
+		done := resp.NextPage == 0
+		page := make([]model.PullRequest, 0, len(prs))
+		for _, listed := range prs {
+			if listed.GetUpdatedAt().Before(since) {
+				done = true // The rest were updated even earlier.
+				break
+			}
+			page = append(page, toInternalPullRequest(listed))
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+
+		if done {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// GetPullRequest fetches a pull request with its line stats, who merged it and its submitted
+// reviews, in one request plus one per 100 reviews. Pending reviews, which only their author can
+// see, are left out.
+func (c *Client) GetPullRequest(ctx context.Context, owner, name string, number int) (model.PullRequest, error) {
+	var pr *github.PullRequest
+	var resp *github.Response
+	var err error
+
+	err = c.retry(ctx, func() (*github.Response, error) {
+		pr, resp, err = c.gh.PullRequests.Get(ctx, owner, name, number)
+		return resp, err
+	})
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	result := toInternalPullRequest(pr)
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var reviews []*github.PullRequestReview
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			reviews, resp, err = c.gh.PullRequests.ListReviews(ctx, owner, name, number, opts)
+			return resp, err
+		})
+		if err != nil {
+			return model.PullRequest{}, err
+		}
+
+		for _, r := range reviews {
+			if r.GetState() == "PENDING" {
+				continue
+			}
+			result.Reviews = append(result.Reviews, model.PullRequestReview{
+				ID:            r.GetID(),
+				ReviewerLogin: r.GetUser().GetLogin(),
+				State:         r.GetState(),
+				CommitSHA:     r.GetCommitID(),
+				SubmittedAt:   r.GetSubmittedAt().Time,
+			})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListLabels returns the labels defined in a repository.
+func (c *Client) ListLabels(ctx context.Context, owner, name string) ([]model.Label, error) {
+	var result []model.Label
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var labels []*github.Label
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			labels, resp, err = c.gh.Issues.ListLabels(ctx, owner, name, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, l := range labels {
+			result = append(result, model.Label{
+				Name:        l.GetName(),
+				Color:       l.GetColor(),
+				Description: l.GetDescription(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ForEachIssuePage calls fn with each page of the issues updated at or after since, most recently
+// updated first. Listings lack comments; ListIssueComments fetches those. Pull requests, which
+// GitHub lists as issues too, are left out, and pages holding nothing else are skipped. It returns custom_errors.ErrNotModified, without
+// calling fn, if the first page is unchanged since the last conditional request.
+func (c *Client) ForEachIssuePage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Issue) error) error {
+	opts := &github.IssueListByRepoOptions{
+		State:     "all",
+		Sort:      "updated",
+		Direction: "desc",
+		Since:     since,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		var issues []*github.Issue
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			c.logger.Debug("Fetching issues page", "owner", owner, "repo", name, "page", opts.Page)
+			issues, resp, err = c.gh.Issues.ListByRepo(ctx, owner, name, opts)
+			return resp, err
+		})
+		if opts.Page == 0 && notModified(resp) {
+			return custom_errors.ErrNotModified
+		}
+		if err != nil {
+			return err
+		}
+
+		page := make([]model.Issue, 0, len(issues))
+		for _, listed := range issues {
+			if listed.IsPullRequest() {
+				continue
+			}
+			page = append(page, toInternalIssue(listed))
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListIssueComments returns the comments on an issue, oldest first, one request per 100 comments.
+func (c *Client) ListIssueComments(ctx context.Context, owner, name string, number int) ([]model.IssueComment, error) {
+	var result []model.IssueComment
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		var comments []*github.IssueComment
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			comments, resp, err = c.gh.Issues.ListComments(ctx, owner, name, number, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, comment := range comments {
+			result = append(result, model.IssueComment{
+				ID:          comment.GetID(),
+				AuthorLogin: comment.GetUser().GetLogin(),
+				CreatedAt:   comment.GetCreatedAt().Time,
+				UpdatedAt:   comment.GetUpdatedAt().Time,
+			})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListTags returns the tags of a repository with the commits they point to.
+func (c *Client) ListTags(ctx context.Context, owner, name string) ([]model.Tag, error) {
+	var result []model.Tag
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var tags []*github.RepositoryTag
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			tags, resp, err = c.gh.Repositories.ListTags(ctx, owner, name, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tags {
+			result = append(result, model.Tag{Name: t.GetName(), SHA: t.GetCommit().GetSHA()})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListReleases returns the releases of a repository with their assets, newest first. Drafts are
+// only listed to users with push access.
+func (c *Client) ListReleases(ctx context.Context, owner, name string) ([]model.Release, error) {
+	var result []model.Release
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var releases []*github.RepositoryRelease
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			releases, resp, err = c.gh.Repositories.ListReleases(ctx, owner, name, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range releases {
+			result = append(result, toInternalRelease(r))
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListStargazers returns the users who starred a repository and when, oldest first. GitHub only
+// lists the first 40,000 stargazers; the later ones are left out.
+func (c *Client) ListStargazers(ctx context.Context, owner, name string) ([]model.Stargazer, error) {
+	var result []model.Stargazer
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var stargazers []*github.Stargazer
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			stargazers, resp, err = c.gh.Activity.ListStargazers(ctx, owner, name, opts)
+			return resp, err
+		})
+		if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+			return result, nil // Past the last page GitHub serves.
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range stargazers {
+			result = append(result, model.Stargazer{Login: s.GetUser().GetLogin(), StarredAt: s.GetStarredAt().Time})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListWorkflows returns the GitHub Actions workflows of a repository.
+func (c *Client) ListWorkflows(ctx context.Context, owner, name string) ([]model.Workflow, error) {
+	var result []model.Workflow
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var workflows *github.Workflows
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			workflows, resp, err = c.gh.Actions.ListWorkflows(ctx, owner, name, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, w := range workflows.Workflows {
+			result = append(result, model.Workflow{ID: w.GetID(), Name: w.GetName(), Path: w.GetPath(), State: w.GetState()})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ForEachWorkflowRunPage calls fn with each page of the workflow runs created at or after since,
+// newest first, and stops at the first error fn returns. Runs are listed without the created
+// filter, as GitHub returns at most 1000 runs for filtered listings; the listing stops at the
+// first run created before since instead.
+func (c *Client) ForEachWorkflowRunPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.WorkflowRun) error) error {
+	opts := &github.ListWorkflowRunsOptions{
+		ExcludePullRequests: true,
+		ListOptions:         github.ListOptions{PerPage: 100},
+	}
+	for {
+		var runs *github.WorkflowRuns
+		var resp *github.Response
+		var err error
+
+		err = c.retry(ctx, func() (*github.Response, error) {
+			c.logger.Debug("Fetching workflow runs page", "owner", owner, "repo", name, "page", opts.Page)
+			runs, resp, err = c.gh.Actions.ListRepositoryWorkflowRuns(ctx, owner, name, opts)
+			return resp, err
+		})
+		if err != nil {
+			return err
+		}
+
+		page := make([]model.WorkflowRun, 0, len(runs.WorkflowRuns))
+		done := false
+		for _, r := range runs.WorkflowRuns {
+			if r.GetCreatedAt().Time.Before(since) {
+				done = true
+				break
+			}
+			page = append(page, toInternalWorkflowRun(r))
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if done || resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Quotas returns the last known rate limit quota of each pooled token.
+// It returns nil unless the client was created WithTokenPool.
+func (c *Client) Quotas() []TokenQuota {
+	if c.pool == nil {
+		return nil
+	}
+	return c.pool.Quotas()
 }
 
 // retry is a generic retry wrapper for GitHub API calls.
@@ -138,9 +938,9 @@ func (c *Client) retry(ctx context.Context, fn func() (*github.Response, error))
 		}
 
 		if resp != nil && resp.StatusCode >= 500 {
-			backoff := float64(retryMinDelay) * math.Pow(2, float64(attempt))
-			if backoff > float64(retryMaxDelay) {
-				backoff = float64(retryMaxDelay)
+			backoff := float64(c.minDelay) * math.Pow(2, float64(attempt))
+			if backoff > float64(c.maxDelay) {
+				backoff = float64(c.maxDelay)
 			}
 			// Add jitter: backoff ± 25%
 			jitter := (c.r.Float64() - 0.5) * backoff * 0.5
@@ -161,6 +961,12 @@ func (c *Client) retry(ctx context.Context, fn func() (*github.Response, error))
 	return err
 }
 
+// notModified reports whether GitHub answered a conditional request with 304 Not Modified.
+// go-github surfaces such responses as an *ErrorResponse, so the status is checked directly.
+func notModified(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotModified
+}
+
 func toInternalRepository(r *github.Repository) *model.Repository {
 	return &model.Repository{
 		GithubRepoID:    r.GetID(),
@@ -173,18 +979,133 @@ func toInternalRepository(r *github.Repository) *model.Repository {
 		StarsCount:      r.GetStargazersCount(),
 		OpenIssuesCount: r.GetOpenIssuesCount(),
 		WatchersCount:   r.GetWatchersCount(),
+		Archived:        r.GetArchived(),
+		Fork:            r.GetFork(),
+		Private:         r.GetPrivate(),
 		RepoCreatedAt:   r.GetCreatedAt().Time,
 		RepoUpdatedAt:   r.GetUpdatedAt().Time,
 	}
 }
 
 func toInternalCommit(c *github.RepositoryCommit) model.Commit {
+	var parents []string
+	for _, p := range c.Parents {
+		parents = append(parents, p.GetSHA())
+	}
 	return model.Commit{
-		SHA:         c.GetSHA(),
-		AuthorName:  c.GetCommit().GetAuthor().GetName(),
-		AuthorEmail: c.GetCommit().GetAuthor().GetEmail(),
-		Message:     c.GetCommit().GetMessage(),
-		URL:         c.GetHTMLURL(),
-		CommitDate:  c.GetCommit().GetAuthor().GetDate().Time,
+		SHA:            c.GetSHA(),
+		Parents:        parents,
+		AuthorName:     c.GetCommit().GetAuthor().GetName(),
+		AuthorEmail:    c.GetCommit().GetAuthor().GetEmail(),
+		AuthorLogin:    c.GetAuthor().GetLogin(),
+		CommitterName:  c.GetCommit().GetCommitter().GetName(),
+		CommitterEmail: c.GetCommit().GetCommitter().GetEmail(),
+		CommitterDate:  c.GetCommit().GetCommitter().GetDate().Time,
+		Message:        c.GetCommit().GetMessage(),
+		URL:            c.GetHTMLURL(),
+		CommitDate:     c.GetCommit().GetAuthor().GetDate().Time,
+		Verified:       c.GetCommit().GetVerification().GetVerified(),
+	}
+}
+
+func toInternalPullRequest(pr *github.PullRequest) model.PullRequest {
+	state := pr.GetState()
+	if pr.MergedAt != nil {
+		state = model.PullRequestMerged
+	}
+	return model.PullRequest{
+		Number:        pr.GetNumber(),
+		GithubID:      pr.GetID(),
+		Title:         pr.GetTitle(),
+		State:         state,
+		AuthorLogin:   pr.GetUser().GetLogin(),
+		BaseRef:       pr.GetBase().GetRef(),
+		HeadRef:       pr.GetHead().GetRef(),
+		Additions:     pr.GetAdditions(),
+		Deletions:     pr.GetDeletions(),
+		MergedByLogin: pr.GetMergedBy().GetLogin(),
+		URL:           pr.GetHTMLURL(),
+		CreatedAt:     pr.GetCreatedAt().Time,
+		UpdatedAt:     pr.GetUpdatedAt().Time,
+		MergedAt:      pr.GetMergedAt().Time,
+		ClosedAt:      pr.GetClosedAt().Time,
+	}
+}
+
+func toInternalIssue(i *github.Issue) model.Issue {
+	labels := make([]string, 0, len(i.Labels))
+	for _, l := range i.Labels {
+		labels = append(labels, l.GetName())
+	}
+	assignees := make([]string, 0, len(i.Assignees))
+	for _, a := range i.Assignees {
+		assignees = append(assignees, a.GetLogin())
+	}
+	return model.Issue{
+		Number:        i.GetNumber(),
+		GithubID:      i.GetID(),
+		Title:         i.GetTitle(),
+		State:         i.GetState(),
+		AuthorLogin:   i.GetUser().GetLogin(),
+		Labels:        labels,
+		Assignees:     assignees,
+		Milestone:     i.GetMilestone().GetTitle(),
+		CommentsCount: i.GetComments(),
+		URL:           i.GetHTMLURL(),
+		CreatedAt:     i.GetCreatedAt().Time,
+		UpdatedAt:     i.GetUpdatedAt().Time,
+		ClosedAt:      i.GetClosedAt().Time,
+	}
+}
+
+func toInternalRelease(r *github.RepositoryRelease) model.Release {
+	assets := make([]model.ReleaseAsset, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		assets = append(assets, model.ReleaseAsset{
+			ID:            a.GetID(),
+			Name:          a.GetName(),
+			Size:          int64(a.GetSize()),
+			DownloadCount: int64(a.GetDownloadCount()),
+		})
+	}
+	return model.Release{
+		GithubID:    r.GetID(),
+		TagName:     r.GetTagName(),
+		Name:        r.GetName(),
+		Draft:       r.GetDraft(),
+		Prerelease:  r.GetPrerelease(),
+		AuthorLogin: r.GetAuthor().GetLogin(),
+		URL:         r.GetHTMLURL(),
+		CreatedAt:   r.GetCreatedAt().Time,
+		PublishedAt: r.GetPublishedAt().Time,
+		Assets:      assets,
+	}
+}
+
+// toInternalWorkflowRun converts a workflow run, folding the statuses of runs that have not
+// started yet into model.WorkflowRunQueued.
+func toInternalWorkflowRun(r *github.WorkflowRun) model.WorkflowRun {
+	status := r.GetStatus()
+	if status != model.WorkflowRunInProgress && status != model.WorkflowRunCompleted {
+		status = model.WorkflowRunQueued
+	}
+	startedAt := r.GetRunStartedAt().Time
+	if startedAt.IsZero() {
+		startedAt = r.GetCreatedAt().Time
+	}
+	return model.WorkflowRun{
+		ID:         r.GetID(),
+		WorkflowID: r.GetWorkflowID(),
+		Name:       r.GetName(),
+		Event:      r.GetEvent(),
+		Status:     status,
+		Conclusion: r.GetConclusion(),
+		HeadSHA:    r.GetHeadSHA(),
+		HeadBranch: r.GetHeadBranch(),
+		RunNumber:  r.GetRunNumber(),
+		RunAttempt: r.GetRunAttempt(),
+		CreatedAt:  r.GetCreatedAt().Time,
+		StartedAt:  startedAt,
+		UpdatedAt:  r.GetUpdatedAt().Time,
 	}
 }
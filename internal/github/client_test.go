@@ -7,7 +7,11 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -15,20 +19,23 @@ import (
 	"github.com/google/go-github/v62/github"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	custom_errors "github-data-fetcher/internal/errors"
+	"github-data-fetcher/internal/github/cassette"
 )
 
 // setupTestClient creates a httptest server and a github client pointing to it.
-func setupTestClient(t *testing.T, handler http.Handler) (*Client, *httptest.Server) {
+func setupTestClient(t *testing.T, handler http.Handler, opts ...Option) (*Client, *httptest.Server) {
 	server := httptest.NewServer(handler)
 
 	// We can pass a nil token because we are not authenticating to the real GitHub.
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	client := NewClient("", logger)
+	client := NewClient("", logger, opts...)
 
-	// Override the client's internal http client to point to our test server.
-	testClient, err := github.NewClient(server.Client()).WithEnterpriseURLs(server.URL, server.URL)
+	// Point the client at our test server, keeping the transport chain built by NewClient.
+	baseURL, err := url.Parse(server.URL + "/")
 	require.NoError(t, err)
-	client.gh = testClient
+	client.gh.BaseURL = baseURL
 
 	return client, server
 }
@@ -74,10 +81,12 @@ func TestClient_GetRepository_Retry(t *testing.T) {
 
 	t.Run("handles rate limit error", func(t *testing.T) {
 		var requestCount int32
-		resetTime := time.Now().Add(50 * time.Millisecond) // Short wait time for test
+		// X-RateLimit-Reset has second precision, so leave enough headroom for truncation.
+		resetTime := time.Now().Add(1100 * time.Millisecond)
 		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			count := atomic.AddInt32(&requestCount, 1)
 			if count == 1 {
+				w.Header().Set("X-RateLimit-Remaining", "0")
 				w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
 				w.WriteHeader(http.StatusForbidden) // RateLimitError is a 403
 				fmt.Fprintln(w, `{"message": "API rate limit exceeded"}`)
@@ -104,7 +113,7 @@ func TestClient_GetRepository_Retry(t *testing.T) {
 			atomic.AddInt32(&requestCount, 1)
 			w.WriteHeader(http.StatusInternalServerError)
 		})
-		client, server := setupTestClient(t, handler)
+		client, server := setupTestClient(t, handler, WithRetryBackoff(time.Millisecond, time.Millisecond))
 		defer server.Close()
 
 		_, err := client.GetRepository(context.Background(), "test", "repo")
@@ -116,3 +125,198 @@ func TestClient_GetRepository_Retry(t *testing.T) {
 		assert.Equal(t, int32(maxRetries), atomic.LoadInt32(&requestCount))
 	})
 }
+
+// memoryValidatorStore is an in-memory ValidatorStore for tests.
+type memoryValidatorStore struct {
+	mu sync.Mutex
+	m  map[string]Validators
+}
+
+func newMemoryValidatorStore() *memoryValidatorStore {
+	return &memoryValidatorStore{m: make(map[string]Validators)}
+}
+
+func (s *memoryValidatorStore) GetValidators(_ context.Context, url string) (Validators, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.m[url]
+	return v, ok, nil
+}
+
+func (s *memoryValidatorStore) SaveValidators(_ context.Context, url string, v Validators) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[url] = v
+	return nil
+}
+
+func (s *memoryValidatorStore) DeleteValidators(_ context.Context, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.m {
+		if k == url || strings.HasPrefix(k, url+"/") {
+			delete(s.m, k)
+		}
+	}
+	return nil
+}
+
+func TestClient_ConditionalRequests(t *testing.T) {
+	t.Run("repository is reported as not modified on matching ETag", func(t *testing.T) {
+		var requestCount int32
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requestCount, 1)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `{"id": 1, "name": "repo", "owner": {"login": "test"}}`)
+		})
+		client, server := setupTestClient(t, handler, WithValidatorStore(newMemoryValidatorStore()))
+		defer server.Close()
+
+		repo, err := client.GetRepository(context.Background(), "test", "repo")
+		require.NoError(t, err)
+		assert.Equal(t, "repo", repo.Name)
+
+		_, err = client.GetRepository(context.Background(), "test", "repo")
+		assert.ErrorIs(t, err, custom_errors.ErrNotModified)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requestCount), "304 must not be retried")
+	})
+
+	t.Run("commits first page is reported as not modified", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-Modified-Since") != "" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 12:00:00 GMT")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `[{"sha": "abc", "commit": {"author": {"name": "tester", "date": "2024-01-01T12:00:00Z"}}}]`)
+		})
+		client, server := setupTestClient(t, handler, WithValidatorStore(newMemoryValidatorStore()))
+		defer server.Close()
+
+		commits, err := client.GetCommits(context.Background(), "test", "repo", time.Time{})
+		require.NoError(t, err)
+		assert.Len(t, commits, 1)
+
+		_, err = client.GetCommits(context.Background(), "test", "repo", time.Time{})
+		assert.ErrorIs(t, err, custom_errors.ErrNotModified)
+	})
+
+	t.Run("later pages are always fetched unconditionally", func(t *testing.T) {
+		var conditionalPages []string
+		var mu sync.Mutex
+		var serverURL string
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page := r.URL.Query().Get("page")
+			if r.Header.Get("If-None-Match") != "" {
+				mu.Lock()
+				conditionalPages = append(conditionalPages, page)
+				mu.Unlock()
+			}
+			w.Header().Set("ETag", `"page-`+page+`"`)
+			if page == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/repos/test/repo/commits?page=2>; rel="next"`, serverURL))
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `[{"sha": "sha-%s"}]`, page)
+		})
+		client, server := setupTestClient(t, handler, WithValidatorStore(newMemoryValidatorStore()))
+		defer server.Close()
+		serverURL = server.URL
+
+		for i := 0; i < 2; i++ {
+			commits, err := client.GetCommits(context.Background(), "test", "repo", time.Time{})
+			require.NoError(t, err)
+			assert.Len(t, commits, 2)
+		}
+		assert.Equal(t, []string{""}, conditionalPages, "only the first page of the second run should be conditional")
+	})
+
+	t.Run("invalidating a repository drops validators of its sub-resources", func(t *testing.T) {
+		store := newMemoryValidatorStore()
+		client, server := setupTestClient(t, http.NotFoundHandler(), WithValidatorStore(store))
+		defer server.Close()
+
+		ctx := context.Background()
+		base := client.repositoryURL("test", "repo")
+		require.NoError(t, store.SaveValidators(ctx, base, Validators{ETag: "a"}))
+		require.NoError(t, store.SaveValidators(ctx, base+"/commits?per_page=100", Validators{ETag: "b"}))
+		require.NoError(t, store.SaveValidators(ctx, base+"-other", Validators{ETag: "c"}))
+
+		require.NoError(t, client.InvalidateRepository(ctx, "test", "repo"))
+
+		_, ok, _ := store.GetValidators(ctx, base)
+		assert.False(t, ok)
+		_, ok, _ = store.GetValidators(ctx, base+"/commits?per_page=100")
+		assert.False(t, ok)
+		_, ok, _ = store.GetValidators(ctx, base+"-other")
+		assert.True(t, ok)
+	})
+}
+
+func TestClient_WithEnterpriseURLs(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/repos/team/service", r.URL.Path)
+		assert.Equal(t, "Bearer ghe-token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, `{"id": 7, "name": "service", "owner": {"login": "team"}}`)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClient("ghe-token", logger).WithEnterpriseURLs(server.URL, "")
+	require.NoError(t, err)
+
+	repo, err := client.GetRepository(context.Background(), "team", "service")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), repo.GithubRepoID)
+	assert.Equal(t, server.URL+"/api/v3/repos/team/service", client.repositoryURL("team", "service"))
+}
+
+// replayClient returns a client that replays testdata/cassettes/<name>.json. Set
+// GITHUB_CASSETTE_RECORD=1 and GITHUB_TOKEN to re-record the cassette against the real API.
+func replayClient(t *testing.T, name string, opts ...Option) *Client {
+	path := filepath.Join("testdata", "cassettes", name+".json")
+	mode := cassette.ModeReplay
+	if os.Getenv("GITHUB_CASSETTE_RECORD") == "1" {
+		mode = cassette.ModeRecord
+	}
+	recorder, err := cassette.New(path, mode, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, recorder.Save()) })
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	opts = append([]Option{WithTransport(recorder), WithRetryBackoff(time.Millisecond, time.Millisecond)}, opts...)
+	return NewClient(os.Getenv("GITHUB_TOKEN"), logger, opts...)
+}
+
+func TestClient_Replay(t *testing.T) {
+	client := replayClient(t, "repository_and_commits")
+	ctx := context.Background()
+
+	repo, err := client.GetRepository(ctx, "octo-org", "hello-world")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1296269), repo.GithubRepoID)
+	assert.Equal(t, "octo-org", repo.Owner)
+	assert.Equal(t, 2503, repo.StarsCount)
+	require.NotNil(t, repo.Language)
+	assert.Equal(t, "Go", *repo.Language)
+
+	// The first commits page fails with a 502 and is retried; the second page is found via the Link header.
+	commits, err := client.GetCommits(ctx, "octo-org", "hello-world", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+	assert.Equal(t, "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d", commits[0].SHA)
+	assert.Equal(t, "octocat", commits[0].AuthorLogin)
+	assert.True(t, commits[0].Verified)
+	assert.Nil(t, commits[0].Additions, "REST ListCommits does not report stats")
+	assert.Equal(t, "762941318ee16e59dabbacb1b4049eec22f0d303", commits[2].SHA)
+	assert.Empty(t, commits[2].AuthorLogin, "commits by unknown emails have no GitHub author")
+}
@@ -0,0 +1,265 @@
+// internal/github/graphql.go
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+
+	"github-data-fetcher/internal/model"
+)
+
+// commitHistoryQuery pages through the default branch history. Unlike REST ListCommits it
+// returns stats, the author's login and the signature status without extra requests. Parents are
+// listed too, so the first release containing each commit can be found by walking them.
+const commitHistoryQuery = `query($owner: String!, $name: String!, $since: GitTimestamp, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    defaultBranchRef {
+      target {
+        ... on Commit {
+          history(since: $since, first: 100, after: $cursor) {
+            pageInfo { hasNextPage endCursor }
+            nodes {
+              oid
+              message
+              url
+              additions
+              deletions
+              author { name email date user { login } }
+              committer { name email date }
+              signature { isValid }
+              parents(first: 100) { nodes { oid } }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// commitHistoryFromQuery pages through the history of a given commit. Resumed listings use it so
+// their cursors keep pointing into the history they were taken from after the branch moves on.
+const commitHistoryFromQuery = `query($owner: String!, $name: String!, $oid: GitObjectID!, $since: GitTimestamp, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    object(oid: $oid) {
+      ... on Commit {
+        history(since: $since, first: 100, after: $cursor) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            oid
+            message
+            url
+            additions
+            deletions
+            author { name email date user { login } }
+            committer { name email date }
+            signature { isValid }
+            parents(first: 100) { nodes { oid } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type graphqlCommit struct {
+	OID       string `json:"oid"`
+	Message   string `json:"message"`
+	URL       string `json:"url"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Author    struct {
+		Name  string    `json:"name"`
+		Email string    `json:"email"`
+		Date  time.Time `json:"date"`
+		User  *struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"author"`
+	Committer struct {
+		Name  string    `json:"name"`
+		Email string    `json:"email"`
+		Date  time.Time `json:"date"`
+	} `json:"committer"`
+	Signature *struct {
+		IsValid bool `json:"isValid"`
+	} `json:"signature"`
+	Parents struct {
+		Nodes []struct {
+			OID string `json:"oid"`
+		} `json:"nodes"`
+	} `json:"parents"`
+}
+
+type commitHistory struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []graphqlCommit `json:"nodes"`
+}
+
+type commitHistoryResponse struct {
+	Data struct {
+		Repository *struct {
+			DefaultBranchRef *struct {
+				Target struct {
+					History commitHistory `json:"history"`
+				} `json:"target"`
+			} `json:"defaultBranchRef"`
+			// Object is set instead of DefaultBranchRef by commitHistoryFromQuery.
+			Object *struct {
+				History commitHistory `json:"history"`
+			} `json:"object"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []graphqlError `json:"errors"`
+}
+
+// forEachCommitPageGraphQL is the GraphQL implementation of ForEachCommitPage and ForEachCommitPageFrom.
+// It lists the history of head, or of the default branch if head is empty, after cursor, passing fn
+// each page with the head it belongs to and the cursor of the next page, "" after the last one.
+// GraphQL requests are POSTs, so they are never conditional and custom_errors.ErrNotModified is
+// never returned.
+func (c *Client) forEachCommitPageGraphQL(ctx context.Context, owner, name string, since time.Time, head, cursor string, fn func(page []model.Commit, head, next string) error) error {
+	query := commitHistoryQuery
+	vars := map[string]any{"owner": owner, "name": name}
+	if head != "" {
+		query = commitHistoryFromQuery
+		vars["oid"] = head
+	}
+	if !since.IsZero() {
+		vars["since"] = since.UTC().Format(time.RFC3339)
+	}
+	if cursor != "" {
+		vars["cursor"] = cursor
+	}
+
+	for {
+		var out commitHistoryResponse
+		err := c.retry(ctx, func() (*github.Response, error) {
+			c.logger.Debug("Fetching commits page", "owner", owner, "repo", name, "cursor", vars["cursor"])
+			out = commitHistoryResponse{}
+			return c.graphql(ctx, graphqlRequest{Query: query, Variables: vars}, &out)
+		})
+		if err != nil {
+			return err
+		}
+
+		repo := out.Data.Repository
+		if repo == nil {
+			return fmt.Errorf("repository %s/%s not found", owner, name)
+		}
+		var history commitHistory
+		switch {
+		case repo.Object != nil:
+			history = repo.Object.History
+		case repo.DefaultBranchRef != nil:
+			history = repo.DefaultBranchRef.Target.History
+		case head != "":
+			return fmt.Errorf("commit %s not found in %s/%s", head, owner, name)
+		default:
+			// Empty repositories have no default branch and therefore no history.
+			return nil
+		}
+
+		page := make([]model.Commit, 0, len(history.Nodes))
+		for _, commit := range history.Nodes {
+			page = append(page, graphqlToInternalCommit(commit))
+		}
+		if head == "" && len(page) > 0 {
+			head = page[0].SHA
+		}
+		next := ""
+		if history.PageInfo.HasNextPage {
+			next = history.PageInfo.EndCursor
+		}
+		if err := fn(page, head, next); err != nil {
+			return err
+		}
+
+		if next == "" {
+			return nil
+		}
+		vars["cursor"] = next
+	}
+}
+
+// graphql posts body to the GraphQL endpoint and decodes the response into out. GitHub reports
+// most GraphQL failures with a 200 status, so errors in the body are turned into Go errors here.
+func (c *Client) graphql(ctx context.Context, body graphqlRequest, out *commitHistoryResponse) (*github.Response, error) {
+	req, err := c.gh.NewRequest(http.MethodPost, c.graphqlURL(), body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.gh.Do(ctx, req, out)
+	if err != nil {
+		return resp, err
+	}
+	if len(out.Errors) == 0 {
+		return resp, nil
+	}
+
+	msgs := make([]string, len(out.Errors))
+	for i, e := range out.Errors {
+		if e.Type == "RATE_LIMITED" {
+			// Surface it like a REST rate limit so retry waits for the reset.
+			return resp, &github.RateLimitError{Rate: resp.Rate, Response: resp.Response, Message: e.Message}
+		}
+		msgs[i] = e.Message
+	}
+	return resp, fmt.Errorf("GitHub GraphQL API error: %s", strings.Join(msgs, "; "))
+}
+
+// graphqlURL returns the GraphQL endpoint belonging to the REST base URL: api.github.com/graphql
+// on github.com and /api/graphql on GitHub Enterprise Server, whose REST API lives under /api/v3/.
+func (c *Client) graphqlURL() string {
+	base := c.gh.BaseURL
+	if strings.HasSuffix(base.Path, "/api/v3/") {
+		u := *base
+		u.Path = strings.TrimSuffix(base.Path, "v3/") + "graphql"
+		return u.String()
+	}
+	return base.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
+
+func graphqlToInternalCommit(c graphqlCommit) model.Commit {
+	commit := model.Commit{
+		SHA:            c.OID,
+		AuthorName:     c.Author.Name,
+		AuthorEmail:    c.Author.Email,
+		CommitterName:  c.Committer.Name,
+		CommitterEmail: c.Committer.Email,
+		CommitterDate:  c.Committer.Date,
+		Message:        c.Message,
+		URL:            c.URL,
+		CommitDate:     c.Author.Date,
+		Additions:      &c.Additions,
+		Deletions:      &c.Deletions,
+	}
+	if c.Author.User != nil {
+		commit.AuthorLogin = c.Author.User.Login
+	}
+	if c.Signature != nil {
+		commit.Verified = c.Signature.IsValid
+	}
+	for _, p := range c.Parents.Nodes {
+		commit.Parents = append(commit.Parents, p.OID)
+	}
+	return commit
+}
@@ -0,0 +1,185 @@
+// internal/github/graphql_test.go
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github-data-fetcher/internal/model"
+)
+
+// graphqlPage renders a commit history response holding one commit.
+func graphqlPage(sha string, hasNext bool, cursor string) string {
+	return fmt.Sprintf(`{"data": {"repository": {"defaultBranchRef": {"target": {"history": {
+		"pageInfo": {"hasNextPage": %t, "endCursor": %q},
+		"nodes": [{
+			"oid": %q, "message": "msg %s", "url": "https://github.com/test/repo/commit/%s",
+			"additions": 10, "deletions": 3,
+			"author": {"name": "tester", "email": "t@t.com", "date": "2024-01-02T12:00:00Z", "user": {"login": "octo"}},
+			"signature": {"isValid": true}
+		}]
+	}}}}}}`, hasNext, cursor, sha, sha, sha)
+}
+
+func TestClient_GetCommits_GraphQL(t *testing.T) {
+	t.Run("pages through history with cursors", func(t *testing.T) {
+		var cursors []any
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/graphql", r.URL.Path)
+
+			var body graphqlRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "test", body.Variables["owner"])
+			assert.Equal(t, "repo", body.Variables["name"])
+			assert.Equal(t, "2024-01-01T00:00:00Z", body.Variables["since"])
+			cursors = append(cursors, body.Variables["cursor"])
+
+			if body.Variables["cursor"] == nil {
+				fmt.Fprintln(w, graphqlPage("abc", true, "c1"))
+				return
+			}
+			fmt.Fprintln(w, graphqlPage("def", false, "c2"))
+		})
+		client, server := setupTestClient(t, handler, WithGraphQLCommits())
+		defer server.Close()
+
+		commits, err := client.GetCommits(context.Background(), "test", "repo", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+		require.NoError(t, err)
+		assert.Equal(t, []any{nil, "c1"}, cursors)
+		require.Len(t, commits, 2)
+		assert.Equal(t, "abc", commits[0].SHA)
+		assert.Equal(t, "def", commits[1].SHA)
+
+		c := commits[0]
+		assert.Equal(t, "tester", c.AuthorName)
+		assert.Equal(t, "t@t.com", c.AuthorEmail)
+		assert.Equal(t, "octo", c.AuthorLogin)
+		assert.Equal(t, "msg abc", c.Message)
+		assert.Equal(t, "https://github.com/test/repo/commit/abc", c.URL)
+		assert.Equal(t, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), c.CommitDate.UTC())
+		require.NotNil(t, c.Additions)
+		require.NotNil(t, c.Deletions)
+		assert.Equal(t, 10, *c.Additions)
+		assert.Equal(t, 3, *c.Deletions)
+		assert.True(t, c.Verified)
+	})
+
+	t.Run("returns no commits for an empty repository", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"data": {"repository": {"defaultBranchRef": null}}}`)
+		})
+		client, server := setupTestClient(t, handler, WithGraphQLCommits())
+		defer server.Close()
+
+		commits, err := client.GetCommits(context.Background(), "test", "repo", time.Time{})
+
+		require.NoError(t, err)
+		assert.Empty(t, commits)
+	})
+
+	t.Run("resumes the history of the commit a cursor was taken from", func(t *testing.T) {
+		var requests []graphqlRequest
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body graphqlRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			requests = append(requests, body)
+			if body.Variables["oid"] == nil {
+				fmt.Fprintln(w, graphqlPage("abc", true, "abc 0"))
+				return
+			}
+			page := strings.Replace(graphqlPage("def", false, "abc 1"), `"defaultBranchRef": {"target": {`, `"object": {`, 1)
+			fmt.Fprintln(w, strings.Replace(page, "}}}}}}", "}}}}}", 1))
+		})
+		client, server := setupTestClient(t, handler, WithGraphQLCommits())
+		defer server.Close()
+
+		var cursor string
+		interrupted := errors.New("interrupted")
+		err := client.ForEachCommitPageFrom(context.Background(), "test", "repo", time.Time{}, "", func(page []model.Commit, next string) error {
+			cursor = next
+			return interrupted
+		})
+		require.ErrorIs(t, err, interrupted)
+		assert.Equal(t, "abc abc 0", cursor)
+
+		var commits []model.Commit
+		err = client.ForEachCommitPageFrom(context.Background(), "test", "repo", time.Time{}, cursor, func(page []model.Commit, next string) error {
+			commits = append(commits, page...)
+			assert.Empty(t, next)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, commits, 1)
+		assert.Equal(t, "def", commits[0].SHA)
+		require.Len(t, requests, 2)
+		assert.Equal(t, commitHistoryFromQuery, requests[1].Query)
+		assert.Equal(t, "abc", requests[1].Variables["oid"])
+		assert.Equal(t, "abc 0", requests[1].Variables["cursor"])
+	})
+
+	t.Run("returns the parents that link commits to the tags above them", func(t *testing.T) {
+		var query string
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body graphqlRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			query = body.Query
+			// v1.0 tags "tagged", whose first parent's first parent is "grandparent".
+			fmt.Fprintln(w, `{"data": {"repository": {"defaultBranchRef": {"target": {"history": {
+				"pageInfo": {"hasNextPage": false, "endCursor": ""},
+				"nodes": [
+					{"oid": "tagged", "parents": {"nodes": [{"oid": "parent"}, {"oid": "merged"}]}},
+					{"oid": "parent", "parents": {"nodes": [{"oid": "grandparent"}]}},
+					{"oid": "grandparent", "parents": {"nodes": []}}
+				]
+			}}}}}}`)
+		})
+		client, server := setupTestClient(t, handler, WithGraphQLCommits())
+		defer server.Close()
+
+		commits, err := client.GetCommits(context.Background(), "test", "repo", time.Time{})
+
+		require.NoError(t, err)
+		assert.Contains(t, query, "parents(first: 100) { nodes { oid } }")
+		require.Len(t, commits, 3)
+		assert.Equal(t, []string{"parent", "merged"}, commits[0].Parents)
+		assert.Equal(t, []string{"grandparent"}, commits[1].Parents)
+		assert.Empty(t, commits[2].Parents)
+	})
+
+	t.Run("surfaces errors reported in the response body", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"data": {"repository": null}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository"}]}`)
+		})
+		client, server := setupTestClient(t, handler, WithGraphQLCommits())
+		defer server.Close()
+
+		_, err := client.GetCommits(context.Background(), "test", "repo", time.Time{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Could not resolve to a Repository")
+	})
+}
+
+func TestClient_GraphQLURL(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	assert.Equal(t, "https://api.github.com/graphql", NewClient("", logger).graphqlURL())
+
+	ghe, err := NewClient("", logger).WithEnterpriseURLs("https://ghe.example.com/", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ghe.example.com/api/graphql", ghe.graphqlURL())
+}
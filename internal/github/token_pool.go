@@ -0,0 +1,247 @@
+// internal/github/token_pool.go
+package github
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	headerRateLimit     = "X-RateLimit-Limit"
+	headerRateRemaining = "X-RateLimit-Remaining"
+	headerRateReset     = "X-RateLimit-Reset"
+)
+
+// TokenQuota is the last known rate limit state of one pooled token for one API resource.
+type TokenQuota struct {
+	Token     string    `json:"token"`
+	Resource  string    `json:"resource"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+type quota struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+type pooledToken struct {
+	name   string
+	source oauth2.TokenSource
+	quotas map[string]*quota // keyed by rate limit resource, e.g. "core" or "graphql"
+}
+
+// tokenPool is a transport that spreads requests over several tokens. Each request is sent
+// with the token that has the most quota left for its resource, and a request rejected by the
+// primary rate limit is replayed with the next best token. Rate limit headers are rewritten to
+// describe the whole pool, so callers only see the limit as exhausted once every token is.
+type tokenPool struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu     sync.Mutex
+	tokens []*pooledToken
+}
+
+func newTokenPool(tokens []string, base http.RoundTripper, logger *slog.Logger) *tokenPool {
+	p := &tokenPool{base: base, logger: logger, now: time.Now}
+	for i, t := range tokens {
+		p.tokens = append(p.tokens, &pooledToken{
+			name:   tokenName(i),
+			source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: t}),
+			quotas: make(map[string]*quota),
+		})
+	}
+	return p
+}
+
+// tokenName identifies a token in logs and the API by its position in the pool, which reveals
+// nothing of the token itself.
+func tokenName(i int) string {
+	return fmt.Sprintf("token-%d", i+1)
+}
+
+func (p *tokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := requestResource(req)
+	tried := make(map[*pooledToken]bool, len(p.tokens))
+
+	for {
+		tok, hasQuota := p.pick(resource, tried)
+		tried[tok] = true
+
+		out, err := p.authorize(req, tok)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := p.base.RoundTrip(out)
+		if err != nil {
+			return nil, err
+		}
+		p.record(tok, resource, resp)
+
+		replayable := req.Body == nil || req.GetBody != nil
+		if isPrimaryRateLimited(resp) && hasQuota && replayable && len(tried) < len(p.tokens) {
+			p.logger.Warn("GitHub token exhausted, rotating to next token", "token", tok.name, "resource", resource)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			continue
+		}
+
+		p.rewriteRateHeaders(resp, resource)
+		return resp, nil
+	}
+}
+
+// pick returns the untried token with the most quota left for resource. Tokens whose quota is
+// unknown or whose reset time has passed are assumed to be full. If every token is exhausted it
+// returns the one that resets first, and the boolean is false.
+func (p *tokenPool) pick(resource string, tried map[*pooledToken]bool) (*pooledToken, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var best, earliest *pooledToken
+	for _, t := range p.tokens {
+		if tried[t] {
+			continue
+		}
+		q := t.quotas[resource]
+		if q == nil || !now.Before(q.reset) {
+			return t, true
+		}
+		if best == nil || q.remaining > best.quotas[resource].remaining {
+			best = t
+		}
+		if earliest == nil || q.reset.Before(earliest.quotas[resource].reset) {
+			earliest = t
+		}
+	}
+	if best.quotas[resource].remaining > 0 {
+		return best, true
+	}
+	return earliest, false
+}
+
+// authorize returns a copy of req carrying tok's credentials.
+func (p *tokenPool) authorize(req *http.Request, tok *pooledToken) (*http.Request, error) {
+	t, err := tok.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	out := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	t.SetAuthHeader(out)
+	return out, nil
+}
+
+// record updates tok's quota for resource from the rate limit headers of resp.
+func (p *tokenPool) record(tok *pooledToken, resource string, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get(headerRateRemaining))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(resp.Header.Get(headerRateLimit))
+	reset, _ := strconv.ParseInt(resp.Header.Get(headerRateReset), 10, 64)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tok.quotas[resource] = &quota{limit: limit, remaining: remaining, reset: time.Unix(reset, 0)}
+	p.logger.Debug("GitHub token quota updated", "token", tok.name, "resource", resource, "remaining", remaining, "limit", limit)
+}
+
+// rewriteRateHeaders makes resp report the combined quota of the pool. go-github refuses to send
+// requests while the last seen X-RateLimit-Remaining is 0, which must only happen once all tokens
+// are exhausted; the reset time is then the earliest one across the pool.
+func (p *tokenPool) rewriteRateHeaders(resp *http.Response, resource string) {
+	if resp.Header.Get(headerRateRemaining) == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var limit, remaining int
+	var reset time.Time
+	for _, t := range p.tokens {
+		q := t.quotas[resource]
+		if q == nil || !now.Before(q.reset) {
+			// Unknown or replenished quota; any positive value keeps requests flowing.
+			remaining++
+			continue
+		}
+		limit += q.limit
+		remaining += q.remaining
+		if q.remaining == 0 && (reset.IsZero() || q.reset.Before(reset)) {
+			reset = q.reset
+		}
+	}
+
+	resp.Header.Set(headerRateLimit, strconv.Itoa(limit))
+	resp.Header.Set(headerRateRemaining, strconv.Itoa(remaining))
+	if remaining == 0 {
+		resp.Header.Set(headerRateReset, strconv.FormatInt(reset.Unix(), 10))
+	}
+}
+
+// Quotas returns the last known quota of every pooled token, sorted by token and resource.
+func (p *tokenPool) Quotas() []TokenQuota {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var out []TokenQuota
+	for _, t := range p.tokens {
+		for resource, q := range t.quotas {
+			out = append(out, TokenQuota{
+				Token:     t.name,
+				Resource:  resource,
+				Limit:     q.limit,
+				Remaining: q.remaining,
+				Reset:     q.reset,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Token != out[j].Token {
+			return out[i].Token < out[j].Token
+		}
+		return out[i].Resource < out[j].Resource
+	})
+	return out
+}
+
+// requestResource guesses the rate limit resource a request is billed to.
+func requestResource(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// isPrimaryRateLimited reports whether resp was rejected because the token's quota is used up.
+func isPrimaryRateLimited(resp *http.Response) bool {
+	return (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get(headerRateRemaining) == "0"
+}
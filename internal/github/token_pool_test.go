@@ -0,0 +1,127 @@
+// internal/github/token_pool_test.go
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quotaServer simulates per-token primary rate limits. Each token starts with the given
+// remaining quota and is rejected with 403 once it reaches zero, until reset.
+type quotaServer struct {
+	mu        sync.Mutex
+	remaining map[string]int
+	reset     time.Time
+	calls     []string
+}
+
+func (s *quotaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	s.calls = append(s.calls, token)
+	if time.Now().After(s.reset) {
+		for k := range s.remaining {
+			s.remaining[k] = 5000
+		}
+	}
+	left := s.remaining[token]
+	if left > 0 {
+		s.remaining[token] = left - 1
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("X-RateLimit-Limit", "5000")
+	w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", s.reset.Unix()))
+	if left == 0 {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"message": "API rate limit exceeded"}`)
+		return
+	}
+	w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", left-1))
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, `{"id": 1, "name": "repo", "owner": {"login": "test"}}`)
+}
+
+func (s *quotaServer) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func TestClient_TokenPool(t *testing.T) {
+	t.Run("routes requests to the token with the most remaining quota", func(t *testing.T) {
+		qs := &quotaServer{remaining: map[string]int{"token-a": 2, "token-b": 10}, reset: time.Now().Add(time.Hour)}
+		client, server := setupTestClient(t, qs, WithTokenPool([]string{"token-a", "token-b"}))
+		defer server.Close()
+
+		for i := 0; i < 4; i++ {
+			_, err := client.GetRepository(context.Background(), "test", "repo")
+			require.NoError(t, err)
+		}
+
+		// Both tokens start unknown; once known, token-b always has more quota left.
+		assert.Equal(t, []string{"token-a", "token-b", "token-b", "token-b"}, qs.Calls())
+	})
+
+	t.Run("rotates to the next token when one is exhausted", func(t *testing.T) {
+		qs := &quotaServer{remaining: map[string]int{"token-a": 0, "token-b": 10}, reset: time.Now().Add(time.Hour)}
+		client, server := setupTestClient(t, qs, WithTokenPool([]string{"token-a", "token-b"}))
+		defer server.Close()
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := client.GetRepository(context.Background(), "test", "repo")
+			require.NoError(t, err)
+		}
+
+		assert.Less(t, time.Since(start), time.Second, "client must not wait while another token has quota")
+		assert.Equal(t, []string{"token-a", "token-b", "token-b", "token-b"}, qs.Calls())
+	})
+
+	t.Run("waits for the earliest reset once every token is exhausted", func(t *testing.T) {
+		qs := &quotaServer{remaining: map[string]int{"token-a": 0, "token-b": 0}, reset: time.Now().Add(1100 * time.Millisecond).Truncate(time.Second)}
+		client, server := setupTestClient(t, qs, WithTokenPool([]string{"token-a", "token-b"}))
+		defer server.Close()
+
+		start := time.Now()
+		_, err := client.GetRepository(context.Background(), "test", "repo")
+
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "client should wait for rate limit reset")
+		assert.Equal(t, []string{"token-a", "token-b"}, qs.Calls()[:2])
+	})
+
+	t.Run("reports per-token quota", func(t *testing.T) {
+		reset := time.Now().Add(time.Hour).Truncate(time.Second)
+		qs := &quotaServer{remaining: map[string]int{"ghp_aaaaaaaaaaaa1111": 3, "ghp_bbbbbbbbbbbb2222": 10}, reset: reset}
+		client, server := setupTestClient(t, qs, WithTokenPool([]string{"ghp_aaaaaaaaaaaa1111", "ghp_bbbbbbbbbbbb2222"}))
+		defer server.Close()
+
+		for i := 0; i < 2; i++ {
+			_, err := client.GetRepository(context.Background(), "test", "repo")
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, []TokenQuota{
+			{Token: "token-1", Resource: "core", Limit: 5000, Remaining: 2, Reset: reset},
+			{Token: "token-2", Resource: "core", Limit: 5000, Remaining: 9, Reset: reset},
+		}, client.Quotas())
+	})
+
+	t.Run("client without a pool reports no quotas", func(t *testing.T) {
+		client, server := setupTestClient(t, http.NotFoundHandler())
+		defer server.Close()
+
+		assert.Nil(t, client.Quotas())
+	})
+}
@@ -0,0 +1,1848 @@
+// internal/githubfake/server.go
+package githubfake
+
+import (
+	"cmp"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultRateLimit = 5000
+	defaultPerPage   = 30
+	maxPerPage       = 100
+
+	// searchCap is how many results GitHub returns at most for filtered workflow run listings.
+	searchCap = 1000
+
+	defaultBranch = "main"
+
+	secondaryRateLimitDocs = "https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"
+)
+
+// Repository is the state of a fake repository.
+type Repository struct {
+	ID          int64
+	Owner       string
+	Name        string
+	Description string
+	Language    string
+	Stars       int
+	Forks       int
+	OpenIssues  int
+	Watchers    int
+	Languages   map[string]int64 // Bytes of code per language.
+	Topics      []string
+	UserOwned   bool // Owner is a user rather than an organization.
+	Archived    bool
+	Fork        bool
+	Private     bool // Left out of user repository listings, as on GitHub.
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Commit is a commit of a fake repository.
+type Commit struct {
+	SHA         string // Generated if empty.
+	Message     string
+	AuthorName  string
+	AuthorEmail string
+	AuthorLogin string // Empty for authors without a GitHub account.
+	Date        time.Time
+	Verified    bool
+	Files       []CommitFile // Only returned for single commits, all on one page.
+}
+
+// CommitFile is a file changed by a fake commit.
+type CommitFile struct {
+	Filename  string
+	Status    string // Defaults to "modified".
+	Additions int
+	Deletions int
+}
+
+// PullRequest is a pull request of a fake repository. It is open until ClosedAt is set, and
+// merged if MergedAt is set too.
+type PullRequest struct {
+	Number      int   // Assigned if zero.
+	ID          int64 // Assigned if zero.
+	Title       string
+	AuthorLogin string
+	Base        string // Defaults to the default branch.
+	Head        string
+	Additions   int
+	Deletions   int
+	CreatedAt   time.Time // Filled in if zero, as is UpdatedAt.
+	UpdatedAt   time.Time
+	ClosedAt    time.Time
+	MergedAt    time.Time
+	MergedBy    string
+	Reviews     []Review
+}
+
+// Review is a review of a fake pull request.
+type Review struct {
+	ID          int64 // Assigned if zero.
+	User        string
+	State       string // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or PENDING.
+	CommitID    string
+	SubmittedAt time.Time // Ignored for pending reviews.
+}
+
+// Issue is an issue of a fake repository. It is open until ClosedAt is set. Issues and pull
+// requests share their numbers, as on GitHub, and pull requests are listed as issues too.
+type Issue struct {
+	Number      int   // Assigned if zero.
+	ID          int64 // Assigned if zero.
+	Title       string
+	AuthorLogin string
+	Labels      []string
+	Assignees   []string
+	Milestone   string
+	CreatedAt   time.Time // Filled in if zero, as is UpdatedAt.
+	UpdatedAt   time.Time
+	ClosedAt    time.Time
+	Comments    []IssueComment
+}
+
+// IssueComment is a comment on a fake issue.
+type IssueComment struct {
+	ID        int64 // Assigned if zero.
+	User      string
+	CreatedAt time.Time
+	UpdatedAt time.Time // Defaults to CreatedAt.
+}
+
+// Label is a label of a fake repository.
+type Label struct {
+	Name        string
+	Color       string
+	Description string
+}
+
+// Tag is a tag of a fake repository.
+type Tag struct {
+	Name string
+	SHA  string
+}
+
+// Release is a release of a fake repository. It is a draft unless PublishedAt is set.
+type Release struct {
+	ID          int64 // Assigned if zero.
+	TagName     string
+	Name        string
+	Prerelease  bool
+	AuthorLogin string
+	CreatedAt   time.Time // Filled in if zero.
+	PublishedAt time.Time
+	Assets      []Asset
+}
+
+// Asset is a file attached to a fake release.
+type Asset struct {
+	ID            int64 // Assigned if zero.
+	Name          string
+	Size          int
+	DownloadCount int
+}
+
+// Workflow is a GitHub Actions workflow of a fake repository.
+type Workflow struct {
+	ID    int64 // Assigned if zero.
+	Name  string
+	Path  string
+	State string // Defaults to "active".
+}
+
+// WorkflowRun is a run of a fake workflow. It is queued until StartedAt is set, and completed
+// once Conclusion is set too.
+type WorkflowRun struct {
+	ID         int64 // Assigned if zero.
+	WorkflowID int64
+	Name       string // Defaults to the workflow's name.
+	Event      string // Defaults to "push".
+	Conclusion string
+	HeadSHA    string
+	HeadBranch string    // Defaults to the default branch.
+	RunNumber  int       // Assigned if zero.
+	RunAttempt int       // Defaults to 1.
+	CreatedAt  time.Time // Filled in if zero.
+	StartedAt  time.Time
+	UpdatedAt  time.Time // Defaults to the later of CreatedAt and StartedAt.
+}
+
+// Stargazer is a user who starred a fake repository.
+type Stargazer struct {
+	Login     string
+	StarredAt time.Time
+}
+
+type repoState struct {
+	repo       Repository
+	commits    []Commit            // default branch, newest first
+	branches   map[string][]Commit // other branches by name, newest first
+	rewritten  [][]Commit          // histories replaced by force-pushes, newest first
+	added      int                 // commits added so far, including rewritten ones
+	pulls      map[int]PullRequest // by number
+	issues     map[int]Issue       // by number
+	labels     []Label
+	tags       []Tag
+	releases   map[int64]Release // by ID
+	workflows  []Workflow
+	runs       map[int64]WorkflowRun // by ID
+	stargazers []Stargazer           // oldest first
+}
+
+// nextNumber returns the number of the next issue or pull request.
+func (st *repoState) nextNumber() int {
+	n := 0
+	for number := range st.pulls {
+		n = max(n, number)
+	}
+	for number := range st.issues {
+		n = max(n, number)
+	}
+	return n + 1
+}
+
+// historyOf returns the commits reachable from sha, newest first, looking through every branch
+// and the histories replaced by force-pushes. The boolean is false for unknown SHAs.
+func (st *repoState) historyOf(sha string) ([]Commit, bool) {
+	histories := [][]Commit{st.commits}
+	for _, name := range st.branchNames() {
+		if name != defaultBranch {
+			histories = append(histories, st.branches[name])
+		}
+	}
+	for _, history := range append(histories, st.rewritten...) {
+		for i, c := range history {
+			if c.SHA == sha {
+				return history[i:], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// resolve returns the commits reachable from ref, which is HEAD, a branch name or a SHA.
+func (st *repoState) resolve(ref string) ([]Commit, bool) {
+	if ref == "HEAD" || ref == defaultBranch {
+		return st.commits, true
+	}
+	if history, ok := st.branches[ref]; ok {
+		return history, true
+	}
+	return st.historyOf(ref)
+}
+
+// branchNames returns the names of the branches with commits, sorted as GitHub lists them.
+func (st *repoState) branchNames() []string {
+	var names []string
+	if len(st.commits) > 0 {
+		names = append(names, defaultBranch)
+	}
+	for name := range st.branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type fault struct {
+	status     int
+	secondary  bool // answer as a secondary rate limit
+	retryAfter time.Duration
+}
+
+// Server is an in-memory fake of the GitHub REST API subset used by the github client:
+// repository metadata, branches, commit listings, with since and sha filtering, single commits
+// with their changed files, commit comparisons, pull requests with their reviews, issues with
+// their comments and labels, tags and releases, Actions workflows and their runs, stargazers, and
+// languages and topics, and the repositories of organizations and users, with Link pagination, ETags, primary rate limits and injectable secondary rate limits and server errors.
+// Requests are also accepted under the /api/v3 prefix used by GitHub Enterprise Server.
+// All methods are safe to call while the server is handling requests.
+type Server struct {
+	handler http.Handler
+	now     func() time.Time
+
+	mu        sync.Mutex
+	repos     map[string]*repoState // keyed by "owner/name"
+	nextID    int64
+	limit     int
+	remaining int
+	reset     time.Time
+	faults    []fault
+	requests  int
+}
+
+// New creates an empty fake with a full primary rate limit.
+func New() *Server {
+	s := &Server{
+		now:    time.Now,
+		repos:  make(map[string]*repoState),
+		nextID: 1000,
+		limit:  defaultRateLimit,
+	}
+	s.remaining = s.limit
+	s.reset = s.now().Add(time.Hour)
+
+	notFound := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+	api := chi.NewRouter()
+	api.NotFound(notFound)
+	api.Get("/orgs/{owner}/repos", s.listOrgRepositories)
+	api.Get("/users/{owner}/repos", s.listUserRepositories)
+	api.Get("/repos/{owner}/{name}", s.getRepository)
+	api.Get("/repos/{owner}/{name}/branches", s.listBranches)
+	api.Get("/repos/{owner}/{name}/commits", s.listCommits)
+	api.Get("/repos/{owner}/{name}/commits/{ref}", s.getCommit)
+	api.Get("/repos/{owner}/{name}/compare/{basehead}", s.compareCommits)
+	api.Get("/repos/{owner}/{name}/pulls", s.listPulls)
+	api.Get("/repos/{owner}/{name}/pulls/{number}", s.getPull)
+	api.Get("/repos/{owner}/{name}/pulls/{number}/reviews", s.listReviews)
+	api.Get("/repos/{owner}/{name}/issues", s.listIssues)
+	api.Get("/repos/{owner}/{name}/issues/{number}/comments", s.listIssueComments)
+	api.Get("/repos/{owner}/{name}/labels", s.listLabels)
+	api.Get("/repos/{owner}/{name}/tags", s.listTags)
+	api.Get("/repos/{owner}/{name}/releases", s.listReleases)
+	api.Get("/repos/{owner}/{name}/actions/workflows", s.listWorkflows)
+	api.Get("/repos/{owner}/{name}/actions/runs", s.listWorkflowRuns)
+	api.Get("/repos/{owner}/{name}/stargazers", s.listStargazers)
+	api.Get("/repos/{owner}/{name}/languages", s.listLanguages)
+	api.Get("/repos/{owner}/{name}/topics", s.listTopics)
+
+	r := chi.NewRouter()
+	r.Use(s.middleware)
+	r.NotFound(notFound)
+	r.Mount("/api/v3", api)
+	r.Mount("/", api)
+	s.handler = r
+	return s
+}
+
+// NewServer starts the fake on a local httptest server. Point a client at server.URL + "/".
+func NewServer() (*Server, *httptest.Server) {
+	s := New()
+	return s, httptest.NewServer(s)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// AddRepository creates or replaces a repository. A zero ID or timestamp is filled in.
+func (s *Server) AddRepository(repo Repository) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo.ID == 0 {
+		s.nextID++
+		repo.ID = s.nextID
+	}
+	now := s.now().UTC().Truncate(time.Second)
+	if repo.CreatedAt.IsZero() {
+		repo.CreatedAt = now
+	}
+	if repo.UpdatedAt.IsZero() {
+		repo.UpdatedAt = now
+	}
+	key := repo.Owner + "/" + repo.Name
+	state, ok := s.repos[key]
+	if !ok {
+		state = &repoState{}
+		s.repos[key] = state
+	}
+	state.repo = repo
+}
+
+// AddCommits pushes commits to an existing repository and bumps its updated_at.
+// Commits without a SHA get a deterministic one, and commits without a date are dated now.
+func (s *Server) AddCommits(owner, name string, commits ...Commit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	s.addCommits(state, commits)
+	return nil
+}
+
+// ForcePush replaces the newest drop commits of a repository's default branch with commits, as
+// a force-push would, and bumps its updated_at. The replaced history can still be looked up and
+// compared by SHA.
+func (s *Server) ForcePush(owner, name string, drop int, commits ...Commit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	if drop > len(state.commits) {
+		return fmt.Errorf("githubfake: repository %s/%s has only %d commits", owner, name, len(state.commits))
+	}
+	state.rewritten = append(state.rewritten, state.commits)
+	state.commits = s.withCommits(state, state.commits[drop:], commits)
+	return nil
+}
+
+// SetBranch points branch, which must not be the default branch, at commits on top of the
+// history of from, a branch name or SHA, creating the branch or replacing its history as a
+// force-push would. Replaced histories can still be looked up and compared by SHA.
+func (s *Server) SetBranch(owner, name, branch, from string, commits ...Commit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	if branch == defaultBranch {
+		return fmt.Errorf("githubfake: use AddCommits or ForcePush for the default branch %q", branch)
+	}
+	base, ok := state.resolve(from)
+	if !ok {
+		return fmt.Errorf("githubfake: repository %s/%s has no ref %q", owner, name, from)
+	}
+	if state.branches == nil {
+		state.branches = make(map[string][]Commit)
+	}
+	if old, ok := state.branches[branch]; ok {
+		state.rewritten = append(state.rewritten, old)
+	}
+	state.branches[branch] = s.withCommits(state, base, commits)
+	return nil
+}
+
+// SetPullRequest creates or replaces the pull request with pr.Number, or creates the next one if
+// it is zero, and returns its number. Missing IDs and timestamps are filled in; UpdatedAt
+// defaults to now, as GitHub bumps it on every change.
+func (s *Server) SetPullRequest(owner, name string, pr PullRequest) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return 0, fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	if state.pulls == nil {
+		state.pulls = make(map[int]PullRequest)
+	}
+	if pr.Number == 0 {
+		pr.Number = state.nextNumber()
+	}
+	if pr.ID == 0 {
+		s.nextID++
+		pr.ID = s.nextID
+	}
+	now := s.now().UTC().Truncate(time.Second)
+	if pr.CreatedAt.IsZero() {
+		pr.CreatedAt = now
+	}
+	if pr.UpdatedAt.IsZero() {
+		pr.UpdatedAt = now
+	}
+	if pr.Base == "" {
+		pr.Base = defaultBranch
+	}
+	pr.Reviews = slices.Clone(pr.Reviews)
+	for i := range pr.Reviews {
+		if pr.Reviews[i].ID == 0 {
+			s.nextID++
+			pr.Reviews[i].ID = s.nextID
+		}
+	}
+	state.pulls[pr.Number] = pr
+	return pr.Number, nil
+}
+
+// SetIssue creates or replaces the issue with issue.Number, or creates the next one if it is zero,
+// and returns its number. Missing IDs and timestamps are filled in like by SetPullRequest.
+func (s *Server) SetIssue(owner, name string, issue Issue) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return 0, fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	if state.issues == nil {
+		state.issues = make(map[int]Issue)
+	}
+	if issue.Number == 0 {
+		issue.Number = state.nextNumber()
+	}
+	if issue.ID == 0 {
+		s.nextID++
+		issue.ID = s.nextID
+	}
+	now := s.now().UTC().Truncate(time.Second)
+	if issue.CreatedAt.IsZero() {
+		issue.CreatedAt = now
+	}
+	if issue.UpdatedAt.IsZero() {
+		issue.UpdatedAt = now
+	}
+	issue.Comments = slices.Clone(issue.Comments)
+	for i := range issue.Comments {
+		if issue.Comments[i].ID == 0 {
+			s.nextID++
+			issue.Comments[i].ID = s.nextID
+		}
+		if issue.Comments[i].UpdatedAt.IsZero() {
+			issue.Comments[i].UpdatedAt = issue.Comments[i].CreatedAt
+		}
+	}
+	state.issues[issue.Number] = issue
+	return issue.Number, nil
+}
+
+// SetLabels replaces the labels of a repository.
+func (s *Server) SetLabels(owner, name string, labels ...Label) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	state.labels = slices.Clone(labels)
+	return nil
+}
+
+// SetTags replaces the tags of a repository. Their SHAs are not checked against its commits.
+func (s *Server) SetTags(owner, name string, tags ...Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	state.tags = slices.Clone(tags)
+	return nil
+}
+
+// SetRelease creates or replaces the release with release.ID, or creates a new one if it is zero,
+// and returns its ID. Missing asset IDs and CreatedAt are filled in.
+func (s *Server) SetRelease(owner, name string, release Release) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return 0, fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	if state.releases == nil {
+		state.releases = make(map[int64]Release)
+	}
+	if release.ID == 0 {
+		s.nextID++
+		release.ID = s.nextID
+	}
+	if release.CreatedAt.IsZero() {
+		release.CreatedAt = s.now().UTC().Truncate(time.Second)
+	}
+	release.Assets = slices.Clone(release.Assets)
+	for i := range release.Assets {
+		if release.Assets[i].ID == 0 {
+			s.nextID++
+			release.Assets[i].ID = s.nextID
+		}
+	}
+	state.releases[release.ID] = release
+	return release.ID, nil
+}
+
+// SetWorkflow creates or replaces a workflow and returns its ID.
+func (s *Server) SetWorkflow(owner, name string, workflow Workflow) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return 0, fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	if workflow.ID == 0 {
+		s.nextID++
+		workflow.ID = s.nextID
+	}
+	if workflow.State == "" {
+		workflow.State = "active"
+	}
+	state.workflows = slices.DeleteFunc(state.workflows, func(w Workflow) bool { return w.ID == workflow.ID })
+	state.workflows = append(state.workflows, workflow)
+	return workflow.ID, nil
+}
+
+// SetWorkflowRun creates or replaces a workflow run and returns its ID. The workflow does not
+// have to exist, as runs of deleted workflows are still listed.
+func (s *Server) SetWorkflowRun(owner, name string, run WorkflowRun) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return 0, fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	if state.runs == nil {
+		state.runs = make(map[int64]WorkflowRun)
+	}
+	if run.ID == 0 {
+		s.nextID++
+		run.ID = s.nextID
+	}
+	if run.RunNumber == 0 {
+		for _, other := range state.runs {
+			if other.WorkflowID == run.WorkflowID {
+				run.RunNumber = max(run.RunNumber, other.RunNumber)
+			}
+		}
+		run.RunNumber++
+	}
+	if run.Name == "" {
+		if i := slices.IndexFunc(state.workflows, func(w Workflow) bool { return w.ID == run.WorkflowID }); i >= 0 {
+			run.Name = state.workflows[i].Name
+		}
+	}
+	if run.Event == "" {
+		run.Event = "push"
+	}
+	if run.HeadBranch == "" {
+		run.HeadBranch = defaultBranch
+	}
+	if run.RunAttempt == 0 {
+		run.RunAttempt = 1
+	}
+	if run.CreatedAt.IsZero() {
+		run.CreatedAt = s.now().UTC().Truncate(time.Second)
+	}
+	if run.UpdatedAt.IsZero() {
+		run.UpdatedAt = run.CreatedAt
+		if run.StartedAt.After(run.UpdatedAt) {
+			run.UpdatedAt = run.StartedAt
+		}
+	}
+	state.runs[run.ID] = run
+	return run.ID, nil
+}
+
+// AddStargazers stars a repository. Stargazers are listed in the order they are added, so they
+// should be added in the order they starred it. The repository's Stars count is left as it is.
+func (s *Server) AddStargazers(owner, name string, stargazers ...Stargazer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.repos[owner+"/"+name]
+	if !ok {
+		return fmt.Errorf("githubfake: repository %s/%s does not exist", owner, name)
+	}
+	state.stargazers = append(state.stargazers, stargazers...)
+	return nil
+}
+
+// addCommits adds commits to a repository's default branch. s.mu must be held.
+func (s *Server) addCommits(state *repoState, commits []Commit) {
+	state.commits = s.withCommits(state, state.commits, commits)
+}
+
+// withCommits returns a copy of history with commits added, newest first, and bumps the
+// repository's updated_at. Commits without a SHA or date get one. s.mu must be held.
+func (s *Server) withCommits(state *repoState, history, commits []Commit) []Commit {
+	now := s.now().UTC().Truncate(time.Second)
+	history = slices.Clone(history)
+	for _, c := range commits {
+		if c.Date.IsZero() {
+			c.Date = now
+		}
+		if c.SHA == "" {
+			sum := sha1.Sum([]byte(fmt.Sprintf("%s/%s/%d/%s/%s", state.repo.Owner, state.repo.Name, state.added, c.Message, c.Date)))
+			c.SHA = hex.EncodeToString(sum[:])
+		}
+		history = append(history, c)
+		state.added++
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.After(history[j].Date)
+	})
+	state.repo.UpdatedAt = now
+	return history
+}
+
+// SetRateLimit sets the primary rate limit. Once remaining reaches 0 requests are rejected
+// with 403 until reset, after which the quota is replenished.
+func (s *Server) SetRateLimit(limit, remaining int, reset time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit, s.remaining, s.reset = limit, remaining, reset
+}
+
+// FailNext answers the next n requests with status, typically a 5xx.
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.faults = append(s.faults, fault{status: status})
+	}
+}
+
+// SecondaryRateLimitNext answers the next n requests with the 403 GitHub sends when a
+// secondary ("abuse") rate limit is hit, asking the client to wait retryAfter.
+func (s *Server) SecondaryRateLimitNext(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.faults = append(s.faults, fault{status: http.StatusForbidden, secondary: true, retryAfter: retryAfter})
+	}
+}
+
+// Requests returns the number of requests received so far, including rejected ones.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// middleware counts requests, injects queued faults and enforces the primary rate limit.
+// Conditional requests that end in 304 Not Modified are not charged, as on GitHub.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		var f *fault
+		if len(s.faults) > 0 {
+			f = &s.faults[0]
+			s.faults = s.faults[1:]
+		}
+		now := s.now()
+		if !now.Before(s.reset) {
+			s.remaining = s.limit
+			s.reset = now.Add(time.Hour)
+		}
+		limited := s.remaining <= 0
+		s.mu.Unlock()
+
+		switch {
+		case f != nil && f.secondary:
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.retryAfter.Seconds())))
+			writeJSON(w, f.status, map[string]string{
+				"message":           "You have exceeded a secondary rate limit. Please wait a few minutes before you try again.",
+				"documentation_url": secondaryRateLimitDocs,
+			})
+		case f != nil:
+			writeJSON(w, f.status, map[string]string{"message": http.StatusText(f.status)})
+		case limited:
+			s.writeRateHeaders(w)
+			writeJSON(w, http.StatusForbidden, map[string]string{
+				"message":           "API rate limit exceeded.",
+				"documentation_url": "https://docs.github.com/rest/overview/rate-limits-for-the-rest-api",
+			})
+		default:
+			next.ServeHTTP(&chargingWriter{ResponseWriter: w, s: s}, r)
+		}
+	})
+}
+
+// chargingWriter charges a request against the rate limit once its status is known.
+type chargingWriter struct {
+	http.ResponseWriter
+	s *Server
+}
+
+func (w *chargingWriter) WriteHeader(status int) {
+	if status != http.StatusNotModified {
+		w.s.mu.Lock()
+		w.s.remaining--
+		w.s.mu.Unlock()
+	}
+	w.s.writeRateHeaders(w.ResponseWriter)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (s *Server) writeRateHeaders(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := w.Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(s.limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(max(s.remaining, 0)))
+	h.Set("X-RateLimit-Used", strconv.Itoa(s.limit-max(s.remaining, 0)))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(s.reset.Unix(), 10))
+	h.Set("X-RateLimit-Resource", "core")
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	if ok {
+		repo = state.repo
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	writeCacheable(w, r, repositoryJSON(r, repo))
+}
+
+// listOrgRepositories lists the repositories of an organization by name. Owners without
+// organization-owned repositories are not organizations, so they are not found.
+func (s *Server) listOrgRepositories(w http.ResponseWriter, r *http.Request) {
+	s.listOwnerRepositories(w, r, false)
+}
+
+// listUserRepositories lists the public repositories of a user, or of an organization, by name.
+func (s *Server) listUserRepositories(w http.ResponseWriter, r *http.Request) {
+	s.listOwnerRepositories(w, r, true)
+}
+
+func (s *Server) listOwnerRepositories(w http.ResponseWriter, r *http.Request, user bool) {
+	perPage, page := pagination(r.URL.Query())
+	owner := chi.URLParam(r, "owner")
+
+	s.mu.Lock()
+	var repos []Repository
+	found := false
+	for _, state := range s.repos {
+		if !strings.EqualFold(state.repo.Owner, owner) {
+			continue
+		}
+		found = found || user || !state.repo.UserOwned
+		if !user || !state.repo.Private {
+			repos = append(repos, state.repo)
+		}
+	}
+	s.mu.Unlock()
+
+	if !found {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	lastPage := max((len(repos)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(repos))
+	end := min(start+perPage, len(repos))
+	out := make([]map[string]any, 0, end-start)
+	for _, repo := range repos[start:end] {
+		out = append(out, repositoryJSON(r, repo))
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listBranches lists the branches of a repository by name, each with the commit it points to.
+func (s *Server) listBranches(w http.ResponseWriter, r *http.Request) {
+	perPage, page := pagination(r.URL.Query())
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var names, heads []string
+	if ok {
+		repo = state.repo
+		names = state.branchNames()
+		for _, name := range names {
+			history, _ := state.resolve(name)
+			heads = append(heads, history[0].SHA)
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	lastPage := max((len(names)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(names))
+	end := min(start+perPage, len(names))
+	out := make([]map[string]any, 0, end-start)
+	for i := start; i < end; i++ {
+		out = append(out, map[string]any{
+			"name": names[i],
+			"commit": map[string]any{
+				"sha": heads[i],
+				"url": htmlURL(r, "/repos/"+repo.Owner+"/"+repo.Name+"/commits/"+heads[i]),
+			},
+			"protected": false,
+		})
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+func (s *Server) listCommits(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Invalid value for parameter 'since'"})
+			return
+		}
+		since = t
+	}
+	perPage, page := pagination(query)
+	sha := query.Get("sha")
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var matching []Commit
+	var repo Repository
+	found := true
+	if ok {
+		repo = state.repo
+		history := state.commits
+		if sha != "" {
+			history, found = state.resolve(sha)
+		}
+		for _, c := range history {
+			if !c.Date.Before(since) {
+				matching = append(matching, c)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	if !found {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "No commit found for SHA: " + sha})
+		return
+	}
+
+	lastPage := max((len(matching)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(matching))
+	end := min(start+perPage, len(matching))
+
+	out := make([]map[string]any, 0, end-start)
+	for _, c := range matching[start:end] {
+		out = append(out, commitJSON(r, repo, c))
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// getCommit serves a single commit with its changed files. ref is a SHA, HEAD or a branch name. The SHA media type
+// returns just the commit's SHA, which is how the head of a branch is looked up cheaply.
+func (s *Server) getCommit(w http.ResponseWriter, r *http.Request) {
+	ref := chi.URLParam(r, "ref")
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var history []Commit
+	empty := false
+	if ok {
+		repo = state.repo
+		empty = len(state.commits) == 0
+		history, _ = state.resolve(ref)
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case empty:
+		writeJSON(w, http.StatusConflict, map[string]string{"message": "Git Repository is empty."})
+	case len(history) == 0:
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "No commit found for SHA: " + ref})
+	case strings.Contains(r.Header.Get("Accept"), "sha"):
+		writeCacheableBody(w, r, "text/plain; charset=utf-8", []byte(history[0].SHA))
+	default:
+		c := history[0]
+		v := commitJSON(r, repo, c)
+		files := make([]map[string]any, 0, len(c.Files))
+		var additions, deletions int
+		for _, f := range c.Files {
+			status := f.Status
+			if status == "" {
+				status = "modified"
+			}
+			files = append(files, map[string]any{
+				"filename":  f.Filename,
+				"status":    status,
+				"additions": f.Additions,
+				"deletions": f.Deletions,
+				"changes":   f.Additions + f.Deletions,
+			})
+			additions += f.Additions
+			deletions += f.Deletions
+		}
+		v["files"] = files
+		v["stats"] = map[string]any{"additions": additions, "deletions": deletions, "total": additions + deletions}
+		writeCacheable(w, r, v)
+	}
+}
+
+// compareCommits compares two commits given as 'base...head', listing the commits reachable
+// from head but not from base, oldest first, as GitHub does.
+func (s *Server) compareCommits(w http.ResponseWriter, r *http.Request) {
+	perPage, page := pagination(r.URL.Query())
+	base, head, _ := strings.Cut(chi.URLParam(r, "basehead"), "...")
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var baseHistory, headHistory []Commit
+	if ok {
+		repo = state.repo
+		var baseFound, headFound bool
+		baseHistory, baseFound = state.historyOf(base)
+		headHistory, headFound = state.historyOf(head)
+		ok = baseFound && headFound
+	}
+	s.mu.Unlock()
+
+	inBase := make(map[string]bool, len(baseHistory))
+	for _, c := range baseHistory {
+		inBase[c.SHA] = true
+	}
+	inHead := make(map[string]bool, len(headHistory))
+	var ahead []Commit
+	var mergeBase *Commit
+	for i, c := range headHistory {
+		inHead[c.SHA] = true
+		if !inBase[c.SHA] {
+			ahead = append(ahead, c)
+		} else if mergeBase == nil {
+			mergeBase = &headHistory[i]
+		}
+	}
+	if !ok || mergeBase == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	slices.Reverse(ahead)
+	behind := 0
+	for _, c := range baseHistory {
+		if !inHead[c.SHA] {
+			behind++
+		}
+	}
+
+	status := "identical"
+	switch {
+	case len(ahead) > 0 && behind > 0:
+		status = "diverged"
+	case len(ahead) > 0:
+		status = "ahead"
+	case behind > 0:
+		status = "behind"
+	}
+
+	lastPage := max((len(ahead)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(ahead))
+	end := min(start+perPage, len(ahead))
+	commits := make([]map[string]any, 0, end-start)
+	for _, c := range ahead[start:end] {
+		commits = append(commits, commitJSON(r, repo, c))
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, map[string]any{
+		"status":            status,
+		"ahead_by":          len(ahead),
+		"behind_by":         behind,
+		"total_commits":     len(ahead),
+		"merge_base_commit": commitJSON(r, repo, *mergeBase),
+		"commits":           commits,
+	})
+}
+
+// listPulls lists pull requests filtered by state (open by default, closed or all) and sorted by
+// created (the default) or updated, newest first unless direction is asc.
+func (s *Server) listPulls(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	perPage, page := pagination(query)
+	filter := query.Get("state")
+	if filter == "" {
+		filter = "open"
+	}
+	sortBy := query.Get("sort")
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var pulls []PullRequest
+	if ok {
+		repo = state.repo
+		for _, pr := range state.pulls {
+			open := pr.ClosedAt.IsZero()
+			if filter == "all" || (filter == "open") == open {
+				pulls = append(pulls, pr)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	sort.Slice(pulls, func(i, j int) bool {
+		a, b := pulls[i].CreatedAt, pulls[j].CreatedAt
+		if sortBy == "updated" {
+			a, b = pulls[i].UpdatedAt, pulls[j].UpdatedAt
+		}
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return pulls[i].Number > pulls[j].Number
+	})
+	if query.Get("direction") == "asc" {
+		slices.Reverse(pulls)
+	}
+
+	lastPage := max((len(pulls)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(pulls))
+	end := min(start+perPage, len(pulls))
+	out := make([]map[string]any, 0, end-start)
+	for _, pr := range pulls[start:end] {
+		out = append(out, pullJSON(r, repo, pr, false))
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// lookupPull returns the pull request the route's {number} refers to, writing a 404 if there is none.
+func (s *Server) lookupPull(w http.ResponseWriter, r *http.Request) (Repository, PullRequest, bool) {
+	number, _ := strconv.Atoi(chi.URLParam(r, "number"))
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var pr PullRequest
+	if ok {
+		repo = state.repo
+		pr, ok = state.pulls[number]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+	return repo, pr, ok
+}
+
+// getPull serves a single pull request, which unlike listings includes line stats and who merged it.
+func (s *Server) getPull(w http.ResponseWriter, r *http.Request) {
+	repo, pr, ok := s.lookupPull(w, r)
+	if !ok {
+		return
+	}
+	writeCacheable(w, r, pullJSON(r, repo, pr, true))
+}
+
+// listReviews lists the reviews of a pull request in the order they were added.
+func (s *Server) listReviews(w http.ResponseWriter, r *http.Request) {
+	repo, pr, ok := s.lookupPull(w, r)
+	if !ok {
+		return
+	}
+	perPage, page := pagination(r.URL.Query())
+
+	lastPage := max((len(pr.Reviews)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(pr.Reviews))
+	end := min(start+perPage, len(pr.Reviews))
+	out := make([]map[string]any, 0, end-start)
+	for _, review := range pr.Reviews[start:end] {
+		v := map[string]any{
+			"id":        review.ID,
+			"user":      map[string]any{"login": review.User, "type": "User"},
+			"state":     review.State,
+			"commit_id": review.CommitID,
+			"html_url":  htmlURL(r, fmt.Sprintf("/%s/%s/pull/%d#pullrequestreview-%d", repo.Owner, repo.Name, pr.Number, review.ID)),
+		}
+		if review.State != "PENDING" {
+			v["submitted_at"] = review.SubmittedAt.UTC().Format(time.RFC3339)
+		}
+		out = append(out, v)
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listIssues lists issues and pull requests, which GitHub lists as issues too, filtered by state
+// (open by default, closed or all) and since, which bounds updated_at, and sorted by created (the
+// default) or updated, newest first unless direction is asc.
+func (s *Server) listIssues(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	perPage, page := pagination(query)
+	filter := query.Get("state")
+	if filter == "" {
+		filter = "open"
+	}
+	sortBy := query.Get("sort")
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Invalid since"})
+			return
+		}
+	}
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var issues []Issue
+	pulls := make(map[int]bool)
+	if ok {
+		repo = state.repo
+		for _, issue := range state.issues {
+			issues = append(issues, issue)
+		}
+		for _, pr := range state.pulls {
+			issues = append(issues, Issue{
+				Number:      pr.Number,
+				ID:          pr.ID,
+				Title:       pr.Title,
+				AuthorLogin: pr.AuthorLogin,
+				CreatedAt:   pr.CreatedAt,
+				UpdatedAt:   pr.UpdatedAt,
+				ClosedAt:    pr.ClosedAt,
+			})
+			pulls[pr.Number] = true
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	issues = slices.DeleteFunc(issues, func(issue Issue) bool {
+		open := issue.ClosedAt.IsZero()
+		return (filter != "all" && (filter == "open") != open) || issue.UpdatedAt.Before(since)
+	})
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i].CreatedAt, issues[j].CreatedAt
+		if sortBy == "updated" {
+			a, b = issues[i].UpdatedAt, issues[j].UpdatedAt
+		}
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return issues[i].Number > issues[j].Number
+	})
+	if query.Get("direction") == "asc" {
+		slices.Reverse(issues)
+	}
+
+	lastPage := max((len(issues)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(issues))
+	end := min(start+perPage, len(issues))
+	out := make([]map[string]any, 0, end-start)
+	for _, issue := range issues[start:end] {
+		v := issueJSON(r, repo, issue)
+		if pulls[issue.Number] {
+			v["pull_request"] = map[string]any{"html_url": htmlURL(r, fmt.Sprintf("/%s/%s/pull/%d", repo.Owner, repo.Name, issue.Number))}
+		}
+		out = append(out, v)
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listIssueComments lists the comments on an issue in the order they were added.
+func (s *Server) listIssueComments(w http.ResponseWriter, r *http.Request) {
+	number, _ := strconv.Atoi(chi.URLParam(r, "number"))
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var issue Issue
+	if ok {
+		repo = state.repo
+		issue, ok = state.issues[number]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	perPage, page := pagination(r.URL.Query())
+
+	lastPage := max((len(issue.Comments)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(issue.Comments))
+	end := min(start+perPage, len(issue.Comments))
+	out := make([]map[string]any, 0, end-start)
+	for _, comment := range issue.Comments[start:end] {
+		out = append(out, map[string]any{
+			"id":         comment.ID,
+			"user":       map[string]any{"login": comment.User, "type": "User"},
+			"html_url":   htmlURL(r, fmt.Sprintf("/%s/%s/issues/%d#issuecomment-%d", repo.Owner, repo.Name, issue.Number, comment.ID)),
+			"created_at": comment.CreatedAt.UTC().Format(time.RFC3339),
+			"updated_at": comment.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listLabels lists the labels of a repository in the order they were set.
+func (s *Server) listLabels(w http.ResponseWriter, r *http.Request) {
+	perPage, page := pagination(r.URL.Query())
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var labels []Label
+	if ok {
+		labels = state.labels
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	lastPage := max((len(labels)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(labels))
+	end := min(start+perPage, len(labels))
+	out := make([]map[string]any, 0, end-start)
+	for _, l := range labels[start:end] {
+		out = append(out, map[string]any{"name": l.Name, "color": l.Color, "description": l.Description})
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listTags lists the tags of a repository in the order they were set.
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	perPage, page := pagination(r.URL.Query())
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var tags []Tag
+	if ok {
+		repo = state.repo
+		tags = state.tags
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	lastPage := max((len(tags)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(tags))
+	end := min(start+perPage, len(tags))
+	out := make([]map[string]any, 0, end-start)
+	for _, t := range tags[start:end] {
+		out = append(out, map[string]any{
+			"name":   t.Name,
+			"commit": map[string]any{"sha": t.SHA, "url": htmlURL(r, "/repos/"+repo.Owner+"/"+repo.Name+"/commits/"+t.SHA)},
+		})
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listReleases lists the releases of a repository, newest first.
+func (s *Server) listReleases(w http.ResponseWriter, r *http.Request) {
+	perPage, page := pagination(r.URL.Query())
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var releases []Release
+	if ok {
+		repo = state.repo
+		for _, release := range state.releases {
+			releases = append(releases, release)
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		a, b := releases[i].CreatedAt, releases[j].CreatedAt
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return releases[i].ID > releases[j].ID
+	})
+
+	lastPage := max((len(releases)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(releases))
+	end := min(start+perPage, len(releases))
+	out := make([]map[string]any, 0, end-start)
+	for _, release := range releases[start:end] {
+		assets := make([]map[string]any, 0, len(release.Assets))
+		for _, a := range release.Assets {
+			assets = append(assets, map[string]any{"id": a.ID, "name": a.Name, "size": a.Size, "download_count": a.DownloadCount})
+		}
+		var publishedAt any
+		if !release.PublishedAt.IsZero() {
+			publishedAt = release.PublishedAt.UTC().Format(time.RFC3339)
+		}
+		out = append(out, map[string]any{
+			"id":           release.ID,
+			"tag_name":     release.TagName,
+			"name":         release.Name,
+			"draft":        release.PublishedAt.IsZero(),
+			"prerelease":   release.Prerelease,
+			"author":       map[string]any{"login": release.AuthorLogin, "type": "User"},
+			"html_url":     htmlURL(r, "/"+repo.Owner+"/"+repo.Name+"/releases/tag/"+release.TagName),
+			"created_at":   release.CreatedAt.UTC().Format(time.RFC3339),
+			"published_at": publishedAt,
+			"assets":       assets,
+		})
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listWorkflows lists the workflows of a repository by ID, wrapped in an object as on GitHub.
+func (s *Server) listWorkflows(w http.ResponseWriter, r *http.Request) {
+	perPage, page := pagination(r.URL.Query())
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var workflows []Workflow
+	if ok {
+		workflows = slices.Clone(state.workflows)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	slices.SortFunc(workflows, func(a, b Workflow) int { return cmp.Compare(a.ID, b.ID) })
+	lastPage := max((len(workflows)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(workflows))
+	end := min(start+perPage, len(workflows))
+	out := make([]map[string]any, 0, end-start)
+	for _, wf := range workflows[start:end] {
+		out = append(out, map[string]any{"id": wf.ID, "name": wf.Name, "path": wf.Path, "state": wf.State})
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, map[string]any{"total_count": len(workflows), "workflows": out})
+}
+
+// listWorkflowRuns lists the workflow runs of a repository, newest first. created only supports
+// the '>=T' form. As on GitHub, filtered listings return at most the first searchCap runs.
+func (s *Server) listWorkflowRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	perPage, page := pagination(query)
+	var since time.Time
+	if v := query.Get("created"); v != "" {
+		t, err := time.Parse(time.RFC3339, strings.TrimPrefix(v, ">="))
+		if err != nil || !strings.HasPrefix(v, ">=") {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Invalid value for parameter 'created'"})
+			return
+		}
+		since = t
+	}
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	var runs []WorkflowRun
+	if ok {
+		repo = state.repo
+		for _, run := range state.runs {
+			if !run.CreatedAt.Before(since) {
+				runs = append(runs, run)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		a, b := runs[i].CreatedAt, runs[j].CreatedAt
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return runs[i].ID > runs[j].ID
+	})
+	if !since.IsZero() && len(runs) > searchCap {
+		runs = runs[:searchCap]
+	}
+	lastPage := max((len(runs)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(runs))
+	end := min(start+perPage, len(runs))
+	out := make([]map[string]any, 0, end-start)
+	for _, run := range runs[start:end] {
+		status, startedAt := "queued", run.CreatedAt
+		var conclusion any
+		switch {
+		case run.Conclusion != "":
+			status, startedAt, conclusion = "completed", run.StartedAt, run.Conclusion
+		case !run.StartedAt.IsZero():
+			status, startedAt = "in_progress", run.StartedAt
+		}
+		out = append(out, map[string]any{
+			"id":             run.ID,
+			"name":           run.Name,
+			"workflow_id":    run.WorkflowID,
+			"event":          run.Event,
+			"status":         status,
+			"conclusion":     conclusion,
+			"head_sha":       run.HeadSHA,
+			"head_branch":    run.HeadBranch,
+			"run_number":     run.RunNumber,
+			"run_attempt":    run.RunAttempt,
+			"html_url":       htmlURL(r, "/"+repo.Owner+"/"+repo.Name+"/actions/runs/"+strconv.FormatInt(run.ID, 10)),
+			"created_at":     run.CreatedAt.UTC().Format(time.RFC3339),
+			"run_started_at": startedAt.UTC().Format(time.RFC3339),
+			"updated_at":     run.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, map[string]any{"total_count": len(runs), "workflow_runs": out})
+}
+
+// listStargazers lists the stargazers of a repository, oldest first. As on GitHub, when they
+// starred it is only included for the application/vnd.github.star+json media type.
+func (s *Server) listStargazers(w http.ResponseWriter, r *http.Request) {
+	perPage, page := pagination(r.URL.Query())
+
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var stargazers []Stargazer
+	if ok {
+		stargazers = state.stargazers
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	withDates := strings.Contains(r.Header.Get("Accept"), "star+json")
+	lastPage := max((len(stargazers)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(stargazers))
+	end := min(start+perPage, len(stargazers))
+	out := make([]map[string]any, 0, end-start)
+	for _, sg := range stargazers[start:end] {
+		user := map[string]any{"login": sg.Login}
+		if withDates {
+			out = append(out, map[string]any{"starred_at": sg.StarredAt.UTC().Format(time.RFC3339), "user": user})
+		} else {
+			out = append(out, user)
+		}
+	}
+	if link := linkHeader(r, page, lastPage); link != "" {
+		w.Header().Set("Link", link)
+	}
+	writeCacheable(w, r, out)
+}
+
+// listLanguages returns the bytes of code per language of a repository.
+func (s *Server) listLanguages(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	if ok {
+		repo = state.repo
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	out := make(map[string]int64, len(repo.Languages))
+	for language, bytes := range repo.Languages {
+		out[language] = bytes
+	}
+	writeCacheable(w, r, out)
+}
+
+// listTopics returns the topics of a repository, all at once as on GitHub.
+func (s *Server) listTopics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	state, ok := s.repos[chi.URLParam(r, "owner")+"/"+chi.URLParam(r, "name")]
+	var repo Repository
+	if ok {
+		repo = state.repo
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	names := make([]string, 0, len(repo.Topics))
+	names = append(names, repo.Topics...)
+	writeCacheable(w, r, map[string]any{"names": names})
+}
+
+// pagination returns the per_page and page query parameters, defaulted and clamped as on GitHub.
+func pagination(query url.Values) (perPage, page int) {
+	perPage, _ = strconv.Atoi(query.Get("per_page"))
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	perPage = min(perPage, maxPerPage)
+	page, _ = strconv.Atoi(query.Get("page"))
+	return perPage, max(page, 1)
+}
+
+// linkHeader builds the pagination Link header for page out of lastPage.
+func linkHeader(r *http.Request, page, lastPage int) string {
+	pageURL := func(p int) string {
+		u := requestURL(r)
+		q := u.Query()
+		q.Set("page", strconv.Itoa(p))
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	var links []string
+	if page < lastPage {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(page+1)), fmt.Sprintf(`<%s>; rel="last"`, pageURL(lastPage)))
+	}
+	if page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(page-1)), fmt.Sprintf(`<%s>; rel="first"`, pageURL(1)))
+	}
+	return strings.Join(links, ", ")
+}
+
+// requestURL reconstructs the absolute URL of r.
+func requestURL(r *http.Request) *url.URL {
+	u := *r.URL
+	u.Host = r.Host
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	return &u
+}
+
+// htmlURL returns the web URL of path on the fake's host.
+func htmlURL(r *http.Request, path string) string {
+	u := requestURL(r)
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: path}).String()
+}
+
+func repositoryJSON(r *http.Request, repo Repository) map[string]any {
+	ownerType, visibility := "Organization", "public"
+	if repo.UserOwned {
+		ownerType = "User"
+	}
+	if repo.Private {
+		visibility = "private"
+	}
+	return map[string]any{
+		"id":                repo.ID,
+		"name":              repo.Name,
+		"full_name":         repo.Owner + "/" + repo.Name,
+		"owner":             map[string]any{"login": repo.Owner, "type": ownerType},
+		"archived":          repo.Archived,
+		"fork":              repo.Fork,
+		"private":           repo.Private,
+		"visibility":        visibility,
+		"html_url":          htmlURL(r, "/"+repo.Owner+"/"+repo.Name),
+		"description":       repo.Description,
+		"language":          repo.Language,
+		"topics":            append([]string{}, repo.Topics...),
+		"stargazers_count":  repo.Stars,
+		"watchers_count":    repo.Watchers,
+		"forks_count":       repo.Forks,
+		"open_issues_count": repo.OpenIssues,
+		"default_branch":    defaultBranch,
+		"created_at":        repo.CreatedAt.Format(time.RFC3339),
+		"updated_at":        repo.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func commitJSON(r *http.Request, repo Repository, c Commit) map[string]any {
+	var author any
+	if c.AuthorLogin != "" {
+		author = map[string]any{"login": c.AuthorLogin, "type": "User"}
+	}
+	reason := "unsigned"
+	if c.Verified {
+		reason = "valid"
+	}
+	signature := map[string]any{"name": c.AuthorName, "email": c.AuthorEmail, "date": c.Date.Format(time.RFC3339)}
+	return map[string]any{
+		"sha": c.SHA,
+		"commit": map[string]any{
+			"author":       signature,
+			"committer":    signature,
+			"message":      c.Message,
+			"verification": map[string]any{"verified": c.Verified, "reason": reason},
+		},
+		"html_url": htmlURL(r, "/"+repo.Owner+"/"+repo.Name+"/commit/"+c.SHA),
+		"author":   author,
+	}
+}
+
+// pullJSON renders a pull request as listed, or with the fields only single pull requests have.
+func pullJSON(r *http.Request, repo Repository, pr PullRequest, detail bool) map[string]any {
+	timestamp := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	state := "open"
+	if !pr.ClosedAt.IsZero() {
+		state = "closed"
+	}
+	v := map[string]any{
+		"id":         pr.ID,
+		"number":     pr.Number,
+		"title":      pr.Title,
+		"state":      state,
+		"user":       map[string]any{"login": pr.AuthorLogin, "type": "User"},
+		"base":       map[string]any{"ref": pr.Base},
+		"head":       map[string]any{"ref": pr.Head},
+		"html_url":   htmlURL(r, fmt.Sprintf("/%s/%s/pull/%d", repo.Owner, repo.Name, pr.Number)),
+		"created_at": timestamp(pr.CreatedAt),
+		"updated_at": timestamp(pr.UpdatedAt),
+		"closed_at":  timestamp(pr.ClosedAt),
+		"merged_at":  timestamp(pr.MergedAt),
+	}
+	if detail {
+		var mergedBy any
+		if pr.MergedBy != "" {
+			mergedBy = map[string]any{"login": pr.MergedBy, "type": "User"}
+		}
+		v["merged"] = !pr.MergedAt.IsZero()
+		v["merged_by"] = mergedBy
+		v["additions"] = pr.Additions
+		v["deletions"] = pr.Deletions
+	}
+	return v
+}
+
+func issueJSON(r *http.Request, repo Repository, issue Issue) map[string]any {
+	timestamp := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	state := "open"
+	if !issue.ClosedAt.IsZero() {
+		state = "closed"
+	}
+	labels := make([]map[string]any, 0, len(issue.Labels))
+	for _, l := range issue.Labels {
+		labels = append(labels, map[string]any{"name": l})
+	}
+	assignees := make([]map[string]any, 0, len(issue.Assignees))
+	for _, a := range issue.Assignees {
+		assignees = append(assignees, map[string]any{"login": a, "type": "User"})
+	}
+	var milestone any
+	if issue.Milestone != "" {
+		milestone = map[string]any{"title": issue.Milestone}
+	}
+	return map[string]any{
+		"id":         issue.ID,
+		"number":     issue.Number,
+		"title":      issue.Title,
+		"state":      state,
+		"user":       map[string]any{"login": issue.AuthorLogin, "type": "User"},
+		"labels":     labels,
+		"assignees":  assignees,
+		"milestone":  milestone,
+		"comments":   len(issue.Comments),
+		"html_url":   htmlURL(r, fmt.Sprintf("/%s/%s/issues/%d", repo.Owner, repo.Name, issue.Number)),
+		"created_at": timestamp(issue.CreatedAt),
+		"updated_at": timestamp(issue.UpdatedAt),
+		"closed_at":  timestamp(issue.ClosedAt),
+	}
+}
+
+// writeCacheable writes v with an ETag, answering 304 Not Modified if the client already has it.
+func writeCacheable(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"message": err.Error()})
+		return
+	}
+	writeCacheableBody(w, r, "application/json; charset=utf-8", body)
+}
+
+// writeCacheableBody writes body with an ETag, answering 304 Not Modified if the client already has it.
+func writeCacheableBody(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,573 @@
+// internal/githubfake/server_test.go
+package githubfake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/model"
+)
+
+func newClient(t *testing.T, baseURL string) *github.Client {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := github.NewClient("", logger, github.WithRetryBackoff(time.Millisecond, time.Millisecond)).WithBaseURL(baseURL)
+	require.NoError(t, err)
+	return client
+}
+
+func seed(t *testing.T, fake *Server, commits int) time.Time {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake.AddRepository(Repository{Owner: "octo-org", Name: "hello-world", Language: "Go", Stars: 42})
+	for i := 0; i < commits; i++ {
+		err := fake.AddCommits("octo-org", "hello-world", Commit{
+			Message:     fmt.Sprintf("commit %d", i),
+			AuthorName:  "Mona",
+			AuthorEmail: "mona@example.com",
+			AuthorLogin: "octocat",
+			Date:        start.Add(time.Duration(i) * time.Hour),
+		})
+		require.NoError(t, err)
+	}
+	return start
+}
+
+func TestServer_WithClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves repository metadata", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		seed(t, fake, 0)
+
+		repo, err := newClient(t, server.URL).GetRepository(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hello-world", repo.Name)
+		assert.Equal(t, 42, repo.StarsCount)
+		assert.Equal(t, server.URL+"/octo-org/hello-world", repo.URL)
+	})
+
+	t.Run("paginates commits and filters by since", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 250)
+
+		commits, err := newClient(t, server.URL).GetCommits(ctx, "octo-org", "hello-world", start.Add(50*time.Hour))
+
+		require.NoError(t, err)
+		require.Len(t, commits, 200)
+		assert.Equal(t, "commit 249", commits[0].Message, "newest commit first")
+		assert.Equal(t, "commit 50", commits[199].Message)
+		assert.Equal(t, "octocat", commits[0].AuthorLogin)
+		assert.Equal(t, 2, fake.Requests(), "two pages of 100")
+	})
+
+	t.Run("resumed listings stay anchored at the original head", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 250)
+		client := newClient(t, server.URL)
+
+		var cursor string
+		err := client.ForEachCommitPageFrom(ctx, "octo-org", "hello-world", time.Time{}, "", func(page []model.Commit, next string) error {
+			cursor = next
+			return errors.New("interrupted")
+		})
+		require.Error(t, err)
+		require.NoError(t, fake.AddCommits("octo-org", "hello-world", Commit{Message: "pushed meanwhile", Date: start.Add(1000 * time.Hour)}))
+
+		var commits []model.Commit
+		err = client.ForEachCommitPageFrom(ctx, "octo-org", "hello-world", time.Time{}, cursor, func(page []model.Commit, next string) error {
+			commits = append(commits, page...)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, commits, 150)
+		assert.Equal(t, "commit 149", commits[0].Message)
+		assert.Equal(t, "commit 0", commits[149].Message)
+	})
+
+	t.Run("compares branch heads across a force-push", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		seed(t, fake, 0)
+		client := newClient(t, server.URL)
+
+		head, err := client.GetHeadSHA(ctx, "octo-org", "hello-world")
+		require.NoError(t, err)
+		assert.Empty(t, head, "empty repositories have no head")
+
+		start := seed(t, fake, 5)
+		oldHead, err := client.GetHeadSHA(ctx, "octo-org", "hello-world")
+		require.NoError(t, err)
+		oldCommits, err := client.GetCommits(ctx, "octo-org", "hello-world", time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, oldCommits[0].SHA, oldHead)
+
+		require.NoError(t, fake.ForcePush("octo-org", "hello-world", 2, Commit{Message: "amended", Date: start.Add(10 * time.Hour)}))
+		newHead, err := client.GetHeadSHA(ctx, "octo-org", "hello-world")
+		require.NoError(t, err)
+
+		cmp, err := client.CompareHeads(ctx, "octo-org", "hello-world", oldHead, newHead)
+
+		require.NoError(t, err)
+		assert.True(t, cmp.Rewritten())
+		assert.ElementsMatch(t, []string{oldCommits[0].SHA, oldCommits[1].SHA}, cmp.Orphaned)
+		assert.Equal(t, oldCommits[2].SHA, cmp.MergeBase)
+
+		cmp, err = client.CompareHeads(ctx, "octo-org", "hello-world", oldCommits[2].SHA, newHead)
+
+		require.NoError(t, err)
+		assert.False(t, cmp.Rewritten(), "fast-forwards orphan nothing")
+
+		cmp, err = client.CompareHeads(ctx, "octo-org", "hello-world", "0123456789abcdef0123456789abcdef01234567", newHead)
+
+		require.NoError(t, err)
+		assert.True(t, cmp.OldHeadMissing)
+	})
+
+	t.Run("lists branches and their histories", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 3)
+		client := newClient(t, server.URL)
+		mainline, err := client.GetCommits(ctx, "octo-org", "hello-world", time.Time{})
+		require.NoError(t, err)
+		require.NoError(t, fake.SetBranch("octo-org", "hello-world", "release/1.0", mainline[1].SHA, Commit{Message: "backport", Date: start.Add(10 * time.Hour)}))
+
+		branches, err := client.ListBranches(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		require.Len(t, branches, 2)
+		assert.Equal(t, model.Branch{Name: "main", HeadSHA: mainline[0].SHA}, branches[0])
+		assert.Equal(t, "release/1.0", branches[1].Name)
+
+		var commits []model.Commit
+		err = client.ForEachBranchCommitPage(ctx, "octo-org", "hello-world", "release/1.0", start.Add(time.Hour), func(page []model.Commit) error {
+			commits = append(commits, page...)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, commits, 2)
+		assert.Equal(t, branches[1].HeadSHA, commits[0].SHA)
+		assert.Equal(t, "backport", commits[0].Message)
+		assert.Equal(t, mainline[1].SHA, commits[1].SHA, "listed since the given time")
+	})
+
+	t.Run("lists pull requests updated since a time and gets their details and reviews", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 0)
+		for i := 0; i < 3; i++ {
+			_, err := fake.SetPullRequest("octo-org", "hello-world", PullRequest{
+				Title:     fmt.Sprintf("pull %d", i),
+				CreatedAt: start,
+				UpdatedAt: start.Add(time.Duration(i) * time.Hour),
+			})
+			require.NoError(t, err)
+		}
+		_, err := fake.SetPullRequest("octo-org", "hello-world", PullRequest{
+			Number:      2,
+			Title:       "feat: greet",
+			AuthorLogin: "octocat",
+			Head:        "feature",
+			Additions:   10,
+			Deletions:   2,
+			CreatedAt:   start,
+			UpdatedAt:   start.Add(5 * time.Hour),
+			ClosedAt:    start.Add(4 * time.Hour),
+			MergedAt:    start.Add(4 * time.Hour),
+			MergedBy:    "hubot",
+			Reviews: []Review{
+				{User: "hubot", State: "APPROVED", CommitID: "abc", SubmittedAt: start.Add(3 * time.Hour)},
+				{User: "mona", State: "PENDING"},
+			},
+		})
+		require.NoError(t, err)
+
+		var pulls []model.PullRequest
+		err = newClient(t, server.URL).ForEachPullRequestPage(ctx, "octo-org", "hello-world", start.Add(2*time.Hour), func(page []model.PullRequest) error {
+			pulls = append(pulls, page...)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, pulls, 2, "pull 1 was last updated before since")
+		pr := pulls[0]
+		assert.Equal(t, 2, pr.Number, "most recently updated first")
+		assert.Equal(t, model.PullRequestMerged, pr.State)
+		assert.Equal(t, "octocat", pr.AuthorLogin)
+		assert.Equal(t, "main", pr.BaseRef)
+		assert.Equal(t, "feature", pr.HeadRef)
+		assert.True(t, pr.MergedAt.Equal(start.Add(4*time.Hour)))
+		assert.Zero(t, pr.Additions, "listings lack line stats")
+		assert.Empty(t, pr.MergedByLogin)
+		assert.Empty(t, pr.Reviews)
+		assert.Equal(t, 3, pulls[1].Number)
+		assert.Equal(t, model.PullRequestOpen, pulls[1].State)
+		assert.True(t, pulls[1].ClosedAt.IsZero())
+
+		pr, err = newClient(t, server.URL).GetPullRequest(ctx, "octo-org", "hello-world", 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, 10, pr.Additions)
+		assert.Equal(t, 2, pr.Deletions)
+		assert.Equal(t, "hubot", pr.MergedByLogin)
+		require.Len(t, pr.Reviews, 1, "pending reviews are left out")
+		assert.Equal(t, model.PullRequestReview{ID: pr.Reviews[0].ID, ReviewerLogin: "hubot", State: "APPROVED", CommitSHA: "abc", SubmittedAt: start.Add(3 * time.Hour)}, pr.Reviews[0])
+	})
+
+	t.Run("lists issues updated since a time and their comments, leaving out pull requests", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 0)
+		_, err := fake.SetIssue("octo-org", "hello-world", Issue{Title: "stale", CreatedAt: start, UpdatedAt: start})
+		require.NoError(t, err)
+		_, err = fake.SetPullRequest("octo-org", "hello-world", PullRequest{Title: "fix: crash", CreatedAt: start, UpdatedAt: start.Add(3 * time.Hour)})
+		require.NoError(t, err)
+		number, err := fake.SetIssue("octo-org", "hello-world", Issue{
+			Title:       "crash on start",
+			AuthorLogin: "mona",
+			Labels:      []string{"bug", "p1"},
+			Assignees:   []string{"octocat"},
+			Milestone:   "v1.0",
+			CreatedAt:   start,
+			UpdatedAt:   start.Add(4 * time.Hour),
+			ClosedAt:    start.Add(4 * time.Hour),
+			Comments:    []IssueComment{{User: "octocat", CreatedAt: start.Add(time.Hour)}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, fake.SetLabels("octo-org", "hello-world", Label{Name: "bug", Color: "d73a4a"}, Label{Name: "p1"}))
+		client := newClient(t, server.URL)
+
+		var issues []model.Issue
+		err = client.ForEachIssuePage(ctx, "octo-org", "hello-world", start.Add(time.Hour), func(page []model.Issue) error {
+			issues = append(issues, page...)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, issues, 1, "the stale issue was last updated before since")
+		issue := issues[0]
+		assert.Equal(t, 3, number, "numbers are shared with pull requests")
+		assert.Equal(t, number, issue.Number)
+		assert.Equal(t, "closed", issue.State)
+		assert.Equal(t, []string{"bug", "p1"}, issue.Labels)
+		assert.Equal(t, []string{"octocat"}, issue.Assignees)
+		assert.Equal(t, "v1.0", issue.Milestone)
+		assert.Equal(t, 1, issue.CommentsCount)
+		assert.True(t, issue.ClosedAt.Equal(start.Add(4*time.Hour)))
+
+		comments, err := client.ListIssueComments(ctx, "octo-org", "hello-world", number)
+
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "octocat", comments[0].AuthorLogin)
+		assert.True(t, comments[0].UpdatedAt.Equal(start.Add(time.Hour)))
+
+		labels, err := client.ListLabels(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		assert.Equal(t, []model.Label{{Name: "bug", Color: "d73a4a"}, {Name: "p1"}}, labels)
+	})
+
+	t.Run("lists tags and releases with their assets", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 1)
+		client := newClient(t, server.URL)
+		commits, err := client.GetCommits(ctx, "octo-org", "hello-world", time.Time{})
+		require.NoError(t, err)
+		require.NoError(t, fake.SetTags("octo-org", "hello-world", Tag{Name: "v1.0.0", SHA: commits[0].SHA}))
+		_, err = fake.SetRelease("octo-org", "hello-world", Release{
+			TagName:     "v1.0.0",
+			Name:        "First",
+			AuthorLogin: "octocat",
+			CreatedAt:   start,
+			PublishedAt: start.Add(time.Hour),
+			Assets:      []Asset{{Name: "hello.zip", Size: 2048, DownloadCount: 12}},
+		})
+		require.NoError(t, err)
+		_, err = fake.SetRelease("octo-org", "hello-world", Release{TagName: "v2.0.0", CreatedAt: start.Add(2 * time.Hour)})
+		require.NoError(t, err)
+
+		tags, err := client.ListTags(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		assert.Equal(t, []model.Tag{{Name: "v1.0.0", SHA: commits[0].SHA}}, tags)
+
+		releases, err := client.ListReleases(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		require.Len(t, releases, 2)
+		assert.True(t, releases[0].Draft, "newest first; unpublished releases are drafts")
+		assert.True(t, releases[0].PublishedAt.IsZero())
+		release := releases[1]
+		assert.Equal(t, "v1.0.0", release.TagName)
+		assert.Equal(t, "octocat", release.AuthorLogin)
+		assert.True(t, release.PublishedAt.Equal(start.Add(time.Hour)))
+		require.Len(t, release.Assets, 1)
+		assert.Equal(t, model.ReleaseAsset{ID: release.Assets[0].ID, Name: "hello.zip", Size: 2048, DownloadCount: 12}, release.Assets[0])
+	})
+
+	t.Run("serves the files a commit changed", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 1)
+		require.NoError(t, fake.AddCommits("octo-org", "hello-world", Commit{
+			Message: "rename",
+			Date:    start.Add(time.Hour),
+			Files: []CommitFile{
+				{Filename: "main.go", Additions: 3, Deletions: 1},
+				{Filename: "docs/guide.md", Status: "renamed", Additions: 10},
+			},
+		}))
+		client := newClient(t, server.URL)
+		commits, err := client.GetCommits(ctx, "octo-org", "hello-world", time.Time{})
+		require.NoError(t, err)
+
+		files, err := client.GetCommitFiles(ctx, "octo-org", "hello-world", commits[0].SHA)
+
+		require.NoError(t, err)
+		assert.Equal(t, []model.CommitFile{
+			{Filename: "main.go", Status: "modified", Additions: 3, Deletions: 1},
+			{Filename: "docs/guide.md", Status: "renamed", Additions: 10},
+		}, files)
+
+		files, err = client.GetCommitFiles(ctx, "octo-org", "hello-world", "0123456789abcdef0123456789abcdef01234567")
+
+		require.NoError(t, err, "commits that are gone change no files")
+		assert.Empty(t, files)
+	})
+
+	t.Run("lists workflows and their runs created since a time", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 0)
+		ci, err := fake.SetWorkflow("octo-org", "hello-world", Workflow{Name: "CI", Path: ".github/workflows/ci.yml"})
+		require.NoError(t, err)
+		_, err = fake.SetWorkflowRun("octo-org", "hello-world", WorkflowRun{WorkflowID: ci, HeadSHA: "aaa", CreatedAt: start})
+		require.NoError(t, err)
+		_, err = fake.SetWorkflowRun("octo-org", "hello-world", WorkflowRun{
+			WorkflowID: ci,
+			HeadSHA:    "bbb",
+			RunAttempt: 2,
+			Conclusion: "failure",
+			CreatedAt:  start.Add(time.Hour),
+			StartedAt:  start.Add(90 * time.Minute),
+			UpdatedAt:  start.Add(100 * time.Minute),
+		})
+		require.NoError(t, err)
+		_, err = fake.SetWorkflowRun("octo-org", "hello-world", WorkflowRun{WorkflowID: ci, Event: "pull_request", CreatedAt: start.Add(2 * time.Hour), StartedAt: start.Add(2 * time.Hour)})
+		require.NoError(t, err)
+		client := newClient(t, server.URL)
+
+		workflows, err := client.ListWorkflows(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		assert.Equal(t, []model.Workflow{{ID: ci, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}}, workflows)
+
+		var runs []model.WorkflowRun
+		err = client.ForEachWorkflowRunPage(ctx, "octo-org", "hello-world", start.Add(time.Hour), func(page []model.WorkflowRun) error {
+			runs = append(runs, page...)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, model.WorkflowRunInProgress, runs[0].Status)
+		assert.Equal(t, "pull_request", runs[0].Event)
+		assert.Equal(t, 3, runs[0].RunNumber)
+		run := runs[1]
+		assert.Equal(t, model.WorkflowRunCompleted, run.Status)
+		assert.Equal(t, "failure", run.Conclusion)
+		assert.Equal(t, "CI", run.Name)
+		assert.Equal(t, "bbb", run.HeadSHA)
+		assert.Equal(t, 2, run.RunAttempt)
+		assert.True(t, run.StartedAt.Equal(start.Add(90*time.Minute)))
+		assert.True(t, run.UpdatedAt.Equal(start.Add(100*time.Minute)))
+	})
+
+	t.Run("lists more workflow runs than filtered listings return", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 0)
+		for i := 0; i < 1050; i++ {
+			_, err := fake.SetWorkflowRun("octo-org", "hello-world", WorkflowRun{WorkflowID: 1, CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+			require.NoError(t, err)
+		}
+		client := newClient(t, server.URL)
+
+		var runs []model.WorkflowRun
+		pages := 0
+		err := client.ForEachWorkflowRunPage(ctx, "octo-org", "hello-world", start.Add(10*time.Minute), func(page []model.WorkflowRun) error {
+			runs = append(runs, page...)
+			pages++
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, runs, 1040)
+		assert.True(t, runs[0].CreatedAt.Equal(start.Add(1049*time.Minute)))
+		assert.True(t, runs[1039].CreatedAt.Equal(start.Add(10*time.Minute)))
+		assert.Equal(t, 11, pages, "the listing stops at the first run created before since")
+	})
+
+	t.Run("lists every stargazer with when they starred", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		start := seed(t, fake, 0)
+		var stargazers []Stargazer
+		for i := 0; i < 150; i++ {
+			stargazers = append(stargazers, Stargazer{Login: fmt.Sprintf("user-%d", i), StarredAt: start.Add(time.Duration(i) * time.Hour)})
+		}
+		require.NoError(t, fake.AddStargazers("octo-org", "hello-world", stargazers...))
+		client := newClient(t, server.URL)
+
+		got, err := client.ListStargazers(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		require.Len(t, got, 150)
+		assert.Equal(t, "user-0", got[0].Login)
+		assert.True(t, got[0].StarredAt.Equal(start))
+		assert.Equal(t, "user-149", got[149].Login)
+		assert.True(t, got[149].StarredAt.Equal(start.Add(149*time.Hour)))
+	})
+
+	t.Run("returns languages and topics", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		fake.AddRepository(Repository{
+			Owner:     "octo-org",
+			Name:      "hello-world",
+			Language:  "Go",
+			Languages: map[string]int64{"Go": 9000, "Shell": 120},
+			Topics:    []string{"api", "golang"},
+		})
+		client := newClient(t, server.URL)
+
+		languages, err := client.GetLanguages(ctx, "octo-org", "hello-world")
+		require.NoError(t, err)
+		topics, err := client.ListTopics(ctx, "octo-org", "hello-world")
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]int64{"Go": 9000, "Shell": 120}, languages)
+		assert.Equal(t, []string{"api", "golang"}, topics)
+	})
+
+	t.Run("lists the repositories of organizations and users", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		for i := 0; i < 150; i++ {
+			fake.AddRepository(Repository{Owner: "octo-org", Name: fmt.Sprintf("service-%03d", i), Private: i == 0, Archived: i == 1})
+		}
+		fake.AddRepository(Repository{Owner: "mona", Name: "dotfiles", UserOwned: true, Fork: true})
+		fake.AddRepository(Repository{Owner: "mona", Name: "secret", UserOwned: true, Private: true})
+		client := newClient(t, server.URL)
+
+		orgRepos, err := client.ListOwnerRepositories(ctx, "octo-org", false)
+		require.NoError(t, err)
+		userRepos, err := client.ListOwnerRepositories(ctx, "mona", false)
+		require.NoError(t, err)
+
+		require.Len(t, orgRepos, 150)
+		assert.True(t, orgRepos[0].Private)
+		assert.True(t, orgRepos[1].Archived)
+		assert.Equal(t, "service-149", orgRepos[149].Name)
+		require.Len(t, userRepos, 1)
+		assert.Equal(t, "mona", userRepos[0].Owner)
+		assert.Equal(t, "dotfiles", userRepos[0].Name)
+		assert.True(t, userRepos[0].Fork)
+	})
+
+	t.Run("client retries injected server errors", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		seed(t, fake, 1)
+		fake.FailNext(2, http.StatusBadGateway)
+
+		_, err := newClient(t, server.URL).GetRepository(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, fake.Requests())
+	})
+
+	t.Run("client waits out secondary rate limits", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		seed(t, fake, 1)
+		fake.SecondaryRateLimitNext(1, 0)
+
+		_, err := newClient(t, server.URL).GetRepository(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, fake.Requests())
+	})
+
+	t.Run("accepts the GitHub Enterprise Server prefix", func(t *testing.T) {
+		fake, server := NewServer()
+		defer server.Close()
+		seed(t, fake, 0)
+
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+		client, err := github.NewClient("", logger).WithEnterpriseURLs(server.URL, "")
+		require.NoError(t, err)
+		_, err = client.GetRepository(ctx, "octo-org", "hello-world")
+
+		require.NoError(t, err)
+	})
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	fake, server := NewServer()
+	defer server.Close()
+	seed(t, fake, 1)
+	fake.SetRateLimit(10, 1, time.Now().Add(time.Hour))
+	url := server.URL + "/repos/octo-org/hello-world"
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	resp, err = http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	fake.SetRateLimit(10, 1, time.Now().Add(time.Hour))
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Remaining"), "304 responses are free")
+}
+
+func TestServer_UnknownRepository(t *testing.T) {
+	_, server := NewServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/repos/octo-org/missing/commits")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
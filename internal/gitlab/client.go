@@ -0,0 +1,453 @@
+// internal/gitlab/client.go
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-data-fetcher/internal/model"
+)
+
+const (
+	// DefaultHost identifies gitlab.com in repository identifiers.
+	DefaultHost = "gitlab.com"
+	// DefaultBaseURL is the root of gitlab.com; the REST API lives under api/v4/.
+	DefaultBaseURL = "https://gitlab.com/"
+
+	maxRetries    = 5
+	retryMinDelay = 1 * time.Second
+	retryMaxDelay = 120 * time.Second
+)
+
+// ErrorResponse is returned for API responses with a non-2xx status.
+type ErrorResponse struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // Set for 429 Too Many Requests.
+}
+
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("gitlab API error: %d %s", e.StatusCode, e.Message)
+}
+
+// Client is a GitLab REST API v4 client with the same retry behaviour as the GitHub client.
+// It implements syncer.Source for projects on gitlab.com or a self-hosted instance.
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+	logger     *slog.Logger
+	r          *rand.Rand
+	minDelay   time.Duration
+	maxDelay   time.Duration
+
+	mu        sync.Mutex
+	remaining int // -1 until the first RateLimit-Remaining header is seen
+	reset     time.Time
+}
+
+// Option configures optional behaviour of a Client.
+type Option func(*Client)
+
+// WithTransport sends requests through rt instead of http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = rt
+	}
+}
+
+// WithRetryBackoff overrides the exponential backoff bounds used when retrying server errors.
+func WithRetryBackoff(minDelay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.minDelay = minDelay
+		c.maxDelay = maxDelay
+	}
+}
+
+// NewClient creates a client for the GitLab instance at baseURL, e.g. DefaultBaseURL.
+// token may be empty to access public projects only.
+func NewClient(baseURL, token string, logger *slog.Logger, opts ...Option) (*Client, error) {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("gitlab base URL %q has no host", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		token:      token,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		logger:     logger,
+		r:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		minDelay:   retryMinDelay,
+		maxDelay:   retryMaxDelay,
+		remaining:  -1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Host returns the host repositories of this instance are stored under, e.g. "gitlab.com".
+func (c *Client) Host() string {
+	return c.baseURL.Host
+}
+
+type project struct {
+	ID              int64     `json:"id"`
+	Path            string    `json:"path"`
+	Description     *string   `json:"description"`
+	WebURL          string    `json:"web_url"`
+	ForksCount      int       `json:"forks_count"`
+	StarCount       int       `json:"star_count"`
+	OpenIssuesCount int       `json:"open_issues_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastActivityAt  time.Time `json:"last_activity_at"`
+	Namespace       namespace `json:"namespace"`
+}
+
+type namespace struct {
+	FullPath string `json:"full_path"`
+}
+
+type commit struct {
+	ID             string    `json:"id"`
+	Message        string    `json:"message"`
+	AuthorName     string    `json:"author_name"`
+	AuthorEmail    string    `json:"author_email"`
+	AuthoredDate   time.Time `json:"authored_date"`
+	CommitterName  string    `json:"committer_name"`
+	CommitterEmail string    `json:"committer_email"`
+	CommittedDate  time.Time `json:"committed_date"`
+	WebURL         string    `json:"web_url"`
+	Stats          *struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+}
+
+// Provider returns model.ProviderGitLab.
+func (c *Client) Provider() string {
+	return model.ProviderGitLab
+}
+
+// GetRepository fetches a project. owner is the full namespace path, which may contain subgroups.
+func (c *Client) GetRepository(ctx context.Context, owner, name string) (*model.Repository, error) {
+	var p project
+	if _, err := c.get(ctx, c.projectURL(owner, name, ""), &p); err != nil {
+		return nil, err
+	}
+	return toInternalRepository(&p), nil
+}
+
+// GetCommits fetches all commits on the default branch since a given time, newest first.
+func (c *Client) GetCommits(ctx context.Context, owner, name string, since time.Time) ([]model.Commit, error) {
+	var allCommits []model.Commit
+	err := c.ForEachCommitPage(ctx, owner, name, since, func(page []model.Commit) error {
+		allCommits = append(allCommits, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allCommits, nil
+}
+
+// ForEachCommitPage fetches the commits on the default branch since a given time, newest first,
+// calling fn with each page as it arrives. It stops at the first error returned by fn.
+// Pages are followed through the Link header, which carries the cursor when GitLab uses
+// keyset pagination, falling back to X-Next-Page for offset pagination.
+func (c *Client) ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error {
+	return c.forEachCommitPage(ctx, owner, name, c.commitsURL(owner, name, since), func(page []model.Commit, _ string) error {
+		return fn(page)
+	})
+}
+
+// ForEachCommitPageFrom is like ForEachCommitPage, but fn also receives a cursor from which a later
+// call resumes the listing after that page, or "" after the last page. An empty cursor starts at
+// the newest commit. Cursors list the history of the head commit seen on the first page, so
+// commits pushed in the meantime do not shift the pages of a resumed listing.
+func (c *Client) ForEachCommitPageFrom(ctx context.Context, owner, name string, since time.Time, cursor string, fn func(page []model.Commit, next string) error) error {
+	start := cursor
+	if start == "" {
+		start = c.commitsURL(owner, name, since)
+	} else if !strings.HasPrefix(cursor, c.projectURL(owner, name, "/repository/commits?")) {
+		return fmt.Errorf("commit cursor %q does not belong to %s/%s on %s", cursor, owner, name, c.Host())
+	}
+
+	var head string
+	return c.forEachCommitPage(ctx, owner, name, start, func(page []model.Commit, next string) error {
+		if head == "" && len(page) > 0 {
+			head = page[0].SHA
+		}
+		if next != "" {
+			u, err := url.Parse(next)
+			if err != nil {
+				return err
+			}
+			q := u.Query()
+			if q.Get("ref_name") == "" {
+				q.Set("ref_name", head)
+				u.RawQuery = q.Encode()
+			}
+			next = u.String()
+		}
+		return fn(page, next)
+	})
+}
+
+// commitsURL returns the URL of the first page of commits since a given time.
+func (c *Client) commitsURL(owner, name string, since time.Time) string {
+	q := url.Values{}
+	q.Set("per_page", "100")
+	q.Set("with_stats", "true")
+	if !since.IsZero() {
+		q.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	return c.projectURL(owner, name, "/repository/commits") + "?" + q.Encode()
+}
+
+// forEachCommitPage fetches the commits page at next and the pages after it, passing fn each page
+// and the URL of the page after it, "" after the last one.
+func (c *Client) forEachCommitPage(ctx context.Context, owner, name, next string, fn func(page []model.Commit, next string) error) error {
+	for next != "" {
+		var commits []commit
+		c.logger.Debug("Fetching commits page", "owner", owner, "repo", name, "url", next)
+		resp, err := c.get(ctx, next, &commits)
+		if err != nil {
+			return err
+		}
+		page := make([]model.Commit, 0, len(commits))
+		for _, cm := range commits {
+			page = append(page, toInternalCommit(cm))
+		}
+		next = nextPageURL(resp)
+		if err := fn(page, next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectURL returns the API URL of a project sub-resource. The project is addressed by its
+// URL-encoded full path, as GitLab expects.
+func (c *Client) projectURL(owner, name, suffix string) string {
+	return c.baseURL.String() + "api/v4/projects/" + url.PathEscape(owner+"/"+name) + suffix
+}
+
+// get fetches rawURL into v with retries. The returned response's body is already closed.
+func (c *Client) get(ctx context.Context, rawURL string, v any) (*http.Response, error) {
+	var resp *http.Response
+	err := c.retry(ctx, func() error {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		if c.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", c.token)
+		}
+
+		resp, err = c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		c.recordRateLimit(resp)
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return newErrorResponse(resp)
+		}
+		return json.NewDecoder(resp.Body).Decode(v)
+	})
+	return resp, err
+}
+
+// retry retries fn on 429 Too Many Requests and 5xx server errors.
+func (c *Client) retry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		errResp, ok := err.(*ErrorResponse)
+		if !ok {
+			return err
+		}
+
+		var sleepDuration time.Duration
+		switch {
+		case errResp.StatusCode == http.StatusTooManyRequests:
+			sleepDuration = errResp.RetryAfter
+			if sleepDuration <= 0 {
+				sleepDuration = c.untilReset()
+			}
+			c.logger.Warn("GitLab API rate limit exceeded. Waiting for reset.", "wait_duration", sleepDuration)
+		case errResp.StatusCode >= 500:
+			backoff := float64(c.minDelay) * math.Pow(2, float64(attempt))
+			if backoff > float64(c.maxDelay) {
+				backoff = float64(c.maxDelay)
+			}
+			// Add jitter: backoff ± 25%
+			jitter := (c.r.Float64() - 0.5) * backoff * 0.5
+			sleepDuration = time.Duration(backoff + jitter)
+			c.logger.Warn("GitLab API server error. Retrying.", "status_code", errResp.StatusCode, "attempt", attempt+1, "backoff", sleepDuration)
+		default:
+			return err
+		}
+
+		select {
+		case <-time.After(sleepDuration):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// recordRateLimit remembers the RateLimit-Remaining and RateLimit-Reset headers of resp.
+func (c *Client) recordRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remaining = remaining
+	c.reset = time.Unix(reset, 0)
+}
+
+// waitForRateLimit blocks until the rate limit resets if the last response reported no
+// remaining requests, instead of sending a request that is bound to be rejected.
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	exhausted := c.remaining == 0
+	c.mu.Unlock()
+	if !exhausted {
+		return nil
+	}
+
+	wait := c.untilReset()
+	if wait <= 0 {
+		return nil
+	}
+	c.logger.Warn("GitLab API rate limit exhausted. Waiting for reset.", "wait_duration", wait)
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) untilReset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Until(c.reset)
+}
+
+func newErrorResponse(resp *http.Response) *ErrorResponse {
+	var body struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	_ = json.Unmarshal(data, &body)
+
+	msg := body.Error
+	if body.Message != nil {
+		msg = fmt.Sprint(body.Message)
+	}
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+
+	errResp := &ErrorResponse{StatusCode: resp.StatusCode, Message: msg}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		errResp.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return errResp
+}
+
+// nextPageURL returns the URL of the next page, or "" on the last page.
+func nextPageURL(resp *http.Response) string {
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+
+	page := resp.Header.Get("X-Next-Page")
+	if page == "" {
+		return ""
+	}
+	u := *resp.Request.URL
+	q := u.Query()
+	q.Set("page", page)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func toInternalRepository(p *project) *model.Repository {
+	return &model.Repository{
+		GithubRepoID:    p.ID,
+		Owner:           p.Namespace.FullPath,
+		Name:            p.Path,
+		Description:     p.Description,
+		URL:             p.WebURL,
+		ForksCount:      p.ForksCount,
+		StarsCount:      p.StarCount,
+		OpenIssuesCount: p.OpenIssuesCount,
+		RepoCreatedAt:   p.CreatedAt,
+		RepoUpdatedAt:   p.LastActivityAt,
+	}
+}
+
+func toInternalCommit(c commit) model.Commit {
+	out := model.Commit{
+		SHA:            c.ID,
+		AuthorName:     c.AuthorName,
+		AuthorEmail:    c.AuthorEmail,
+		CommitterName:  c.CommitterName,
+		CommitterEmail: c.CommitterEmail,
+		CommitterDate:  c.CommittedDate,
+		Message:        c.Message,
+		URL:            c.WebURL,
+		CommitDate:     c.AuthoredDate,
+	}
+	if c.Stats != nil {
+		out.Additions = &c.Stats.Additions
+		out.Deletions = &c.Stats.Deletions
+	}
+	return out
+}
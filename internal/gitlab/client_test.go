@@ -0,0 +1,233 @@
+// internal/gitlab/client_test.go
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github-data-fetcher/internal/model"
+)
+
+// setupTestClient creates a httptest server and a GitLab client pointing to it.
+func setupTestClient(t *testing.T, handler http.Handler) (*Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClient(server.URL, "glpat-test", logger, WithRetryBackoff(time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+	return client, server
+}
+
+func TestClient_GetRepository(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/group%2Fsub%2Fproject", r.URL.EscapedPath())
+		assert.Equal(t, "glpat-test", r.Header.Get("PRIVATE-TOKEN"))
+		fmt.Fprintln(w, `{
+			"id": 42, "path": "project", "description": "A project",
+			"web_url": "https://gitlab.example.com/group/sub/project",
+			"forks_count": 3, "star_count": 7, "open_issues_count": 2,
+			"created_at": "2023-05-01T10:00:00.000Z", "last_activity_at": "2024-03-01T12:30:00.000Z",
+			"namespace": {"full_path": "group/sub"}
+		}`)
+	})
+	client, server := setupTestClient(t, handler)
+	defer server.Close()
+
+	repo, err := client.GetRepository(context.Background(), "group/sub", "project")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), repo.GithubRepoID)
+	assert.Equal(t, "group/sub", repo.Owner)
+	assert.Equal(t, "project", repo.Name)
+	assert.Equal(t, "A project", *repo.Description)
+	assert.Equal(t, 7, repo.StarsCount)
+	assert.Equal(t, 3, repo.ForksCount)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), repo.RepoUpdatedAt)
+}
+
+func TestClient_GetCommits(t *testing.T) {
+	commitJSON := func(sha string) string {
+		return fmt.Sprintf(`{"id": %q, "message": "msg %s", "author_name": "Jane", "author_email": "jane@example.com",
+			"authored_date": "2024-03-01T10:00:00+01:00", "web_url": "https://gitlab.com/c/%s",
+			"committer_name": "CI Bot", "committer_email": "ci@example.com", "committed_date": "2024-03-02T10:00:00+01:00",
+			"stats": {"additions": 5, "deletions": 1}}`, sha, sha, sha)
+	}
+
+	t.Run("follows keyset pagination links", func(t *testing.T) {
+		var requestCount int32
+		var server *httptest.Server
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requestCount, 1)
+			assert.Equal(t, "/api/v4/projects/group%2Fproject/repository/commits", r.URL.EscapedPath())
+			if r.URL.Query().Get("cursor") == "" {
+				assert.Equal(t, "2024-01-01T00:00:00Z", r.URL.Query().Get("since"))
+				assert.Equal(t, "true", r.URL.Query().Get("with_stats"))
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?cursor=abc&per_page=100>; rel="next"`, server.URL, r.URL.EscapedPath()))
+				fmt.Fprintf(w, "[%s]", commitJSON("b"))
+				return
+			}
+			fmt.Fprintf(w, "[%s]", commitJSON("a"))
+		})
+		client, s := setupTestClient(t, handler)
+		server = s
+		defer server.Close()
+
+		commits, err := client.GetCommits(context.Background(), "group", "project", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+		require.NoError(t, err)
+		require.Len(t, commits, 2)
+		assert.Equal(t, "b", commits[0].SHA)
+		assert.Equal(t, "a", commits[1].SHA)
+		assert.Equal(t, "Jane", commits[0].AuthorName)
+		assert.Equal(t, "CI Bot", commits[0].CommitterName)
+		assert.Equal(t, time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC), commits[0].CommitterDate.UTC())
+		assert.Equal(t, 5, *commits[0].Additions)
+		assert.Equal(t, 1, *commits[0].Deletions)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requestCount))
+	})
+
+	t.Run("falls back to offset pagination", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("page") {
+			case "":
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprintf(w, "[%s]", commitJSON("c"))
+			case "2":
+				w.Header().Set("X-Next-Page", "")
+				fmt.Fprintf(w, "[%s]", commitJSON("b"))
+			default:
+				t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+			}
+		})
+		client, server := setupTestClient(t, handler)
+		defer server.Close()
+
+		commits, err := client.GetCommits(context.Background(), "group", "project", time.Time{})
+
+		require.NoError(t, err)
+		require.Len(t, commits, 2)
+		assert.Equal(t, "b", commits[1].SHA)
+	})
+}
+
+func TestClient_ForEachCommitPageFrom(t *testing.T) {
+	commitJSON := func(sha string) string {
+		return fmt.Sprintf(`{"id": %q, "authored_date": "2024-03-01T10:00:00+01:00"}`, sha)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			assert.Empty(t, r.URL.Query().Get("ref_name"))
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprintf(w, "[%s]", commitJSON("c"))
+		case "2":
+			assert.Equal(t, "c", r.URL.Query().Get("ref_name"), "resumed pages list the first page's head")
+			fmt.Fprintf(w, "[%s]", commitJSON("b"))
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+	client, server := setupTestClient(t, handler)
+	defer server.Close()
+
+	var cursor string
+	interrupted := errors.New("interrupted")
+	err := client.ForEachCommitPageFrom(context.Background(), "group", "project", time.Time{}, "", func(page []model.Commit, next string) error {
+		cursor = next
+		return interrupted
+	})
+	require.ErrorIs(t, err, interrupted)
+
+	var commits []model.Commit
+	err = client.ForEachCommitPageFrom(context.Background(), "group", "project", time.Time{}, cursor, func(page []model.Commit, next string) error {
+		commits = append(commits, page...)
+		assert.Empty(t, next)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "b", commits[0].SHA)
+
+	t.Run("rejects cursors of other projects", func(t *testing.T) {
+		err := client.ForEachCommitPageFrom(context.Background(), "group", "other", time.Time{}, cursor, func(page []model.Commit, next string) error {
+			return nil
+		})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	t.Run("retries after 429 using Retry-After", func(t *testing.T) {
+		var requestCount int32
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requestCount, 1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprintln(w, `{"message": "429 Too Many Requests"}`)
+				return
+			}
+			fmt.Fprintln(w, `{"id": 1, "path": "project", "namespace": {"full_path": "group"}}`)
+		})
+		client, server := setupTestClient(t, handler)
+		defer server.Close()
+
+		_, err := client.GetRepository(context.Background(), "group", "project")
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requestCount))
+	})
+
+	t.Run("waits for the reset when no requests remain", func(t *testing.T) {
+		reset := time.Now().Truncate(time.Second).Add(time.Second)
+		var requestTimes []time.Time
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestTimes = append(requestTimes, time.Now())
+			w.Header().Set("RateLimit-Limit", "10")
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			fmt.Fprintln(w, `{"id": 1, "path": "project", "namespace": {"full_path": "group"}}`)
+		})
+		client, server := setupTestClient(t, handler)
+		defer server.Close()
+
+		_, err := client.GetRepository(context.Background(), "group", "project")
+		require.NoError(t, err)
+		_, err = client.GetRepository(context.Background(), "group", "project")
+		require.NoError(t, err)
+
+		require.Len(t, requestTimes, 2)
+		assert.False(t, requestTimes[1].Before(reset), "second request must wait for the reset")
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var requestCount int32
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requestCount, 1)
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"message": "404 Project Not Found"}`)
+		})
+		client, server := setupTestClient(t, handler)
+		defer server.Close()
+
+		_, err := client.GetRepository(context.Background(), "group", "missing")
+
+		var errResp *ErrorResponse
+		require.ErrorAs(t, err, &errResp)
+		assert.Equal(t, http.StatusNotFound, errResp.StatusCode)
+		assert.Equal(t, "404 Project Not Found", errResp.Message)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requestCount))
+	})
+}
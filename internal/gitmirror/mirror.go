@@ -0,0 +1,434 @@
+// internal/gitmirror/mirror.go
+package gitmirror
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-data-fetcher/internal/model"
+)
+
+const (
+	recordSep = "\x1e"
+	fieldSep  = "\x1f"
+
+	// logFormat prints one record per commit. The message comes last but one because it may
+	// span several lines; the --numstat lines git appends to each commit follow it.
+	logFormat = recordSep + "%H" + fieldSep + "%P" + fieldSep + "%an" + fieldSep + "%ae" + fieldSep + "%aI" +
+		fieldSep + "%cn" + fieldSep + "%ce" + fieldSep + "%cI" + fieldSep + "%B" + fieldSep
+	logFields = 10
+
+	// pageSize is the number of commits passed to ForEachCommitPage callbacks at a time.
+	pageSize = 100
+	// maxRecordSize bounds the log output of a single commit, including its numstat lines.
+	maxRecordSize = 64 << 20
+)
+
+// Mirror keeps bare mirrors of remote repositories on disk and lists their commits with
+// git log, so commit history can be ingested without spending API quota. It requires the
+// git binary.
+type Mirror struct {
+	dir       string
+	remoteURL string
+	token     string
+	logger    *slog.Logger
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // per mirror path; git does not allow concurrent fetches
+}
+
+// Option configures optional behaviour of a Mirror.
+type Option func(*Mirror)
+
+// WithToken authenticates HTTPS fetches with token, e.g. a GitHub personal access token.
+// The token is passed to git through its environment, never on the command line.
+func WithToken(token string) Option {
+	return func(m *Mirror) {
+		m.token = token
+	}
+}
+
+// New creates a Mirror that clones owner/name from remoteURL + "owner/name.git" into
+// dir/owner/name.git. remoteURL is e.g. "https://github.com/" or, for tests, a file:// URL.
+func New(dir, remoteURL string, logger *slog.Logger, opts ...Option) *Mirror {
+	if !strings.HasSuffix(remoteURL, "/") {
+		remoteURL += "/"
+	}
+	m := &Mirror{
+		dir:       dir,
+		remoteURL: remoteURL,
+		logger:    logger,
+		locks:     make(map[string]*sync.Mutex),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// GetCommits updates the mirror and returns every commit reachable from the default branch
+// committed after since, newest first.
+func (m *Mirror) GetCommits(ctx context.Context, owner, name string, since time.Time) ([]model.Commit, error) {
+	return m.GetCommitsSinceSHA(ctx, owner, name, "", since)
+}
+
+// GetCommitsSinceSHA updates the mirror and returns the commits reachable from the default
+// branch but not from sha, newest first. If sha is empty or unknown to the mirror, it falls
+// back to the commits committed after since.
+func (m *Mirror) GetCommitsSinceSHA(ctx context.Context, owner, name, sha string, since time.Time) ([]model.Commit, error) {
+	var allCommits []model.Commit
+	err := m.ForEachCommitPageSinceSHA(ctx, owner, name, sha, since, func(page []model.Commit) error {
+		allCommits = append(allCommits, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allCommits, nil
+}
+
+// ForEachCommitPage is the streaming form of GetCommits: fn is called with pages of up to
+// pageSize commits as git log produces them. It stops at the first error returned by fn.
+func (m *Mirror) ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error {
+	return m.ForEachCommitPageSinceSHA(ctx, owner, name, "", since, fn)
+}
+
+// ForEachCommitPageSinceSHA is the streaming form of GetCommitsSinceSHA.
+func (m *Mirror) ForEachCommitPageSinceSHA(ctx context.Context, owner, name, sha string, since time.Time, fn func(page []model.Commit) error) error {
+	return m.forEachLogPage(ctx, owner, name, func(path string) ([]string, error) {
+		if sha != "" && m.hasCommit(ctx, path, sha) {
+			return []string{sha + "..HEAD"}, nil
+		}
+		if sha != "" {
+			m.logger.Warn("Last stored commit not found in mirror, falling back to date", "path", path, "sha", sha)
+		}
+		var args []string
+		if !since.IsZero() {
+			args = append(args, "--since="+since.UTC().Format(time.RFC3339))
+		}
+		return append(args, "HEAD"), nil
+	}, fn)
+}
+
+// ForEachCommitPageFrom is like ForEachCommitPage, but fn also receives a cursor from which a later
+// call resumes the listing after that page. An empty cursor starts at the newest commit. Cursors
+// name the head commit the listing started from, so commits fetched in the meantime do not
+// shift a resumed listing.
+func (m *Mirror) ForEachCommitPageFrom(ctx context.Context, owner, name string, since time.Time, cursor string, fn func(page []model.Commit, next string) error) error {
+	head, pos, _ := strings.Cut(cursor, " ")
+	skip := 0
+	if pos != "" {
+		var err error
+		if skip, err = strconv.Atoi(pos); err != nil {
+			return fmt.Errorf("invalid commit cursor %q: %w", cursor, err)
+		}
+	}
+
+	return m.forEachLogPage(ctx, owner, name, func(path string) ([]string, error) {
+		if head == "" {
+			out, err := m.git(ctx, path, "rev-parse", "HEAD^{commit}")
+			if err != nil {
+				return nil, err
+			}
+			head = strings.TrimSpace(string(out))
+		} else if !m.hasCommit(ctx, path, head) {
+			return nil, fmt.Errorf("commit %s of cursor not found in mirror %s", head, path)
+		}
+		args := []string{"--skip=" + strconv.Itoa(skip)}
+		if !since.IsZero() {
+			args = append(args, "--since="+since.UTC().Format(time.RFC3339))
+		}
+		return append(args, head), nil
+	}, func(page []model.Commit) error {
+		skip += len(page)
+		return fn(page, head+" "+strconv.Itoa(skip))
+	})
+}
+
+// forEachLogPage updates the mirror of owner/name and streams the git log of the revisions
+// returned by revs to fn in pages. Mirrors without commits produce no pages.
+func (m *Mirror) forEachLogPage(ctx context.Context, owner, name string, revs func(path string) ([]string, error), fn func(page []model.Commit) error) error {
+	path := m.path(owner, name)
+	lock := m.lock(path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := m.update(ctx, path, owner, name); err != nil {
+		return err
+	}
+
+	if _, err := m.git(ctx, path, "rev-parse", "--verify", "--quiet", "HEAD^{commit}"); err != nil {
+		m.logger.Info("Mirror has no commits on the default branch", "path", path)
+		return nil
+	}
+
+	r, err := revs(path)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"-c", "core.quotePath=off", "log", "--format=" + logFormat, "--numstat", "--no-renames"}, r...)
+
+	urlPrefix := m.commitURLPrefix(owner, name)
+	return m.gitStream(ctx, path, args, func(stdout io.Reader) error {
+		return parseLog(stdout, urlPrefix, pageSize, fn)
+	})
+}
+
+// path returns where the mirror of owner/name lives. GitLab owners may contain slashes,
+// which become nested directories.
+func (m *Mirror) path(owner, name string) string {
+	return filepath.Join(m.dir, filepath.FromSlash(owner), name+".git")
+}
+
+func (m *Mirror) lock(path string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.locks[path]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[path] = l
+	}
+	return l
+}
+
+// update clones the mirror on first use and fetches new objects afterwards. Clones go to a
+// temporary directory first so an interrupted clone is never mistaken for a mirror.
+func (m *Mirror) update(ctx context.Context, path, owner, name string) error {
+	if _, err := os.Stat(path); err == nil {
+		m.logger.Debug("Fetching mirror", "path", path)
+		_, err := m.git(ctx, path, "fetch", "--prune", "--quiet", "origin")
+		return err
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	remote := m.remoteURL + owner + "/" + name + ".git"
+	m.logger.Info("Cloning mirror", "remote", remote, "path", path)
+	if _, err := m.git(ctx, "", "clone", "--mirror", "--quiet", remote, tmp); err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (m *Mirror) hasCommit(ctx context.Context, path, sha string) bool {
+	_, err := m.git(ctx, path, "cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// commitURLPrefix returns the web URL commits are linked to, or "" for non-HTTP remotes.
+func (m *Mirror) commitURLPrefix(owner, name string) string {
+	if !strings.HasPrefix(m.remoteURL, "http://") && !strings.HasPrefix(m.remoteURL, "https://") {
+		return ""
+	}
+	return m.remoteURL + owner + "/" + name + "/commit/"
+}
+
+// git runs git in dir and returns its standard output.
+func (m *Mirror) git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := m.gitStream(ctx, dir, args, func(r io.Reader) error {
+		_, err := stdout.ReadFrom(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// gitStream runs git in dir, passing its standard output to consume as it is produced. If
+// consume fails, git is killed and consume's error is returned.
+func (m *Mirror) gitStream(ctx context.Context, dir string, args []string, consume func(stdout io.Reader) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if m.token != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + m.token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := consume(stdout); err != nil {
+		cancel()
+		_ = cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("git %s: %w: %s", gitSubcommand(args), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// gitSubcommand returns the first argument that is not a global option, for error messages.
+func gitSubcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
+
+// parseLog parses git log output produced with logFormat and --numstat, calling fn with
+// pages of up to pageSize commits.
+func parseLog(r io.Reader, urlPrefix string, pageSize int, fn func(page []model.Commit) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	scanner.Split(splitRecords)
+
+	page := make([]model.Commit, 0, pageSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue // the output starts with a separator
+		}
+		c, err := parseRecord(scanner.Text(), urlPrefix)
+		if err != nil {
+			return err
+		}
+		page = append(page, c)
+		if len(page) == pageSize {
+			if err := fn(page); err != nil {
+				return err
+			}
+			page = make([]model.Commit, 0, pageSize)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(page) > 0 {
+		return fn(page)
+	}
+	return nil
+}
+
+// splitRecords is a bufio.SplitFunc splitting on recordSep.
+func splitRecords(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, recordSep[0]); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// parseRecord parses the logFormat record of a single commit.
+func parseRecord(record, urlPrefix string) (model.Commit, error) {
+	fields := strings.SplitN(record, fieldSep, logFields)
+	if len(fields) != logFields {
+		return model.Commit{}, fmt.Errorf("malformed git log record %q", record)
+	}
+
+	authorDate, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return model.Commit{}, err
+	}
+	committerDate, err := time.Parse(time.RFC3339, fields[7])
+	if err != nil {
+		return model.Commit{}, err
+	}
+
+	c := model.Commit{
+		SHA:            fields[0],
+		Parents:        strings.Fields(fields[1]),
+		AuthorName:     fields[2],
+		AuthorEmail:    fields[3],
+		CommitDate:     authorDate,
+		CommitterName:  fields[5],
+		CommitterEmail: fields[6],
+		CommitterDate:  committerDate,
+		Message:        strings.TrimRight(fields[8], "\n"),
+	}
+	if urlPrefix != "" {
+		c.URL = urlPrefix + c.SHA
+	}
+
+	files, err := parseNumstat(fields[9])
+	if err != nil {
+		return model.Commit{}, err
+	}
+	// git log shows no diff for merge commits, so their stats are unknown rather than zero.
+	if len(c.Parents) <= 1 {
+		c.Files = files
+		var additions, deletions int
+		for _, f := range files {
+			additions += f.Additions
+			deletions += f.Deletions
+		}
+		c.Additions = &additions
+		c.Deletions = &deletions
+	}
+	return c, nil
+}
+
+// parseNumstat parses "added<TAB>deleted<TAB>path" lines. Binary files report "-" counts.
+func parseNumstat(s string) ([]model.CommitFile, error) {
+	var files []model.CommitFile
+	for _, line := range strings.Split(s, "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("malformed numstat line %q", line)
+		}
+		f := model.CommitFile{Filename: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			f.Binary = true
+		} else {
+			var err error
+			if f.Additions, err = strconv.Atoi(parts[0]); err != nil {
+				return nil, err
+			}
+			if f.Deletions, err = strconv.Atoi(parts[1]); err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
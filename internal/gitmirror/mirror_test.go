@@ -0,0 +1,235 @@
+// internal/gitmirror/mirror_test.go
+package gitmirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github-data-fetcher/internal/model"
+)
+
+// upstream is a local repository served to the mirror through a file:// URL.
+type upstream struct {
+	t    *testing.T
+	root string // remote base directory
+	dir  string // root/owner/name.git, a non-bare repository
+	date time.Time
+}
+
+func newUpstream(t *testing.T, owner, name string) *upstream {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+	root := t.TempDir()
+	u := &upstream{t: t, root: root, dir: filepath.Join(root, owner, name+".git"), date: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	require.NoError(t, os.MkdirAll(u.dir, 0o755))
+	u.git("init", "--quiet", "--initial-branch=main")
+	return u
+}
+
+func (u *upstream) git(args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = u.dir
+	date := u.date.Format(time.RFC3339)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com", "GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME=CI Bot", "GIT_COMMITTER_EMAIL=ci@example.com", "GIT_COMMITTER_DATE="+date,
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(u.t, err, string(out))
+	return string(out)
+}
+
+// commit writes files and commits them one hour after the previous commit, returning the SHA.
+func (u *upstream) commit(message string, files map[string]string) string {
+	u.date = u.date.Add(time.Hour)
+	for path, content := range files {
+		require.NoError(u.t, os.WriteFile(filepath.Join(u.dir, path), []byte(content), 0o644))
+		u.git("add", path)
+	}
+	u.git("commit", "--quiet", "--allow-empty", "-m", message)
+	return u.head()
+}
+
+func (u *upstream) head() string {
+	out := u.git("rev-parse", "HEAD")
+	return out[:40]
+}
+
+func newMirror(t *testing.T, u *upstream) *Mirror {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return New(filepath.Join(t.TempDir(), "mirrors"), "file://"+u.root, logger)
+}
+
+func TestMirror_GetCommits(t *testing.T) {
+	ctx := context.Background()
+	u := newUpstream(t, "octo-org", "hello-world")
+	first := u.commit("initial commit", map[string]string{"README.md": "hello\n", "logo.bin": "\x00\x01\x02"})
+	second := u.commit("feat: add greeting\n\nWith a body.", map[string]string{"README.md": "hello\nworld\n", "main.go": "package main\n"})
+	m := newMirror(t, u)
+
+	commits, err := m.GetCommits(ctx, "octo-org", "hello-world", time.Time{})
+
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	c := commits[0]
+	assert.Equal(t, second, c.SHA, "newest commit first")
+	assert.Equal(t, []string{first}, c.Parents)
+	assert.Equal(t, "feat: add greeting\n\nWith a body.", c.Message)
+	assert.Equal(t, "Jane Doe", c.AuthorName)
+	assert.Equal(t, "jane@example.com", c.AuthorEmail)
+	assert.Equal(t, "CI Bot", c.CommitterName)
+	assert.Equal(t, "ci@example.com", c.CommitterEmail)
+	assert.True(t, c.CommitDate.Equal(time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)))
+	assert.True(t, c.CommitterDate.Equal(c.CommitDate))
+	assert.Equal(t, 2, *c.Additions)
+	assert.Equal(t, 0, *c.Deletions)
+	assert.Len(t, c.Files, 2)
+	assert.Empty(t, c.URL, "file:// remotes have no web URL")
+
+	assert.Empty(t, commits[1].Parents)
+	assert.Contains(t, commits[1].Files, model.CommitFile{Filename: "logo.bin", Binary: true})
+
+	t.Run("lists only commits after a known SHA", func(t *testing.T) {
+		third := u.commit("fix: typo", map[string]string{"README.md": "hello\nWorld\n"})
+
+		commits, err := m.GetCommitsSinceSHA(ctx, "octo-org", "hello-world", second, time.Time{})
+
+		require.NoError(t, err)
+		require.Len(t, commits, 1)
+		assert.Equal(t, third, commits[0].SHA)
+		assert.Equal(t, []string{second}, commits[0].Parents)
+		assert.Equal(t, 1, *commits[0].Additions)
+		assert.Equal(t, 1, *commits[0].Deletions)
+	})
+
+	t.Run("falls back to the date for unknown SHAs", func(t *testing.T) {
+		commits, err := m.GetCommitsSinceSHA(ctx, "octo-org", "hello-world", "0123456789abcdef0123456789abcdef01234567", time.Date(2024, 1, 1, 14, 30, 0, 0, time.UTC))
+
+		require.NoError(t, err)
+		require.Len(t, commits, 1)
+		assert.Equal(t, "fix: typo", commits[0].Message)
+	})
+}
+
+func TestMirror_ForEachCommitPageFrom(t *testing.T) {
+	ctx := context.Background()
+	u := newUpstream(t, "octo-org", "hello-world")
+	first := u.commit("initial commit", nil)
+	u.commit("second commit", nil)
+	m := newMirror(t, u)
+
+	var cursors []string
+	err := m.ForEachCommitPageFrom(ctx, "octo-org", "hello-world", time.Time{}, "", func(page []model.Commit, next string) error {
+		cursors = append(cursors, next)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, cursors, 1)
+
+	// Resuming after the first commit skips it even though the branch has moved on.
+	head, _, _ := strings.Cut(cursors[0], " ")
+	u.commit("pushed meanwhile", nil)
+	var commits []model.Commit
+	err = m.ForEachCommitPageFrom(ctx, "octo-org", "hello-world", time.Time{}, head+" 1", func(page []model.Commit, next string) error {
+		commits = append(commits, page...)
+		assert.Equal(t, head+" 2", next)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, first, commits[0].SHA)
+}
+
+func TestMirror_MergeCommits(t *testing.T) {
+	ctx := context.Background()
+	u := newUpstream(t, "octo-org", "hello-world")
+	u.commit("initial commit", map[string]string{"a.txt": "a\n"})
+	u.git("checkout", "--quiet", "-b", "feature")
+	feature := u.commit("feature work", map[string]string{"b.txt": "b\n"})
+	u.git("checkout", "--quiet", "main")
+	mainline := u.commit("mainline work", map[string]string{"c.txt": "c\n"})
+	u.date = u.date.Add(time.Hour)
+	u.git("merge", "--quiet", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+	m := newMirror(t, u)
+
+	commits, err := m.GetCommits(ctx, "octo-org", "hello-world", time.Time{})
+
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	merge := commits[0]
+	assert.Equal(t, []string{mainline, feature}, merge.Parents)
+	assert.Nil(t, merge.Additions, "git log reports no stats for merges")
+	assert.Nil(t, merge.Files)
+}
+
+func TestMirror_EmptyRepository(t *testing.T) {
+	u := newUpstream(t, "octo-org", "empty")
+	m := newMirror(t, u)
+
+	commits, err := m.GetCommits(context.Background(), "octo-org", "empty", time.Time{})
+
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestMirror_MissingRemote(t *testing.T) {
+	u := newUpstream(t, "octo-org", "hello-world")
+	m := newMirror(t, u)
+
+	_, err := m.GetCommits(context.Background(), "octo-org", "missing", time.Time{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git clone")
+	_, statErr := os.Stat(filepath.Join(m.dir, "octo-org", "missing.git.tmp"))
+	assert.True(t, os.IsNotExist(statErr), "failed clones are cleaned up")
+}
+
+func TestParseLog(t *testing.T) {
+	record := func(sha string) string {
+		return fmt.Sprintf("%[1]s%[3]s%[2]s%[2]sMona%[2]smona@example.com%[2]s2024-03-01T10:00:00+01:00%[2]sMona%[2]smona@example.com%[2]s2024-03-01T10:05:00+01:00%[2]sInitial\n%[2]s\n\n3\t0\tdir with space/ü.txt\n", recordSep, fieldSep, sha)
+	}
+	out := record("abc") + record("def") + record("123")
+
+	t.Run("parses records into pages", func(t *testing.T) {
+		var pages [][]model.Commit
+		err := parseLog(strings.NewReader(out), "https://github.com/octo-org/hello-world/commit/", 2, func(page []model.Commit) error {
+			pages = append(pages, page)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, pages, 2)
+		assert.Len(t, pages[0], 2)
+		assert.Len(t, pages[1], 1)
+		c := pages[0][0]
+		assert.Equal(t, "https://github.com/octo-org/hello-world/commit/abc", c.URL)
+		assert.Equal(t, "Initial", c.Message)
+		assert.Equal(t, "dir with space/ü.txt", c.Files[0].Filename)
+		assert.Equal(t, 3, *c.Additions)
+	})
+
+	t.Run("stops at the first callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := parseLog(strings.NewReader(out), "", 1, func(page []model.Commit) error {
+			calls++
+			return stop
+		})
+
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -6,10 +6,18 @@ import (
 	"time"
 )
 
-// Repository represents the metadata of a GitHub repository.
+// Code hosting providers repositories can be synced from.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Repository represents the metadata of a GitHub or GitLab repository.
 type Repository struct {
 	ID              int64
-	GithubRepoID    int64 `json:"github_repo_id"`
+	GithubRepoID    int64 `json:"github_repo_id"` // The provider's numeric ID; the project ID on GitLab.
+	Provider        string
+	Host            string
 	Owner           string
 	Name            string
 	Description     *string
@@ -19,6 +27,9 @@ type Repository struct {
 	StarsCount      int
 	OpenIssuesCount int
 	WatchersCount   int
+	Archived        bool // Archived, Fork and Private are not stored; they filter discovered repositories.
+	Fork            bool
+	Private         bool
 	RepoCreatedAt   time.Time
 	RepoUpdatedAt   time.Time
 	LastSyncedAt    sql.NullTime
@@ -27,12 +38,187 @@ type Repository struct {
 }
 
 type Commit struct {
-	SHA          string
-	RepositoryID int64
-	AuthorName   string
-	AuthorEmail  string
-	Message      string
-	URL          string
-	CommitDate   time.Time
-	DBCreatedAt  time.Time
+	SHA            string
+	RepositoryID   int64
+	Parents        []string
+	AuthorName     string
+	AuthorEmail    string
+	AuthorLogin    string // GitHub user the author email is linked to, if any.
+	CommitterName  string
+	CommitterEmail string
+	CommitterDate  time.Time // Zero unless the backend reports it.
+	Message        string
+	URL            string
+	CommitDate     time.Time
+	Additions      *int // nil unless the backend reports commit stats.
+	Deletions      *int
+	Files          []CommitFile // Only reported by the git mirror backend.
+	Verified       bool         // Whether the commit signature was verified by GitHub.
+	DBCreatedAt    time.Time
+}
+
+// CommitFile holds the line stats of a file changed by a commit.
+type CommitFile struct {
+	Filename  string
+	Status    string // added, removed, modified, renamed, ...; empty unless reported by GitHub.
+	Additions int
+	Deletions int
+	Binary    bool // Binary files have no line stats.
+}
+
+// Branch is a branch of a repository and the commit it points to.
+type Branch struct {
+	Name    string
+	HeadSHA string
+}
+
+// States of a pull request. Closed pull requests were closed without being merged.
+const (
+	PullRequestOpen   = "open"
+	PullRequestClosed = "closed"
+	PullRequestMerged = "merged"
+)
+
+// PullRequest is a pull request and its submitted reviews. Listings leave Additions, Deletions,
+// MergedByLogin and Reviews empty.
+type PullRequest struct {
+	Number        int
+	GithubID      int64 // The provider's numeric ID.
+	Title         string
+	State         string // PullRequestOpen, PullRequestClosed or PullRequestMerged.
+	AuthorLogin   string
+	BaseRef       string
+	HeadRef       string
+	Additions     int
+	Deletions     int
+	MergedByLogin string
+	URL           string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	MergedAt      time.Time // Zero unless merged.
+	ClosedAt      time.Time // Zero while open.
+	Reviews       []PullRequestReview
+}
+
+// PullRequestReview is a submitted review of a pull request.
+type PullRequestReview struct {
+	ID            int64
+	ReviewerLogin string
+	State         string // APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED.
+	CommitSHA     string // The head commit the review was submitted for.
+	SubmittedAt   time.Time
+}
+
+// Issue is an issue. Pull requests, which GitHub also lists as issues, are not issues here.
+type Issue struct {
+	Number        int
+	GithubID      int64 // The provider's numeric ID.
+	Title         string
+	State         string // open or closed.
+	AuthorLogin   string
+	Labels        []string // Label names.
+	Assignees     []string // Assignee logins.
+	Milestone     string   // The milestone's title, if any.
+	CommentsCount int
+	URL           string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ClosedAt      time.Time // Zero while open.
+}
+
+// IssueComment is a comment on an issue.
+type IssueComment struct {
+	ID          int64
+	AuthorLogin string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Label is a label defined in a repository.
+type Label struct {
+	Name        string
+	Color       string // A hex color code without the leading #.
+	Description string
+}
+
+// Tag is a tag of a repository and the commit it points to.
+type Tag struct {
+	Name string
+	SHA  string
+}
+
+// Release is a release of a repository and its assets.
+type Release struct {
+	GithubID    int64 // The provider's numeric ID.
+	TagName     string
+	Name        string
+	Draft       bool
+	Prerelease  bool
+	AuthorLogin string
+	URL         string
+	CreatedAt   time.Time
+	PublishedAt time.Time // Zero for drafts.
+	Assets      []ReleaseAsset
+}
+
+// ReleaseAsset is a file attached to a release.
+type ReleaseAsset struct {
+	ID            int64
+	Name          string
+	Size          int64
+	DownloadCount int64
+}
+
+// Workflow is a GitHub Actions workflow of a repository.
+type Workflow struct {
+	ID    int64
+	Name  string
+	Path  string // e.g. .github/workflows/ci.yml
+	State string // active, disabled_manually, ...
+}
+
+// States of a workflow run. Runs waiting, pending or requested are reported as queued.
+const (
+	WorkflowRunQueued     = "queued"
+	WorkflowRunInProgress = "in_progress"
+	WorkflowRunCompleted  = "completed"
+)
+
+// WorkflowRun is the latest attempt of a workflow run.
+type WorkflowRun struct {
+	ID         int64
+	WorkflowID int64
+	Name       string
+	Event      string // What triggered the run, e.g. push or pull_request.
+	Status     string
+	Conclusion string // success, failure, cancelled, ...; empty until the run completes.
+	HeadSHA    string
+	HeadBranch string
+	RunNumber  int
+	RunAttempt int
+	CreatedAt  time.Time
+	StartedAt  time.Time // When the latest attempt started.
+	UpdatedAt  time.Time
+}
+
+// Stargazer is a user who starred a repository.
+type Stargazer struct {
+	Login     string
+	StarredAt time.Time
+}
+
+// HeadComparison describes how a branch got from one head commit to another.
+type HeadComparison struct {
+	// Orphaned lists the commits reachable from the old head but not from the new one, i.e.
+	// those a force-push removed from the branch. It is empty if the branch only moved forward.
+	Orphaned  []string
+	MergeBase string // The newest commit reachable from both heads, if known.
+	// OldHeadMissing reports that the old head no longer exists or shares no history with the
+	// new one, so the branch was rewritten but Orphaned is unknown.
+	OldHeadMissing bool
+}
+
+// Rewritten reports whether the old head is no longer an ancestor of the new one.
+func (c *HeadComparison) Rewritten() bool {
+	return c.OldHeadMissing || len(c.Orphaned) > 0
 }
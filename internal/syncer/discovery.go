@@ -0,0 +1,169 @@
+// internal/syncer/discovery.go
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	custom_errors "github-data-fetcher/internal/errors"
+	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/model"
+)
+
+// Prefixes of REPOS_TO_SYNC entries that stand for every repository of an owner.
+const (
+	orgPrefix  = "org:"
+	userPrefix = "user:"
+)
+
+// DiscoveryFilter selects which of the repositories found through org:, user: and wildcard
+// entries are synced. Repositories listed by name are synced regardless.
+type DiscoveryFilter struct {
+	Archived bool // include archived repositories
+	Forks    bool // include forks
+	Private  bool // include private and internal repositories
+}
+
+// WithDiscoveryFilter replaces the default filter, which skips archived repositories and forks.
+func WithDiscoveryFilter(filter DiscoveryFilter) Option {
+	return func(s *Syncer) {
+		s.discoveryFilter = filter
+	}
+}
+
+// discoveryRule is a REPOS_TO_SYNC entry that is expanded every cycle by listing the repositories
+// of an owner on a GitHub host.
+type discoveryRule struct {
+	entry   string // as configured, without the github.com host
+	host    string
+	owner   string
+	user    bool   // owner was given as a user, so it is not looked up as an organization
+	pattern string // path.Match pattern the names of the repositories must match
+}
+
+// matches reports whether the repository is one the rule may discover, whether or not it passes
+// the filter.
+func (r discoveryRule) matches(id RepoIdentifier) bool {
+	if id.Provider != model.ProviderGitHub || id.Host != r.host || !strings.EqualFold(id.Owner, r.owner) {
+		return false
+	}
+	ok, _ := path.Match(r.pattern, id.Name)
+	return ok
+}
+
+// parseDiscoveryRules splits the entries of repos that are expanded by listing repositories off
+// the rest: 'org:owner' and 'user:owner', or 'org:host/owner' for GitHub Enterprise hosts, and
+// entries with a path.Match pattern as repository name, e.g. 'owner/service-*'.
+func parseDiscoveryRules(repos []string) (rules []discoveryRule, rest []string, err error) {
+	for _, r := range repos {
+		var prefix, owner string
+		if o, ok := strings.CutPrefix(r, orgPrefix); ok {
+			prefix, owner = orgPrefix, o
+		} else if o, ok := strings.CutPrefix(r, userPrefix); ok {
+			prefix, owner = userPrefix, o
+		} else if strings.ContainsAny(r, "*?[") {
+			rule, err := parseWildcard(r)
+			if err != nil {
+				return nil, nil, err
+			}
+			rules = append(rules, rule)
+			continue
+		} else {
+			rest = append(rest, r)
+			continue
+		}
+
+		host := github.DefaultHost
+		if h, o, ok := strings.Cut(owner, "/"); ok {
+			host, owner = h, o
+		}
+		if host == "" || owner == "" || strings.Contains(owner, "/") {
+			return nil, nil, &custom_errors.ErrInvalidRepoFormat{Repo: r}
+		}
+		entry := prefix + owner
+		if host != github.DefaultHost {
+			entry = prefix + host + "/" + owner
+		}
+		rules = append(rules, discoveryRule{entry: entry, host: host, owner: owner, user: prefix == userPrefix, pattern: "*"})
+	}
+	return rules, rest, nil
+}
+
+// parseWildcard parses an 'owner/pattern' or 'host/owner/pattern' entry.
+func parseWildcard(entry string) (discoveryRule, error) {
+	if strings.HasPrefix(entry, gitlabPrefix) {
+		return discoveryRule{}, fmt.Errorf("wildcard entry %q: repositories can only be discovered on GitHub hosts", entry)
+	}
+	ids, err := parseRepoIdentifiers([]string{entry})
+	if err != nil {
+		return discoveryRule{}, err
+	}
+	id := ids[0]
+	if strings.ContainsAny(id.Host+id.Owner, "*?[") {
+		return discoveryRule{}, fmt.Errorf("wildcard entry %q: only the repository name may contain a pattern", entry)
+	}
+	if _, err := path.Match(id.Name, ""); err != nil {
+		return discoveryRule{}, fmt.Errorf("wildcard entry %q: %w", entry, err)
+	}
+	return discoveryRule{entry: id.String(), host: id.Host, owner: id.Owner, pattern: id.Name}, nil
+}
+
+// discoverRepos lists the repositories the discovery rules stand for, leaving out those that are
+// also listed by name, paused or do not pass the filter, and stores them in s.discovered. A rule whose
+// listing fails keeps the repositories it discovered in the previous cycle.
+func (s *Syncer) discoverRepos(ctx context.Context) {
+	seen := make(map[string]bool, len(s.reposToSync)+len(s.paused))
+	for _, id := range s.reposToSync {
+		seen[strings.ToLower(id.String())] = true
+	}
+	for key := range s.paused {
+		seen[key] = true
+	}
+	var discovered []RepoIdentifier
+	add := func(id RepoIdentifier) {
+		if key := strings.ToLower(id.String()); !seen[key] {
+			seen[key] = true
+			discovered = append(discovered, id)
+		}
+	}
+
+	for _, rule := range s.discovery {
+		ids, err := s.discover(ctx, rule)
+		if err != nil {
+			s.logger.Error("Failed to discover repositories, syncing those discovered before", "entry", rule.entry, "error", err)
+			for _, id := range s.discovered {
+				if rule.matches(id) {
+					add(id)
+				}
+			}
+			continue
+		}
+		for _, id := range ids {
+			add(id)
+		}
+	}
+	s.mu.Lock()
+	s.discovered = discovered
+	s.mu.Unlock()
+}
+
+// discover returns the repositories of the rule's owner that match it and pass the filter.
+func (s *Syncer) discover(ctx context.Context, rule discoveryRule) ([]RepoIdentifier, error) {
+	repos, err := s.sources[rule.host].(repositoryLister).ListOwnerRepositories(ctx, rule.owner, rule.user)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []RepoIdentifier
+	for _, repo := range repos {
+		id := RepoIdentifier{Provider: model.ProviderGitHub, Host: rule.host, Owner: repo.Owner, Name: repo.Name}
+		if !rule.matches(id) || (repo.Archived && !s.discoveryFilter.Archived) || (repo.Fork && !s.discoveryFilter.Forks) || (repo.Private && !s.discoveryFilter.Private) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	s.logger.Info("Discovered repositories", "entry", rule.entry, "listed", len(repos), "synced", len(ids))
+	return ids, nil
+}
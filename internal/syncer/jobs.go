@@ -0,0 +1,230 @@
+// internal/syncer/jobs.go
+package syncer
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github-data-fetcher/internal/database"
+	custom_errors "github-data-fetcher/internal/errors"
+)
+
+// States of a sync job, as stored in sync_jobs.state.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// jobQueueSize is how many on-demand syncs may wait for a worker before new ones are refused.
+const jobQueueSize = 100
+
+// queuedJob is an on-demand sync waiting for a worker.
+type queuedJob struct {
+	id   int64
+	repo RepoIdentifier
+}
+
+// repoLocks keeps a repository from being synced by a cycle and a job at the same time. The
+// zero value is ready to use.
+type repoLocks struct {
+	mu   sync.Mutex
+	held map[string]chan struct{} // closed when the lock is released
+}
+
+// tryLock locks the repository if it is not locked and reports whether it did.
+func (l *repoLocks) tryLock(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.held[key]; ok {
+		return false
+	}
+	if l.held == nil {
+		l.held = make(map[string]chan struct{})
+	}
+	l.held[key] = make(chan struct{})
+	return true
+}
+
+// lock waits until the repository is unlocked and locks it, or returns ctx's error.
+func (l *repoLocks) lock(ctx context.Context, key string) error {
+	for {
+		if l.tryLock(key) {
+			return nil
+		}
+		l.mu.Lock()
+		released, ok := l.held[key]
+		l.mu.Unlock()
+		if !ok {
+			continue
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *repoLocks) unlock(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	close(l.held[key])
+	delete(l.held, key)
+}
+
+// EnqueueSync queues an immediate sync of a tracked repository, given as a REPOS_TO_SYNC entry
+// naming it, and returns the job recording its progress. If a sync of the repository is queued
+// already, that job is returned instead. Repositories neither tracked by name nor matched by an
+// org:, user: or wildcard entry are refused with ErrNotTracked, those paused by name with
+// ErrPaused. The job waits while the sync cycle is syncing the repository.
+func (s *Syncer) EnqueueSync(ctx context.Context, entry string) (database.SyncJob, error) {
+	ids, rules, err := s.parseEntries([]string{entry})
+	if err != nil {
+		return database.SyncJob{}, err
+	}
+	if len(rules) > 0 {
+		return database.SyncJob{}, &custom_errors.ErrInvalidRepoFormat{Repo: entry}
+	}
+	id, err := s.trackedRepo(ctx, ids[0])
+	if err != nil {
+		return database.SyncJob{}, err
+	}
+
+	key := strings.ToLower(id.String())
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	if job, ok := s.queued[key]; ok {
+		return job, nil
+	}
+	// Only EnqueueSync sends on the channel, so the send below cannot block.
+	if len(s.jobs) == cap(s.jobs) {
+		return database.SyncJob{}, custom_errors.ErrSyncQueueFull
+	}
+
+	var job database.SyncJob
+	err = s.withTx(ctx, func(q database.Store) error {
+		var err error
+		job, err = q.CreateSyncJob(ctx, database.CreateSyncJobParams{
+			Provider: id.Provider,
+			Host:     id.Host,
+			Owner:    id.Owner,
+			Name:     id.Name,
+		})
+		return err
+	})
+	if err != nil {
+		return database.SyncJob{}, err
+	}
+	if s.queued == nil {
+		s.queued = make(map[string]database.SyncJob)
+	}
+	s.queued[key] = job
+	s.jobs <- queuedJob{id: job.ID, repo: id}
+	return job, nil
+}
+
+// trackedRepo returns the repository as it is tracked, which may differ from id in case, if it is
+// tracked by name or matched by a discovery rule. The tracked entries are read afresh, so
+// repositories added since the cycle started can be synced.
+func (s *Syncer) trackedRepo(ctx context.Context, id RepoIdentifier) (RepoIdentifier, error) {
+	repos, rules, paused, err := s.readTracked(ctx)
+	if err != nil {
+		return RepoIdentifier{}, err
+	}
+	key := strings.ToLower(id.String())
+	if paused[key] {
+		return RepoIdentifier{}, custom_errors.ErrPaused
+	}
+	for _, r := range repos {
+		if strings.ToLower(r.String()) == key {
+			return r, nil
+		}
+	}
+	for _, rule := range rules {
+		if rule.matches(id) {
+			return id, nil
+		}
+	}
+	return RepoIdentifier{}, custom_errors.ErrNotTracked
+}
+
+// failUnfinishedJobs fails the jobs a previous process queued or started but did not finish.
+// Jobs queued since this process started are kept.
+func (s *Syncer) failUnfinishedJobs(ctx context.Context) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	keep := []int64{} // not nil, which would match no ids
+	for _, job := range s.queued {
+		keep = append(keep, job.ID)
+	}
+
+	var n int64
+	err := s.withTx(ctx, func(q database.Store) error {
+		var err error
+		n, err = q.FailUnfinishedSyncJobs(ctx, database.FailUnfinishedSyncJobsParams{Message: "interrupted by a restart", Keep: keep})
+		return err
+	})
+	if err != nil {
+		s.logger.Error("Failed to fail unfinished sync jobs", "error", err)
+		return
+	}
+	if n > 0 {
+		s.logger.Warn("Failed sync jobs interrupted by a restart", "count", n)
+	}
+}
+
+// runJobs runs queued jobs one after another until ctx is done. Start runs concurrency of them,
+// which take their slots from those of the sync cycle, so the cycle and jobs together sync at most
+// concurrency repositories at once.
+func (s *Syncer) runJobs(ctx context.Context) {
+	for {
+		select {
+		case job := <-s.jobs:
+			s.runJob(ctx, job)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runJob syncs the job's repository once neither the cycle nor another job is syncing it and a
+// slot is free, and records the outcome.
+func (s *Syncer) runJob(ctx context.Context, job queuedJob) {
+	logger := s.logger.With("job_id", job.id, "host", job.repo.Host, "owner", job.repo.Owner, "repo", job.repo.Name)
+	key := strings.ToLower(job.repo.String())
+	if err := s.locks.lock(ctx, key); err != nil {
+		return // shutting down; the job is failed on the next start
+	}
+	defer s.locks.unlock(key)
+	if err := s.acquireSlot(ctx); err != nil {
+		return
+	}
+	defer s.releaseSlot()
+
+	// Syncs requested from now on run after this one, as they may be after data it misses.
+	s.jobsMu.Lock()
+	delete(s.queued, key)
+	s.jobsMu.Unlock()
+
+	if err := s.withTx(ctx, func(q database.Store) error { return q.StartSyncJob(ctx, job.id) }); err != nil {
+		logger.Error("Failed to start sync job", "error", err)
+	}
+	logger.Info("Running sync job")
+	inserted, err := s.syncRepoInTransaction(ctx, job.repo)
+
+	arg := database.FinishSyncJobParams{ID: job.id, State: JobSucceeded, CommitsInserted: inserted}
+	if err != nil {
+		arg.State, arg.Error = JobFailed, err.Error()
+		logger.Error("Sync job failed", "commits_inserted", inserted, "error", err)
+	} else {
+		logger.Info("Sync job finished", "commits_inserted", inserted)
+	}
+	// Recorded when shutting down too, so the job does not look like it is still running.
+	ctx = context.WithoutCancel(ctx)
+	if err := s.withTx(ctx, func(q database.Store) error { return q.FinishSyncJob(ctx, arg) }); err != nil {
+		logger.Error("Failed to record the outcome of sync job", "error", err)
+	}
+}
@@ -0,0 +1,378 @@
+// internal/syncer/source.go
+package syncer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/gitlab"
+	"github-data-fetcher/internal/gitmirror"
+	"github-data-fetcher/internal/model"
+)
+
+// Source is where the syncer fetches repository data from. *github.Client and *gitlab.Client
+// are the production implementations; tests can substitute a fake. Sources may additionally
+// implement cacheInvalidator, quotaReporter, headTracker, branchSource, pullRequestSource,
+// pullRequestDetailSource, issueSource, issueCommentSource, releaseSource, commitFileSource,
+// workflowSource, stargazerSource, languageSource and repositoryLister.
+type Source interface {
+	// Provider returns the kind of forge the source talks to, model.ProviderGitHub or
+	// model.ProviderGitLab.
+	Provider() string
+	// GetRepository returns the repository's metadata, or custom_errors.ErrNotModified if it
+	// is unchanged since the last request.
+	GetRepository(ctx context.Context, owner, name string) (*model.Repository, error)
+	// ForEachCommitPage calls fn with each page of commits since the given time, newest first,
+	// as pages arrive, and stops at the first error fn returns. It returns
+	// custom_errors.ErrNotModified, without calling fn, if there is nothing new since the last request.
+	ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error
+}
+
+// cacheInvalidator is implemented by sources that answer ErrNotModified based on state
+// kept outside the database transaction, which must be dropped when a sync is rolled back.
+type cacheInvalidator interface {
+	InvalidateRepository(ctx context.Context, owner, name string) error
+}
+
+// quotaReporter is implemented by sources that track API rate limits.
+type quotaReporter interface {
+	Quotas() []github.TokenQuota
+}
+
+// shaCommitSource is implemented by sources that can list the commits reachable from the
+// default branch but not from a previously stored commit. Unlike a date cut-off this is exact
+// even when commit dates are out of order. since is used when sha is empty or unknown.
+type shaCommitSource interface {
+	ForEachCommitPageSinceSHA(ctx context.Context, owner, name, sha string, since time.Time, fn func(page []model.Commit) error) error
+}
+
+// resumableCommitSource is implemented by sources whose commit listing can be resumed after a
+// restart. fn receives each page with an opaque cursor from which a later call resumes the
+// listing after that page, or "" once the last page is known to have been reached.
+// Initial backfills from such sources are committed page by page; see Syncer.backfill.
+type resumableCommitSource interface {
+	ForEachCommitPageFrom(ctx context.Context, owner, name string, since time.Time, cursor string, fn func(page []model.Commit, next string) error) error
+}
+
+// headTracker is implemented by sources that can tell where the default branch points and how
+// it got there from an earlier head, which lets the syncer detect force-pushes.
+type headTracker interface {
+	// GetHeadSHA returns the SHA of the default branch head, or "" if it is unknown, e.g. because
+	// the repository is empty. It returns custom_errors.ErrNotModified if the head is unchanged
+	// since the last request.
+	GetHeadSHA(ctx context.Context, owner, name string) (string, error)
+	CompareHeads(ctx context.Context, owner, name, oldHead, newHead string) (*model.HeadComparison, error)
+}
+
+// branchSource is implemented by sources that can list a repository's branches and the history
+// of each, which is required to track branches other than the default one.
+type branchSource interface {
+	ListBranches(ctx context.Context, owner, name string) ([]model.Branch, error)
+	// ForEachBranchCommitPage is like Source.ForEachCommitPage for the history of branch.
+	ForEachBranchCommitPage(ctx context.Context, owner, name, branch string, since time.Time, fn func(page []model.Commit) error) error
+}
+
+// pullRequestSource is implemented by sources that can list pull requests.
+type pullRequestSource interface {
+	// ForEachPullRequestPage calls fn with each page of the pull requests updated at or after
+	// since, most recently updated first, and stops at the first error fn returns. It returns
+	// custom_errors.ErrNotModified, without calling fn, if there is nothing new since the last request.
+	ForEachPullRequestPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.PullRequest) error) error
+}
+
+// pullRequestDetailSource is implemented by sources that can fetch the line stats, merger and
+// reviews their pull request listings lack, at least one request per pull request.
+type pullRequestDetailSource interface {
+	GetPullRequest(ctx context.Context, owner, name string, number int) (model.PullRequest, error)
+}
+
+// issueSource is implemented by sources that can list issues and the labels defined in a repository.
+type issueSource interface {
+	ListLabels(ctx context.Context, owner, name string) ([]model.Label, error)
+	// ForEachIssuePage is like pullRequestSource.ForEachPullRequestPage for issues, which
+	// do not include pull requests.
+	ForEachIssuePage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Issue) error) error
+}
+
+// issueCommentSource is implemented by sources that can list the comments their issue listings
+// lack, at least one request per issue.
+type issueCommentSource interface {
+	ListIssueComments(ctx context.Context, owner, name string, number int) ([]model.IssueComment, error)
+}
+
+// releaseSource is implemented by sources that can list a repository's tags and releases.
+type releaseSource interface {
+	ListTags(ctx context.Context, owner, name string) ([]model.Tag, error)
+	ListReleases(ctx context.Context, owner, name string) ([]model.Release, error)
+}
+
+// commitFileSource is implemented by sources that can list the files a commit changed, one
+// request per commit.
+type commitFileSource interface {
+	GetCommitFiles(ctx context.Context, owner, name, sha string) ([]model.CommitFile, error)
+}
+
+// workflowSource is implemented by sources that can list a repository's CI workflows and their runs.
+type workflowSource interface {
+	ListWorkflows(ctx context.Context, owner, name string) ([]model.Workflow, error)
+	// ForEachWorkflowRunPage calls fn with each page of the runs created at or after since,
+	// newest first, and stops at the first error fn returns.
+	ForEachWorkflowRunPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.WorkflowRun) error) error
+}
+
+// stargazerSource is implemented by sources that can tell who starred a repository and when.
+type stargazerSource interface {
+	ListStargazers(ctx context.Context, owner, name string) ([]model.Stargazer, error)
+}
+
+// languageSource is implemented by sources that can tell which languages a repository's code is
+// in and which topics it is tagged with.
+type languageSource interface {
+	// GetLanguages returns the bytes of code in each language, or custom_errors.ErrNotModified if
+	// they are unchanged since the last request.
+	GetLanguages(ctx context.Context, owner, name string) (map[string]int64, error)
+	// ListTopics is like GetLanguages for the repository's topics.
+	ListTopics(ctx context.Context, owner, name string) ([]string, error)
+}
+
+// repositoryLister is implemented by sources that can list the repositories of an owner, which
+// org:, user: and wildcard REPOS_TO_SYNC entries are expanded with.
+type repositoryLister interface {
+	// ListOwnerRepositories returns the repositories of an organization or, if user is true or
+	// there is no organization called owner, of a user.
+	ListOwnerRepositories(ctx context.Context, owner string, user bool) ([]model.Repository, error)
+}
+
+// CommitSource lists commits from somewhere other than the repository's API, such as a
+// *gitmirror.Mirror.
+type CommitSource interface {
+	ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error
+	ForEachCommitPageSinceSHA(ctx context.Context, owner, name, sha string, since time.Time, fn func(page []model.Commit) error) error
+	ForEachCommitPageFrom(ctx context.Context, owner, name string, since time.Time, cursor string, fn func(page []model.Commit, next string) error) error
+}
+
+// WithCommitSource returns a Source that fetches repository metadata from src and commits
+// from commits.
+func WithCommitSource(src Source, commits CommitSource) Source {
+	return &splitSource{Source: src, commits: commits}
+}
+
+// splitSource combines the metadata of one source with the commits of another. It forwards
+// the optional interfaces of the metadata source.
+type splitSource struct {
+	Source
+	commits CommitSource
+}
+
+func (s *splitSource) ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error {
+	return s.commits.ForEachCommitPage(ctx, owner, name, since, fn)
+}
+
+func (s *splitSource) ForEachCommitPageSinceSHA(ctx context.Context, owner, name, sha string, since time.Time, fn func(page []model.Commit) error) error {
+	return s.commits.ForEachCommitPageSinceSHA(ctx, owner, name, sha, since, fn)
+}
+
+func (s *splitSource) ForEachCommitPageFrom(ctx context.Context, owner, name string, since time.Time, cursor string, fn func(page []model.Commit, next string) error) error {
+	return s.commits.ForEachCommitPageFrom(ctx, owner, name, since, cursor, fn)
+}
+
+func (s *splitSource) InvalidateRepository(ctx context.Context, owner, name string) error {
+	if inv, ok := s.Source.(cacheInvalidator); ok {
+		return inv.InvalidateRepository(ctx, owner, name)
+	}
+	return nil
+}
+
+// GetHeadSHA asks the metadata source, which describes the same repository as the commit
+// source. Sources that cannot tell report an unknown head.
+func (s *splitSource) GetHeadSHA(ctx context.Context, owner, name string) (string, error) {
+	if ht, ok := s.Source.(headTracker); ok {
+		return ht.GetHeadSHA(ctx, owner, name)
+	}
+	return "", nil
+}
+
+func (s *splitSource) CompareHeads(ctx context.Context, owner, name, oldHead, newHead string) (*model.HeadComparison, error) {
+	if ht, ok := s.Source.(headTracker); ok {
+		return ht.CompareHeads(ctx, owner, name, oldHead, newHead)
+	}
+	return &model.HeadComparison{}, nil
+}
+
+// ListBranches asks the metadata source, like GetHeadSHA. Branch histories come from it too, as
+// commit sources only walk the default branch.
+func (s *splitSource) ListBranches(ctx context.Context, owner, name string) ([]model.Branch, error) {
+	if bs, ok := s.Source.(branchSource); ok {
+		return bs.ListBranches(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+func (s *splitSource) ForEachBranchCommitPage(ctx context.Context, owner, name, branch string, since time.Time, fn func(page []model.Commit) error) error {
+	if bs, ok := s.Source.(branchSource); ok {
+		return bs.ForEachBranchCommitPage(ctx, owner, name, branch, since, fn)
+	}
+	return errors.ErrUnsupported
+}
+
+// ForEachPullRequestPage asks the metadata source, as pull requests are not part of the history
+// commit sources walk.
+func (s *splitSource) ForEachPullRequestPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.PullRequest) error) error {
+	if ps, ok := s.Source.(pullRequestSource); ok {
+		return ps.ForEachPullRequestPage(ctx, owner, name, since, fn)
+	}
+	return errors.ErrUnsupported
+}
+
+// GetPullRequest asks the metadata source, like ForEachPullRequestPage.
+func (s *splitSource) GetPullRequest(ctx context.Context, owner, name string, number int) (model.PullRequest, error) {
+	if ps, ok := s.Source.(pullRequestDetailSource); ok {
+		return ps.GetPullRequest(ctx, owner, name, number)
+	}
+	return model.PullRequest{}, errors.ErrUnsupported
+}
+
+// ListLabels asks the metadata source, like ForEachPullRequestPage.
+func (s *splitSource) ListLabels(ctx context.Context, owner, name string) ([]model.Label, error) {
+	if is, ok := s.Source.(issueSource); ok {
+		return is.ListLabels(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+func (s *splitSource) ForEachIssuePage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Issue) error) error {
+	if is, ok := s.Source.(issueSource); ok {
+		return is.ForEachIssuePage(ctx, owner, name, since, fn)
+	}
+	return errors.ErrUnsupported
+}
+
+// ListIssueComments asks the metadata source, like ForEachPullRequestPage.
+func (s *splitSource) ListIssueComments(ctx context.Context, owner, name string, number int) ([]model.IssueComment, error) {
+	if is, ok := s.Source.(issueCommentSource); ok {
+		return is.ListIssueComments(ctx, owner, name, number)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// ListTags asks the metadata source, like ListBranches.
+func (s *splitSource) ListTags(ctx context.Context, owner, name string) ([]model.Tag, error) {
+	if rs, ok := s.Source.(releaseSource); ok {
+		return rs.ListTags(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+func (s *splitSource) ListReleases(ctx context.Context, owner, name string) ([]model.Release, error) {
+	if rs, ok := s.Source.(releaseSource); ok {
+		return rs.ListReleases(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// GetCommitFiles asks the metadata source, like ListBranches.
+func (s *splitSource) GetCommitFiles(ctx context.Context, owner, name, sha string) ([]model.CommitFile, error) {
+	if cs, ok := s.Source.(commitFileSource); ok {
+		return cs.GetCommitFiles(ctx, owner, name, sha)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// ListWorkflows asks the metadata source, like ListBranches.
+func (s *splitSource) ListWorkflows(ctx context.Context, owner, name string) ([]model.Workflow, error) {
+	if ws, ok := s.Source.(workflowSource); ok {
+		return ws.ListWorkflows(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+func (s *splitSource) ForEachWorkflowRunPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.WorkflowRun) error) error {
+	if ws, ok := s.Source.(workflowSource); ok {
+		return ws.ForEachWorkflowRunPage(ctx, owner, name, since, fn)
+	}
+	return errors.ErrUnsupported
+}
+
+// ListStargazers asks the metadata source, like ListBranches.
+func (s *splitSource) ListStargazers(ctx context.Context, owner, name string) ([]model.Stargazer, error) {
+	if ss, ok := s.Source.(stargazerSource); ok {
+		return ss.ListStargazers(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// GetLanguages asks the metadata source, like ListBranches. A mirror's files could tell too,
+// but not how the forge classifies them.
+func (s *splitSource) GetLanguages(ctx context.Context, owner, name string) (map[string]int64, error) {
+	if ls, ok := s.Source.(languageSource); ok {
+		return ls.GetLanguages(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+func (s *splitSource) ListTopics(ctx context.Context, owner, name string) ([]string, error) {
+	if ls, ok := s.Source.(languageSource); ok {
+		return ls.ListTopics(ctx, owner, name)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+func (s *splitSource) ListOwnerRepositories(ctx context.Context, owner string, user bool) ([]model.Repository, error) {
+	if rl, ok := s.Source.(repositoryLister); ok {
+		return rl.ListOwnerRepositories(ctx, owner, user)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+func (s *splitSource) Quotas() []github.TokenQuota {
+	if qr, ok := s.Source.(quotaReporter); ok {
+		return qr.Quotas()
+	}
+	return nil
+}
+
+var (
+	_ Source                  = (*github.Client)(nil)
+	_ cacheInvalidator        = (*github.Client)(nil)
+	_ quotaReporter           = (*github.Client)(nil)
+	_ headTracker             = (*github.Client)(nil)
+	_ branchSource            = (*github.Client)(nil)
+	_ pullRequestSource       = (*github.Client)(nil)
+	_ pullRequestDetailSource = (*github.Client)(nil)
+	_ issueSource             = (*github.Client)(nil)
+	_ issueCommentSource      = (*github.Client)(nil)
+	_ releaseSource           = (*github.Client)(nil)
+	_ commitFileSource        = (*github.Client)(nil)
+	_ workflowSource          = (*github.Client)(nil)
+	_ stargazerSource         = (*github.Client)(nil)
+	_ languageSource          = (*github.Client)(nil)
+	_ repositoryLister        = (*github.Client)(nil)
+	_ resumableCommitSource   = (*github.Client)(nil)
+	_ Source                  = (*gitlab.Client)(nil)
+	_ resumableCommitSource   = (*gitlab.Client)(nil)
+	_ CommitSource            = (*gitmirror.Mirror)(nil)
+	_ shaCommitSource         = (*splitSource)(nil)
+	_ resumableCommitSource   = (*splitSource)(nil)
+	_ headTracker             = (*splitSource)(nil)
+	_ branchSource            = (*splitSource)(nil)
+	_ pullRequestSource       = (*splitSource)(nil)
+	_ pullRequestDetailSource = (*splitSource)(nil)
+	_ issueSource             = (*splitSource)(nil)
+	_ issueCommentSource      = (*splitSource)(nil)
+	_ releaseSource           = (*splitSource)(nil)
+	_ commitFileSource        = (*splitSource)(nil)
+	_ workflowSource          = (*splitSource)(nil)
+	_ stargazerSource         = (*splitSource)(nil)
+	_ languageSource          = (*splitSource)(nil)
+	_ repositoryLister        = (*splitSource)(nil)
+)
+
+// invalidate drops the source's cached state for a repository, if it keeps any.
+func invalidate(ctx context.Context, src Source, id RepoIdentifier) error {
+	if inv, ok := src.(cacheInvalidator); ok {
+		return inv.InvalidateRepository(ctx, id.Owner, id.Name)
+	}
+	return nil
+}
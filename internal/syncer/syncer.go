@@ -5,56 +5,258 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
+	"path"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/sync/errgroup"
 
 	"github-data-fetcher/internal/database"
 	custom_errors "github-data-fetcher/internal/errors"
 	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/gitlab"
 	"github-data-fetcher/internal/model"
 )
 
 const (
-	// Number of repositories to sync in parallel
+	// Number of repositories to sync in parallel, by the sync cycle and on-demand jobs together
 	concurrency = 5
+
+	// gitlabPrefix marks REPOS_TO_SYNC entries that live on GitLab.
+	gitlabPrefix = model.ProviderGitLab + ":"
+
+	// sinceOverlap is how far before the newest stored commit date incremental syncs start
+	// listing, so commits dated behind it, e.g. because of clock skew, are not missed. Commits
+	// listed again are skipped by UpsertCommits.
+	sinceOverlap = 24 * time.Hour
 )
 
-// RepoIdentifier holds the owner and name of a repository.
+// RepoIdentifier holds the provider, host, owner and name of a repository.
 type RepoIdentifier struct {
-	Owner string
-	Name  string
+	Provider string
+	Host     string
+	Owner    string
+	Name     string
+}
+
+// String returns the identifier in the format accepted by REPOS_TO_SYNC.
+func (id RepoIdentifier) String() string {
+	if id.Provider == model.ProviderGitLab {
+		return gitlabPrefix + id.Owner + "/" + id.Name
+	}
+	if id.Host == github.DefaultHost {
+		return id.Owner + "/" + id.Name
+	}
+	return id.Host + "/" + id.Owner + "/" + id.Name
 }
 
 // Syncer orchestrates the fetching and storing of data.
 type Syncer struct {
-	dbpool       *pgxpool.Pool
-	ghClient     *github.Client
-	logger       *slog.Logger
-	reposToSync  []RepoIdentifier
-	syncInterval time.Duration
-	defaultSince time.Time
+	dbpool        *pgxpool.Pool
+	sources       map[string]Source // keyed by host
+	logger        *slog.Logger
+	reposToSync   []RepoIdentifier // repositories tracked by name
+	syncInterval  time.Duration
+	defaultSince  time.Time
+	branches      map[string][]string // branch patterns keyed by RepoIdentifier.String()
+	commitFiles   int                 // commits per cycle whose changed files are fetched
+	pullDetails   int                 // pull requests per cycle whose details and reviews are fetched
+	issueComments int                 // issues per cycle whose comments are fetched
+	stargazers    bool                // whether to backfill who starred each repository and when
+
+	discovery       []discoveryRule
+	discoveryFilter DiscoveryFilter
+	discovered      []RepoIdentifier // repositories found through discovery in the current cycle
+	paused          map[string]bool  // lowercased RepoIdentifier.String() of paused repositories
+
+	// mu guards the writes the sync cycle makes to reposToSync, discovery, discovered and paused,
+	// and the reads other goroutines make.
+	mu sync.Mutex
+
+	locks  repoLocks                   // repositories being synced, by a cycle or a job
+	slots  chan struct{}               // holds a value per repository being synced, up to concurrency
+	jobs   chan queuedJob              // on-demand syncs waiting for a worker
+	jobsMu sync.Mutex                  // guards queued
+	queued map[string]database.SyncJob // jobs not started yet, keyed by lowercased RepoIdentifier.String()
+
+	// seed holds the REPOS_TO_SYNC entries, which are tracked in the database on start.
+	seed []string
+
+	// withTx runs fn in a database transaction. Tests replace it to run fn against a mock.
+	withTx func(ctx context.Context, fn func(q database.Store) error) error
 }
 
-// NewSyncer creates a new Syncer instance.
-func NewSyncer(dbpool *pgxpool.Pool, ghClient *github.Client, logger *slog.Logger, repos []string, interval time.Duration, defaultSince time.Time) (*Syncer, error) {
-	parsedRepos, err := parseRepoIdentifiers(repos)
-	if err != nil {
-		return nil, err
+// Option configures optional behaviour of a Syncer.
+type Option func(*Syncer)
+
+// WithBranches tracks, besides the default branch, the branches matching the patterns given
+// for a repository. patterns is keyed by REPOS_TO_SYNC entry; patterns use path.Match syntax,
+// so "release/*" matches "release/1.0" but not "release/1.0/hotfix".
+func WithBranches(patterns map[string][]string) Option {
+	return func(s *Syncer) {
+		s.branches = patterns
+	}
+}
+
+// WithCommitFiles fetches the files changed by up to budget stored commits per sync cycle,
+// newest first, from sources that can list them. The budget is split evenly between the
+// repositories, so a long backlog in one does not hold up the others. Zero disables it.
+func WithCommitFiles(budget int) Option {
+	return func(s *Syncer) {
+		s.commitFiles = budget
+	}
+}
+
+// WithPullRequestDetails fetches the line stats, merger and reviews of up to budget stored pull
+// requests per sync cycle, most recently updated first, from sources that can fetch them. Pull
+// request listings lack those, and fetching them takes at least one request per pull request. The
+// budget is split evenly between the repositories, like the one of WithCommitFiles. Zero disables it.
+func WithPullRequestDetails(budget int) Option {
+	return func(s *Syncer) {
+		s.pullDetails = budget
+	}
+}
+
+// WithIssueComments fetches the comments of up to budget stored issues per sync cycle, most
+// recently updated first, from sources that can list them. Issue listings only count comments,
+// and listing them takes at least one request per issue. The budget is split evenly between the
+// repositories, like the one of WithCommitFiles. Zero disables it.
+func WithIssueComments(budget int) Option {
+	return func(s *Syncer) {
+		s.issueComments = budget
+	}
+}
+
+// WithStargazers backfills, once per repository, who starred it and when from sources that can
+// list stargazers, which extends its growth history to before the first sync.
+func WithStargazers(backfill bool) Option {
+	return func(s *Syncer) {
+		s.stargazers = backfill
 	}
+}
 
-	return &Syncer{
+// NewSyncer creates a new Syncer instance. sources maps each host repositories may live on
+// (github.DefaultHost, a GitHub Enterprise host or the GitLab host) to the Source used to reach it.
+// 'gitlab:' entries are synced from the source whose provider is GitLab. org:, user: and wildcard
+// entries are expanded at the start of every cycle, from sources that can list repositories.
+// repos seeds the tracked_repositories table, which the set of repositories is read from every
+// cycle, so entries can be added, paused and removed without a restart.
+func NewSyncer(dbpool *pgxpool.Pool, sources map[string]Source, logger *slog.Logger, repos []string, interval time.Duration, defaultSince time.Time, opts ...Option) (*Syncer, error) {
+	s := &Syncer{
 		dbpool:       dbpool,
-		ghClient:     ghClient,
+		sources:      sources,
 		logger:       logger,
-		reposToSync:  parsedRepos,
 		syncInterval: interval,
 		defaultSince: defaultSince,
-	}, nil
+
+		discoveryFilter: DiscoveryFilter{Private: true},
+		jobs:            make(chan queuedJob, jobQueueSize),
+		slots:           make(chan struct{}, concurrency),
+	}
+	parsedRepos, rules, err := s.parseEntries(repos)
+	if err != nil {
+		return nil, err
+	}
+	s.reposToSync, s.discovery = parsedRepos, rules
+	s.seed = make([]string, 0, len(repos))
+	for _, id := range parsedRepos {
+		s.seed = append(s.seed, id.String())
+	}
+	for _, rule := range rules {
+		s.seed = append(s.seed, rule.entry)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.resolveBranches(); err != nil {
+		return nil, err
+	}
+	s.withTx = s.poolTx
+	return s, nil
+}
+
+// parseEntries parses REPOS_TO_SYNC entries into the repositories they name and the discovery
+// rules, and checks that each can be synced from one of the sources.
+func (s *Syncer) parseEntries(entries []string) ([]RepoIdentifier, []discoveryRule, error) {
+	rules, entries, err := parseDiscoveryRules(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, rule := range rules {
+		src, ok := s.sources[rule.host]
+		if !ok {
+			return nil, nil, &custom_errors.ErrUnknownHost{Host: rule.host, Repo: rule.entry}
+		}
+		if _, ok := src.(repositoryLister); !ok || src.Provider() != model.ProviderGitHub {
+			return nil, nil, fmt.Errorf("%q lists the repositories of an owner, which is only supported on GitHub hosts", rule.entry)
+		}
+	}
+	ids, err := parseRepoIdentifiers(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, id := range ids {
+		if id.Provider == model.ProviderGitLab {
+			for host, src := range s.sources {
+				if src.Provider() == model.ProviderGitLab {
+					ids[i].Host = host
+				}
+			}
+		}
+		if src, ok := s.sources[ids[i].Host]; !ok || src.Provider() != id.Provider {
+			return nil, nil, &custom_errors.ErrUnknownHost{Host: ids[i].Host, Repo: id.String()}
+		}
+	}
+	return ids, rules, nil
+}
+
+// ValidateEntry checks that a REPOS_TO_SYNC entry can be synced and returns it in the form it
+// is tracked in, e.g. 'golang/go' for 'github.com/golang/go'.
+func (s *Syncer) ValidateEntry(entry string) (string, error) {
+	ids, rules, err := s.parseEntries([]string{entry})
+	if err != nil {
+		return "", err
+	}
+	if len(rules) > 0 {
+		return rules[0].entry, nil
+	}
+	return ids[0].String(), nil
+}
+
+// resolveBranches validates the branch patterns and rekeys them by RepoIdentifier.String(), so
+// entries naming the same repository differently, e.g. with the github.com host, are found.
+// Patterns may be configured for repositories that are not tracked yet, as repositories can be
+// added at runtime.
+func (s *Syncer) resolveBranches() error {
+	resolved := make(map[string][]string, len(s.branches))
+	for repo, patterns := range s.branches {
+		ids, err := parseRepoIdentifiers([]string{repo})
+		if err != nil {
+			return err
+		}
+		key := ids[0].String()
+		if _, ok := s.sources[ids[0].Host]; !ok && ids[0].Provider == model.ProviderGitHub {
+			return &custom_errors.ErrUnknownHost{Host: ids[0].Host, Repo: key}
+		}
+		if _, ok := s.sources[ids[0].Host].(branchSource); !ok || ids[0].Provider != model.ProviderGitHub {
+			return fmt.Errorf("branches are configured for %q, but branch tracking is only supported for GitHub repositories", repo)
+		}
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid branch pattern %q for %q: %w", p, repo, err)
+			}
+		}
+		resolved[key] = patterns
+	}
+	s.branches = resolved
+	return nil
 }
 
 // Start begins the continuous synchronization process.
@@ -63,6 +265,13 @@ func (s *Syncer) Start(ctx context.Context) {
 	ticker := time.NewTicker(s.syncInterval)
 	defer ticker.Stop()
 
+	if err := s.withTx(ctx, func(q database.Store) error { return q.SeedTrackedRepositories(ctx, s.seed) }); err != nil {
+		s.logger.Error("Failed to track the repositories in REPOS_TO_SYNC", "error", err)
+	}
+	s.failUnfinishedJobs(ctx)
+	for i := 0; i < concurrency; i++ {
+		go s.runJobs(ctx)
+	}
 	s.runSyncCycle(ctx) // Initial sync
 
 	for {
@@ -76,21 +285,46 @@ func (s *Syncer) Start(ctx context.Context) {
 	}
 }
 
+// acquireSlot waits until fewer than concurrency repositories are being synced, by the cycle and
+// jobs together, and takes a slot, or returns ctx's error. releaseSlot gives the slot back.
+func (s *Syncer) acquireSlot(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Syncer) releaseSlot() {
+	<-s.slots
+}
+
 // runSyncCycle performs a synchronization pass for all configured repositories concurrently.
 func (s *Syncer) runSyncCycle(ctx context.Context) {
 	s.logger.Info("Starting new sync cycle")
+	s.loadRepos(ctx)
+	s.discoverRepos(ctx)
 	g, gctx := errgroup.WithContext(ctx)
 	g.SetLimit(concurrency)
 
-	for _, repoID := range s.reposToSync {
+	for _, repoID := range slices.Concat(s.reposToSync, s.discovered) {
 		repoID := repoID
 		g.Go(func() error {
-			if gctx.Err() != nil {
+			if s.acquireSlot(gctx) != nil {
+				return nil
+			}
+			defer s.releaseSlot()
+			// A job syncing the repository right now leaves nothing for the cycle to do.
+			key := strings.ToLower(repoID.String())
+			if !s.locks.tryLock(key) {
+				s.logger.Info("Skipping repository that a sync job is syncing", "provider", repoID.Provider, "host", repoID.Host, "owner", repoID.Owner, "repo", repoID.Name)
 				return nil
 			}
-			err := s.syncRepoInTransaction(gctx, repoID)
+			defer s.locks.unlock(key)
+			_, err := s.syncRepoInTransaction(gctx, repoID)
 			if err != nil && !errors.Is(err, context.Canceled) {
-				s.logger.Error("Failed to sync repository", "owner", repoID.Owner, "repo", repoID.Name, "error", err)
+				s.logger.Error("Failed to sync repository", "provider", repoID.Provider, "host", repoID.Host, "owner", repoID.Owner, "repo", repoID.Name, "error", err)
 			}
 			return nil
 		})
@@ -101,75 +335,358 @@ func (s *Syncer) runSyncCycle(ctx context.Context) {
 	} else {
 		s.logger.Info("Sync cycle finished")
 	}
+
+	for host, src := range s.sources {
+		qr, ok := src.(quotaReporter)
+		if !ok {
+			continue
+		}
+		for _, q := range qr.Quotas() {
+			s.logger.Info("GitHub token quota", "host", host, "token", q.Token, "resource", q.Resource, "remaining", q.Remaining, "limit", q.Limit, "reset", q.Reset)
+		}
+	}
 }
 
-// syncRepoInTransaction wraps the sync logic for a single repo in a DB transaction.
-func (s *Syncer) syncRepoInTransaction(ctx context.Context, id RepoIdentifier) error {
-	tx, err := s.dbpool.Begin(ctx)
+// loadRepos replaces the repositories tracked by name and the discovery rules with the entries
+// of the tracked_repositories table that are not paused. If the table cannot be read, those of
+// the previous cycle are kept.
+func (s *Syncer) loadRepos(ctx context.Context) {
+	repos, rules, paused, err := s.readTracked(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load tracked repositories, syncing those of the previous cycle", "error", err)
+		return
+	}
+	s.mu.Lock()
+	s.reposToSync, s.discovery, s.paused = repos, rules, paused
+	s.mu.Unlock()
+}
+
+// readTracked reads the tracked_repositories table: the repositories tracked by name and the
+// discovery rules that are not paused, and the lowercased RepoIdentifier.String() of repositories
+// paused by name. Entries that cannot be synced, e.g. because their host is no longer
+// configured, are skipped.
+func (s *Syncer) readTracked(ctx context.Context) (repos []RepoIdentifier, rules []discoveryRule, paused map[string]bool, err error) {
+	var tracked []database.TrackedRepository
+	err = s.withTx(ctx, func(q database.Store) error {
+		var err error
+		tracked, err = q.ListTrackedRepositories(ctx)
+		return err
+	})
 	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	paused = make(map[string]bool)
+	for _, t := range tracked {
+		ids, entryRules, err := s.parseEntries([]string{t.Entry})
+		if err != nil {
+			s.logger.Error("Skipping tracked repository that cannot be synced", "entry", t.Entry, "error", err)
+			continue
+		}
+		if t.Paused {
+			for _, id := range ids {
+				paused[strings.ToLower(id.String())] = true
+			}
+			continue
+		}
+		repos = append(repos, ids...)
+		rules = append(rules, entryRules...)
+	}
+	return repos, rules, paused, nil
+}
+
+// syncRepoInTransaction wraps the sync logic for a single repo in a DB transaction. Initial
+// backfills are the exception: once the repository is stored they continue in backfill, which
+// commits page by page. Tracked branches, pull requests, issues, releases, workflow runs,
+// stargazers, and languages and topics are synced afterwards, each in its own transaction, and
+// last the details of pull requests, the comments of issues and the changed files of commits are
+// fetched. It returns the number of commits inserted, which is also reported when a later step
+// fails.
+func (s *Syncer) syncRepoInTransaction(ctx context.Context, id RepoIdentifier) (int64, error) {
+	var checkpoint *database.SyncCheckpoint
+	var inserted int64
+	err := s.inTx(ctx, id, func(q database.Store) error {
+		var err error
+		checkpoint, inserted, err = s.syncRepo(ctx, q, id)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
-	defer tx.Rollback(ctx) // Rollback is a no-op if the transaction is already committed.
+	if checkpoint != nil {
+		n, err := s.backfill(ctx, id, *checkpoint)
+		inserted += n
+		if err != nil {
+			return inserted, err
+		}
+	}
+	n, err := s.syncBranches(ctx, id)
+	inserted += n
+	if err != nil {
+		return inserted, err
+	}
+	for _, sync := range []func(context.Context, database.Store, RepoIdentifier) error{s.syncPullRequests, s.syncIssues, s.syncReleases, s.syncWorkflows, s.syncStargazers, s.syncLanguages} {
+		if err := s.inTx(ctx, id, func(q database.Store) error { return sync(ctx, q, id) }); err != nil {
+			return inserted, err
+		}
+	}
+	if err := s.syncPullRequestDetails(ctx, id); err != nil {
+		return inserted, err
+	}
+	if err := s.syncIssueComments(ctx, id); err != nil {
+		return inserted, err
+	}
+	return inserted, s.syncCommitFiles(ctx, id)
+}
 
-	qtx := database.New(tx)
-	err = s.syncRepo(ctx, qtx, id)
+// inTx runs fn in a transaction via withTx. Validators saved while fn ran describe data that
+// is being rolled back, so they are dropped if the transaction fails.
+func (s *Syncer) inTx(ctx context.Context, id RepoIdentifier, fn func(q database.Store) error) error {
+	err := s.withTx(ctx, fn)
+	if err != nil {
+		if ierr := invalidate(context.WithoutCancel(ctx), s.sources[id.Host], id); ierr != nil {
+			s.logger.Warn("Failed to invalidate cache validators", "host", id.Host, "owner", id.Owner, "repo", id.Name, "error", ierr)
+		}
+		return err
+	}
+	return nil
+}
+
+// poolTx is the production withTx.
+func (s *Syncer) poolTx(ctx context.Context, fn func(q database.Store) error) error {
+	tx, err := s.dbpool.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx) // Rollback is a no-op if the transaction is already committed.
 
+	if err := fn(database.New(tx)); err != nil {
+		return err
+	}
 	return tx.Commit(ctx)
 }
 
-// syncRepo handles the full synchronization logic for a single repository.
-func (s *Syncer) syncRepo(ctx context.Context, q database.Querier, id RepoIdentifier) error {
+// syncRepo handles the full synchronization logic for a single repository. For sources that
+// can resume a listing it returns the checkpoint of an initial backfill that is still to be
+// done instead of fetching commits; the caller continues it with backfill once the repository
+// row is committed. It also returns the number of commits inserted.
+func (s *Syncer) syncRepo(ctx context.Context, q database.Store, id RepoIdentifier) (*database.SyncCheckpoint, int64, error) {
 	// ** THIS IS THE CORRECTED LINE **
-	logger := s.logger.With("owner", id.Owner, "repo", id.Name)
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name)
 	logger.Info("Syncing repository")
 
-	ghRepo, err := s.ghClient.GetRepository(ctx, id.Owner, id.Name)
+	dbRepo, repoUnchanged, err := s.fetchRepository(ctx, q, id)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
+	logger = logger.With("repo_id", dbRepo.ID)
 
-	dbRepo, err := s.upsertRepository(ctx, q, ghRepo)
-	if err != nil {
-		return err
+	// Unchanged counters are recorded too, so the growth series has a point for every sync.
+	if err := q.CreateRepositorySnapshot(ctx, dbRepo.ID); err != nil {
+		return nil, 0, err
+	}
+
+	_, resumable := s.sources[id.Host].(resumableCommitSource)
+	if resumable {
+		checkpoint, err := q.GetSyncCheckpoint(ctx, dbRepo.ID)
+		if err == nil && !checkpoint.CompletedAt.Valid {
+			logger.Info("Found incomplete backfill", "commits_imported", checkpoint.CommitsImported)
+			return &checkpoint, 0, nil
+		}
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, err
+		}
+	}
+
+	// Commits a force-push orphaned must stop counting before the newest stored commit is looked up.
+	if err := s.trackHead(ctx, q, id, dbRepo); err != nil {
+		return nil, 0, err
 	}
-	logger = logger.With("repo_id", dbRepo.ID)
 
-	since, err := s.getSinceTimestamp(ctx, q, dbRepo.ID)
+	since, hasCommits, err := s.getSinceTimestamp(ctx, q, dbRepo.ID)
 	if err != nil {
-		return err
+		return nil, 0, err
+	}
+	if resumable && !hasCommits {
+		checkpoint, err := q.StartSyncCheckpoint(ctx, database.StartSyncCheckpointParams{
+			RepositoryID: dbRepo.ID,
+			Since:        since,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return &checkpoint, 0, nil
 	}
 	logger.Info("Fetching commits since", "timestamp", since.Format(time.RFC3339))
 
-	commits, err := s.ghClient.GetCommits(ctx, id.Owner, id.Name, since)
-	if err != nil {
-		return err
+	// Pages are written as they arrive so a large history is never held in memory at once.
+	var inserted, skipped int64
+	err = s.forEachCommitPage(ctx, q, id, dbRepo.ID, since, func(commits []model.Commit) error {
+		if len(commits) == 0 {
+			return nil
+		}
+		n, err := q.UpsertCommits(ctx, prepareCommitBulkInsert(dbRepo.ID, commits, true))
+		if err != nil {
+			return err
+		}
+		inserted += n
+		skipped += int64(len(commits)) - n
+		logger.Debug("Inserted page of commits", "count", n, "skipped", int64(len(commits))-n, "total", inserted)
+		return nil
+	})
+	commitsUnchanged := errors.Is(err, custom_errors.ErrNotModified)
+	if err != nil && !commitsUnchanged {
+		return nil, 0, err
+	}
+
+	if repoUnchanged && commitsUnchanged {
+		logger.Info("Repository unchanged since last sync")
+		return nil, 0, q.MarkRepositorySynced(ctx, dbRepo.ID)
 	}
 
-	if len(commits) == 0 {
-		logger.Info("No new commits found")
+	if inserted == 0 {
+		logger.Info("No new commits found", "skipped", skipped)
 		// Still update repo sync time even if no new commits, and do it inside the transaction.
-		_, err := q.UpdateRepositorySyncData(ctx, database.UpdateRepositorySyncDataParams{ID: dbRepo.ID})
-		return err
+		return nil, 0, q.MarkRepositorySynced(ctx, dbRepo.ID)
+	}
+
+	logger.Info("Successfully inserted commits into database", "count", inserted, "skipped", skipped)
+
+	return nil, inserted, nil
+}
+
+// backfill imports a repository's history from a checkpoint onwards. Each page is committed in
+// its own transaction together with the checkpoint, so an interrupted backfill resumes after
+// the last committed page instead of starting over. The checkpoint is completed, and the
+// repository marked synced, once the listing is exhausted. It returns the number of commits
+// imported, including those of pages committed before an error.
+func (s *Syncer) backfill(ctx context.Context, id RepoIdentifier, checkpoint database.SyncCheckpoint) (int64, error) {
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", checkpoint.RepositoryID)
+	src := s.sources[id.Host]
+	if checkpoint.PageCursor == "" {
+		logger.Info("Starting backfill", "since", checkpoint.Since.Format(time.RFC3339))
+	} else {
+		logger.Info("Resuming backfill", "commits_imported", checkpoint.CommitsImported, "oldest_sha", checkpoint.OldestSha)
+	}
+
+	// Pages fetched by an interrupted attempt must not come back as 304 Not Modified.
+	if err := invalidate(ctx, src, id); err != nil {
+		return 0, err
 	}
 
-	logger.Info("Found new commits", "count", len(commits))
-	n, err := q.CreateCommits(ctx, prepareCommitBulkInsert(dbRepo.ID, commits))
+	var imported int64
+	done := false
+	err := src.(resumableCommitSource).ForEachCommitPageFrom(ctx, id.Owner, id.Name, checkpoint.Since, checkpoint.PageCursor, func(page []model.Commit, next string) error {
+		arg := database.AdvanceSyncCheckpointParams{
+			RepositoryID:     checkpoint.RepositoryID,
+			PageCursor:       next,
+			OldestSha:        checkpoint.OldestSha,
+			OldestCommitDate: checkpoint.OldestCommitDate,
+		}
+		if len(page) > 0 {
+			oldest := page[len(page)-1]
+			arg.OldestSha = oldest.SHA
+			arg.OldestCommitDate = toPgTimestamptz(oldest.CommitDate)
+		}
+
+		err := s.inTx(ctx, id, func(q database.Store) error {
+			if len(page) > 0 {
+				n, err := q.UpsertCommits(ctx, prepareCommitBulkInsert(checkpoint.RepositoryID, page, true))
+				if err != nil {
+					return err
+				}
+				arg.CommitsImported = n
+			}
+			if err := q.AdvanceSyncCheckpoint(ctx, arg); err != nil {
+				return err
+			}
+			if next == "" {
+				return completeBackfill(ctx, q, checkpoint.RepositoryID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		done = next == ""
+		checkpoint.PageCursor = arg.PageCursor
+		checkpoint.OldestSha = arg.OldestSha
+		checkpoint.OldestCommitDate = arg.OldestCommitDate
+		checkpoint.CommitsImported += arg.CommitsImported
+		imported += arg.CommitsImported
+		logger.Debug("Committed backfill page", "count", arg.CommitsImported, "skipped", int64(len(page))-arg.CommitsImported, "total", checkpoint.CommitsImported)
+		return nil
+	})
 	if err != nil {
+		return imported, err
+	}
+
+	// Some sources only find out that the last page was the last one after passing it on.
+	if !done {
+		err = s.inTx(ctx, id, func(q database.Store) error {
+			return completeBackfill(ctx, q, checkpoint.RepositoryID)
+		})
+		if err != nil {
+			return imported, err
+		}
+	}
+	logger.Info("Backfill complete", "commits_imported", checkpoint.CommitsImported)
+	return imported, nil
+}
+
+func completeBackfill(ctx context.Context, q database.Querier, repoID int64) error {
+	if err := q.CompleteSyncCheckpoint(ctx, repoID); err != nil {
 		return err
 	}
-	logger.Info("Successfully inserted commits into database", "count", n)
+	return q.MarkRepositorySynced(ctx, repoID)
+}
 
-	return nil
+// fetchRepository fetches repository metadata from its source and stores it. The boolean reports
+// whether the source answered 304 Not Modified, in which case the stored row is returned as is.
+func (s *Syncer) fetchRepository(ctx context.Context, q database.Querier, id RepoIdentifier) (database.Repository, bool, error) {
+	src := s.sources[id.Host]
+	ghRepo, err := src.GetRepository(ctx, id.Owner, id.Name)
+	if errors.Is(err, custom_errors.ErrNotModified) {
+		dbRepo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+			Provider: id.Provider,
+			Host:     id.Host,
+			Owner:    id.Owner,
+			Name:     id.Name,
+		})
+		if err == nil {
+			return dbRepo, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return database.Repository{}, false, err
+		}
+
+		// The validators outlived the row they describe, so fetch the full payload again.
+		s.logger.Warn("Repository not modified but missing from DB, refetching", "host", id.Host, "owner", id.Owner, "repo", id.Name)
+		if err := invalidate(ctx, src, id); err != nil {
+			return database.Repository{}, false, err
+		}
+		ghRepo, err = src.GetRepository(ctx, id.Owner, id.Name)
+		if err != nil {
+			return database.Repository{}, false, err
+		}
+	} else if err != nil {
+		return database.Repository{}, false, err
+	}
+
+	ghRepo.Provider = id.Provider
+	ghRepo.Host = id.Host
+	dbRepo, err := s.upsertRepository(ctx, q, ghRepo)
+	return dbRepo, false, err
 }
 
 // upsertRepository creates or updates a repository.
 func (s *Syncer) upsertRepository(ctx context.Context, q database.Querier, repo *model.Repository) (database.Repository, error) {
-	existingRepo, err := q.GetRepositoryByOwnerAndName(ctx, database.GetRepositoryByOwnerAndNameParams{
-		Owner: repo.Owner,
-		Name:  repo.Name,
+	existingRepo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: repo.Provider,
+		Host:     repo.Host,
+		Owner:    repo.Owner,
+		Name:     repo.Name,
 	})
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -187,6 +704,8 @@ func (s *Syncer) upsertRepository(ctx context.Context, q database.Querier, repo
 			WatchersCount:   int32(repo.WatchersCount),
 			RepoCreatedAt:   repo.RepoCreatedAt,
 			RepoUpdatedAt:   repo.RepoUpdatedAt,
+			Host:            repo.Host,
+			Provider:        repo.Provider,
 		})
 	} else if err != nil {
 		return database.Repository{}, err
@@ -205,55 +724,1189 @@ func (s *Syncer) upsertRepository(ctx context.Context, q database.Querier, repo
 	})
 }
 
-func (s *Syncer) getSinceTimestamp(ctx context.Context, q database.Querier, repoID int64) (time.Time, error) {
-	latestCommitDate, err := q.GetLatestCommitDateForRepo(ctx, repoID)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return time.Time{}, err
+// trackHead stores where the default branch points, for sources that can tell. If the stored head
+// is no longer an ancestor of the current one, the history was rewritten: the stored commits only
+// the old head reached are marked unreachable, which keeps them out of statistics, and the
+// rewrite is recorded.
+func (s *Syncer) trackHead(ctx context.Context, q database.Querier, id RepoIdentifier, repo database.Repository) error {
+	src := s.sources[id.Host]
+	ht, ok := src.(headTracker)
+	if !ok {
+		return nil
 	}
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repo.ID)
 
-	if !latestCommitDate.Valid {
-		s.logger.Info("No existing commits found for repository, using default start date", "default_since", s.defaultSince)
-		return s.defaultSince, nil
+	head, err := ht.GetHeadSHA(ctx, id.Owner, id.Name)
+	if errors.Is(err, custom_errors.ErrNotModified) {
+		if repo.HeadSha != "" {
+			return nil
+		}
+		// The validators outlived the head they describe, so fetch it again.
+		logger.Warn("Branch head not modified but missing from DB, refetching")
+		if err := invalidate(ctx, src, id); err != nil {
+			return err
+		}
+		head, err = ht.GetHeadSHA(ctx, id.Owner, id.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if head == "" || head == repo.HeadSha {
+		return nil
 	}
 
-	s.logger.Info("Found latest commit in DB", "timestamp", latestCommitDate.Time)
-	return latestCommitDate.Time.Add(1 * time.Second), nil
+	if repo.HeadSha != "" {
+		cmp, err := ht.CompareHeads(ctx, id.Owner, id.Name, repo.HeadSha, head)
+		if err != nil {
+			return err
+		}
+		if cmp.Rewritten() {
+			var unreachable int64
+			if len(cmp.Orphaned) > 0 {
+				unreachable, err = q.MarkCommitsUnreachable(ctx, database.MarkCommitsUnreachableParams{
+					RepositoryID: repo.ID,
+					Shas:         cmp.Orphaned,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			_, err = q.CreateHistoryRewrite(ctx, database.CreateHistoryRewriteParams{
+				RepositoryID:       repo.ID,
+				OldHeadSha:         repo.HeadSha,
+				NewHeadSha:         head,
+				MergeBaseSha:       cmp.MergeBase,
+				UnreachableCommits: unreachable,
+			})
+			if err != nil {
+				return err
+			}
+			logger.Warn("Default branch history was rewritten", "old_head", repo.HeadSha, "new_head", head, "merge_base", cmp.MergeBase, "old_head_missing", cmp.OldHeadMissing, "unreachable_commits", unreachable)
+		}
+	}
+
+	return q.UpdateRepositoryHead(ctx, database.UpdateRepositoryHeadParams{
+		ID:      repo.ID,
+		HeadSha: head,
+	})
 }
 
-func parseRepoIdentifiers(repos []string) ([]RepoIdentifier, error) {
-	var identifiers []RepoIdentifier
-	for _, r := range repos {
-		parts := strings.Split(r, "/")
-		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-			return nil, &custom_errors.ErrInvalidRepoFormat{Repo: r}
+// syncBranches syncs the branches of a repository matching its configured patterns. Their commits
+// are stored like those of the default branch and linked to each branch they are on, so every
+// commit is stored once however many branches it is on. Branches that are gone or no longer
+// match are forgotten along with their links; their commits stay. It returns the number of
+// commits inserted that were not stored yet.
+func (s *Syncer) syncBranches(ctx context.Context, id RepoIdentifier) (int64, error) {
+	patterns := s.branches[id.String()]
+	bs, ok := s.sources[id.Host].(branchSource)
+	if len(patterns) == 0 || !ok {
+		return 0, nil
+	}
+
+	remote, err := bs.ListBranches(ctx, id.Owner, id.Name)
+	if err != nil {
+		return 0, err
+	}
+	var tracked []model.Branch
+	names := []string{} // not nil, which would match no names in DeleteBranchesNotIn
+	for _, b := range remote {
+		if matchesAny(patterns, b.Name) && b.HeadSHA != "" {
+			tracked = append(tracked, b)
+			names = append(names, b.Name)
 		}
-		identifiers = append(identifiers, RepoIdentifier{Owner: parts[0], Name: parts[1]})
 	}
-	return identifiers, nil
+
+	var repoID int64
+	stored := make(map[string]database.Branch)
+	err = s.inTx(ctx, id, func(q database.Store) error {
+		repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+			Provider: id.Provider,
+			Host:     id.Host,
+			Owner:    id.Owner,
+			Name:     id.Name,
+		})
+		if err != nil {
+			return err
+		}
+		repoID = repo.ID
+		if err := q.DeleteBranchesNotIn(ctx, database.DeleteBranchesNotInParams{RepositoryID: repo.ID, Names: names}); err != nil {
+			return err
+		}
+		branches, err := q.GetBranchesByRepoID(ctx, repo.ID)
+		if err != nil {
+			return err
+		}
+		for _, b := range branches {
+			stored[b.Name] = b
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var inserted int64
+	for _, b := range tracked {
+		if stored[b.Name].HeadSha == b.HeadSHA {
+			continue
+		}
+		var n int64
+		err := s.inTx(ctx, id, func(q database.Store) error {
+			var err error
+			n, err = s.syncBranch(ctx, q, id, repoID, stored[b.Name], b)
+			return err
+		})
+		if err != nil {
+			return inserted, fmt.Errorf("sync branch %q: %w", b.Name, err)
+		}
+		inserted += n
+	}
+	return inserted, nil
 }
 
-func prepareCommitBulkInsert(repoID int64, commits []model.Commit) []database.CreateCommitsParams {
-	params := make([]database.CreateCommitsParams, len(commits))
-	for i, c := range commits {
-		params[i] = database.CreateCommitsParams{
-			RepositoryID: repoID,
-			Sha:          c.SHA,
-			AuthorName:   c.AuthorName,
-			AuthorEmail:  c.AuthorEmail,
-			Message:      c.Message,
-			Url:          c.URL,
-			CommitDate:   c.CommitDate,
+// syncBranch moves a tracked branch from its stored head, if any, to b.HeadSHA. Commits a
+// force-push removed from the branch are unlinked from it. New commits are listed from
+// sinceOverlap before the newest commit linked to the branch, or from the default date for
+// branches seen for the first time. It returns the number of commits inserted.
+func (s *Syncer) syncBranch(ctx context.Context, q database.Store, id RepoIdentifier, repoID int64, stored database.Branch, b model.Branch) (int64, error) {
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repoID, "branch", b.Name)
+	src := s.sources[id.Host]
+
+	branch, err := q.UpsertBranch(ctx, database.UpsertBranchParams{
+		RepositoryID: repoID,
+		Name:         b.Name,
+		HeadSha:      b.HeadSHA,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if ht, ok := src.(headTracker); ok && stored.HeadSha != "" {
+		cmp, err := ht.CompareHeads(ctx, id.Owner, id.Name, stored.HeadSha, b.HeadSHA)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case cmp.OldHeadMissing:
+			// Which commits left the branch is unknown, so it is relinked from scratch.
+			logger.Warn("Branch history was rewritten, relinking all of its commits", "old_head", stored.HeadSha, "new_head", b.HeadSHA)
+			if err := q.ClearBranchCommits(ctx, branch.ID); err != nil {
+				return 0, err
+			}
+		case len(cmp.Orphaned) > 0:
+			n, err := q.RemoveCommitsFromBranch(ctx, database.RemoveCommitsFromBranchParams{BranchID: branch.ID, Shas: cmp.Orphaned})
+			if err != nil {
+				return 0, err
+			}
+			logger.Warn("Branch history was rewritten", "old_head", stored.HeadSha, "new_head", b.HeadSHA, "merge_base", cmp.MergeBase, "unlinked_commits", n)
 		}
 	}
-	return params
+
+	since := s.defaultSince
+	latest, err := q.GetLatestCommitDateForBranch(ctx, branch.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	if latest.Valid {
+		since = latest.Time.Add(-sinceOverlap)
+	}
+
+	var inserted, linked int64
+	err = src.(branchSource).ForEachBranchCommitPage(ctx, id.Owner, id.Name, b.Name, since, func(commits []model.Commit) error {
+		if len(commits) == 0 {
+			return nil
+		}
+		n, err := q.UpsertCommits(ctx, prepareCommitBulkInsert(repoID, commits, false))
+		if err != nil {
+			return err
+		}
+		shas := make([]string, len(commits))
+		for i, c := range commits {
+			shas[i] = c.SHA
+		}
+		if err := q.AddCommitsToBranch(ctx, database.AddCommitsToBranchParams{BranchID: branch.ID, RepositoryID: repoID, Shas: shas}); err != nil {
+			return err
+		}
+		inserted += n
+		linked += int64(len(commits))
+		return nil
+	})
+	if err != nil && !errors.Is(err, custom_errors.ErrNotModified) {
+		return 0, err
+	}
+
+	logger.Info("Synced branch", "head", b.HeadSHA, "since", since.Format(time.RFC3339), "linked", linked, "inserted", inserted)
+	return inserted, nil
 }
 
-func toSQLNullString(s *string) sql.NullString {
-	if s == nil {
-		return sql.NullString{}
+// matchesAny reports whether name matches one of the path.Match patterns, which NewSyncer validated.
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
 	}
-	return sql.NullString{
-		String: *s,
-		Valid:  *s != "",
+	return false
+}
+
+// syncPullRequests stores the pull requests of a repository updated since the newest stored update,
+// or since the default date on the first sync. Pull requests listed again, such as those updated at
+// the newest stored time, are updated in place; syncPullRequestDetails fetches what listings lack.
+func (s *Syncer) syncPullRequests(ctx context.Context, q database.Store, id RepoIdentifier) error {
+	ps, ok := s.sources[id.Host].(pullRequestSource)
+	if !ok {
+		return nil
+	}
+
+	repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: id.Provider,
+		Host:     id.Host,
+		Owner:    id.Owner,
+		Name:     id.Name,
+	})
+	if err != nil {
+		return err
+	}
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repo.ID)
+
+	since := s.defaultSince
+	latest, err := q.GetLatestPullRequestUpdateForRepo(ctx, repo.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if latest.Valid {
+		since = latest.Time
+	}
+
+	var pulls int
+	err = ps.ForEachPullRequestPage(ctx, id.Owner, id.Name, since, func(page []model.PullRequest) error {
+		for _, pr := range page {
+			if _, err := q.UpsertPullRequest(ctx, preparePullRequestUpsert(repo.ID, pr)); err != nil {
+				return err
+			}
+			pulls++
+		}
+		logger.Debug("Stored page of pull requests", "count", len(page), "total", pulls)
+		return nil
+	})
+	switch {
+	case errors.Is(err, custom_errors.ErrNotModified):
+		logger.Info("Pull requests unchanged since last sync")
+		return nil
+	case errors.Is(err, errors.ErrUnsupported):
+		return nil // e.g. a git mirror with GitLab metadata
+	case err != nil:
+		return err
+	}
+
+	logger.Info("Synced pull requests", "since", since.Format(time.RFC3339), "pull_requests", pulls)
+	return nil
+}
+
+// syncIssues replaces the stored labels of a repository with its current ones, and stores its
+// issues updated since the newest stored update, or since the default date on the first sync.
+// Issues listed without comments lose those stored for them, which have been deleted;
+// syncIssueComments fetches the comments of the others.
+func (s *Syncer) syncIssues(ctx context.Context, q database.Store, id RepoIdentifier) error {
+	is, ok := s.sources[id.Host].(issueSource)
+	if !ok {
+		return nil
+	}
+
+	repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: id.Provider,
+		Host:     id.Host,
+		Owner:    id.Owner,
+		Name:     id.Name,
+	})
+	if err != nil {
+		return err
+	}
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repo.ID)
+
+	labels, err := is.ListLabels(ctx, id.Owner, id.Name)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil // e.g. a git mirror with GitLab metadata
+	}
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		err := q.UpsertLabel(ctx, database.UpsertLabelParams{
+			RepositoryID: repo.ID,
+			Name:         l.Name,
+			Color:        l.Color,
+			Description:  l.Description,
+		})
+		if err != nil {
+			return err
+		}
+		names = append(names, l.Name)
+	}
+	if err := q.DeleteLabelsNotIn(ctx, database.DeleteLabelsNotInParams{RepositoryID: repo.ID, Names: names}); err != nil {
+		return err
+	}
+
+	since := s.defaultSince
+	latest, err := q.GetLatestIssueUpdateForRepo(ctx, repo.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if latest.Valid {
+		since = latest.Time
+	}
+
+	var issues int
+	err = is.ForEachIssuePage(ctx, id.Owner, id.Name, since, func(page []model.Issue) error {
+		for _, issue := range page {
+			stored, err := q.UpsertIssue(ctx, prepareIssueUpsert(repo.ID, issue))
+			if err != nil {
+				return err
+			}
+			if issue.CommentsCount == 0 {
+				err := q.DeleteIssueCommentsNotIn(ctx, database.DeleteIssueCommentsNotInParams{
+					IssueID:          stored.ID,
+					GithubCommentIds: []int64{}, // not nil, which would delete nothing
+				})
+				if err != nil {
+					return err
+				}
+			}
+			issues++
+		}
+		logger.Debug("Stored page of issues", "count", len(page), "total", issues)
+		return nil
+	})
+	switch {
+	case errors.Is(err, custom_errors.ErrNotModified):
+		logger.Info("Issues unchanged since last sync", "labels", len(labels))
+		return nil
+	case errors.Is(err, errors.ErrUnsupported):
+		return nil // the labels, and any issues listed before, are kept
+	case err != nil:
+		return err
+	}
+
+	logger.Info("Synced issues", "since", since.Format(time.RFC3339), "issues", issues, "labels", len(labels))
+	return nil
+}
+
+// syncReleases replaces the stored releases and tags of a repository with its current ones, then
+// maps the stored commits to their first release; see mapFirstReleases. Only new tags, and tagged
+// commits stored after their tag, are walked; the first release of every stored commit is only
+// recomputed when an existing tag moved or was deleted.
+func (s *Syncer) syncReleases(ctx context.Context, q database.Store, id RepoIdentifier) error {
+	rs, ok := s.sources[id.Host].(releaseSource)
+	if !ok {
+		return nil
+	}
+
+	repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: id.Provider,
+		Host:     id.Host,
+		Owner:    id.Owner,
+		Name:     id.Name,
+	})
+	if err != nil {
+		return err
+	}
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repo.ID)
+
+	releases, err := rs.ListReleases(ctx, id.Owner, id.Name)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil // e.g. a git mirror with GitLab metadata
+	}
+	if err != nil {
+		return err
+	}
+	releaseIDs := make([]int64, 0, len(releases))
+	for _, r := range releases {
+		if err := storeRelease(ctx, q, repo.ID, r); err != nil {
+			return err
+		}
+		releaseIDs = append(releaseIDs, r.GithubID)
+	}
+	if err := q.DeleteReleasesNotIn(ctx, database.DeleteReleasesNotInParams{RepositoryID: repo.ID, GithubReleaseIds: releaseIDs}); err != nil {
+		return err
+	}
+
+	tags, err := rs.ListTags(ctx, id.Owner, id.Name)
+	if err != nil {
+		return err
+	}
+	added, moved, err := storeTags(ctx, q, repo.ID, tags)
+	if err != nil {
+		return err
+	}
+	mapped, err := mapFirstReleases(ctx, q, repo.ID, added, moved)
+	if err != nil {
+		return err
+	}
+	if mapped > 0 {
+		logger.Info("Mapped commits to their first release", "commits", mapped, "recomputed", moved)
+	}
+
+	logger.Info("Synced releases", "releases", len(releases), "tags", len(tags))
+	return nil
+}
+
+// storeRelease upserts a release and replaces its stored assets.
+func storeRelease(ctx context.Context, q database.Querier, repoID int64, r model.Release) error {
+	stored, err := q.UpsertRelease(ctx, database.UpsertReleaseParams{
+		RepositoryID:     repoID,
+		GithubReleaseID:  r.GithubID,
+		TagName:          r.TagName,
+		Name:             r.Name,
+		Draft:            r.Draft,
+		Prerelease:       r.Prerelease,
+		AuthorLogin:      r.AuthorLogin,
+		Url:              r.URL,
+		ReleaseCreatedAt: r.CreatedAt,
+		PublishedAt:      toPgTimestamptz(r.PublishedAt),
+	})
+	if err != nil {
+		return err
+	}
+	assetIDs := make([]int64, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		err := q.UpsertReleaseAsset(ctx, database.UpsertReleaseAssetParams{
+			ReleaseID:     stored.ID,
+			GithubAssetID: a.ID,
+			Name:          a.Name,
+			Size:          a.Size,
+			DownloadCount: a.DownloadCount,
+		})
+		if err != nil {
+			return err
+		}
+		assetIDs = append(assetIDs, a.ID)
+	}
+	return q.DeleteReleaseAssetsNotIn(ctx, database.DeleteReleaseAssetsNotInParams{ReleaseID: stored.ID, GithubAssetIds: assetIDs})
+}
+
+// storeTags replaces the stored tags of a repository with tags. It returns the names of the tags
+// that were not stored before, and whether a stored tag moved to another commit or was deleted.
+func storeTags(ctx context.Context, q database.Querier, repoID int64, tags []model.Tag) (added []string, moved bool, err error) {
+	stored, err := q.GetTagsByRepoID(ctx, repoID)
+	if err != nil {
+		return nil, false, err
+	}
+	storedSHAs := make(map[string]string, len(stored))
+	for _, t := range stored {
+		storedSHAs[t.Name] = t.Sha
+	}
+	listedSHAs := make(map[string]string, len(tags))
+	for _, t := range tags {
+		listedSHAs[t.Name] = t.SHA
+	}
+	for _, t := range stored {
+		if sha, ok := listedSHAs[t.Name]; !ok || sha != t.Sha {
+			moved = true
+		}
+	}
+
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+		sha, ok := storedSHAs[t.Name]
+		if ok && sha == t.SHA {
+			continue
+		}
+		if !ok {
+			added = append(added, t.Name)
+		}
+		if err := q.UpsertTag(ctx, database.UpsertTagParams{RepositoryID: repoID, Name: t.Name, Sha: t.SHA}); err != nil {
+			return nil, false, err
+		}
+	}
+	if !moved {
+		return added, false, nil
+	}
+	return added, true, q.DeleteTagsNotIn(ctx, database.DeleteTagsNotInParams{RepositoryID: repoID, Names: names})
+}
+
+// mapFirstReleases maps the stored commits of a repository to their first release, the earliest
+// tag containing them, and returns how many commits it mapped. Tags are walked in the order of the
+// commits they point to, oldest first, each claiming the commits it contains that no older tag
+// does. Containment is followed through the stored parents, so commits stored without them only
+// get a first release if a tag points at them.
+//
+// Only the tags whose commit has no first release yet are walked, as long as they are newer than
+// every tag already mapped. When a tag moved or was deleted, when a tag was added to commits an
+// existing tag contains, or when an unmapped tag is older than a mapped one, the mapping of every
+// stored commit is cleared and recomputed instead.
+func mapFirstReleases(ctx context.Context, q database.Querier, repoID int64, added []string, moved bool) (int64, error) {
+	tags, err := q.GetTagsByCommitDate(ctx, repoID)
+	if err != nil {
+		return 0, err
+	}
+	recompute := moved
+	var unmapped []database.GetTagsByCommitDateRow
+	for _, t := range tags {
+		switch {
+		case t.FirstRelease == "":
+			unmapped = append(unmapped, t)
+		case slices.Contains(added, t.Name), len(unmapped) > 0:
+			recompute = true
+		}
+	}
+	if recompute {
+		if err := q.ClearFirstReleases(ctx, repoID); err != nil {
+			return 0, err
+		}
+		unmapped = tags
+	}
+
+	var mapped int64
+	for _, t := range unmapped {
+		n, err := q.AssignFirstRelease(ctx, database.AssignFirstReleaseParams{RepositoryID: repoID, Sha: t.Sha, Tag: t.Name})
+		if err != nil {
+			return 0, err
+		}
+		mapped += n
+	}
+	return mapped, nil
+}
+
+// syncWorkflows stores the CI workflows of a repository and the runs created since the oldest
+// stored run that had not completed, or else since the newest stored run, less sinceOverlap so
+// runs that were re-run shortly after are updated too. The first sync starts at the default date.
+func (s *Syncer) syncWorkflows(ctx context.Context, q database.Store, id RepoIdentifier) error {
+	ws, ok := s.sources[id.Host].(workflowSource)
+	if !ok {
+		return nil
+	}
+
+	repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: id.Provider,
+		Host:     id.Host,
+		Owner:    id.Owner,
+		Name:     id.Name,
+	})
+	if err != nil {
+		return err
+	}
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repo.ID)
+
+	workflows, err := ws.ListWorkflows(ctx, id.Owner, id.Name)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	known := make(map[int64]bool, len(workflows))
+	for _, w := range workflows {
+		err := q.UpsertWorkflow(ctx, database.UpsertWorkflowParams{
+			RepositoryID:     repo.ID,
+			GithubWorkflowID: w.ID,
+			Name:             w.Name,
+			Path:             w.Path,
+			State:            w.State,
+		})
+		if err != nil {
+			return err
+		}
+		known[w.ID] = true
+	}
+
+	since := s.defaultSince
+	start, err := q.GetWorkflowRunSyncStart(ctx, repo.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if start.Valid {
+		since = start.Time.Add(-sinceOverlap)
+	}
+
+	var runs int
+	err = ws.ForEachWorkflowRunPage(ctx, id.Owner, id.Name, since, func(page []model.WorkflowRun) error {
+		for _, r := range page {
+			// Runs of deleted workflows are still listed, so their workflow is stored by the run's name.
+			if !known[r.WorkflowID] {
+				err := q.UpsertWorkflow(ctx, database.UpsertWorkflowParams{
+					RepositoryID:     repo.ID,
+					GithubWorkflowID: r.WorkflowID,
+					Name:             r.Name,
+					State:            "deleted",
+				})
+				if err != nil {
+					return err
+				}
+				known[r.WorkflowID] = true
+			}
+			if err := q.UpsertWorkflowRun(ctx, prepareWorkflowRunUpsert(repo.ID, r)); err != nil {
+				return err
+			}
+			runs++
+		}
+		return nil
+	})
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil // the workflows, and any runs listed before, are kept
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Synced workflow runs", "since", since.Format(time.RFC3339), "workflows", len(workflows), "runs", runs)
+	return nil
+}
+
+// syncStargazers backfills who starred a repository and when, if enabled and none are stored yet.
+// It runs once per repository: later stars are recorded by the snapshot every sync takes.
+func (s *Syncer) syncStargazers(ctx context.Context, q database.Store, id RepoIdentifier) error {
+	ss, ok := s.sources[id.Host].(stargazerSource)
+	if !s.stargazers || !ok {
+		return nil
+	}
+
+	repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: id.Provider,
+		Host:     id.Host,
+		Owner:    id.Owner,
+		Name:     id.Name,
+	})
+	if err != nil || repo.StarsCount == 0 {
+		return err
+	}
+	stored, err := q.CountStargazers(ctx, repo.ID)
+	if err != nil || stored > 0 {
+		return err
+	}
+
+	stargazers, err := ss.ListStargazers(ctx, id.Owner, id.Name)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(stargazers))
+	params := make([]database.CreateStargazersParams, 0, len(stargazers))
+	for _, sg := range stargazers {
+		// Someone who takes back their star and stars again while the listing is paged through
+		// is listed twice.
+		if seen[sg.Login] {
+			continue
+		}
+		seen[sg.Login] = true
+		params = append(params, database.CreateStargazersParams{RepositoryID: repo.ID, Login: sg.Login, StarredAt: sg.StarredAt})
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	n, err := q.CreateStargazers(ctx, params)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Backfilled stargazers", "host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repo.ID, "count", n)
+	return nil
+}
+
+// syncLanguages replaces the stored languages and topics of a repository with its current ones,
+// and records its languages with the snapshot taken earlier in the sync.
+func (s *Syncer) syncLanguages(ctx context.Context, q database.Store, id RepoIdentifier) error {
+	ls, ok := s.sources[id.Host].(languageSource)
+	if !ok {
+		return nil
+	}
+
+	repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: id.Provider,
+		Host:     id.Host,
+		Owner:    id.Owner,
+		Name:     id.Name,
+	})
+	if err != nil {
+		return err
+	}
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repo.ID)
+
+	languages, err := ls.GetLanguages(ctx, id.Owner, id.Name)
+	switch {
+	case errors.Is(err, custom_errors.ErrNotModified):
+		logger.Debug("Languages unchanged since last sync")
+	case errors.Is(err, errors.ErrUnsupported):
+		return nil // e.g. a git mirror with GitLab metadata
+	case err != nil:
+		return err
+	default:
+		if err := storeLanguages(ctx, q, repo.ID, languages); err != nil {
+			return err
+		}
+		logger.Info("Stored languages", "count", len(languages))
+	}
+	if err := q.CreateSnapshotLanguages(ctx, repo.ID); err != nil {
+		return err
+	}
+
+	topics, err := ls.ListTopics(ctx, id.Owner, id.Name)
+	if errors.Is(err, custom_errors.ErrNotModified) {
+		logger.Debug("Topics unchanged since last sync")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if topics == nil {
+		topics = []string{} // A NULL array would keep every stored topic.
+	}
+	if err := q.DeleteRepositoryTopicsNotIn(ctx, database.DeleteRepositoryTopicsNotInParams{RepositoryID: repo.ID, Topics: topics}); err != nil {
+		return err
+	}
+	if err := q.AddRepositoryTopics(ctx, database.AddRepositoryTopicsParams{RepositoryID: repo.ID, Topics: topics}); err != nil {
+		return err
+	}
+	logger.Info("Stored topics", "count", len(topics))
+	return nil
+}
+
+// storeLanguages replaces the stored languages of a repository.
+func storeLanguages(ctx context.Context, q database.Querier, repoID int64, languages map[string]int64) error {
+	names := make([]string, 0, len(languages))
+	for language := range languages {
+		names = append(names, language)
+	}
+	slices.Sort(names)
+	bytes := make([]int64, len(names))
+	for i, language := range names {
+		bytes[i] = languages[language]
+	}
+	if err := q.DeleteRepositoryLanguagesNotIn(ctx, database.DeleteRepositoryLanguagesNotInParams{RepositoryID: repoID, Languages: names}); err != nil {
+		return err
+	}
+	return q.UpsertRepositoryLanguages(ctx, database.UpsertRepositoryLanguagesParams{RepositoryID: repoID, Languages: names, Bytes: bytes})
+}
+
+// syncCommitFiles fetches the files changed by the newest stored commits whose files are not
+// stored yet, up to the repository's share of the per-cycle budget. Each commit is stored in
+// its own transaction, so an error does not lose the commits fetched before it. Commits whose
+// files cannot be fetched are skipped and tried again next cycle, so one does not hold up the
+// others or fail the sync.
+func (s *Syncer) syncCommitFiles(ctx context.Context, id RepoIdentifier) error {
+	cs, ok := s.sources[id.Host].(commitFileSource)
+	if s.commitFiles <= 0 || !ok {
+		return nil
+	}
+	share := s.share(s.commitFiles)
+
+	var repoID int64
+	var shas []string
+	err := s.inTx(ctx, id, func(q database.Store) error {
+		repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+			Provider: id.Provider,
+			Host:     id.Host,
+			Owner:    id.Owner,
+			Name:     id.Name,
+		})
+		if err != nil {
+			return err
+		}
+		repoID = repo.ID
+		shas, err = q.GetCommitsWithoutFiles(ctx, database.GetCommitsWithoutFilesParams{RepositoryID: repo.ID, Limit: int32(share)})
+		return err
+	})
+	if err != nil || len(shas) == 0 {
+		return err
+	}
+
+	logger := s.logger.With("host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repoID)
+	var stored int
+	for _, sha := range shas {
+		files, err := cs.GetCommitFiles(ctx, id.Owner, id.Name, sha)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			logger.Warn("Failed to get changed files of commit", "sha", sha, "error", err)
+			continue
+		}
+		err = s.inTx(ctx, id, func(q database.Store) error {
+			return storeCommitFiles(ctx, q, repoID, sha, files)
+		})
+		if err != nil {
+			return err
+		}
+		stored++
+	}
+	logger.Info("Stored changed files of commits", "count", stored, "failed", len(shas)-stored)
+	return nil
+}
+
+// share returns a repository's share of a per-cycle budget, rounded up.
+func (s *Syncer) share(budget int) int {
+	// On-demand syncs may run before the first cycle has loaded any repositories.
+	s.mu.Lock()
+	repos := max(len(s.reposToSync)+len(s.discovered), 1)
+	s.mu.Unlock()
+	return (budget + repos - 1) / repos
+}
+
+// syncPullRequestDetails fetches the line stats, merger and reviews of the most recently updated
+// stored pull requests updated since they were last fetched, up to the repository's share of the
+// per-cycle budget. Each pull request is stored in its own transaction, like in syncCommitFiles.
+func (s *Syncer) syncPullRequestDetails(ctx context.Context, id RepoIdentifier) error {
+	ps, ok := s.sources[id.Host].(pullRequestDetailSource)
+	if s.pullDetails <= 0 || !ok {
+		return nil
+	}
+
+	var repoID int64
+	var numbers []int32
+	err := s.inTx(ctx, id, func(q database.Store) error {
+		repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+			Provider: id.Provider,
+			Host:     id.Host,
+			Owner:    id.Owner,
+			Name:     id.Name,
+		})
+		if err != nil {
+			return err
+		}
+		repoID = repo.ID
+		numbers, err = q.GetPullRequestsWithoutDetails(ctx, database.GetPullRequestsWithoutDetailsParams{RepositoryID: repo.ID, Limit: int32(s.share(s.pullDetails))})
+		return err
+	})
+	if err != nil || len(numbers) == 0 {
+		return err
+	}
+
+	var reviews int
+	for _, number := range numbers {
+		pr, err := ps.GetPullRequest(ctx, id.Owner, id.Name, int(number))
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get pull request %d: %w", number, err)
+		}
+		err = s.inTx(ctx, id, func(q database.Store) error {
+			return storePullRequestDetails(ctx, q, repoID, pr)
+		})
+		if err != nil {
+			return err
+		}
+		reviews += len(pr.Reviews)
+	}
+	s.logger.Info("Stored details of pull requests", "host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repoID, "count", len(numbers), "reviews", reviews)
+	return nil
+}
+
+// syncIssueComments fetches the comments of the most recently updated stored issues whose comments
+// were listed before their last update, up to the repository's share of the per-cycle budget. Each
+// issue's comments are stored in their own transaction, like in syncCommitFiles.
+func (s *Syncer) syncIssueComments(ctx context.Context, id RepoIdentifier) error {
+	is, ok := s.sources[id.Host].(issueCommentSource)
+	if s.issueComments <= 0 || !ok {
+		return nil
+	}
+
+	var repoID int64
+	var numbers []int32
+	err := s.inTx(ctx, id, func(q database.Store) error {
+		repo, err := q.GetRepositoryByProviderHostOwnerAndName(ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+			Provider: id.Provider,
+			Host:     id.Host,
+			Owner:    id.Owner,
+			Name:     id.Name,
+		})
+		if err != nil {
+			return err
+		}
+		repoID = repo.ID
+		numbers, err = q.GetIssuesWithStaleComments(ctx, database.GetIssuesWithStaleCommentsParams{RepositoryID: repo.ID, Limit: int32(s.share(s.issueComments))})
+		return err
+	})
+	if err != nil || len(numbers) == 0 {
+		return err
+	}
+
+	var comments int
+	for _, number := range numbers {
+		listed, err := is.ListIssueComments(ctx, id.Owner, id.Name, int(number))
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("list comments of issue %d: %w", number, err)
+		}
+		err = s.inTx(ctx, id, func(q database.Store) error {
+			return storeIssueComments(ctx, q, repoID, int(number), listed)
+		})
+		if err != nil {
+			return err
+		}
+		comments += len(listed)
+	}
+	s.logger.Info("Stored comments of issues", "host", id.Host, "owner", id.Owner, "repo", id.Name, "repo_id", repoID, "count", len(numbers), "comments", comments)
+	return nil
+}
+
+// storeIssueComments replaces the stored comments of an issue with its current ones and marks them
+// as up to date. Comments no longer listed have been deleted and are removed.
+func storeIssueComments(ctx context.Context, q database.Querier, repoID int64, number int, comments []model.IssueComment) error {
+	issueID, err := q.MarkIssueCommentsSynced(ctx, database.MarkIssueCommentsSyncedParams{RepositoryID: repoID, Number: int32(number)})
+	if err != nil {
+		return err
+	}
+	commentIDs := make([]int64, 0, len(comments))
+	for _, c := range comments {
+		err := q.UpsertIssueComment(ctx, database.UpsertIssueCommentParams{
+			IssueID:          issueID,
+			GithubCommentID:  c.ID,
+			AuthorLogin:      c.AuthorLogin,
+			CommentCreatedAt: c.CreatedAt,
+			CommentUpdatedAt: c.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		commentIDs = append(commentIDs, c.ID)
+	}
+	return q.DeleteIssueCommentsNotIn(ctx, database.DeleteIssueCommentsNotInParams{
+		IssueID:          issueID,
+		GithubCommentIds: commentIDs,
+	})
+}
+
+// storePullRequestDetails stores the line stats, merger and reviews of a pull request. Reviews
+// fetched again are updated in place.
+func storePullRequestDetails(ctx context.Context, q database.Querier, repoID int64, pr model.PullRequest) error {
+	pullID, err := q.UpdatePullRequestDetails(ctx, database.UpdatePullRequestDetailsParams{
+		RepositoryID:     repoID,
+		Number:           int32(pr.Number),
+		Additions:        int32(pr.Additions),
+		Deletions:        int32(pr.Deletions),
+		MergedByLogin:    pr.MergedByLogin,
+		DetailsUpdatedAt: toPgTimestamptz(pr.UpdatedAt),
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range pr.Reviews {
+		err := q.UpsertPullRequestReview(ctx, database.UpsertPullRequestReviewParams{
+			PullRequestID:  pullID,
+			GithubReviewID: r.ID,
+			ReviewerLogin:  r.ReviewerLogin,
+			State:          r.State,
+			CommitSha:      r.CommitSHA,
+			SubmittedAt:    r.SubmittedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeCommitFiles stores the files a commit changed and marks it as done. Commits whose
+// backend did not report line stats get the totals of their files.
+func storeCommitFiles(ctx context.Context, q database.Querier, repoID int64, sha string, files []model.CommitFile) error {
+	arg := database.MarkCommitFilesSyncedParams{RepositoryID: repoID, Sha: sha}
+	params := make([]database.CreateCommitFilesParams, len(files))
+	for i, f := range files {
+		params[i] = database.CreateCommitFilesParams{
+			RepositoryID: repoID,
+			Sha:          sha,
+			Path:         f.Filename,
+			Status:       f.Status,
+			Additions:    int32(f.Additions),
+			Deletions:    int32(f.Deletions),
+		}
+		arg.Additions += int32(f.Additions)
+		arg.Deletions += int32(f.Deletions)
+	}
+	if len(params) > 0 {
+		if _, err := q.CreateCommitFiles(ctx, params); err != nil {
+			return err
+		}
+	}
+	return q.MarkCommitFilesSynced(ctx, arg)
+}
+
+// getSinceTimestamp returns the time to fetch commits from and whether any commits are stored.
+// Listings start sinceOverlap before the newest stored commit date rather than right after it,
+// since commit dates are not monotonic.
+func (s *Syncer) getSinceTimestamp(ctx context.Context, q database.Querier, repoID int64) (time.Time, bool, error) {
+	latestCommitDate, err := q.GetLatestCommitDateForRepo(ctx, repoID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, err
+	}
+
+	if !latestCommitDate.Valid {
+		s.logger.Info("No existing commits found for repository, using default start date", "default_since", s.defaultSince)
+		return s.defaultSince, false, nil
+	}
+
+	s.logger.Info("Found latest commit in DB", "timestamp", latestCommitDate.Time)
+	return latestCommitDate.Time.Add(-sinceOverlap), true, nil
+}
+
+// forEachCommitPage streams the new commits of a repository to fn: those after the newest stored
+// commit if the source can walk history from a SHA, otherwise those since the given time.
+func (s *Syncer) forEachCommitPage(ctx context.Context, q database.Querier, id RepoIdentifier, repoID int64, since time.Time, fn func(page []model.Commit) error) error {
+	src := s.sources[id.Host]
+	ss, ok := src.(shaCommitSource)
+	if !ok {
+		return src.ForEachCommitPage(ctx, id.Owner, id.Name, since, fn)
+	}
+
+	sha, err := q.GetLatestCommitSHAForRepo(ctx, repoID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	return ss.ForEachCommitPageSinceSHA(ctx, id.Owner, id.Name, sha, since, fn)
+}
+
+// parseRepoIdentifiers parses 'owner/name' entries, which live on github.DefaultHost,
+// 'host/owner/name' entries for GitHub Enterprise hosts, and 'gitlab:group/project' entries,
+// whose group may contain subgroups. GitLab entries get gitlab.DefaultHost, which NewSyncer
+// replaces with the host of the configured GitLab instance.
+func parseRepoIdentifiers(repos []string) ([]RepoIdentifier, error) {
+	var identifiers []RepoIdentifier
+	for _, r := range repos {
+		if path, ok := strings.CutPrefix(r, gitlabPrefix); ok {
+			i := strings.LastIndex(path, "/")
+			if i <= 0 || i == len(path)-1 || strings.Contains(path, "//") {
+				return nil, &custom_errors.ErrInvalidRepoFormat{Repo: r}
+			}
+			identifiers = append(identifiers, RepoIdentifier{Provider: model.ProviderGitLab, Host: gitlab.DefaultHost, Owner: path[:i], Name: path[i+1:]})
+			continue
+		}
+
+		parts := strings.Split(r, "/")
+		if len(parts) == 2 {
+			parts = append([]string{github.DefaultHost}, parts...)
+		}
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, &custom_errors.ErrInvalidRepoFormat{Repo: r}
+		}
+		identifiers = append(identifiers, RepoIdentifier{Provider: model.ProviderGitHub, Host: parts[0], Owner: parts[1], Name: parts[2]})
+	}
+	return identifiers, nil
+}
+
+// prepareCommitBulkInsert converts commits for UpsertCommits. onDefaultBranch is false for
+// commits listed on other branches, which must not move the default branch's watermark.
+func prepareCommitBulkInsert(repoID int64, commits []model.Commit, onDefaultBranch bool) []database.CreateCommitsParams {
+	params := make([]database.CreateCommitsParams, len(commits))
+	for i, c := range commits {
+		params[i] = database.CreateCommitsParams{
+			RepositoryID:    repoID,
+			Sha:             c.SHA,
+			AuthorName:      c.AuthorName,
+			AuthorEmail:     c.AuthorEmail,
+			Message:         c.Message,
+			Url:             c.URL,
+			CommitDate:      c.CommitDate,
+			AuthorLogin:     c.AuthorLogin,
+			Additions:       toPgInt4(c.Additions),
+			Deletions:       toPgInt4(c.Deletions),
+			Verified:        c.Verified,
+			Parents:         c.Parents,
+			CommitterName:   c.CommitterName,
+			CommitterEmail:  c.CommitterEmail,
+			CommitterDate:   toPgTimestamptz(c.CommitterDate),
+			OnDefaultBranch: onDefaultBranch,
+		}
+		if params[i].Parents == nil {
+			params[i].Parents = []string{} // parents is NOT NULL
+		}
+	}
+	return params
+}
+
+// preparePullRequestUpsert converts a pull request for UpsertPullRequest.
+func preparePullRequestUpsert(repoID int64, pr model.PullRequest) database.UpsertPullRequestParams {
+	return database.UpsertPullRequestParams{
+		RepositoryID: repoID,
+		Number:       int32(pr.Number),
+		GithubPrID:   pr.GithubID,
+		Title:        pr.Title,
+		State:        pr.State,
+		AuthorLogin:  pr.AuthorLogin,
+		BaseRef:      pr.BaseRef,
+		HeadRef:      pr.HeadRef,
+		Url:          pr.URL,
+		PrCreatedAt:  pr.CreatedAt,
+		PrUpdatedAt:  pr.UpdatedAt,
+		MergedAt:     toPgTimestamptz(pr.MergedAt),
+		ClosedAt:     toPgTimestamptz(pr.ClosedAt),
+	}
+}
+
+func prepareIssueUpsert(repoID int64, issue model.Issue) database.UpsertIssueParams {
+	params := database.UpsertIssueParams{
+		RepositoryID:   repoID,
+		Number:         int32(issue.Number),
+		GithubIssueID:  issue.GithubID,
+		Title:          issue.Title,
+		State:          issue.State,
+		AuthorLogin:    issue.AuthorLogin,
+		Labels:         issue.Labels,
+		Assignees:      issue.Assignees,
+		Milestone:      issue.Milestone,
+		CommentsCount:  int32(issue.CommentsCount),
+		Url:            issue.URL,
+		IssueCreatedAt: issue.CreatedAt,
+		IssueUpdatedAt: issue.UpdatedAt,
+		ClosedAt:       toPgTimestamptz(issue.ClosedAt),
+	}
+	// labels and assignees are NOT NULL
+	if params.Labels == nil {
+		params.Labels = []string{}
+	}
+	if params.Assignees == nil {
+		params.Assignees = []string{}
+	}
+	return params
+}
+
+// prepareWorkflowRunUpsert converts a workflow run for UpsertWorkflowRun.
+func prepareWorkflowRunUpsert(repoID int64, r model.WorkflowRun) database.UpsertWorkflowRunParams {
+	return database.UpsertWorkflowRunParams{
+		RepositoryID: repoID,
+		GithubRunID:  r.ID,
+		WorkflowID:   r.WorkflowID,
+		Name:         r.Name,
+		Event:        r.Event,
+		Status:       r.Status,
+		Conclusion:   r.Conclusion,
+		HeadSha:      r.HeadSHA,
+		HeadBranch:   r.HeadBranch,
+		RunNumber:    int32(r.RunNumber),
+		RunAttempt:   int32(r.RunAttempt),
+		RunCreatedAt: r.CreatedAt,
+		RunStartedAt: r.StartedAt,
+		RunUpdatedAt: r.UpdatedAt,
+	}
+}
+
+func toSQLNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{
+		String: *s,
+		Valid:  *s != "",
+	}
+}
+
+func toPgInt4(i *int) pgtype.Int4 {
+	if i == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(*i), Valid: true}
+}
+
+func toPgTimestamptz(t time.Time) pgtype.Timestamptz {
+	if t.IsZero() {
+		return pgtype.Timestamptz{}
 	}
+	return pgtype.Timestamptz{Time: t, Valid: true}
 }
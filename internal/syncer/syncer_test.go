@@ -4,8 +4,15 @@ package syncer
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,44 +20,374 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github-data-fetcher/internal/database"
+	custom_errors "github-data-fetcher/internal/errors"
+	"github-data-fetcher/internal/github"
+	"github-data-fetcher/internal/github/cassette"
+	"github-data-fetcher/internal/githubfake"
+	"github-data-fetcher/internal/gitlab"
 	"github-data-fetcher/internal/model"
 )
 
-// MockQuerier is a mock of the database.Querier interface.
+// MockQuerier is a mock of the database.Store interface.
 type MockQuerier struct {
 	mock.Mock
 }
 
+func (m *MockQuerier) AddCommitsToBranch(ctx context.Context, arg database.AddCommitsToBranchParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) AddRepositoryTopics(ctx context.Context, arg database.AddRepositoryTopicsParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) AdvanceSyncCheckpoint(ctx context.Context, arg database.AdvanceSyncCheckpointParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) AssignFirstRelease(ctx context.Context, arg database.AssignFirstReleaseParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) ClearBranchCommits(ctx context.Context, branchID int64) error {
+	args := m.Called(ctx, branchID)
+	return args.Error(0)
+}
+func (m *MockQuerier) ClearFirstReleases(ctx context.Context, repositoryID int64) error {
+	args := m.Called(ctx, repositoryID)
+	return args.Error(0)
+}
+func (m *MockQuerier) CompleteSyncCheckpoint(ctx context.Context, repositoryID int64) error {
+	args := m.Called(ctx, repositoryID)
+	return args.Error(0)
+}
+func (m *MockQuerier) CountStargazers(ctx context.Context, repositoryID int64) (int64, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) CreateCommitFiles(ctx context.Context, arg []database.CreateCommitFilesParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
 func (m *MockQuerier) CreateCommits(ctx context.Context, arg []database.CreateCommitsParams) (int64, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).(int64), args.Error(1)
 }
+func (m *MockQuerier) CreateHistoryRewrite(ctx context.Context, arg database.CreateHistoryRewriteParams) (database.HistoryRewrite, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.HistoryRewrite), args.Error(1)
+}
 func (m *MockQuerier) CreateRepository(ctx context.Context, arg database.CreateRepositoryParams) (database.Repository, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).(database.Repository), args.Error(1)
 }
-func (m *MockQuerier) GetCommitsByRepoID(ctx context.Context, repositoryID int64) ([]database.Commit, error) {
+func (m *MockQuerier) CreateRepositorySnapshot(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockQuerier) CreateSnapshotLanguages(ctx context.Context, repositoryID int64) error {
+	args := m.Called(ctx, repositoryID)
+	return args.Error(0)
+}
+func (m *MockQuerier) CreateStargazers(ctx context.Context, arg []database.CreateStargazersParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) CreateSyncJob(ctx context.Context, arg database.CreateSyncJobParams) (database.SyncJob, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.SyncJob), args.Error(1)
+}
+func (m *MockQuerier) DeleteBranchesNotIn(ctx context.Context, arg database.DeleteBranchesNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteHTTPValidators(ctx context.Context, url string) error {
+	args := m.Called(ctx, url)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteIssueCommentsNotIn(ctx context.Context, arg database.DeleteIssueCommentsNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteLabelsNotIn(ctx context.Context, arg database.DeleteLabelsNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteReleaseAssetsNotIn(ctx context.Context, arg database.DeleteReleaseAssetsNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteReleasesNotIn(ctx context.Context, arg database.DeleteReleasesNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteRepositoryLanguagesNotIn(ctx context.Context, arg database.DeleteRepositoryLanguagesNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteRepositoryTopicsNotIn(ctx context.Context, arg database.DeleteRepositoryTopicsNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteTagsNotIn(ctx context.Context, arg database.DeleteTagsNotInParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) DeleteTrackedRepository(ctx context.Context, entry string) (int64, error) {
+	args := m.Called(ctx, entry)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) FailUnfinishedSyncJobs(ctx context.Context, arg database.FailUnfinishedSyncJobsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) FinishSyncJob(ctx context.Context, arg database.FinishSyncJobParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) GetBranch(ctx context.Context, arg database.GetBranchParams) (database.Branch, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Branch), args.Error(1)
+}
+func (m *MockQuerier) GetBranchesByRepoID(ctx context.Context, repositoryID int64) ([]database.Branch, error) {
 	args := m.Called(ctx, repositoryID)
+	return args.Get(0).([]database.Branch), args.Error(1)
+}
+func (m *MockQuerier) GetCommitsByBranchID(ctx context.Context, branchID int64) ([]database.Commit, error) {
+	args := m.Called(ctx, branchID)
+	return args.Get(0).([]database.Commit), args.Error(1)
+}
+func (m *MockQuerier) GetCommitsByRepoID(ctx context.Context, arg database.GetCommitsByRepoIDParams) ([]database.Commit, error) {
+	args := m.Called(ctx, arg)
 	return args.Get(0).([]database.Commit), args.Error(1)
 }
+func (m *MockQuerier) GetCommitsWithoutFiles(ctx context.Context, arg database.GetCommitsWithoutFilesParams) ([]string, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *MockQuerier) GetHTTPValidators(ctx context.Context, url string) (database.HttpValidator, error) {
+	args := m.Called(ctx, url)
+	return args.Get(0).(database.HttpValidator), args.Error(1)
+}
+func (m *MockQuerier) GetHistoryRewritesByRepoID(ctx context.Context, repositoryID int64) ([]database.HistoryRewrite, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).([]database.HistoryRewrite), args.Error(1)
+}
+func (m *MockQuerier) GetIssuesByRepoID(ctx context.Context, arg database.GetIssuesByRepoIDParams) ([]database.Issue, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.Issue), args.Error(1)
+}
+func (m *MockQuerier) GetIssuesWithStaleComments(ctx context.Context, arg database.GetIssuesWithStaleCommentsParams) ([]int32, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]int32), args.Error(1)
+}
+func (m *MockQuerier) GetLabelsByRepoID(ctx context.Context, repositoryID int64) ([]database.Label, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).([]database.Label), args.Error(1)
+}
+func (m *MockQuerier) GetLatestCommitDateForBranch(ctx context.Context, branchID int64) (pgtype.Timestamp, error) {
+	args := m.Called(ctx, branchID)
+	return args.Get(0).(pgtype.Timestamp), args.Error(1)
+}
 func (m *MockQuerier) GetLatestCommitDateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamp, error) {
 	args := m.Called(ctx, repositoryID)
 	return args.Get(0).(pgtype.Timestamp), args.Error(1)
 }
-func (m *MockQuerier) GetRepositoryByOwnerAndName(ctx context.Context, arg database.GetRepositoryByOwnerAndNameParams) (database.Repository, error) {
+func (m *MockQuerier) GetLatestCommitSHAForRepo(ctx context.Context, repositoryID int64) (string, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.String(0), args.Error(1)
+}
+func (m *MockQuerier) GetLatestIssueUpdateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).(pgtype.Timestamptz), args.Error(1)
+}
+func (m *MockQuerier) GetLatestPullRequestUpdateForRepo(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).(pgtype.Timestamptz), args.Error(1)
+}
+func (m *MockQuerier) GetPullRequestReviewsByPullRequestIDs(ctx context.Context, pullRequestIds []int64) ([]database.PullRequestReview, error) {
+	args := m.Called(ctx, pullRequestIds)
+	return args.Get(0).([]database.PullRequestReview), args.Error(1)
+}
+func (m *MockQuerier) GetPullRequestsByRepoID(ctx context.Context, arg database.GetPullRequestsByRepoIDParams) ([]database.PullRequest, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.PullRequest), args.Error(1)
+}
+func (m *MockQuerier) GetPullRequestsWithoutDetails(ctx context.Context, arg database.GetPullRequestsWithoutDetailsParams) ([]int32, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]int32), args.Error(1)
+}
+func (m *MockQuerier) GetReleaseAssetsByReleaseIDs(ctx context.Context, releaseIds []int64) ([]database.ReleaseAsset, error) {
+	args := m.Called(ctx, releaseIds)
+	return args.Get(0).([]database.ReleaseAsset), args.Error(1)
+}
+func (m *MockQuerier) GetReleasesByRepoID(ctx context.Context, repositoryID int64) ([]database.GetReleasesByRepoIDRow, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).([]database.GetReleasesByRepoIDRow), args.Error(1)
+}
+func (m *MockQuerier) GetRepositoryByProviderHostOwnerAndName(ctx context.Context, arg database.GetRepositoryByProviderHostOwnerAndNameParams) (database.Repository, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).(database.Repository), args.Error(1)
 }
+func (m *MockQuerier) GetRepositoryGrowth(ctx context.Context, arg database.GetRepositoryGrowthParams) ([]database.GetRepositoryGrowthRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetRepositoryGrowthRow), args.Error(1)
+}
+func (m *MockQuerier) GetRepositoryLanguagesByRepoIDs(ctx context.Context, repositoryIds []int64) ([]database.GetRepositoryLanguagesByRepoIDsRow, error) {
+	args := m.Called(ctx, repositoryIds)
+	return args.Get(0).([]database.GetRepositoryLanguagesByRepoIDsRow), args.Error(1)
+}
+func (m *MockQuerier) GetRepositoryTopicsByRepoIDs(ctx context.Context, repositoryIds []int64) ([]database.RepositoryTopic, error) {
+	args := m.Called(ctx, repositoryIds)
+	return args.Get(0).([]database.RepositoryTopic), args.Error(1)
+}
+func (m *MockQuerier) GetStarHistory(ctx context.Context, arg database.GetStarHistoryParams) ([]database.GetStarHistoryRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetStarHistoryRow), args.Error(1)
+}
+func (m *MockQuerier) GetSyncCheckpoint(ctx context.Context, repositoryID int64) (database.SyncCheckpoint, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).(database.SyncCheckpoint), args.Error(1)
+}
+func (m *MockQuerier) GetSyncJob(ctx context.Context, id int64) (database.SyncJob, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.SyncJob), args.Error(1)
+}
+func (m *MockQuerier) GetTagsByCommitDate(ctx context.Context, repositoryID int64) ([]database.GetTagsByCommitDateRow, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).([]database.GetTagsByCommitDateRow), args.Error(1)
+}
+func (m *MockQuerier) GetTagsByRepoID(ctx context.Context, repositoryID int64) ([]database.Tag, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).([]database.Tag), args.Error(1)
+}
 func (m *MockQuerier) GetTopNCommitAuthors(ctx context.Context, arg database.GetTopNCommitAuthorsParams) ([]database.GetTopNCommitAuthorsRow, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).([]database.GetTopNCommitAuthorsRow), args.Error(1)
 }
+func (m *MockQuerier) GetWorkflowRunSyncStart(ctx context.Context, repositoryID int64) (pgtype.Timestamptz, error) {
+	args := m.Called(ctx, repositoryID)
+	return args.Get(0).(pgtype.Timestamptz), args.Error(1)
+}
+func (m *MockQuerier) GetWorkflowStats(ctx context.Context, arg database.GetWorkflowStatsParams) ([]database.GetWorkflowStatsRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetWorkflowStatsRow), args.Error(1)
+}
+func (m *MockQuerier) ListRepositories(ctx context.Context, arg database.ListRepositoriesParams) ([]database.Repository, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.Repository), args.Error(1)
+}
+func (m *MockQuerier) ListTrackedRepositories(ctx context.Context) ([]database.TrackedRepository, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.TrackedRepository), args.Error(1)
+}
+func (m *MockQuerier) MarkCommitFilesSynced(ctx context.Context, arg database.MarkCommitFilesSyncedParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) MarkCommitsUnreachable(ctx context.Context, arg database.MarkCommitsUnreachableParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) MarkIssueCommentsSynced(ctx context.Context, arg database.MarkIssueCommentsSyncedParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) MarkRepositorySynced(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockQuerier) RemoveCommitsFromBranch(ctx context.Context, arg database.RemoveCommitsFromBranchParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) SeedTrackedRepositories(ctx context.Context, entries []string) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+func (m *MockQuerier) StartSyncCheckpoint(ctx context.Context, arg database.StartSyncCheckpointParams) (database.SyncCheckpoint, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.SyncCheckpoint), args.Error(1)
+}
+func (m *MockQuerier) StartSyncJob(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpdatePullRequestDetails(ctx context.Context, arg database.UpdatePullRequestDetailsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) UpdateRepositoryHead(ctx context.Context, arg database.UpdateRepositoryHeadParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
 func (m *MockQuerier) UpdateRepositorySyncData(ctx context.Context, arg database.UpdateRepositorySyncDataParams) (database.Repository, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).(database.Repository), args.Error(1)
 }
+func (m *MockQuerier) UpsertBranch(ctx context.Context, arg database.UpsertBranchParams) (database.Branch, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Branch), args.Error(1)
+}
+func (m *MockQuerier) UpsertCommits(ctx context.Context, arg []database.CreateCommitsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockQuerier) UpsertHTTPValidators(ctx context.Context, arg database.UpsertHTTPValidatorsParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertIssue(ctx context.Context, arg database.UpsertIssueParams) (database.Issue, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Issue), args.Error(1)
+}
+func (m *MockQuerier) UpsertIssueComment(ctx context.Context, arg database.UpsertIssueCommentParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertLabel(ctx context.Context, arg database.UpsertLabelParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertPullRequest(ctx context.Context, arg database.UpsertPullRequestParams) (database.PullRequest, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.PullRequest), args.Error(1)
+}
+func (m *MockQuerier) UpsertPullRequestReview(ctx context.Context, arg database.UpsertPullRequestReviewParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertRelease(ctx context.Context, arg database.UpsertReleaseParams) (database.Release, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Release), args.Error(1)
+}
+func (m *MockQuerier) UpsertReleaseAsset(ctx context.Context, arg database.UpsertReleaseAssetParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertRepositoryLanguages(ctx context.Context, arg database.UpsertRepositoryLanguagesParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertTag(ctx context.Context, arg database.UpsertTagParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertTrackedRepository(ctx context.Context, arg database.UpsertTrackedRepositoryParams) (database.UpsertTrackedRepositoryRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.UpsertTrackedRepositoryRow), args.Error(1)
+}
+func (m *MockQuerier) UpsertWorkflow(ctx context.Context, arg database.UpsertWorkflowParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+func (m *MockQuerier) UpsertWorkflowRun(ctx context.Context, arg database.UpsertWorkflowRunParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
 
 func TestSyncer_UpsertRepository(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
@@ -70,7 +407,7 @@ func TestSyncer_UpsertRepository(t *testing.T) {
 		mockQ := new(MockQuerier)
 		syncer := &Syncer{logger: logger}
 
-		mockQ.On("GetRepositoryByOwnerAndName", ctx, mock.Anything).Return(database.Repository{}, pgx.ErrNoRows).Once()
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(database.Repository{}, pgx.ErrNoRows).Once()
 		expectedRepo := database.Repository{ID: 1, Owner: "test-owner", Name: "test-repo"}
 		mockQ.On("CreateRepository", ctx, mock.Anything).Return(expectedRepo, nil).Once()
 
@@ -86,7 +423,7 @@ func TestSyncer_UpsertRepository(t *testing.T) {
 		syncer := &Syncer{logger: logger}
 
 		existingRepo := database.Repository{ID: 1, Owner: "test-owner", Name: "test-repo"}
-		mockQ.On("GetRepositoryByOwnerAndName", ctx, mock.Anything).Return(existingRepo, nil).Once()
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(existingRepo, nil).Once()
 
 		updatedRepo := database.Repository{ID: 1, Owner: "test-owner", Name: "test-repo", StarsCount: 100}
 		mockQ.On("UpdateRepositorySyncData", ctx, mock.Anything).Return(updatedRepo, nil).Once()
@@ -104,7 +441,7 @@ func TestSyncer_UpsertRepository(t *testing.T) {
 		syncer := &Syncer{logger: logger}
 		dbError := errors.New("unexpected database error")
 
-		mockQ.On("GetRepositoryByOwnerAndName", ctx, mock.Anything).Return(database.Repository{}, dbError).Once()
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(database.Repository{}, dbError).Once()
 
 		_, err := syncer.upsertRepository(ctx, mockQ, ghRepo)
 
@@ -115,3 +452,2189 @@ func TestSyncer_UpsertRepository(t *testing.T) {
 		mockQ.AssertNotCalled(t, "UpdateRepositorySyncData")
 	})
 }
+
+func TestValidatorStore(t *testing.T) {
+	ctx := context.Background()
+	url := "https://api.github.com/repos/test-owner/test-repo"
+
+	t.Run("reports missing validators without an error", func(t *testing.T) {
+		mockQ := new(MockQuerier)
+		mockQ.On("GetHTTPValidators", ctx, url).Return(database.HttpValidator{}, pgx.ErrNoRows).Once()
+
+		_, ok, err := NewValidatorStore(mockQ).GetValidators(ctx, url)
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("returns stored validators", func(t *testing.T) {
+		mockQ := new(MockQuerier)
+		row := database.HttpValidator{Url: url, Etag: `"abc"`, LastModified: "Mon, 01 Jan 2024 12:00:00 GMT"}
+		mockQ.On("GetHTTPValidators", ctx, url).Return(row, nil).Once()
+
+		v, ok, err := NewValidatorStore(mockQ).GetValidators(ctx, url)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, github.Validators{ETag: `"abc"`, LastModified: "Mon, 01 Jan 2024 12:00:00 GMT"}, v)
+		mockQ.AssertExpectations(t)
+	})
+}
+
+func TestParseRepoIdentifiers(t *testing.T) {
+	t.Run("defaults to github.com and accepts enterprise hosts", func(t *testing.T) {
+		ids, err := parseRepoIdentifiers([]string{"golang/go", "ghe.example.com/team/service"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []RepoIdentifier{
+			{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "golang", Name: "go"},
+			{Provider: model.ProviderGitHub, Host: "ghe.example.com", Owner: "team", Name: "service"},
+		}, ids)
+	})
+
+	t.Run("accepts gitlab projects in nested groups", func(t *testing.T) {
+		ids, err := parseRepoIdentifiers([]string{"gitlab:gitlab-org/gitlab", "gitlab:group/sub/project"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []RepoIdentifier{
+			{Provider: model.ProviderGitLab, Host: gitlab.DefaultHost, Owner: "gitlab-org", Name: "gitlab"},
+			{Provider: model.ProviderGitLab, Host: gitlab.DefaultHost, Owner: "group/sub", Name: "project"},
+		}, ids)
+		assert.Equal(t, "gitlab:group/sub/project", ids[1].String())
+	})
+
+	t.Run("rejects malformed entries", func(t *testing.T) {
+		for _, r := range []string{"golang", "golang/", "/go", "ghe.example.com//service", "a/b/c/d", "gitlab:project", "gitlab:group/", "gitlab:group//project"} {
+			_, err := parseRepoIdentifiers([]string{r})
+			assert.Error(t, err, r)
+		}
+	})
+}
+
+func TestParseDiscoveryRules(t *testing.T) {
+	t.Run("splits owner and wildcard entries off the rest", func(t *testing.T) {
+		rules, rest, err := parseDiscoveryRules([]string{"golang/go", "org:octo-org", "user:ghe.example.com/mona", "octo-org/service-*", "gitlab:group/project"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"golang/go", "gitlab:group/project"}, rest)
+		assert.Equal(t, []discoveryRule{
+			{entry: "org:octo-org", host: github.DefaultHost, owner: "octo-org", pattern: "*"},
+			{entry: "user:ghe.example.com/mona", host: "ghe.example.com", owner: "mona", user: true, pattern: "*"},
+			{entry: "octo-org/service-*", host: github.DefaultHost, owner: "octo-org", pattern: "service-*"},
+		}, rules)
+	})
+
+	t.Run("rejects malformed entries", func(t *testing.T) {
+		for _, r := range []string{"org:", "user:host/", "org:a/b/c", "octo-*/service", "octo-org/service-[", "gitlab:group/*"} {
+			_, _, err := parseDiscoveryRules([]string{r})
+			assert.Error(t, err, r)
+		}
+	})
+}
+
+func TestSyncer_DiscoverRepos(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	repo := func(owner, name string) RepoIdentifier {
+		return RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: owner, Name: name}
+	}
+
+	setup := func(t *testing.T, repos []string, opts ...Option) (*githubfake.Server, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "octo-org", Name: "service-api"})
+		fake.AddRepository(githubfake.Repository{Owner: "octo-org", Name: "service-old", Archived: true})
+		fake.AddRepository(githubfake.Repository{Owner: "octo-org", Name: "service-fork", Fork: true})
+		fake.AddRepository(githubfake.Repository{Owner: "octo-org", Name: "service-secret", Private: true})
+		fake.AddRepository(githubfake.Repository{Owner: "octo-org", Name: "website"})
+		fake.AddRepository(githubfake.Repository{Owner: "mona", Name: "dotfiles", UserOwned: true})
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		s, err := NewSyncer(nil, map[string]Source{github.DefaultHost: client}, logger, repos, time.Hour, time.Time{}, opts...)
+		require.NoError(t, err)
+		return fake, s
+	}
+
+	t.Run("expands owners and wildcards, skipping archived repositories, forks and those listed by name", func(t *testing.T) {
+		_, s := setup(t, []string{"octo-org/website", "octo-org/service-*", "user:mona"})
+
+		s.discoverRepos(ctx)
+
+		assert.Equal(t, []RepoIdentifier{repo("octo-org", "service-api"), repo("octo-org", "service-secret"), repo("mona", "dotfiles")}, s.discovered)
+	})
+
+	t.Run("applies the configured filter", func(t *testing.T) {
+		_, s := setup(t, []string{"org:octo-org"}, WithDiscoveryFilter(DiscoveryFilter{Archived: true, Forks: true}))
+
+		s.discoverRepos(ctx)
+
+		assert.Equal(t, []RepoIdentifier{
+			repo("octo-org", "service-api"),
+			repo("octo-org", "service-fork"),
+			repo("octo-org", "service-old"),
+			repo("octo-org", "website"),
+		}, s.discovered)
+	})
+
+	t.Run("looks up wildcard owners that are users as users", func(t *testing.T) {
+		_, s := setup(t, []string{"mona/dot*"})
+
+		s.discoverRepos(ctx)
+
+		assert.Equal(t, []RepoIdentifier{repo("mona", "dotfiles")}, s.discovered)
+	})
+
+	t.Run("skips paused repositories", func(t *testing.T) {
+		_, s := setup(t, []string{"octo-org/service-*"})
+		s.paused = map[string]bool{"octo-org/service-secret": true}
+
+		s.discoverRepos(ctx)
+
+		assert.Equal(t, []RepoIdentifier{repo("octo-org", "service-api")}, s.discovered)
+	})
+
+	t.Run("keeps the repositories discovered before when listing fails", func(t *testing.T) {
+		fake, s := setup(t, []string{"octo-org/service-*"})
+		s.discoverRepos(ctx)
+		fake.FailNext(1, http.StatusForbidden)
+
+		s.discoverRepos(ctx)
+
+		assert.Equal(t, []RepoIdentifier{repo("octo-org", "service-api"), repo("octo-org", "service-secret")}, s.discovered)
+	})
+}
+
+func TestSyncer_LoadRepos(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	repo := func(owner, name string) RepoIdentifier {
+		return RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: owner, Name: name}
+	}
+
+	setup := func(t *testing.T) (*MockQuerier, *Syncer) {
+		s, err := NewSyncer(nil, map[string]Source{github.DefaultHost: github.NewClient("", logger)}, logger, []string{"golang/go"}, time.Hour, time.Time{})
+		require.NoError(t, err)
+		mockQ := new(MockQuerier)
+		s.withTx = func(ctx context.Context, fn func(q database.Store) error) error {
+			return fn(mockQ)
+		}
+		return mockQ, s
+	}
+
+	t.Run("syncs the tracked entries that are not paused", func(t *testing.T) {
+		mockQ, s := setup(t)
+		mockQ.On("ListTrackedRepositories", ctx).Return([]database.TrackedRepository{
+			{Entry: "octo-org/hello-world"},
+			{Entry: "octo-org/legacy", Paused: true},
+			{Entry: "org:octo-org"},
+			{Entry: "ghe.example.com/team/service"},
+		}, nil).Once()
+
+		s.loadRepos(ctx)
+
+		assert.Equal(t, []RepoIdentifier{repo("octo-org", "hello-world")}, s.reposToSync)
+		assert.Equal(t, []discoveryRule{{entry: "org:octo-org", host: github.DefaultHost, owner: "octo-org", pattern: "*"}}, s.discovery)
+		assert.Equal(t, map[string]bool{"octo-org/legacy": true}, s.paused)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("keeps the previous repositories when the table cannot be read", func(t *testing.T) {
+		mockQ, s := setup(t)
+		mockQ.On("ListTrackedRepositories", ctx).Return([]database.TrackedRepository(nil), errors.New("connection refused")).Once()
+
+		s.loadRepos(ctx)
+
+		assert.Equal(t, []RepoIdentifier{repo("golang", "go")}, s.reposToSync)
+	})
+}
+
+func TestSyncer_EnqueueSync(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+
+	setup := func(t *testing.T, tracked ...database.TrackedRepository) (*MockQuerier, *Syncer) {
+		s, err := NewSyncer(nil, map[string]Source{github.DefaultHost: github.NewClient("", logger)}, logger, nil, time.Hour, time.Time{})
+		require.NoError(t, err)
+		mockQ := new(MockQuerier)
+		mockQ.On("ListTrackedRepositories", ctx).Return(tracked, nil)
+		s.withTx = func(ctx context.Context, fn func(q database.Store) error) error {
+			return fn(mockQ)
+		}
+		return mockQ, s
+	}
+
+	t.Run("queues a tracked repository once", func(t *testing.T) {
+		mockQ, s := setup(t, database.TrackedRepository{Entry: "golang/go"})
+		queued := database.SyncJob{ID: 3, Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "golang", Name: "go", State: JobQueued}
+		mockQ.On("CreateSyncJob", ctx, database.CreateSyncJobParams{
+			Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "golang", Name: "go",
+		}).Return(queued, nil).Once()
+
+		job, err := s.EnqueueSync(ctx, "golang/go")
+		require.NoError(t, err)
+		again, err := s.EnqueueSync(ctx, "github.com/Golang/Go")
+		require.NoError(t, err)
+
+		assert.Equal(t, queued, job)
+		assert.Equal(t, queued, again, "the queued job is returned for a repeated request")
+		assert.Len(t, s.jobs, 1)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("queues repositories an org: entry matches", func(t *testing.T) {
+		mockQ, s := setup(t, database.TrackedRepository{Entry: "org:octo-org"})
+		mockQ.On("CreateSyncJob", ctx, database.CreateSyncJobParams{
+			Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "octo-org", Name: "website",
+		}).Return(database.SyncJob{ID: 4}, nil).Once()
+
+		_, err := s.EnqueueSync(ctx, "octo-org/website")
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("refuses repositories that are not tracked or paused", func(t *testing.T) {
+		_, s := setup(t, database.TrackedRepository{Entry: "golang/go"}, database.TrackedRepository{Entry: "octo-org/legacy", Paused: true})
+
+		_, err := s.EnqueueSync(ctx, "rust-lang/rust")
+		assert.ErrorIs(t, err, custom_errors.ErrNotTracked)
+		_, err = s.EnqueueSync(ctx, "octo-org/legacy")
+		assert.ErrorIs(t, err, custom_errors.ErrPaused)
+		_, err = s.EnqueueSync(ctx, "org:golang")
+		var invalid *custom_errors.ErrInvalidRepoFormat
+		assert.ErrorAs(t, err, &invalid, "entries must name a single repository")
+		assert.Empty(t, s.jobs)
+	})
+
+	t.Run("refuses jobs when the queue is full", func(t *testing.T) {
+		_, s := setup(t, database.TrackedRepository{Entry: "golang/go"})
+		s.jobs = make(chan queuedJob)
+
+		_, err := s.EnqueueSync(ctx, "golang/go")
+
+		assert.ErrorIs(t, err, custom_errors.ErrSyncQueueFull)
+	})
+}
+
+func TestSyncer_RunJob(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	job := queuedJob{id: 3, repo: RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "golang", Name: "go"}}
+
+	setup := func(t *testing.T) (*MockQuerier, *Syncer) {
+		_, server := githubfake.NewServer() // knows no repositories, so every sync fails
+		t.Cleanup(server.Close)
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+		s, err := NewSyncer(nil, map[string]Source{github.DefaultHost: client}, logger, []string{"golang/go"}, time.Hour, time.Time{})
+		require.NoError(t, err)
+		mockQ := new(MockQuerier)
+		s.withTx = func(ctx context.Context, fn func(q database.Store) error) error {
+			return fn(mockQ)
+		}
+		s.queued = map[string]database.SyncJob{"golang/go": {ID: 3}}
+		return mockQ, s
+	}
+
+	t.Run("records why a sync failed", func(t *testing.T) {
+		mockQ, s := setup(t)
+		mockQ.On("StartSyncJob", ctx, int64(3)).Return(nil).Once()
+		mockQ.On("FinishSyncJob", mock.Anything, mock.MatchedBy(func(arg database.FinishSyncJobParams) bool {
+			return arg.ID == 3 && arg.State == JobFailed && arg.Error != "" && arg.CommitsInserted == 0
+		})).Return(nil).Once()
+
+		s.runJob(ctx, job)
+
+		mockQ.AssertExpectations(t)
+		assert.Empty(t, s.queued)
+	})
+
+	t.Run("waits while the repository is being synced", func(t *testing.T) {
+		mockQ, s := setup(t)
+		started := make(chan struct{})
+		mockQ.On("StartSyncJob", ctx, int64(3)).Run(func(mock.Arguments) { close(started) }).Return(nil).Once()
+		mockQ.On("FinishSyncJob", mock.Anything, mock.Anything).Return(nil).Once()
+		require.True(t, s.locks.tryLock("golang/go"))
+
+		done := make(chan struct{})
+		go func() {
+			s.runJob(ctx, job)
+			close(done)
+		}()
+		select {
+		case <-started:
+			t.Fatal("the job started while the repository was locked")
+		case <-time.After(50 * time.Millisecond):
+		}
+		s.jobsMu.Lock()
+		assert.Len(t, s.queued, 1, "the job stays queued while it waits")
+		s.jobsMu.Unlock()
+		s.locks.unlock("golang/go")
+
+		<-done
+		mockQ.AssertExpectations(t)
+		assert.True(t, s.locks.tryLock("golang/go"), "the job releases the lock")
+	})
+
+	t.Run("waits while the cycle and other jobs use every slot", func(t *testing.T) {
+		mockQ, s := setup(t)
+		started := make(chan struct{})
+		mockQ.On("StartSyncJob", ctx, int64(3)).Run(func(mock.Arguments) { close(started) }).Return(nil).Once()
+		mockQ.On("FinishSyncJob", mock.Anything, mock.Anything).Return(nil).Once()
+		for i := 0; i < concurrency; i++ {
+			require.NoError(t, s.acquireSlot(ctx))
+		}
+
+		done := make(chan struct{})
+		go func() {
+			s.runJob(ctx, job)
+			close(done)
+		}()
+		select {
+		case <-started:
+			t.Fatal("the job started while every slot was taken")
+		case <-time.After(50 * time.Millisecond):
+		}
+		s.releaseSlot()
+
+		<-done
+		mockQ.AssertExpectations(t)
+		assert.Len(t, s.slots, concurrency-1, "the job releases its slot")
+	})
+
+	t.Run("gives up waiting when the syncer shuts down", func(t *testing.T) {
+		mockQ, s := setup(t)
+		require.True(t, s.locks.tryLock("golang/go"))
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		s.runJob(ctx, job)
+
+		mockQ.AssertNotCalled(t, "StartSyncJob", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSyncer_SyncRepo_Replay(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+
+	recorder, err := cassette.New(filepath.Join("testdata", "cassettes", "sync_repository.json"), cassette.ModeReplay, nil)
+	require.NoError(t, err)
+	client := github.NewClient("", logger, github.WithTransport(recorder))
+	syncer := &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: client}}
+
+	mockQ := new(MockQuerier)
+	existingRepo := database.Repository{ID: 7, Host: github.DefaultHost, Owner: "octo-org", Name: "hello-world"}
+	mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+		Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "octo-org", Name: "hello-world",
+	}).Return(existingRepo, nil).Once()
+	mockQ.On("UpdateRepositorySyncData", ctx, mock.MatchedBy(func(arg database.UpdateRepositorySyncDataParams) bool {
+		return arg.ID == 7 && arg.StarsCount == 2503 && arg.Language == "Go"
+	})).Return(existingRepo, nil).Once()
+	mockQ.On("CreateRepositorySnapshot", ctx, int64(7)).Return(nil).Once()
+	mockQ.On("GetSyncCheckpoint", ctx, int64(7)).Return(database.SyncCheckpoint{}, pgx.ErrNoRows).Once()
+	mockQ.On("UpdateRepositoryHead", ctx, database.UpdateRepositoryHeadParams{ID: 7, HeadSha: "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d"}).Return(nil).Once()
+	lastCommit := pgtype.Timestamp{Time: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), Valid: true}
+	mockQ.On("GetLatestCommitDateForRepo", ctx, int64(7)).Return(lastCommit, nil).Once()
+	var inserted []database.CreateCommitsParams
+	mockQ.On("UpsertCommits", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		inserted = args.Get(1).([]database.CreateCommitsParams)
+	}).Return(int64(2), nil).Once()
+
+	_, _, err = syncer.syncRepo(ctx, mockQ, RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "octo-org", Name: "hello-world"})
+
+	require.NoError(t, err)
+	mockQ.AssertExpectations(t)
+	require.Len(t, inserted, 2)
+	assert.Equal(t, int64(7), inserted[0].RepositoryID)
+	assert.Equal(t, "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d", inserted[0].Sha)
+	assert.Equal(t, "octocat", inserted[0].AuthorLogin)
+	assert.True(t, inserted[0].Verified)
+	assert.Equal(t, "Spaceghost", inserted[1].AuthorLogin)
+}
+
+// fakeSource is an in-memory Source. Queued errors are returned, one per call, before the
+// configured data.
+type fakeSource struct {
+	provider    string // defaults to model.ProviderGitHub
+	repo        *model.Repository
+	repoErrs    []error
+	commits     []model.Commit
+	commitsErrs []error
+
+	repoCalls   int
+	since       []time.Time
+	invalidated int
+}
+
+func (f *fakeSource) Provider() string {
+	if f.provider == "" {
+		return model.ProviderGitHub
+	}
+	return f.provider
+}
+
+func (f *fakeSource) GetRepository(ctx context.Context, owner, name string) (*model.Repository, error) {
+	f.repoCalls++
+	if len(f.repoErrs) > 0 {
+		err := f.repoErrs[0]
+		f.repoErrs = f.repoErrs[1:]
+		return nil, err
+	}
+	repo := *f.repo
+	return &repo, nil
+}
+
+func (f *fakeSource) ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error {
+	f.since = append(f.since, since)
+	if len(f.commitsErrs) > 0 {
+		err := f.commitsErrs[0]
+		f.commitsErrs = f.commitsErrs[1:]
+		return err
+	}
+	return fn(f.commits)
+}
+
+func (f *fakeSource) InvalidateRepository(ctx context.Context, owner, name string) error {
+	f.invalidated++
+	return nil
+}
+
+// fakeMirror is a CommitSource that records the SHAs it is asked to list commits after.
+type fakeMirror struct {
+	commits []model.Commit
+	shas    []string
+}
+
+func (f *fakeMirror) ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Commit) error) error {
+	return f.ForEachCommitPageSinceSHA(ctx, owner, name, "", since, fn)
+}
+
+func (f *fakeMirror) ForEachCommitPageSinceSHA(ctx context.Context, owner, name, sha string, since time.Time, fn func(page []model.Commit) error) error {
+	f.shas = append(f.shas, sha)
+	return fn(f.commits)
+}
+
+func (f *fakeMirror) ForEachCommitPageFrom(ctx context.Context, owner, name string, since time.Time, cursor string, fn func(page []model.Commit, next string) error) error {
+	return fn(f.commits, "")
+}
+
+// truncatedSource is a fakeSource whose listings of pull requests, issues and workflow runs
+// report errors.ErrUnsupported after their first page.
+type truncatedSource struct {
+	fakeSource
+	pulls     []model.PullRequest
+	labels    []model.Label
+	issues    []model.Issue
+	workflows []model.Workflow
+	runs      []model.WorkflowRun
+}
+
+func (f *truncatedSource) ForEachPullRequestPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.PullRequest) error) error {
+	if err := fn(f.pulls); err != nil {
+		return err
+	}
+	return errors.ErrUnsupported
+}
+
+func (f *truncatedSource) ListLabels(ctx context.Context, owner, name string) ([]model.Label, error) {
+	return f.labels, nil
+}
+
+func (f *truncatedSource) ForEachIssuePage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.Issue) error) error {
+	if err := fn(f.issues); err != nil {
+		return err
+	}
+	return errors.ErrUnsupported
+}
+
+func (f *truncatedSource) ListWorkflows(ctx context.Context, owner, name string) ([]model.Workflow, error) {
+	return f.workflows, nil
+}
+
+func (f *truncatedSource) ForEachWorkflowRunPage(ctx context.Context, owner, name string, since time.Time, fn func(page []model.WorkflowRun) error) error {
+	if err := fn(f.runs); err != nil {
+		return err
+	}
+	return errors.ErrUnsupported
+}
+
+// memoryValidators is an in-memory github.ValidatorStore.
+type memoryValidators struct {
+	mu sync.Mutex
+	m  map[string]github.Validators
+}
+
+func (s *memoryValidators) GetValidators(ctx context.Context, url string) (github.Validators, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.m[url]
+	return v, ok, nil
+}
+
+func (s *memoryValidators) SaveValidators(ctx context.Context, url string, v github.Validators) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = make(map[string]github.Validators)
+	}
+	s.m[url] = v
+	return nil
+}
+
+func (s *memoryValidators) DeleteValidators(ctx context.Context, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.m {
+		if k == url || strings.HasPrefix(k, url+"/") {
+			delete(s.m, k)
+		}
+	}
+	return nil
+}
+
+func TestSyncer_SyncRepo(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	defaultSince := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastCommit := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	ghRepo := &model.Repository{GithubRepoID: 12345, Owner: "test-owner", Name: "test-repo", StarsCount: 20}
+	commits := []model.Commit{
+		{SHA: "def", AuthorName: "tester", Message: "fix: a bug", CommitDate: lastCommit.Add(2 * time.Hour)},
+		{SHA: "abc", AuthorName: "tester", Message: "feat: new feature", CommitDate: lastCommit.Add(time.Hour)},
+	}
+
+	newSyncer := func(src Source) *Syncer {
+		return &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: src}, defaultSince: defaultSince}
+	}
+	expectUpsert := func(mockQ *MockQuerier) {
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("UpdateRepositorySyncData", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("CreateRepositorySnapshot", ctx, int64(1)).Return(nil).Once()
+	}
+	latestCommit := func(mockQ *MockQuerier, ts pgtype.Timestamp) {
+		mockQ.On("GetLatestCommitDateForRepo", ctx, int64(1)).Return(ts, nil).Once()
+	}
+	noCheckpoint := func(mockQ *MockQuerier) {
+		mockQ.On("GetSyncCheckpoint", ctx, int64(1)).Return(database.SyncCheckpoint{}, pgx.ErrNoRows).Once()
+	}
+
+	t.Run("inserts commits listed from shortly before the latest stored commit", func(t *testing.T) {
+		src := &fakeSource{repo: ghRepo, commits: commits}
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("UpsertCommits", ctx, mock.MatchedBy(func(arg []database.CreateCommitsParams) bool {
+			return len(arg) == 2 && arg[0].Sha == "def" && arg[0].RepositoryID == 1 && arg[1].Sha == "abc"
+		})).Return(int64(2), nil).Once()
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{lastCommit.Add(-sinceOverlap)}, src.since)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("skips commits that are already stored", func(t *testing.T) {
+		src := &fakeSource{repo: ghRepo, commits: commits}
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("UpsertCommits", ctx, mock.Anything).Return(int64(0), nil).Once()
+		mockQ.On("MarkRepositorySynced", ctx, int64(1)).Return(nil).Once()
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("starts from the default date when no commits are stored", func(t *testing.T) {
+		src := &fakeSource{repo: ghRepo, commits: commits}
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{})
+		mockQ.On("UpsertCommits", ctx, mock.Anything).Return(int64(2), nil).Once()
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{defaultSince}, src.since)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("only marks the repository synced when there are no new commits", func(t *testing.T) {
+		src := &fakeSource{repo: ghRepo}
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("MarkRepositorySynced", ctx, int64(1)).Return(nil).Once()
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "UpsertCommits", mock.Anything, mock.Anything)
+	})
+
+	t.Run("only records a snapshot and the sync time when nothing changed upstream", func(t *testing.T) {
+		src := &fakeSource{
+			repo:        ghRepo,
+			repoErrs:    []error{custom_errors.ErrNotModified},
+			commitsErrs: []error{custom_errors.ErrNotModified},
+		}
+		mockQ := new(MockQuerier)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("CreateRepositorySnapshot", ctx, int64(1)).Return(nil).Once()
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("MarkRepositorySynced", ctx, int64(1)).Return(nil).Once()
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "UpdateRepositorySyncData", mock.Anything, mock.Anything)
+		mockQ.AssertNotCalled(t, "UpsertCommits", mock.Anything, mock.Anything)
+	})
+
+	t.Run("refetches a repository that is unchanged upstream but missing locally", func(t *testing.T) {
+		src := &fakeSource{repo: ghRepo, repoErrs: []error{custom_errors.ErrNotModified}}
+		mockQ := new(MockQuerier)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(database.Repository{}, pgx.ErrNoRows).Twice()
+		mockQ.On("CreateRepository", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("CreateRepositorySnapshot", ctx, int64(1)).Return(nil).Once()
+		latestCommit(mockQ, pgtype.Timestamp{})
+		mockQ.On("MarkRepositorySynced", ctx, int64(1)).Return(nil).Once()
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, src.repoCalls)
+		assert.Equal(t, 1, src.invalidated)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("fails without writing when the repository cannot be fetched", func(t *testing.T) {
+		fetchErr := errors.New("github unavailable")
+		src := &fakeSource{repo: ghRepo, repoErrs: []error{fetchErr}}
+		mockQ := new(MockQuerier)
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		assert.ErrorIs(t, err, fetchErr)
+		assert.Empty(t, src.since)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("fails when commits cannot be fetched", func(t *testing.T) {
+		fetchErr := errors.New("github unavailable")
+		src := &fakeSource{repo: ghRepo, commitsErrs: []error{fetchErr}}
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		assert.ErrorIs(t, err, fetchErr)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "UpsertCommits", mock.Anything, mock.Anything)
+		mockQ.AssertNotCalled(t, "MarkRepositorySynced", mock.Anything, mock.Anything)
+	})
+
+	t.Run("fails when commits cannot be stored", func(t *testing.T) {
+		dbErr := errors.New("disk full")
+		src := &fakeSource{repo: ghRepo, commits: commits}
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("UpsertCommits", ctx, mock.Anything).Return(int64(0), dbErr).Once()
+
+		_, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		assert.ErrorIs(t, err, dbErr)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("stores every page of a paginated history", func(t *testing.T) {
+		fake, server := githubfake.NewServer()
+		defer server.Close()
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		for i := 0; i < 250; i++ {
+			require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{
+				Message: fmt.Sprintf("commit %d", i),
+				Date:    lastCommit.Add(time.Duration(i+1) * time.Minute),
+			}))
+		}
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		noCheckpoint(mockQ)
+		mockQ.On("UpdateRepositoryHead", ctx, mock.Anything).Return(nil).Once()
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		var batches [][]database.CreateCommitsParams
+		mockQ.On("UpsertCommits", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			batches = append(batches, args.Get(1).([]database.CreateCommitsParams))
+		}).Return(int64(100), nil).Twice()
+		mockQ.On("UpsertCommits", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			batches = append(batches, args.Get(1).([]database.CreateCommitsParams))
+		}).Return(int64(50), nil).Once()
+
+		_, _, err = newSyncer(client).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		require.Len(t, batches, 3, "one insert per page")
+		assert.Len(t, batches[0], 100)
+		assert.Len(t, batches[2], 50)
+		assert.Equal(t, "commit 249", batches[0][0].Message)
+		assert.Equal(t, "commit 0", batches[2][49].Message)
+		assert.Equal(t, 5, fake.Requests(), "repository, head and three pages of commits")
+	})
+
+	t.Run("stops fetching pages once an insert fails", func(t *testing.T) {
+		fake, server := githubfake.NewServer()
+		defer server.Close()
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		for i := 0; i < 250; i++ {
+			require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{
+				Message: fmt.Sprintf("commit %d", i),
+				Date:    lastCommit.Add(time.Duration(i+1) * time.Minute),
+			}))
+		}
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+		dbErr := errors.New("disk full")
+
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		noCheckpoint(mockQ)
+		mockQ.On("UpdateRepositoryHead", ctx, mock.Anything).Return(nil).Once()
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("UpsertCommits", ctx, mock.Anything).Return(int64(0), dbErr).Once()
+
+		_, _, err = newSyncer(client).syncRepo(ctx, mockQ, id)
+
+		assert.ErrorIs(t, err, dbErr)
+		mockQ.AssertExpectations(t)
+		assert.Equal(t, 3, fake.Requests(), "repository, head and the first page of commits")
+	})
+	t.Run("marks commits orphaned by a force-push unreachable", func(t *testing.T) {
+		fake, server := githubfake.NewServer()
+		defer server.Close()
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		for i := 0; i < 3; i++ {
+			require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{
+				Message: fmt.Sprintf("commit %d", i),
+				Date:    lastCommit.Add(time.Duration(i-2) * time.Hour),
+			}))
+		}
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+		old, err := client.GetCommits(ctx, "test-owner", "test-repo", time.Time{})
+		require.NoError(t, err)
+		require.NoError(t, fake.ForcePush("test-owner", "test-repo", 2, githubfake.Commit{Message: "squashed", Date: lastCommit.Add(time.Hour)}))
+
+		rewritten := storedRepo
+		rewritten.HeadSha = old[0].SHA
+		mockQ := new(MockQuerier)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(rewritten, nil).Once()
+		mockQ.On("UpdateRepositorySyncData", ctx, mock.Anything).Return(rewritten, nil).Once()
+		mockQ.On("CreateRepositorySnapshot", ctx, int64(1)).Return(nil).Once()
+		noCheckpoint(mockQ)
+		mockQ.On("MarkCommitsUnreachable", ctx, mock.MatchedBy(func(arg database.MarkCommitsUnreachableParams) bool {
+			return arg.RepositoryID == 1 && len(arg.Shas) == 2 && slices.Contains(arg.Shas, old[0].SHA) && slices.Contains(arg.Shas, old[1].SHA)
+		})).Return(int64(2), nil).Once()
+		mockQ.On("CreateHistoryRewrite", ctx, mock.MatchedBy(func(arg database.CreateHistoryRewriteParams) bool {
+			return arg.OldHeadSha == old[0].SHA && arg.MergeBaseSha == old[2].SHA && arg.UnreachableCommits == 2
+		})).Return(database.HistoryRewrite{}, nil).Once()
+		var head string
+		mockQ.On("UpdateRepositoryHead", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			head = args.Get(1).(database.UpdateRepositoryHeadParams).HeadSha
+		}).Return(nil).Once()
+		latestCommit(mockQ, pgtype.Timestamp{Time: old[2].CommitDate, Valid: true})
+		mockQ.On("UpsertCommits", ctx, mock.MatchedBy(func(arg []database.CreateCommitsParams) bool {
+			return len(arg) == 2 && arg[0].Message == "squashed"
+		})).Return(int64(1), nil).Once()
+
+		_, _, err = newSyncer(client).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		assert.NotEqual(t, old[0].SHA, head)
+	})
+
+	t.Run("records no rewrite when the branch only moved forward", func(t *testing.T) {
+		fake, server := githubfake.NewServer()
+		defer server.Close()
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{Message: "stored", Date: lastCommit}))
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+		stored, err := client.GetHeadSHA(ctx, "test-owner", "test-repo")
+		require.NoError(t, err)
+		require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{Message: "pushed", Date: lastCommit.Add(time.Hour)}))
+
+		moved := storedRepo
+		moved.HeadSha = stored
+		mockQ := new(MockQuerier)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(moved, nil).Once()
+		mockQ.On("UpdateRepositorySyncData", ctx, mock.Anything).Return(moved, nil).Once()
+		mockQ.On("CreateRepositorySnapshot", ctx, int64(1)).Return(nil).Once()
+		noCheckpoint(mockQ)
+		mockQ.On("UpdateRepositoryHead", ctx, mock.Anything).Return(nil).Once()
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("UpsertCommits", ctx, mock.Anything).Return(int64(1), nil).Once()
+
+		_, _, err = newSyncer(client).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "MarkCommitsUnreachable", mock.Anything, mock.Anything)
+		mockQ.AssertNotCalled(t, "CreateHistoryRewrite", mock.Anything, mock.Anything)
+	})
+
+	t.Run("walks a commit source's history from the newest stored commit", func(t *testing.T) {
+		src := &fakeSource{repo: ghRepo, commitsErrs: []error{errors.New("API must not be used for commits")}}
+		mirror := &fakeMirror{commits: []model.Commit{{SHA: "def", Parents: []string{"abc"}, CommitDate: lastCommit, CommitterName: "CI Bot", CommitterDate: lastCommit}}}
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		noCheckpoint(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{Time: lastCommit, Valid: true})
+		mockQ.On("GetLatestCommitSHAForRepo", ctx, int64(1)).Return("abc", nil).Once()
+		mockQ.On("UpsertCommits", ctx, mock.MatchedBy(func(arg []database.CreateCommitsParams) bool {
+			return len(arg) == 1 && arg[0].Parents[0] == "abc" && arg[0].CommitterName == "CI Bot" && arg[0].CommitterDate.Valid
+		})).Return(int64(1), nil).Once()
+
+		_, _, err := newSyncer(WithCommitSource(src, mirror)).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"abc"}, mirror.shas)
+		assert.Empty(t, src.since)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("starts a backfill when a resumable source has no stored commits", func(t *testing.T) {
+		src := WithCommitSource(&fakeSource{repo: ghRepo}, &fakeMirror{})
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		noCheckpoint(mockQ)
+		latestCommit(mockQ, pgtype.Timestamp{})
+		started := database.SyncCheckpoint{RepositoryID: 1, Since: defaultSince}
+		mockQ.On("StartSyncCheckpoint", ctx, database.StartSyncCheckpointParams{RepositoryID: 1, Since: defaultSince}).Return(started, nil).Once()
+
+		checkpoint, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, &started, checkpoint)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "UpsertCommits", mock.Anything, mock.Anything)
+	})
+
+	t.Run("resumes an incomplete backfill instead of syncing incrementally", func(t *testing.T) {
+		mirror := &fakeMirror{}
+		src := WithCommitSource(&fakeSource{repo: ghRepo}, mirror)
+		mockQ := new(MockQuerier)
+		expectUpsert(mockQ)
+		pending := database.SyncCheckpoint{RepositoryID: 1, Since: defaultSince, PageCursor: "abc 100", CommitsImported: 100}
+		mockQ.On("GetSyncCheckpoint", ctx, int64(1)).Return(pending, nil).Once()
+
+		checkpoint, _, err := newSyncer(src).syncRepo(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, &pending, checkpoint)
+		assert.Empty(t, mirror.shas)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "GetLatestCommitDateForRepo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stores gitlab projects under the gitlab provider", func(t *testing.T) {
+		src := &fakeSource{provider: model.ProviderGitLab, repo: ghRepo}
+		gitlabID := RepoIdentifier{Provider: model.ProviderGitLab, Host: "gitlab.example.com", Owner: "group/sub", Name: "test-repo"}
+		mockQ := new(MockQuerier)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, database.GetRepositoryByProviderHostOwnerAndNameParams{
+			Provider: model.ProviderGitLab, Host: "gitlab.example.com", Owner: "test-owner", Name: "test-repo",
+		}).Return(database.Repository{}, pgx.ErrNoRows).Once()
+		mockQ.On("CreateRepository", ctx, mock.MatchedBy(func(arg database.CreateRepositoryParams) bool {
+			return arg.Provider == model.ProviderGitLab && arg.Host == "gitlab.example.com"
+		})).Return(storedRepo, nil).Once()
+		mockQ.On("CreateRepositorySnapshot", ctx, int64(1)).Return(nil).Once()
+		latestCommit(mockQ, pgtype.Timestamp{})
+		mockQ.On("MarkRepositorySynced", ctx, int64(1)).Return(nil).Once()
+
+		syncer := &Syncer{logger: logger, sources: map[string]Source{"gitlab.example.com": src}, defaultSince: defaultSince}
+		_, _, err := syncer.syncRepo(ctx, mockQ, gitlabID)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+}
+
+func TestSyncer_Backfill(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fake, server := githubfake.NewServer()
+	defer server.Close()
+	fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+	for i := 0; i < 250; i++ {
+		require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{
+			Message: fmt.Sprintf("commit %d", i),
+			Date:    start.Add(time.Duration(i+1) * time.Minute),
+		}))
+	}
+	client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+	require.NoError(t, err)
+
+	mockQ := new(MockQuerier)
+	syncer := &Syncer{
+		logger:  logger,
+		sources: map[string]Source{github.DefaultHost: client},
+		withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+			return fn(mockQ)
+		},
+	}
+	var batches [][]database.CreateCommitsParams
+	recordBatch := func(args mock.Arguments) {
+		batches = append(batches, args.Get(1).([]database.CreateCommitsParams))
+	}
+	var advanced []database.AdvanceSyncCheckpointParams
+	mockQ.On("AdvanceSyncCheckpoint", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		advanced = append(advanced, args.Get(1).(database.AdvanceSyncCheckpointParams))
+	}).Return(nil)
+
+	// The first attempt commits one page and fails on the second.
+	dbErr := errors.New("connection reset")
+	mockQ.On("UpsertCommits", ctx, mock.Anything).Run(recordBatch).Return(int64(100), nil).Once()
+	mockQ.On("UpsertCommits", ctx, mock.Anything).Return(int64(0), dbErr).Once()
+
+	imported, err := syncer.backfill(ctx, id, database.SyncCheckpoint{RepositoryID: 1, Since: start})
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.Equal(t, int64(100), imported, "pages committed before the error are counted")
+	require.Len(t, advanced, 1, "the failed page's checkpoint is not written")
+	assert.Equal(t, int64(100), advanced[0].CommitsImported)
+	assert.Equal(t, batches[0][99].Sha, advanced[0].OldestSha)
+	assert.True(t, advanced[0].OldestCommitDate.Time.Equal(start.Add(151*time.Minute)))
+	assert.Equal(t, batches[0][0].Sha+" 2", advanced[0].PageCursor)
+
+	// Commits pushed before the restart do not shift the remaining pages.
+	require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{Message: "pushed meanwhile", Date: start.Add(time.Hour * 24)}))
+	mockQ.On("UpsertCommits", ctx, mock.Anything).Run(recordBatch).Return(int64(100), nil).Once()
+	mockQ.On("UpsertCommits", ctx, mock.Anything).Run(recordBatch).Return(int64(50), nil).Once()
+	mockQ.On("CompleteSyncCheckpoint", ctx, int64(1)).Return(nil).Once()
+	mockQ.On("MarkRepositorySynced", ctx, int64(1)).Return(nil).Once()
+
+	imported, err = syncer.backfill(ctx, id, database.SyncCheckpoint{
+		RepositoryID:     1,
+		Since:            start,
+		PageCursor:       advanced[0].PageCursor,
+		OldestSha:        advanced[0].OldestSha,
+		OldestCommitDate: advanced[0].OldestCommitDate,
+		CommitsImported:  100,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), imported)
+	mockQ.AssertExpectations(t)
+	require.Len(t, batches, 3)
+	assert.Equal(t, "commit 149", batches[1][0].Message)
+	assert.Equal(t, "commit 0", batches[2][49].Message)
+	require.Len(t, advanced, 3)
+	assert.Equal(t, "", advanced[2].PageCursor)
+	assert.Equal(t, batches[2][49].Sha, advanced[2].OldestSha)
+}
+
+func TestSyncer_Backfill_GraphQL(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	// v1.0 tags "tagged"; "grandparent" is two levels below it.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"data": {"repository": {"defaultBranchRef": {"target": {"history": {
+			"pageInfo": {"hasNextPage": false, "endCursor": ""},
+			"nodes": [
+				{"oid": "tagged", "author": {"date": "2024-01-03T00:00:00Z"}, "parents": {"nodes": [{"oid": "parent"}]}},
+				{"oid": "parent", "author": {"date": "2024-01-02T00:00:00Z"}, "parents": {"nodes": [{"oid": "grandparent"}]}},
+				{"oid": "grandparent", "author": {"date": "2024-01-01T00:00:00Z"}, "parents": {"nodes": []}}
+			]
+		}}}}}}`)
+	}))
+	defer server.Close()
+	client, err := github.NewClient("", logger, github.WithGraphQLCommits()).WithBaseURL(server.URL)
+	require.NoError(t, err)
+
+	mockQ := new(MockQuerier)
+	syncer := &Syncer{
+		logger:  logger,
+		sources: map[string]Source{github.DefaultHost: client},
+		withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+			return fn(mockQ)
+		},
+	}
+	var stored []database.CreateCommitsParams
+	mockQ.On("UpsertCommits", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).([]database.CreateCommitsParams)
+	}).Return(int64(3), nil).Once()
+	mockQ.On("AdvanceSyncCheckpoint", ctx, mock.Anything).Return(nil).Once()
+	mockQ.On("CompleteSyncCheckpoint", ctx, int64(1)).Return(nil).Once()
+	mockQ.On("MarkRepositorySynced", ctx, int64(1)).Return(nil).Once()
+
+	_, err = syncer.backfill(ctx, id, database.SyncCheckpoint{RepositoryID: 1})
+
+	require.NoError(t, err)
+	mockQ.AssertExpectations(t)
+	// AssignFirstRelease reaches "grandparent" from the tag through these parents.
+	require.Len(t, stored, 3)
+	assert.Equal(t, []string{"parent"}, stored[0].Parents)
+	assert.Equal(t, []string{"grandparent"}, stored[1].Parents)
+	assert.Equal(t, []string{}, stored[2].Parents)
+}
+
+func TestSyncer_SyncBranches(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T) (*githubfake.Server, *github.Client, *MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		for i := 0; i < 3; i++ {
+			require.NoError(t, fake.AddCommits("test-owner", "test-repo", githubfake.Commit{
+				Message: fmt.Sprintf("commit %d", i),
+				Date:    start.Add(time.Duration(i) * time.Hour),
+			}))
+		}
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{
+			logger:       logger,
+			sources:      map[string]Source{github.DefaultHost: client},
+			defaultSince: start,
+			branches:     map[string][]string{"test-owner/test-repo": {"release/*"}},
+			withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+				return fn(mockQ)
+			},
+		}
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		return fake, client, mockQ, syncer
+	}
+
+	t.Run("stores the commits of new matching branches and links them", func(t *testing.T) {
+		fake, client, mockQ, syncer := setup(t)
+		mainline, err := client.GetCommits(ctx, "test-owner", "test-repo", time.Time{})
+		require.NoError(t, err)
+		require.NoError(t, fake.SetBranch("test-owner", "test-repo", "release/1.0", mainline[1].SHA, githubfake.Commit{Message: "backport", Date: start.Add(5 * time.Hour)}))
+		require.NoError(t, fake.SetBranch("test-owner", "test-repo", "feature/x", mainline[0].SHA, githubfake.Commit{Message: "wip"}))
+
+		mockQ.On("DeleteBranchesNotIn", ctx, database.DeleteBranchesNotInParams{RepositoryID: 1, Names: []string{"release/1.0"}}).Return(nil).Once()
+		mockQ.On("GetBranchesByRepoID", ctx, int64(1)).Return([]database.Branch(nil), nil).Once()
+		mockQ.On("UpsertBranch", ctx, mock.MatchedBy(func(arg database.UpsertBranchParams) bool {
+			return arg.RepositoryID == 1 && arg.Name == "release/1.0" && arg.HeadSha != ""
+		})).Return(database.Branch{ID: 5, RepositoryID: 1, Name: "release/1.0"}, nil).Once()
+		mockQ.On("GetLatestCommitDateForBranch", ctx, int64(5)).Return(pgtype.Timestamp{}, nil).Once()
+		mockQ.On("UpsertCommits", ctx, mock.MatchedBy(func(arg []database.CreateCommitsParams) bool {
+			return len(arg) == 3 && arg[0].Message == "backport" && !arg[0].OnDefaultBranch && !arg[2].OnDefaultBranch
+		})).Return(int64(1), nil).Once()
+		mockQ.On("AddCommitsToBranch", ctx, mock.MatchedBy(func(arg database.AddCommitsToBranchParams) bool {
+			return arg.BranchID == 5 && arg.RepositoryID == 1 && len(arg.Shas) == 3 && arg.Shas[1] == mainline[1].SHA
+		})).Return(nil).Once()
+
+		inserted, err := syncer.syncBranches(ctx, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), inserted)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("skips branches whose head has not moved", func(t *testing.T) {
+		fake, client, mockQ, syncer := setup(t)
+		mainline, err := client.GetCommits(ctx, "test-owner", "test-repo", time.Time{})
+		require.NoError(t, err)
+		require.NoError(t, fake.SetBranch("test-owner", "test-repo", "release/1.0", mainline[0].SHA))
+
+		mockQ.On("DeleteBranchesNotIn", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("GetBranchesByRepoID", ctx, int64(1)).Return([]database.Branch{{ID: 5, Name: "release/1.0", HeadSha: mainline[0].SHA}}, nil).Once()
+
+		_, err = syncer.syncBranches(ctx, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "UpsertBranch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unlinks commits a force-push removed from a branch", func(t *testing.T) {
+		fake, client, mockQ, syncer := setup(t)
+		mainline, err := client.GetCommits(ctx, "test-owner", "test-repo", time.Time{})
+		require.NoError(t, err)
+		require.NoError(t, fake.SetBranch("test-owner", "test-repo", "release/1.0", mainline[0].SHA, githubfake.Commit{Message: "broken", Date: start.Add(5 * time.Hour)}))
+		branches, err := client.ListBranches(ctx, "test-owner", "test-repo")
+		require.NoError(t, err)
+		oldHead := branches[1].HeadSHA
+		require.NoError(t, fake.SetBranch("test-owner", "test-repo", "release/1.0", mainline[0].SHA, githubfake.Commit{Message: "fixed", Date: start.Add(6 * time.Hour)}))
+
+		mockQ.On("DeleteBranchesNotIn", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("GetBranchesByRepoID", ctx, int64(1)).Return([]database.Branch{{ID: 5, Name: "release/1.0", HeadSha: oldHead}}, nil).Once()
+		mockQ.On("UpsertBranch", ctx, mock.Anything).Return(database.Branch{ID: 5, RepositoryID: 1, Name: "release/1.0"}, nil).Once()
+		mockQ.On("RemoveCommitsFromBranch", ctx, database.RemoveCommitsFromBranchParams{BranchID: 5, Shas: []string{oldHead}}).Return(int64(1), nil).Once()
+		mockQ.On("GetLatestCommitDateForBranch", ctx, int64(5)).Return(pgtype.Timestamp{Time: mainline[0].CommitDate, Valid: true}, nil).Once()
+		mockQ.On("UpsertCommits", ctx, mock.MatchedBy(func(arg []database.CreateCommitsParams) bool {
+			return arg[0].Message == "fixed"
+		})).Return(int64(1), nil).Once()
+		mockQ.On("AddCommitsToBranch", ctx, mock.Anything).Return(nil).Once()
+
+		_, err = syncer.syncBranches(ctx, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("does nothing for repositories without branch patterns", func(t *testing.T) {
+		_, client, _, _ := setup(t)
+		syncer := &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: client}}
+
+		_, err := syncer.syncBranches(ctx, id)
+		require.NoError(t, err)
+	})
+}
+
+func TestSyncer_SyncPullRequests(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T) (*githubfake.Server, *MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		_, err := fake.SetPullRequest("test-owner", "test-repo", githubfake.PullRequest{Title: "old", CreatedAt: start, UpdatedAt: start.Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = fake.SetPullRequest("test-owner", "test-repo", githubfake.PullRequest{
+			Title:     "feat: greet",
+			CreatedAt: start,
+			UpdatedAt: start.Add(3 * time.Hour),
+			ClosedAt:  start.Add(3 * time.Hour),
+			MergedAt:  start.Add(3 * time.Hour),
+			Reviews:   []githubfake.Review{{User: "hubot", State: "APPROVED", SubmittedAt: start.Add(2 * time.Hour)}},
+		})
+		require.NoError(t, err)
+		client, err := github.NewClient("", logger, github.WithValidatorStore(&memoryValidators{})).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: client}, defaultSince: start}
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		return fake, mockQ, syncer
+	}
+
+	t.Run("stores every pull request since the default date on the first sync", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		mockQ.On("GetLatestPullRequestUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertPullRequest", ctx, mock.MatchedBy(func(arg database.UpsertPullRequestParams) bool {
+			return arg.Number == 2 && arg.State == model.PullRequestMerged && arg.MergedAt.Valid && arg.RepositoryID == 1
+		})).Return(database.PullRequest{ID: 7}, nil).Once()
+		mockQ.On("UpsertPullRequest", ctx, mock.MatchedBy(func(arg database.UpsertPullRequestParams) bool {
+			return arg.Number == 1 && arg.State == model.PullRequestOpen && !arg.MergedAt.Valid && !arg.ClosedAt.Valid
+		})).Return(database.PullRequest{ID: 6}, nil).Once()
+
+		err := syncer.syncPullRequests(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("only stores pull requests updated since the newest stored update", func(t *testing.T) {
+		fake, mockQ, syncer := setup(t)
+		_, err := fake.SetPullRequest("test-owner", "test-repo", githubfake.PullRequest{Number: 1, Title: "old, renamed", CreatedAt: start, UpdatedAt: start.Add(5 * time.Hour)})
+		require.NoError(t, err)
+		mockQ.On("GetLatestPullRequestUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{Time: start.Add(4 * time.Hour), Valid: true}, nil).Once()
+		mockQ.On("UpsertPullRequest", ctx, mock.MatchedBy(func(arg database.UpsertPullRequestParams) bool {
+			return arg.Number == 1 && arg.Title == "old, renamed"
+		})).Return(database.PullRequest{ID: 6}, nil).Once()
+
+		err = syncer.syncPullRequests(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("lists the pull requests updated at the newest stored update again", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		mockQ.On("GetLatestPullRequestUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{Time: start.Add(3 * time.Hour), Valid: true}, nil).Once()
+		mockQ.On("UpsertPullRequest", ctx, mock.MatchedBy(func(arg database.UpsertPullRequestParams) bool {
+			return arg.Number == 2
+		})).Return(database.PullRequest{ID: 7}, nil).Once()
+
+		err := syncer.syncPullRequests(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("stores nothing while the first page is unchanged", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetLatestPullRequestUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{Time: start.Add(3 * time.Hour), Valid: true}, nil).Twice()
+		mockQ.On("UpsertPullRequest", ctx, mock.Anything).Return(database.PullRequest{ID: 7}, nil).Once()
+		require.NoError(t, syncer.syncPullRequests(ctx, mockQ, id))
+
+		err := syncer.syncPullRequests(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("keeps the pull requests listed before the source stops listing them", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		syncer.sources = map[string]Source{github.DefaultHost: &truncatedSource{
+			pulls: []model.PullRequest{{Number: 3, State: model.PullRequestOpen, CreatedAt: start, UpdatedAt: start}},
+		}}
+		mockQ.On("GetLatestPullRequestUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertPullRequest", ctx, mock.MatchedBy(func(arg database.UpsertPullRequestParams) bool {
+			return arg.Number == 3
+		})).Return(database.PullRequest{ID: 8}, nil).Once()
+
+		err := syncer.syncPullRequests(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("fails when a pull request cannot be stored", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		dbErr := errors.New("db down")
+		mockQ.On("GetLatestPullRequestUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertPullRequest", ctx, mock.Anything).Return(database.PullRequest{}, dbErr).Once()
+
+		err := syncer.syncPullRequests(ctx, mockQ, id)
+
+		assert.ErrorIs(t, err, dbErr)
+		mockQ.AssertExpectations(t)
+	})
+}
+
+func TestSyncer_SyncPullRequestDetails(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T, budget int) (*MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		_, err := fake.SetPullRequest("test-owner", "test-repo", githubfake.PullRequest{Title: "draft", CreatedAt: start, UpdatedAt: start.Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = fake.SetPullRequest("test-owner", "test-repo", githubfake.PullRequest{
+			Title:     "feat: greet",
+			Additions: 10,
+			Deletions: 2,
+			CreatedAt: start,
+			UpdatedAt: start.Add(3 * time.Hour),
+			ClosedAt:  start.Add(3 * time.Hour),
+			MergedAt:  start.Add(3 * time.Hour),
+			MergedBy:  "hubot",
+			Reviews:   []githubfake.Review{{User: "hubot", State: "APPROVED", SubmittedAt: start.Add(2 * time.Hour)}},
+		})
+		require.NoError(t, err)
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{
+			logger:      logger,
+			sources:     map[string]Source{github.DefaultHost: client},
+			reposToSync: []RepoIdentifier{id, {Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "other-repo"}},
+			pullDetails: budget,
+			withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+				return fn(mockQ)
+			},
+		}
+		return mockQ, syncer
+	}
+
+	t.Run("stores the details of pull requests without them within the repository's share of the budget", func(t *testing.T) {
+		mockQ, syncer := setup(t, 3)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetPullRequestsWithoutDetails", ctx, database.GetPullRequestsWithoutDetailsParams{RepositoryID: 1, Limit: 2}).Return([]int32{2, 1}, nil).Once()
+		mockQ.On("UpdatePullRequestDetails", ctx, database.UpdatePullRequestDetailsParams{
+			RepositoryID:     1,
+			Number:           2,
+			Additions:        10,
+			Deletions:        2,
+			MergedByLogin:    "hubot",
+			DetailsUpdatedAt: pgtype.Timestamptz{Time: start.Add(3 * time.Hour), Valid: true},
+		}).Return(int64(7), nil).Once()
+		mockQ.On("UpsertPullRequestReview", ctx, mock.MatchedBy(func(arg database.UpsertPullRequestReviewParams) bool {
+			return arg.PullRequestID == 7 && arg.ReviewerLogin == "hubot" && arg.State == "APPROVED"
+		})).Return(nil).Once()
+		mockQ.On("UpdatePullRequestDetails", ctx, database.UpdatePullRequestDetailsParams{
+			RepositoryID:     1,
+			Number:           1,
+			DetailsUpdatedAt: pgtype.Timestamptz{Time: start.Add(time.Hour), Valid: true},
+		}).Return(int64(6), nil).Once()
+
+		err := syncer.syncPullRequestDetails(ctx, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("fails when the details of a pull request cannot be stored", func(t *testing.T) {
+		mockQ, syncer := setup(t, 3)
+		dbErr := errors.New("db down")
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetPullRequestsWithoutDetails", ctx, mock.Anything).Return([]int32{2, 1}, nil).Once()
+		mockQ.On("UpdatePullRequestDetails", ctx, mock.Anything).Return(int64(0), dbErr).Once()
+
+		err := syncer.syncPullRequestDetails(ctx, id)
+
+		assert.ErrorIs(t, err, dbErr)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("does nothing without a budget", func(t *testing.T) {
+		mockQ, syncer := setup(t, 0)
+
+		require.NoError(t, syncer.syncPullRequestDetails(ctx, id))
+		mockQ.AssertNotCalled(t, "GetPullRequestsWithoutDetails", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSyncer_SyncIssues(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T) (*githubfake.Server, *MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		require.NoError(t, fake.SetLabels("test-owner", "test-repo", githubfake.Label{Name: "bug", Color: "d73a4a"}))
+		_, err := fake.SetIssue("test-owner", "test-repo", githubfake.Issue{Title: "old", CreatedAt: start, UpdatedAt: start.Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = fake.SetIssue("test-owner", "test-repo", githubfake.Issue{
+			Title:     "crash on start",
+			Labels:    []string{"bug"},
+			CreatedAt: start,
+			UpdatedAt: start.Add(3 * time.Hour),
+			ClosedAt:  start.Add(3 * time.Hour),
+			Comments:  []githubfake.IssueComment{{ID: 42, User: "hubot", CreatedAt: start.Add(2 * time.Hour)}},
+		})
+		require.NoError(t, err)
+		_, err = fake.SetPullRequest("test-owner", "test-repo", githubfake.PullRequest{Title: "fix: crash", CreatedAt: start, UpdatedAt: start.Add(3 * time.Hour)})
+		require.NoError(t, err)
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: client}, defaultSince: start}
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("UpsertLabel", ctx, database.UpsertLabelParams{RepositoryID: 1, Name: "bug", Color: "d73a4a"}).Return(nil).Once()
+		mockQ.On("DeleteLabelsNotIn", ctx, database.DeleteLabelsNotInParams{RepositoryID: 1, Names: []string{"bug"}}).Return(nil).Once()
+		return fake, mockQ, syncer
+	}
+
+	t.Run("stores labels and every issue since the default date on the first sync", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		mockQ.On("GetLatestIssueUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertIssue", ctx, mock.MatchedBy(func(arg database.UpsertIssueParams) bool {
+			return arg.Number == 2 && arg.State == "closed" && arg.ClosedAt.Valid && arg.CommentsCount == 1 && slices.Equal(arg.Labels, []string{"bug"})
+		})).Return(database.Issue{ID: 7}, nil).Once()
+		mockQ.On("UpsertIssue", ctx, mock.MatchedBy(func(arg database.UpsertIssueParams) bool {
+			return arg.Number == 1 && arg.State == "open" && !arg.ClosedAt.Valid && arg.Labels != nil && arg.Assignees != nil
+		})).Return(database.Issue{ID: 6}, nil).Once()
+		// Only issue 6, listed without comments, loses its stored ones here.
+		mockQ.On("DeleteIssueCommentsNotIn", ctx, database.DeleteIssueCommentsNotInParams{IssueID: 6, GithubCommentIds: []int64{}}).Return(nil).Once()
+
+		err := syncer.syncIssues(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("only stores issues updated since the newest stored update", func(t *testing.T) {
+		fake, mockQ, syncer := setup(t)
+		_, err := fake.SetIssue("test-owner", "test-repo", githubfake.Issue{Number: 1, Title: "old, renamed", CreatedAt: start, UpdatedAt: start.Add(5 * time.Hour)})
+		require.NoError(t, err)
+		mockQ.On("GetLatestIssueUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{Time: start.Add(4 * time.Hour), Valid: true}, nil).Once()
+		mockQ.On("UpsertIssue", ctx, mock.MatchedBy(func(arg database.UpsertIssueParams) bool {
+			return arg.Number == 1 && arg.Title == "old, renamed"
+		})).Return(database.Issue{ID: 6}, nil).Once()
+		mockQ.On("DeleteIssueCommentsNotIn", ctx, database.DeleteIssueCommentsNotInParams{IssueID: 6, GithubCommentIds: []int64{}}).Return(nil).Once()
+
+		err = syncer.syncIssues(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("keeps the labels and issues listed before the source stops listing them", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		syncer.sources = map[string]Source{github.DefaultHost: &truncatedSource{
+			labels: []model.Label{{Name: "bug", Color: "d73a4a"}},
+			issues: []model.Issue{{Number: 3, State: "open", CommentsCount: 1, CreatedAt: start, UpdatedAt: start}},
+		}}
+		mockQ.On("GetLatestIssueUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertIssue", ctx, mock.MatchedBy(func(arg database.UpsertIssueParams) bool {
+			return arg.Number == 3
+		})).Return(database.Issue{ID: 8}, nil).Once()
+
+		err := syncer.syncIssues(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("fails when an issue cannot be stored", func(t *testing.T) {
+		_, mockQ, syncer := setup(t)
+		dbErr := errors.New("db down")
+		mockQ.On("GetLatestIssueUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertIssue", ctx, mock.Anything).Return(database.Issue{}, dbErr).Once()
+
+		err := syncer.syncIssues(ctx, mockQ, id)
+
+		assert.ErrorIs(t, err, dbErr)
+		mockQ.AssertExpectations(t)
+	})
+}
+
+func TestSyncer_SyncIssueComments(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T, budget int) (*MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		_, err := fake.SetIssue("test-owner", "test-repo", githubfake.Issue{
+			Title:     "crash on start",
+			CreatedAt: start,
+			UpdatedAt: start.Add(3 * time.Hour),
+			Comments:  []githubfake.IssueComment{{ID: 42, User: "hubot", CreatedAt: start.Add(2 * time.Hour)}},
+		})
+		require.NoError(t, err)
+		_, err = fake.SetIssue("test-owner", "test-repo", githubfake.Issue{Title: "all comments deleted", CreatedAt: start, UpdatedAt: start.Add(time.Hour)})
+		require.NoError(t, err)
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{
+			logger:        logger,
+			sources:       map[string]Source{github.DefaultHost: client},
+			reposToSync:   []RepoIdentifier{id, {Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "other-repo"}},
+			issueComments: budget,
+			withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+				return fn(mockQ)
+			},
+		}
+		return mockQ, syncer
+	}
+
+	t.Run("replaces the comments of issues with stale ones within the repository's share of the budget", func(t *testing.T) {
+		mockQ, syncer := setup(t, 3)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetIssuesWithStaleComments", ctx, database.GetIssuesWithStaleCommentsParams{RepositoryID: 1, Limit: 2}).Return([]int32{1, 2}, nil).Once()
+		mockQ.On("MarkIssueCommentsSynced", ctx, database.MarkIssueCommentsSyncedParams{RepositoryID: 1, Number: 1}).Return(int64(7), nil).Once()
+		mockQ.On("UpsertIssueComment", ctx, database.UpsertIssueCommentParams{
+			IssueID:          7,
+			GithubCommentID:  42,
+			AuthorLogin:      "hubot",
+			CommentCreatedAt: start.Add(2 * time.Hour),
+			CommentUpdatedAt: start.Add(2 * time.Hour),
+		}).Return(nil).Once()
+		mockQ.On("DeleteIssueCommentsNotIn", ctx, database.DeleteIssueCommentsNotInParams{IssueID: 7, GithubCommentIds: []int64{42}}).Return(nil).Once()
+		mockQ.On("MarkIssueCommentsSynced", ctx, database.MarkIssueCommentsSyncedParams{RepositoryID: 1, Number: 2}).Return(int64(8), nil).Once()
+		mockQ.On("DeleteIssueCommentsNotIn", ctx, database.DeleteIssueCommentsNotInParams{IssueID: 8, GithubCommentIds: []int64{}}).Return(nil).Once()
+
+		err := syncer.syncIssueComments(ctx, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("keeps the comments stored before an issue whose comments cannot be stored", func(t *testing.T) {
+		mockQ, syncer := setup(t, 3)
+		committed := 0
+		syncer.withTx = func(ctx context.Context, fn func(q database.Store) error) error {
+			if err := fn(mockQ); err != nil {
+				return err
+			}
+			committed++
+			return nil
+		}
+		dbErr := errors.New("db down")
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetIssuesWithStaleComments", ctx, mock.Anything).Return([]int32{2, 1}, nil).Once()
+		mockQ.On("MarkIssueCommentsSynced", ctx, database.MarkIssueCommentsSyncedParams{RepositoryID: 1, Number: 2}).Return(int64(8), nil).Once()
+		mockQ.On("DeleteIssueCommentsNotIn", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("MarkIssueCommentsSynced", ctx, database.MarkIssueCommentsSyncedParams{RepositoryID: 1, Number: 1}).Return(int64(0), dbErr).Once()
+
+		err := syncer.syncIssueComments(ctx, id)
+
+		assert.ErrorIs(t, err, dbErr)
+		assert.Equal(t, 2, committed, "the lookup and issue 2 were committed before issue 1 failed")
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("does nothing without a budget", func(t *testing.T) {
+		mockQ, syncer := setup(t, 0)
+
+		require.NoError(t, syncer.syncIssueComments(ctx, id))
+		mockQ.AssertNotCalled(t, "GetIssuesWithStaleComments", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSyncer_SyncReleases(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	storedTags := []database.Tag{{RepositoryID: 1, Name: "v1.0.0", Sha: "aaa"}, {RepositoryID: 1, Name: "v1.1.0", Sha: "bbb"}}
+
+	setup := func(t *testing.T) (*MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		require.NoError(t, fake.SetTags("test-owner", "test-repo", githubfake.Tag{Name: "v1.1.0", SHA: "bbb"}, githubfake.Tag{Name: "v1.0.0", SHA: "aaa"}))
+		_, err := fake.SetRelease("test-owner", "test-repo", githubfake.Release{
+			ID:          10,
+			TagName:     "v1.0.0",
+			CreatedAt:   start,
+			PublishedAt: start,
+			Assets:      []githubfake.Asset{{ID: 20, Name: "app.tar.gz", Size: 1024, DownloadCount: 7}},
+		})
+		require.NoError(t, err)
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: client}}
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("UpsertRelease", ctx, mock.MatchedBy(func(arg database.UpsertReleaseParams) bool {
+			return arg.GithubReleaseID == 10 && arg.TagName == "v1.0.0" && !arg.Draft && arg.PublishedAt.Valid
+		})).Return(database.Release{ID: 5}, nil).Once()
+		mockQ.On("UpsertReleaseAsset", ctx, database.UpsertReleaseAssetParams{ReleaseID: 5, GithubAssetID: 20, Name: "app.tar.gz", Size: 1024, DownloadCount: 7}).Return(nil).Once()
+		mockQ.On("DeleteReleaseAssetsNotIn", ctx, database.DeleteReleaseAssetsNotInParams{ReleaseID: 5, GithubAssetIds: []int64{20}}).Return(nil).Once()
+		mockQ.On("DeleteReleasesNotIn", ctx, database.DeleteReleasesNotInParams{RepositoryID: 1, GithubReleaseIds: []int64{10}}).Return(nil).Once()
+		return mockQ, syncer
+	}
+	expectTags := func(mockQ *MockQuerier, firstReleases ...string) {
+		mockQ.On("GetTagsByCommitDate", ctx, int64(1)).Return([]database.GetTagsByCommitDateRow{
+			{Name: "v1.0.0", Sha: "aaa", FirstRelease: firstReleases[0]},
+			{Name: "v1.1.0", Sha: "bbb", FirstRelease: firstReleases[1]},
+		}, nil).Once()
+	}
+	expectRecompute := func(mockQ *MockQuerier) {
+		mockQ.On("ClearFirstReleases", ctx, int64(1)).Return(nil).Once()
+		first := mockQ.On("AssignFirstRelease", ctx, database.AssignFirstReleaseParams{RepositoryID: 1, Sha: "aaa", Tag: "v1.0.0"}).Return(int64(10), nil).Once()
+		mockQ.On("AssignFirstRelease", ctx, database.AssignFirstReleaseParams{RepositoryID: 1, Sha: "bbb", Tag: "v1.1.0"}).Return(int64(3), nil).Once().NotBefore(first)
+	}
+
+	t.Run("maps only the commits of new tags", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetTagsByRepoID", ctx, int64(1)).Return(storedTags[:1], nil).Once()
+		mockQ.On("UpsertTag", ctx, database.UpsertTagParams{RepositoryID: 1, Name: "v1.1.0", Sha: "bbb"}).Return(nil).Once()
+		expectTags(mockQ, "v1.0.0", "")
+		mockQ.On("AssignFirstRelease", ctx, database.AssignFirstReleaseParams{RepositoryID: 1, Sha: "bbb", Tag: "v1.1.0"}).Return(int64(3), nil).Once()
+
+		err := syncer.syncReleases(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "ClearFirstReleases", mock.Anything, mock.Anything)
+		mockQ.AssertNotCalled(t, "DeleteTagsNotIn", mock.Anything, mock.Anything)
+	})
+
+	t.Run("recomputes every first release when a tag moved", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetTagsByRepoID", ctx, int64(1)).Return([]database.Tag{storedTags[0], {RepositoryID: 1, Name: "v1.1.0", Sha: "old"}}, nil).Once()
+		mockQ.On("UpsertTag", ctx, database.UpsertTagParams{RepositoryID: 1, Name: "v1.1.0", Sha: "bbb"}).Return(nil).Once()
+		mockQ.On("DeleteTagsNotIn", ctx, database.DeleteTagsNotInParams{RepositoryID: 1, Names: []string{"v1.1.0", "v1.0.0"}}).Return(nil).Once()
+		expectTags(mockQ, "v1.0.0", "")
+		expectRecompute(mockQ)
+
+		err := syncer.syncReleases(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("recomputes every first release when a tag was deleted", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetTagsByRepoID", ctx, int64(1)).Return(append([]database.Tag{{RepositoryID: 1, Name: "v0.9.0", Sha: "ccc"}}, storedTags...), nil).Once()
+		mockQ.On("DeleteTagsNotIn", ctx, database.DeleteTagsNotInParams{RepositoryID: 1, Names: []string{"v1.1.0", "v1.0.0"}}).Return(nil).Once()
+		expectTags(mockQ, "v1.0.0", "v1.1.0")
+		expectRecompute(mockQ)
+
+		err := syncer.syncReleases(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("recomputes every first release when a tag was added to released commits", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetTagsByRepoID", ctx, int64(1)).Return(storedTags[1:], nil).Once()
+		mockQ.On("UpsertTag", ctx, database.UpsertTagParams{RepositoryID: 1, Name: "v1.0.0", Sha: "aaa"}).Return(nil).Once()
+		expectTags(mockQ, "v1.1.0", "v1.1.0")
+		expectRecompute(mockQ)
+
+		err := syncer.syncReleases(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("keeps the mapping while the tags are unchanged", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetTagsByRepoID", ctx, int64(1)).Return(storedTags, nil).Once()
+		expectTags(mockQ, "v1.0.0", "v1.1.0")
+
+		err := syncer.syncReleases(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "ClearFirstReleases", mock.Anything, mock.Anything)
+		mockQ.AssertNotCalled(t, "AssignFirstRelease", mock.Anything, mock.Anything)
+	})
+
+	t.Run("maps a tagged commit stored after the newest tag", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetTagsByRepoID", ctx, int64(1)).Return(storedTags, nil).Once()
+		expectTags(mockQ, "v1.0.0", "")
+		mockQ.On("AssignFirstRelease", ctx, database.AssignFirstReleaseParams{RepositoryID: 1, Sha: "bbb", Tag: "v1.1.0"}).Return(int64(3), nil).Once()
+
+		err := syncer.syncReleases(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "ClearFirstReleases", mock.Anything, mock.Anything)
+	})
+
+	t.Run("recomputes every first release when a tagged commit older than a mapped one was stored after its tag", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetTagsByRepoID", ctx, int64(1)).Return(storedTags, nil).Once()
+		expectTags(mockQ, "", "v1.1.0")
+		expectRecompute(mockQ)
+
+		err := syncer.syncReleases(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+}
+
+func TestSyncer_SyncWorkflows(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T) (*MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		_, err := fake.SetWorkflow("test-owner", "test-repo", githubfake.Workflow{ID: 10, Name: "CI", Path: ".github/workflows/ci.yml"})
+		require.NoError(t, err)
+		runs := []githubfake.WorkflowRun{
+			{ID: 100, WorkflowID: 10, HeadSHA: "aaa", CreatedAt: start, StartedAt: start, Conclusion: "success", UpdatedAt: start.Add(5 * time.Minute)},
+			{ID: 101, WorkflowID: 10, HeadSHA: "bbb", CreatedAt: start.Add(48 * time.Hour), StartedAt: start.Add(48 * time.Hour)},
+			{ID: 102, WorkflowID: 11, Name: "Nightly", HeadSHA: "bbb", CreatedAt: start.Add(72 * time.Hour)},
+		}
+		for _, run := range runs {
+			_, err := fake.SetWorkflowRun("test-owner", "test-repo", run)
+			require.NoError(t, err)
+		}
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: client}, defaultSince: start}
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("UpsertWorkflow", ctx, database.UpsertWorkflowParams{RepositoryID: 1, GithubWorkflowID: 10, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}).Return(nil).Once()
+		return mockQ, syncer
+	}
+
+	t.Run("stores workflows and their runs since the default date on the first sync", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetWorkflowRunSyncStart", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertWorkflow", ctx, database.UpsertWorkflowParams{RepositoryID: 1, GithubWorkflowID: 11, Name: "Nightly", State: "deleted"}).Return(nil).Once()
+		mockQ.On("UpsertWorkflowRun", ctx, database.UpsertWorkflowRunParams{
+			RepositoryID: 1,
+			GithubRunID:  100,
+			WorkflowID:   10,
+			Name:         "CI",
+			Event:        "push",
+			Status:       model.WorkflowRunCompleted,
+			Conclusion:   "success",
+			HeadSha:      "aaa",
+			HeadBranch:   "main",
+			RunNumber:    1,
+			RunAttempt:   1,
+			RunCreatedAt: start,
+			RunStartedAt: start,
+			RunUpdatedAt: start.Add(5 * time.Minute),
+		}).Return(nil).Once()
+		mockQ.On("UpsertWorkflowRun", ctx, mock.MatchedBy(func(arg database.UpsertWorkflowRunParams) bool {
+			return arg.GithubRunID == 101 && arg.Status == model.WorkflowRunInProgress && arg.Conclusion == ""
+		})).Return(nil).Once()
+		mockQ.On("UpsertWorkflowRun", ctx, mock.MatchedBy(func(arg database.UpsertWorkflowRunParams) bool {
+			return arg.GithubRunID == 102 && arg.WorkflowID == 11 && arg.Status == model.WorkflowRunQueued
+		})).Return(nil).Once()
+
+		err := syncer.syncWorkflows(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("lists runs from before the oldest incomplete run", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetWorkflowRunSyncStart", ctx, int64(1)).Return(pgtype.Timestamptz{Time: start.Add(72 * time.Hour), Valid: true}, nil).Once()
+		mockQ.On("UpsertWorkflow", ctx, mock.MatchedBy(func(arg database.UpsertWorkflowParams) bool { return arg.GithubWorkflowID == 11 })).Return(nil).Once()
+		mockQ.On("UpsertWorkflowRun", ctx, mock.MatchedBy(func(arg database.UpsertWorkflowRunParams) bool {
+			return arg.GithubRunID == 101 || arg.GithubRunID == 102
+		})).Return(nil).Twice()
+
+		err := syncer.syncWorkflows(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("lists runs created exactly sinceOverlap before the sync start", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("GetWorkflowRunSyncStart", ctx, int64(1)).Return(pgtype.Timestamptz{Time: start.Add(72*time.Hour + sinceOverlap), Valid: true}, nil).Once()
+		mockQ.On("UpsertWorkflow", ctx, mock.MatchedBy(func(arg database.UpsertWorkflowParams) bool { return arg.GithubWorkflowID == 11 })).Return(nil).Once()
+		mockQ.On("UpsertWorkflowRun", ctx, mock.MatchedBy(func(arg database.UpsertWorkflowRunParams) bool {
+			return arg.GithubRunID == 102
+		})).Return(nil).Once()
+
+		err := syncer.syncWorkflows(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("keeps the workflows and runs listed before the source stops listing them", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		syncer.sources = map[string]Source{github.DefaultHost: &truncatedSource{
+			workflows: []model.Workflow{{ID: 10, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}},
+			runs:      []model.WorkflowRun{{ID: 100, WorkflowID: 10, Name: "CI", Status: model.WorkflowRunQueued, CreatedAt: start}},
+		}}
+		mockQ.On("GetWorkflowRunSyncStart", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Once()
+		mockQ.On("UpsertWorkflowRun", ctx, mock.MatchedBy(func(arg database.UpsertWorkflowRunParams) bool {
+			return arg.GithubRunID == 100
+		})).Return(nil).Once()
+
+		err := syncer.syncWorkflows(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+}
+
+func TestSyncer_SyncStargazers(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo", StarsCount: 2}
+
+	setup := func(t *testing.T) (*MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo", Stars: 2})
+		require.NoError(t, fake.AddStargazers("test-owner", "test-repo",
+			githubfake.Stargazer{Login: "alice", StarredAt: start},
+			githubfake.Stargazer{Login: "bob", StarredAt: start.Add(time.Hour)},
+			githubfake.Stargazer{Login: "alice", StarredAt: start.Add(2 * time.Hour)},
+		))
+		client, err := github.NewClient("", logger).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{logger: logger, sources: map[string]Source{github.DefaultHost: client}, stargazers: true}
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		return mockQ, syncer
+	}
+
+	t.Run("backfills each stargazer once on the first sync", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("CountStargazers", ctx, int64(1)).Return(int64(0), nil).Once()
+		mockQ.On("CreateStargazers", ctx, []database.CreateStargazersParams{
+			{RepositoryID: 1, Login: "alice", StarredAt: start},
+			{RepositoryID: 1, Login: "bob", StarredAt: start.Add(time.Hour)},
+		}).Return(int64(2), nil).Once()
+
+		err := syncer.syncStargazers(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("skips repositories whose stargazers are stored", func(t *testing.T) {
+		mockQ, syncer := setup(t)
+		mockQ.On("CountStargazers", ctx, int64(1)).Return(int64(2), nil).Once()
+
+		err := syncer.syncStargazers(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "CreateStargazers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("does nothing unless enabled", func(t *testing.T) {
+		_, syncer := setup(t)
+		syncer.stargazers = false
+
+		require.NoError(t, syncer.syncStargazers(ctx, new(MockQuerier), id))
+	})
+}
+
+func TestSyncer_SyncLanguages(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T, repo githubfake.Repository) (*MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		repo.Owner, repo.Name = "test-owner", "test-repo"
+		fake.AddRepository(repo)
+		client, err := github.NewClient("", logger, github.WithValidatorStore(&memoryValidators{})).WithBaseURL(server.URL)
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{
+			logger:  logger,
+			sources: map[string]Source{github.DefaultHost: client},
+			withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+				return fn(mockQ)
+			},
+		}
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("CreateSnapshotLanguages", ctx, int64(1)).Return(nil).Once()
+		return mockQ, syncer
+	}
+
+	t.Run("replaces languages and topics and records languages with the snapshot", func(t *testing.T) {
+		mockQ, syncer := setup(t, githubfake.Repository{
+			Languages: map[string]int64{"Shell": 120, "Go": 9000},
+			Topics:    []string{"api", "golang"},
+		})
+		mockQ.On("DeleteRepositoryLanguagesNotIn", ctx, database.DeleteRepositoryLanguagesNotInParams{RepositoryID: 1, Languages: []string{"Go", "Shell"}}).Return(nil).Once()
+		mockQ.On("UpsertRepositoryLanguages", ctx, database.UpsertRepositoryLanguagesParams{RepositoryID: 1, Languages: []string{"Go", "Shell"}, Bytes: []int64{9000, 120}}).Return(nil).Once()
+		mockQ.On("DeleteRepositoryTopicsNotIn", ctx, database.DeleteRepositoryTopicsNotInParams{RepositoryID: 1, Topics: []string{"api", "golang"}}).Return(nil).Once()
+		mockQ.On("AddRepositoryTopics", ctx, database.AddRepositoryTopicsParams{RepositoryID: 1, Topics: []string{"api", "golang"}}).Return(nil).Once()
+
+		err := syncer.syncLanguages(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("clears languages and topics that were all removed", func(t *testing.T) {
+		mockQ, syncer := setup(t, githubfake.Repository{})
+		mockQ.On("DeleteRepositoryLanguagesNotIn", ctx, database.DeleteRepositoryLanguagesNotInParams{RepositoryID: 1, Languages: []string{}}).Return(nil).Once()
+		mockQ.On("UpsertRepositoryLanguages", ctx, database.UpsertRepositoryLanguagesParams{RepositoryID: 1, Languages: []string{}, Bytes: []int64{}}).Return(nil).Once()
+		mockQ.On("DeleteRepositoryTopicsNotIn", ctx, database.DeleteRepositoryTopicsNotInParams{RepositoryID: 1, Topics: []string{}}).Return(nil).Once()
+		mockQ.On("AddRepositoryTopics", ctx, database.AddRepositoryTopicsParams{RepositoryID: 1, Topics: []string{}}).Return(nil).Once()
+
+		err := syncer.syncLanguages(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("only records the snapshot while languages and topics are unchanged", func(t *testing.T) {
+		mockQ, syncer := setup(t, githubfake.Repository{Languages: map[string]int64{"Go": 9000}, Topics: []string{"golang"}})
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("CreateSnapshotLanguages", ctx, int64(1)).Return(nil).Once()
+		mockQ.On("DeleteRepositoryLanguagesNotIn", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("UpsertRepositoryLanguages", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("DeleteRepositoryTopicsNotIn", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("AddRepositoryTopics", ctx, mock.Anything).Return(nil).Once()
+		require.NoError(t, syncer.syncLanguages(ctx, mockQ, id))
+
+		err := syncer.syncLanguages(ctx, mockQ, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("fetches languages again after their transaction was rolled back", func(t *testing.T) {
+		mockQ, syncer := setup(t, githubfake.Repository{Languages: map[string]int64{"Go": 9000}})
+		dbErr := errors.New("db down")
+		mockQ.On("DeleteRepositoryLanguagesNotIn", ctx, mock.Anything).Return(dbErr).Once()
+		err := syncer.inTx(ctx, id, func(q database.Store) error { return syncer.syncLanguages(ctx, q, id) })
+		require.ErrorIs(t, err, dbErr)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("DeleteRepositoryLanguagesNotIn", ctx, database.DeleteRepositoryLanguagesNotInParams{RepositoryID: 1, Languages: []string{"Go"}}).Return(nil).Once()
+		mockQ.On("UpsertRepositoryLanguages", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("DeleteRepositoryTopicsNotIn", ctx, mock.Anything).Return(nil).Once()
+		mockQ.On("AddRepositoryTopics", ctx, mock.Anything).Return(nil).Once()
+
+		err = syncer.inTx(ctx, id, func(q database.Store) error { return syncer.syncLanguages(ctx, q, id) })
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+}
+
+func TestSyncer_SyncCommitFiles(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+
+	setup := func(t *testing.T, budget int) (*githubfake.Server, []model.Commit, *MockQuerier, *Syncer) {
+		fake, server := githubfake.NewServer()
+		t.Cleanup(server.Close)
+		fake.AddRepository(githubfake.Repository{Owner: "test-owner", Name: "test-repo"})
+		require.NoError(t, fake.AddCommits("test-owner", "test-repo",
+			githubfake.Commit{Message: "empty", Date: start},
+			githubfake.Commit{Message: "feature", Date: start.Add(time.Hour), Files: []githubfake.CommitFile{
+				{Filename: "main.go", Additions: 5, Deletions: 2},
+				{Filename: "main_test.go", Status: "added", Additions: 20},
+			}},
+		))
+		client, err := github.NewClient("", logger, github.WithRetryBackoff(time.Millisecond, time.Millisecond)).WithBaseURL(server.URL)
+		require.NoError(t, err)
+		commits, err := client.GetCommits(ctx, "test-owner", "test-repo", time.Time{})
+		require.NoError(t, err)
+
+		mockQ := new(MockQuerier)
+		syncer := &Syncer{
+			logger:      logger,
+			sources:     map[string]Source{github.DefaultHost: client},
+			reposToSync: []RepoIdentifier{id, {Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "other-repo"}},
+			commitFiles: budget,
+			withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+				return fn(mockQ)
+			},
+		}
+		return fake, commits, mockQ, syncer
+	}
+
+	t.Run("stores the files of commits without them within the repository's share of the budget", func(t *testing.T) {
+		_, commits, mockQ, syncer := setup(t, 3)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetCommitsWithoutFiles", ctx, database.GetCommitsWithoutFilesParams{RepositoryID: 1, Limit: 2}).Return([]string{commits[0].SHA, commits[1].SHA}, nil).Once()
+		mockQ.On("CreateCommitFiles", ctx, []database.CreateCommitFilesParams{
+			{RepositoryID: 1, Sha: commits[0].SHA, Path: "main.go", Status: "modified", Additions: 5, Deletions: 2},
+			{RepositoryID: 1, Sha: commits[0].SHA, Path: "main_test.go", Status: "added", Additions: 20},
+		}).Return(int64(2), nil).Once()
+		mockQ.On("MarkCommitFilesSynced", ctx, database.MarkCommitFilesSyncedParams{RepositoryID: 1, Sha: commits[0].SHA, Additions: 25, Deletions: 2}).Return(nil).Once()
+		mockQ.On("MarkCommitFilesSynced", ctx, database.MarkCommitFilesSyncedParams{RepositoryID: 1, Sha: commits[1].SHA}).Return(nil).Once()
+
+		err := syncer.syncCommitFiles(ctx, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("skips commits whose files cannot be fetched", func(t *testing.T) {
+		fake, commits, mockQ, syncer := setup(t, 3)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetCommitsWithoutFiles", ctx, mock.Anything).Return([]string{commits[0].SHA, commits[1].SHA}, nil).Once()
+		mockQ.On("MarkCommitFilesSynced", ctx, database.MarkCommitFilesSyncedParams{RepositoryID: 1, Sha: commits[1].SHA}).Return(nil).Once()
+		fake.FailNext(5, http.StatusBadGateway) // every attempt for the first commit
+
+		err := syncer.syncCommitFiles(ctx, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+	})
+
+	t.Run("marks commits GitHub no longer serves as changing no files", func(t *testing.T) {
+		fake, commits, mockQ, syncer := setup(t, 3)
+		mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Once()
+		mockQ.On("GetCommitsWithoutFiles", ctx, mock.Anything).Return([]string{commits[0].SHA}, nil).Once()
+		mockQ.On("MarkCommitFilesSynced", ctx, database.MarkCommitFilesSyncedParams{RepositoryID: 1, Sha: commits[0].SHA}).Return(nil).Once()
+		fake.FailNext(1, http.StatusNotFound)
+
+		err := syncer.syncCommitFiles(ctx, id)
+
+		require.NoError(t, err)
+		mockQ.AssertExpectations(t)
+		mockQ.AssertNotCalled(t, "CreateCommitFiles", mock.Anything, mock.Anything)
+	})
+
+	t.Run("does nothing without a budget", func(t *testing.T) {
+		_, _, mockQ, syncer := setup(t, 0)
+
+		require.NoError(t, syncer.syncCommitFiles(ctx, id))
+		mockQ.AssertNotCalled(t, "GetCommitsWithoutFiles", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSyncer_SyncMetadata_Unsupported(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	id := RepoIdentifier{Provider: model.ProviderGitHub, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo"}
+	storedRepo := database.Repository{ID: 1, Host: github.DefaultHost, Owner: "test-owner", Name: "test-repo", StarsCount: 2}
+
+	sources := []struct {
+		name string
+		src  Source
+	}{
+		// The capability interface is not implemented.
+		{"fakeSource", &fakeSource{}},
+		// The capability interface is forwarded, returning errors.ErrUnsupported.
+		{"splitSource", WithCommitSource(&fakeSource{provider: model.ProviderGitLab}, &fakeMirror{})},
+	}
+	steps := []struct {
+		capability string
+		sync       func(s *Syncer, q database.Store) error
+	}{
+		{"pullRequestSource", func(s *Syncer, q database.Store) error { return s.syncPullRequests(ctx, q, id) }},
+		{"pullRequestDetailSource", func(s *Syncer, q database.Store) error { return s.syncPullRequestDetails(ctx, id) }},
+		{"issueSource", func(s *Syncer, q database.Store) error { return s.syncIssues(ctx, q, id) }},
+		{"issueCommentSource", func(s *Syncer, q database.Store) error { return s.syncIssueComments(ctx, id) }},
+		{"releaseSource", func(s *Syncer, q database.Store) error { return s.syncReleases(ctx, q, id) }},
+		{"workflowSource", func(s *Syncer, q database.Store) error { return s.syncWorkflows(ctx, q, id) }},
+		{"stargazerSource", func(s *Syncer, q database.Store) error { return s.syncStargazers(ctx, q, id) }},
+		{"languageSource", func(s *Syncer, q database.Store) error { return s.syncLanguages(ctx, q, id) }},
+		{"commitFileSource", func(s *Syncer, q database.Store) error { return s.syncCommitFiles(ctx, id) }},
+	}
+	for _, source := range sources {
+		for _, step := range steps {
+			t.Run(source.name+" without "+step.capability, func(t *testing.T) {
+				// Only reads are expected: the mock fails on any write.
+				mockQ := new(MockQuerier)
+				mockQ.On("GetRepositoryByProviderHostOwnerAndName", ctx, mock.Anything).Return(storedRepo, nil).Maybe()
+				mockQ.On("GetLatestPullRequestUpdateForRepo", ctx, int64(1)).Return(pgtype.Timestamptz{}, nil).Maybe()
+				mockQ.On("GetPullRequestsWithoutDetails", ctx, mock.Anything).Return([]int32{1}, nil).Maybe()
+				mockQ.On("GetIssuesWithStaleComments", ctx, mock.Anything).Return([]int32{1}, nil).Maybe()
+				mockQ.On("GetCommitsWithoutFiles", ctx, mock.Anything).Return([]string{"aaa"}, nil).Maybe()
+				mockQ.On("CountStargazers", ctx, int64(1)).Return(int64(0), nil).Maybe()
+				syncer := &Syncer{
+					logger:        logger,
+					sources:       map[string]Source{github.DefaultHost: source.src},
+					reposToSync:   []RepoIdentifier{id},
+					pullDetails:   10,
+					issueComments: 10,
+					commitFiles:   10,
+					stargazers:    true,
+					withTx: func(ctx context.Context, fn func(q database.Store) error) error {
+						return fn(mockQ)
+					},
+				}
+
+				err := step.sync(syncer, mockQ)
+
+				require.NoError(t, err)
+				mockQ.AssertExpectations(t)
+			})
+		}
+	}
+}
+
+func TestNewSyncer(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	sources := map[string]Source{
+		github.DefaultHost:   &fakeSource{},
+		"gitlab.example.com": &fakeSource{provider: model.ProviderGitLab},
+	}
+
+	t.Run("resolves gitlab entries to the gitlab source", func(t *testing.T) {
+		s, err := NewSyncer(nil, sources, logger, []string{"octo-org/hello-world", "gitlab:group/project"}, time.Hour, time.Time{})
+
+		require.NoError(t, err)
+		assert.Equal(t, "gitlab.example.com", s.reposToSync[1].Host)
+	})
+
+	t.Run("rejects hosts without a source of the right provider", func(t *testing.T) {
+		for _, r := range []string{"ghe.example.com/team/service", "gitlab.example.com/group/project"} {
+			_, err := NewSyncer(nil, sources, logger, []string{r}, time.Hour, time.Time{})
+
+			var unknownHost *custom_errors.ErrUnknownHost
+			assert.ErrorAs(t, err, &unknownHost, r)
+		}
+	})
+
+	t.Run("resolves branch patterns by repository", func(t *testing.T) {
+		client := github.NewClient("", logger)
+		branches := WithBranches(map[string][]string{"github.com/octo-org/hello-world": {"main", "release/*"}})
+
+		s, err := NewSyncer(nil, map[string]Source{github.DefaultHost: client}, logger, []string{"octo-org/hello-world"}, time.Hour, time.Time{}, branches)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"octo-org/hello-world": {"main", "release/*"}}, s.branches)
+	})
+
+	t.Run("rejects invalid branch patterns", func(t *testing.T) {
+		client := github.NewClient("", logger)
+		for name, patterns := range map[string]map[string][]string{
+			"unknown host":      {"ghe.example.com/team/service": {"main"}},
+			"malformed pattern": {"octo-org/hello-world": {"release/["}},
+			"gitlab project":    {"gitlab:group/project": {"main"}},
+		} {
+			_, err := NewSyncer(nil, map[string]Source{github.DefaultHost: client, "gitlab.example.com": &fakeSource{provider: model.ProviderGitLab}}, logger, []string{"octo-org/hello-world", "gitlab:group/project"}, time.Hour, time.Time{}, WithBranches(patterns))
+
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("accepts branch patterns for repositories that are not tracked yet", func(t *testing.T) {
+		client := github.NewClient("", logger)
+		branches := WithBranches(map[string][]string{"octo-org/service-api": {"main"}})
+
+		s, err := NewSyncer(nil, map[string]Source{github.DefaultHost: client}, logger, []string{"octo-org/service-*"}, time.Hour, time.Time{}, branches)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"octo-org/service-api": {"main"}}, s.branches)
+	})
+
+	t.Run("seeds the tracked repositories with the canonical entries", func(t *testing.T) {
+		client := github.NewClient("", logger)
+
+		s, err := NewSyncer(nil, map[string]Source{github.DefaultHost: client}, logger, []string{"github.com/octo-org/hello-world", "org:github.com/octo-org"}, time.Hour, time.Time{})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"octo-org/hello-world", "org:octo-org"}, s.seed)
+	})
+
+	t.Run("rejects discovery on hosts that cannot list repositories", func(t *testing.T) {
+		for _, r := range []string{"org:octo-org", "org:ghe.example.com/team"} {
+			_, err := NewSyncer(nil, sources, logger, []string{r}, time.Hour, time.Time{})
+
+			assert.Error(t, err, r)
+		}
+	})
+
+	t.Run("rejects gitlab entries when no gitlab source is configured", func(t *testing.T) {
+		_, err := NewSyncer(nil, map[string]Source{github.DefaultHost: &fakeSource{}}, logger, []string{"gitlab:group/project"}, time.Hour, time.Time{})
+
+		var unknownHost *custom_errors.ErrUnknownHost
+		assert.ErrorAs(t, err, &unknownHost)
+	})
+}
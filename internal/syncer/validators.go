@@ -0,0 +1,48 @@
+// internal/syncer/validators.go
+package syncer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github-data-fetcher/internal/database"
+	"github-data-fetcher/internal/github"
+)
+
+// ValidatorStore persists the GitHub client's cache validators in the http_validators table.
+type ValidatorStore struct {
+	q database.Querier
+}
+
+// NewValidatorStore creates a ValidatorStore backed by q.
+func NewValidatorStore(q database.Querier) *ValidatorStore {
+	return &ValidatorStore{q: q}
+}
+
+// GetValidators implements github.ValidatorStore.
+func (s *ValidatorStore) GetValidators(ctx context.Context, url string) (github.Validators, bool, error) {
+	row, err := s.q.GetHTTPValidators(ctx, url)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return github.Validators{}, false, nil
+	}
+	if err != nil {
+		return github.Validators{}, false, err
+	}
+	return github.Validators{ETag: row.Etag, LastModified: row.LastModified}, true, nil
+}
+
+// SaveValidators implements github.ValidatorStore.
+func (s *ValidatorStore) SaveValidators(ctx context.Context, url string, v github.Validators) error {
+	return s.q.UpsertHTTPValidators(ctx, database.UpsertHTTPValidatorsParams{
+		Url:          url,
+		Etag:         v.ETag,
+		LastModified: v.LastModified,
+	})
+}
+
+// DeleteValidators implements github.ValidatorStore.
+func (s *ValidatorStore) DeleteValidators(ctx context.Context, url string) error {
+	return s.q.DeleteHTTPValidators(ctx, url)
+}
@@ -0,0 +1,2 @@
+-- migrations/000002_create_http_validators.down.sql
+DROP TABLE IF EXISTS http_validators;
@@ -0,0 +1,7 @@
+-- migrations/000002_create_http_validators.up.sql
+CREATE TABLE http_validators (
+                                 url TEXT PRIMARY KEY,
+                                 etag TEXT NOT NULL DEFAULT '',
+                                 last_modified TEXT NOT NULL DEFAULT '',
+                                 updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
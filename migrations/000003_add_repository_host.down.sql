@@ -0,0 +1,6 @@
+-- migrations/000003_add_repository_host.down.sql
+ALTER TABLE repositories DROP CONSTRAINT uq_host_github_repo_id;
+ALTER TABLE repositories DROP CONSTRAINT uq_host_owner_name;
+ALTER TABLE repositories ADD CONSTRAINT repositories_github_repo_id_key UNIQUE (github_repo_id);
+ALTER TABLE repositories ADD CONSTRAINT uq_owner_name UNIQUE (owner, name);
+ALTER TABLE repositories DROP COLUMN host;
@@ -0,0 +1,8 @@
+-- migrations/000003_add_repository_host.up.sql
+ALTER TABLE repositories ADD COLUMN host TEXT NOT NULL DEFAULT 'github.com';
+
+-- The same owner/name, and even the same numeric repository ID, can exist on several hosts.
+ALTER TABLE repositories DROP CONSTRAINT uq_owner_name;
+ALTER TABLE repositories DROP CONSTRAINT repositories_github_repo_id_key;
+ALTER TABLE repositories ADD CONSTRAINT uq_host_owner_name UNIQUE (host, owner, name);
+ALTER TABLE repositories ADD CONSTRAINT uq_host_github_repo_id UNIQUE (host, github_repo_id);
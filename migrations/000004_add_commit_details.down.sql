@@ -0,0 +1,5 @@
+-- migrations/000004_add_commit_details.down.sql
+ALTER TABLE commits DROP COLUMN verified;
+ALTER TABLE commits DROP COLUMN deletions;
+ALTER TABLE commits DROP COLUMN additions;
+ALTER TABLE commits DROP COLUMN author_login;
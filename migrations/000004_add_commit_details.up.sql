@@ -0,0 +1,6 @@
+-- migrations/000004_add_commit_details.up.sql
+-- additions/deletions are only known when commits are fetched through the GraphQL API.
+ALTER TABLE commits ADD COLUMN author_login TEXT NOT NULL DEFAULT '';
+ALTER TABLE commits ADD COLUMN additions INT;
+ALTER TABLE commits ADD COLUMN deletions INT;
+ALTER TABLE commits ADD COLUMN verified BOOLEAN NOT NULL DEFAULT FALSE;
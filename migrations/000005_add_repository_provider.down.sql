@@ -0,0 +1,6 @@
+-- migrations/000005_add_repository_provider.down.sql
+ALTER TABLE repositories DROP CONSTRAINT uq_provider_host_repo_id;
+ALTER TABLE repositories DROP CONSTRAINT uq_provider_host_owner_name;
+ALTER TABLE repositories ADD CONSTRAINT uq_host_github_repo_id UNIQUE (host, github_repo_id);
+ALTER TABLE repositories ADD CONSTRAINT uq_host_owner_name UNIQUE (host, owner, name);
+ALTER TABLE repositories DROP COLUMN provider;
@@ -0,0 +1,7 @@
+-- migrations/000005_add_repository_provider.up.sql
+ALTER TABLE repositories ADD COLUMN provider TEXT NOT NULL DEFAULT 'github';
+
+ALTER TABLE repositories DROP CONSTRAINT uq_host_owner_name;
+ALTER TABLE repositories DROP CONSTRAINT uq_host_github_repo_id;
+ALTER TABLE repositories ADD CONSTRAINT uq_provider_host_owner_name UNIQUE (provider, host, owner, name);
+ALTER TABLE repositories ADD CONSTRAINT uq_provider_host_repo_id UNIQUE (provider, host, github_repo_id);
@@ -0,0 +1,5 @@
+-- migrations/000006_add_commit_parents_and_committer.down.sql
+ALTER TABLE commits DROP COLUMN committer_date;
+ALTER TABLE commits DROP COLUMN committer_email;
+ALTER TABLE commits DROP COLUMN committer_name;
+ALTER TABLE commits DROP COLUMN parents;
@@ -0,0 +1,6 @@
+-- migrations/000006_add_commit_parents_and_committer.up.sql
+-- committer_date is only known when the backend reports it, e.g. the git mirror.
+ALTER TABLE commits ADD COLUMN parents TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE commits ADD COLUMN committer_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE commits ADD COLUMN committer_email TEXT NOT NULL DEFAULT '';
+ALTER TABLE commits ADD COLUMN committer_date TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- migrations/000007_create_sync_checkpoints.down.sql
+DROP TABLE IF EXISTS sync_checkpoints;
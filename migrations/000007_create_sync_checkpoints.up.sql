@@ -0,0 +1,18 @@
+-- migrations/000007_create_sync_checkpoints.up.sql
+-- Progress of a repository's initial commit backfill. The row stays behind once the backfill
+-- completes; a NULL completed_at marks a partially imported repository.
+CREATE TABLE sync_checkpoints (
+                                  repository_id BIGINT PRIMARY KEY,
+                                  since TIMESTAMPTZ NOT NULL,
+                                  page_cursor TEXT NOT NULL DEFAULT '',
+                                  oldest_sha TEXT NOT NULL DEFAULT '',
+                                  oldest_commit_date TIMESTAMPTZ,
+                                  commits_imported BIGINT NOT NULL DEFAULT 0,
+                                  started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                  completed_at TIMESTAMPTZ,
+                                  CONSTRAINT fk_repository
+                                      FOREIGN KEY (repository_id)
+                                          REFERENCES repositories(id)
+                                          ON DELETE CASCADE
+);
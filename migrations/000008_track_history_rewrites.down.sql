@@ -0,0 +1,4 @@
+-- migrations/000008_track_history_rewrites.down.sql
+DROP TABLE IF EXISTS history_rewrites;
+ALTER TABLE commits DROP COLUMN unreachable_at;
+ALTER TABLE repositories DROP COLUMN head_sha;
@@ -0,0 +1,24 @@
+-- migrations/000008_track_history_rewrites.up.sql
+-- head_sha is where the default branch pointed at the last sync, empty until it is known.
+ALTER TABLE repositories ADD COLUMN head_sha TEXT NOT NULL DEFAULT '';
+
+-- Commits a force-push removed from the default branch are kept, but no longer counted.
+ALTER TABLE commits ADD COLUMN unreachable_at TIMESTAMPTZ;
+
+-- A history rewrite is recorded whenever the stored head is no longer an ancestor of the
+-- current one.
+CREATE TABLE history_rewrites (
+                                  id BIGSERIAL PRIMARY KEY,
+                                  repository_id BIGINT NOT NULL,
+                                  old_head_sha TEXT NOT NULL,
+                                  new_head_sha TEXT NOT NULL,
+                                  merge_base_sha TEXT NOT NULL DEFAULT '',
+                                  unreachable_commits BIGINT NOT NULL DEFAULT 0,
+                                  detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                  CONSTRAINT fk_repository
+                                      FOREIGN KEY (repository_id)
+                                          REFERENCES repositories(id)
+                                          ON DELETE CASCADE
+);
+
+CREATE INDEX idx_history_rewrites_repository_id ON history_rewrites(repository_id, detected_at DESC);
@@ -0,0 +1,4 @@
+-- migrations/000009_track_branches.down.sql
+DROP TABLE IF EXISTS commit_branches;
+DROP TABLE IF EXISTS branches;
+ALTER TABLE commits DROP COLUMN on_default_branch;
@@ -0,0 +1,37 @@
+-- migrations/000009_track_branches.up.sql
+-- Commits listed only on other tracked branches must not move the default branch's sync
+-- watermark. Existing commits all came from the default branch.
+ALTER TABLE commits ADD COLUMN on_default_branch BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Branches matching a repository's configured patterns, with where they pointed at the last sync.
+CREATE TABLE branches (
+                          id BIGSERIAL PRIMARY KEY,
+                          repository_id BIGINT NOT NULL,
+                          name TEXT NOT NULL,
+                          head_sha TEXT NOT NULL DEFAULT '',
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          CONSTRAINT uq_repository_branch UNIQUE (repository_id, name),
+                          CONSTRAINT fk_repository
+                              FOREIGN KEY (repository_id)
+                                  REFERENCES repositories(id)
+                                  ON DELETE CASCADE
+);
+
+-- Each commit is stored once in commits and linked to every tracked branch it is on.
+CREATE TABLE commit_branches (
+                                 branch_id BIGINT NOT NULL,
+                                 repository_id BIGINT NOT NULL,
+                                 sha VARCHAR(40) NOT NULL,
+                                 PRIMARY KEY (branch_id, sha),
+                                 CONSTRAINT fk_branch
+                                     FOREIGN KEY (branch_id)
+                                         REFERENCES branches(id)
+                                         ON DELETE CASCADE,
+                                 CONSTRAINT fk_commit
+                                     FOREIGN KEY (repository_id, sha)
+                                         REFERENCES commits(repository_id, sha)
+                                         ON DELETE CASCADE
+);
+
+CREATE INDEX idx_commit_branches_commit ON commit_branches(repository_id, sha);
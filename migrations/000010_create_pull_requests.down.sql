@@ -0,0 +1,3 @@
+-- migrations/000010_create_pull_requests.down.sql
+DROP TABLE IF EXISTS pull_request_reviews;
+DROP TABLE IF EXISTS pull_requests;
@@ -0,0 +1,48 @@
+-- migrations/000010_create_pull_requests.up.sql
+-- state is open, closed (without merging) or merged. Pull requests are synced incrementally,
+-- listing those updated since the newest pr_updated_at stored for the repository.
+CREATE TABLE pull_requests (
+                               id BIGSERIAL PRIMARY KEY,
+                               repository_id BIGINT NOT NULL,
+                               number INT NOT NULL,
+                               github_pr_id BIGINT NOT NULL,
+                               title TEXT NOT NULL,
+                               state VARCHAR(10) NOT NULL,
+                               author_login TEXT NOT NULL DEFAULT '',
+                               base_ref TEXT NOT NULL,
+                               head_ref TEXT NOT NULL,
+                               additions INT NOT NULL DEFAULT 0,
+                               deletions INT NOT NULL DEFAULT 0,
+                               merged_by_login TEXT NOT NULL DEFAULT '',
+                               url TEXT NOT NULL,
+                               pr_created_at TIMESTAMPTZ NOT NULL,
+                               pr_updated_at TIMESTAMPTZ NOT NULL,
+                               merged_at TIMESTAMPTZ,
+                               closed_at TIMESTAMPTZ,
+                               created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                               updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                               CONSTRAINT uq_repository_pull_request UNIQUE (repository_id, number),
+                               CONSTRAINT fk_repository
+                                   FOREIGN KEY (repository_id)
+                                       REFERENCES repositories(id)
+                                       ON DELETE CASCADE
+);
+
+CREATE INDEX idx_pull_requests_updated_at ON pull_requests(repository_id, pr_updated_at DESC);
+CREATE INDEX idx_pull_requests_created_at ON pull_requests(repository_id, pr_created_at DESC);
+
+-- Submitted reviews; state is APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED. Review IDs
+-- are only unique per host, so they are keyed by pull request.
+CREATE TABLE pull_request_reviews (
+                                      pull_request_id BIGINT NOT NULL,
+                                      github_review_id BIGINT NOT NULL,
+                                      reviewer_login TEXT NOT NULL DEFAULT '',
+                                      state VARCHAR(20) NOT NULL,
+                                      commit_sha TEXT NOT NULL DEFAULT '',
+                                      submitted_at TIMESTAMPTZ NOT NULL,
+                                      PRIMARY KEY (pull_request_id, github_review_id),
+                                      CONSTRAINT fk_pull_request
+                                          FOREIGN KEY (pull_request_id)
+                                              REFERENCES pull_requests(id)
+                                              ON DELETE CASCADE
+);
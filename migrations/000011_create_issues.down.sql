@@ -0,0 +1,4 @@
+-- migrations/000011_create_issues.down.sql
+DROP TABLE IF EXISTS issue_comments;
+DROP TABLE IF EXISTS issues;
+DROP TABLE IF EXISTS labels;
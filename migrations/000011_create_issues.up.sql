@@ -0,0 +1,61 @@
+-- migrations/000011_create_issues.up.sql
+-- Label definitions, replaced by the current set on every sync.
+CREATE TABLE labels (
+                        id BIGSERIAL PRIMARY KEY,
+                        repository_id BIGINT NOT NULL,
+                        name TEXT NOT NULL,
+                        color TEXT NOT NULL DEFAULT '',
+                        description TEXT NOT NULL DEFAULT '',
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                        CONSTRAINT uq_repository_label UNIQUE (repository_id, name),
+                        CONSTRAINT fk_repository
+                            FOREIGN KEY (repository_id)
+                                REFERENCES repositories(id)
+                                ON DELETE CASCADE
+);
+
+-- Issues, without pull requests, which GitHub lists as issues too. state is open or closed.
+-- labels and assignees hold label names and user logins. Issues are synced incrementally,
+-- listing those updated since the newest issue_updated_at stored for the repository.
+CREATE TABLE issues (
+                        id BIGSERIAL PRIMARY KEY,
+                        repository_id BIGINT NOT NULL,
+                        number INT NOT NULL,
+                        github_issue_id BIGINT NOT NULL,
+                        title TEXT NOT NULL,
+                        state VARCHAR(10) NOT NULL,
+                        author_login TEXT NOT NULL DEFAULT '',
+                        labels TEXT[] NOT NULL DEFAULT '{}',
+                        assignees TEXT[] NOT NULL DEFAULT '{}',
+                        milestone TEXT NOT NULL DEFAULT '',
+                        comments_count INT NOT NULL DEFAULT 0,
+                        url TEXT NOT NULL,
+                        issue_created_at TIMESTAMPTZ NOT NULL,
+                        issue_updated_at TIMESTAMPTZ NOT NULL,
+                        closed_at TIMESTAMPTZ,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                        CONSTRAINT uq_repository_issue UNIQUE (repository_id, number),
+                        CONSTRAINT fk_repository
+                            FOREIGN KEY (repository_id)
+                                REFERENCES repositories(id)
+                                ON DELETE CASCADE
+);
+
+CREATE INDEX idx_issues_updated_at ON issues(repository_id, issue_updated_at DESC);
+CREATE INDEX idx_issues_created_at ON issues(repository_id, issue_created_at DESC);
+
+-- Comment IDs are only unique per host, so comments are keyed by issue.
+CREATE TABLE issue_comments (
+                                issue_id BIGINT NOT NULL,
+                                github_comment_id BIGINT NOT NULL,
+                                author_login TEXT NOT NULL DEFAULT '',
+                                comment_created_at TIMESTAMPTZ NOT NULL,
+                                comment_updated_at TIMESTAMPTZ NOT NULL,
+                                PRIMARY KEY (issue_id, github_comment_id),
+                                CONSTRAINT fk_issue
+                                    FOREIGN KEY (issue_id)
+                                        REFERENCES issues(id)
+                                        ON DELETE CASCADE
+);
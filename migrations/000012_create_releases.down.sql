@@ -0,0 +1,5 @@
+-- migrations/000012_create_releases.down.sql
+DROP TABLE IF EXISTS release_assets;
+DROP TABLE IF EXISTS releases;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE commits DROP COLUMN first_release;
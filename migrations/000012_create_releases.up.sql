@@ -0,0 +1,53 @@
+-- migrations/000012_create_releases.up.sql
+-- first_release is the name of the earliest tag containing the commit, empty until one does.
+-- Tags are ordered by the date of the commit they point to.
+ALTER TABLE commits ADD COLUMN first_release TEXT NOT NULL DEFAULT '';
+
+-- Tags and the commit each points to, replaced by the current set on every sync.
+CREATE TABLE tags (
+                      repository_id BIGINT NOT NULL,
+                      name TEXT NOT NULL,
+                      sha VARCHAR(40) NOT NULL,
+                      created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                      updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                      PRIMARY KEY (repository_id, name),
+                      CONSTRAINT fk_repository
+                          FOREIGN KEY (repository_id)
+                              REFERENCES repositories(id)
+                              ON DELETE CASCADE
+);
+
+-- Releases, keyed by their GitHub ID as their tag can be renamed. published_at is NULL for drafts.
+CREATE TABLE releases (
+                          id BIGSERIAL PRIMARY KEY,
+                          repository_id BIGINT NOT NULL,
+                          github_release_id BIGINT NOT NULL,
+                          tag_name TEXT NOT NULL,
+                          name TEXT NOT NULL DEFAULT '',
+                          draft BOOLEAN NOT NULL DEFAULT FALSE,
+                          prerelease BOOLEAN NOT NULL DEFAULT FALSE,
+                          author_login TEXT NOT NULL DEFAULT '',
+                          url TEXT NOT NULL,
+                          release_created_at TIMESTAMPTZ NOT NULL,
+                          published_at TIMESTAMPTZ,
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          CONSTRAINT uq_repository_release UNIQUE (repository_id, github_release_id),
+                          CONSTRAINT fk_repository
+                              FOREIGN KEY (repository_id)
+                                  REFERENCES repositories(id)
+                                  ON DELETE CASCADE
+);
+
+CREATE TABLE release_assets (
+                                release_id BIGINT NOT NULL,
+                                github_asset_id BIGINT NOT NULL,
+                                name TEXT NOT NULL,
+                                size BIGINT NOT NULL DEFAULT 0,
+                                download_count BIGINT NOT NULL DEFAULT 0,
+                                PRIMARY KEY (release_id, github_asset_id),
+                                CONSTRAINT fk_release
+                                    FOREIGN KEY (release_id)
+                                        REFERENCES releases(id)
+                                        ON DELETE CASCADE
+);
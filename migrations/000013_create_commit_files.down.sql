@@ -0,0 +1,4 @@
+-- migrations/000013_create_commit_files.down.sql
+DROP TABLE IF EXISTS commit_files;
+DROP INDEX IF EXISTS idx_commits_files_pending;
+ALTER TABLE commits DROP COLUMN files_synced_at;
@@ -0,0 +1,22 @@
+-- migrations/000013_create_commit_files.up.sql
+-- files_synced_at is when the files a commit changed were stored, NULL until the optional
+-- enrichment pass has fetched them.
+ALTER TABLE commits ADD COLUMN files_synced_at TIMESTAMPTZ;
+
+CREATE INDEX idx_commits_files_pending ON commits(repository_id, commit_date DESC) WHERE files_synced_at IS NULL;
+
+CREATE TABLE commit_files (
+                              repository_id BIGINT NOT NULL,
+                              sha VARCHAR(40) NOT NULL,
+                              path TEXT NOT NULL,
+                              status TEXT NOT NULL DEFAULT '',
+                              additions INT NOT NULL DEFAULT 0,
+                              deletions INT NOT NULL DEFAULT 0,
+                              PRIMARY KEY (repository_id, sha, path),
+                              CONSTRAINT fk_commit
+                                  FOREIGN KEY (repository_id, sha)
+                                      REFERENCES commits(repository_id, sha)
+                                      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_commit_files_path ON commit_files(repository_id, path);
@@ -0,0 +1,3 @@
+-- migrations/000014_create_workflow_runs.down.sql
+DROP TABLE IF EXISTS workflow_runs;
+DROP TABLE IF EXISTS workflows;
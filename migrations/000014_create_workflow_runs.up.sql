@@ -0,0 +1,48 @@
+-- migrations/000014_create_workflow_runs.up.sql
+-- GitHub Actions workflows. Workflows removed from a repository are kept, as their runs still
+-- refer to them.
+CREATE TABLE workflows (
+                           repository_id BIGINT NOT NULL,
+                           github_workflow_id BIGINT NOT NULL,
+                           name TEXT NOT NULL,
+                           path TEXT NOT NULL DEFAULT '',
+                           state TEXT NOT NULL DEFAULT '',
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                           updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                           PRIMARY KEY (repository_id, github_workflow_id),
+                           CONSTRAINT fk_repository
+                               FOREIGN KEY (repository_id)
+                                   REFERENCES repositories(id)
+                                   ON DELETE CASCADE
+);
+
+-- Workflow runs, each row holding the latest attempt of a run. Runs are linked to commits by
+-- head_sha rather than a foreign key, as runs on branches that are not synced refer to commits
+-- that are not stored. status is queued, in_progress or completed; conclusion is empty until
+-- the run completes.
+CREATE TABLE workflow_runs (
+                               repository_id BIGINT NOT NULL,
+                               github_run_id BIGINT NOT NULL,
+                               workflow_id BIGINT NOT NULL,
+                               name TEXT NOT NULL DEFAULT '',
+                               event TEXT NOT NULL,
+                               status TEXT NOT NULL,
+                               conclusion TEXT NOT NULL DEFAULT '',
+                               head_sha VARCHAR(40) NOT NULL,
+                               head_branch TEXT NOT NULL DEFAULT '',
+                               run_number INT NOT NULL,
+                               run_attempt INT NOT NULL DEFAULT 1,
+                               run_created_at TIMESTAMPTZ NOT NULL,
+                               run_started_at TIMESTAMPTZ NOT NULL,
+                               run_updated_at TIMESTAMPTZ NOT NULL,
+                               created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                               updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                               PRIMARY KEY (repository_id, github_run_id),
+                               CONSTRAINT fk_workflow
+                                   FOREIGN KEY (repository_id, workflow_id)
+                                       REFERENCES workflows(repository_id, github_workflow_id)
+                                       ON DELETE CASCADE
+);
+
+CREATE INDEX idx_workflow_runs_created_at ON workflow_runs(repository_id, run_created_at DESC);
+CREATE INDEX idx_workflow_runs_head_sha ON workflow_runs(repository_id, head_sha);
@@ -0,0 +1,3 @@
+-- migrations/000015_create_repository_snapshots.down.sql
+DROP TABLE IF EXISTS stargazers;
+DROP TABLE IF EXISTS repository_snapshots;
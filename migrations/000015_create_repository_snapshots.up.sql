@@ -0,0 +1,31 @@
+-- migrations/000015_create_repository_snapshots.up.sql
+-- The counters of a repository as of each sync, which the repositories row only holds the
+-- latest values of.
+CREATE TABLE repository_snapshots (
+                                      repository_id BIGINT NOT NULL,
+                                      captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                      stars_count INT NOT NULL,
+                                      forks_count INT NOT NULL,
+                                      watchers_count INT NOT NULL,
+                                      open_issues_count INT NOT NULL,
+                                      PRIMARY KEY (repository_id, captured_at),
+                                      CONSTRAINT fk_repository
+                                          FOREIGN KEY (repository_id)
+                                              REFERENCES repositories(id)
+                                              ON DELETE CASCADE
+);
+
+-- Who starred a repository and when, backfilled from the stargazers API. Stars taken back are
+-- not listed by the API and so are not stored.
+CREATE TABLE stargazers (
+                            repository_id BIGINT NOT NULL,
+                            login TEXT NOT NULL,
+                            starred_at TIMESTAMPTZ NOT NULL,
+                            PRIMARY KEY (repository_id, login),
+                            CONSTRAINT fk_repository
+                                FOREIGN KEY (repository_id)
+                                    REFERENCES repositories(id)
+                                    ON DELETE CASCADE
+);
+
+CREATE INDEX idx_stargazers_starred_at ON stargazers(repository_id, starred_at);
@@ -0,0 +1,4 @@
+-- migrations/000016_create_repository_languages.down.sql
+DROP TABLE IF EXISTS repository_snapshot_languages;
+DROP TABLE IF EXISTS repository_topics;
+DROP TABLE IF EXISTS repository_languages;
@@ -0,0 +1,41 @@
+-- migrations/000016_create_repository_languages.up.sql
+-- The bytes of code in each language of a repository, as GitHub's linguist counts them. The
+-- language column of repositories only holds the main one.
+CREATE TABLE repository_languages (
+                                      repository_id BIGINT NOT NULL,
+                                      language TEXT NOT NULL,
+                                      bytes BIGINT NOT NULL,
+                                      PRIMARY KEY (repository_id, language),
+                                      CONSTRAINT fk_repository
+                                          FOREIGN KEY (repository_id)
+                                              REFERENCES repositories(id)
+                                              ON DELETE CASCADE
+);
+
+CREATE INDEX idx_repository_languages_language ON repository_languages(language);
+
+-- The topics a repository is tagged with.
+CREATE TABLE repository_topics (
+                                   repository_id BIGINT NOT NULL,
+                                   topic TEXT NOT NULL,
+                                   PRIMARY KEY (repository_id, topic),
+                                   CONSTRAINT fk_repository
+                                       FOREIGN KEY (repository_id)
+                                           REFERENCES repositories(id)
+                                           ON DELETE CASCADE
+);
+
+CREATE INDEX idx_repository_topics_topic ON repository_topics(topic);
+
+-- The languages of a repository as of each snapshot.
+CREATE TABLE repository_snapshot_languages (
+                                               repository_id BIGINT NOT NULL,
+                                               captured_at TIMESTAMPTZ NOT NULL,
+                                               language TEXT NOT NULL,
+                                               bytes BIGINT NOT NULL,
+                                               PRIMARY KEY (repository_id, captured_at, language),
+                                               CONSTRAINT fk_snapshot
+                                                   FOREIGN KEY (repository_id, captured_at)
+                                                       REFERENCES repository_snapshots(repository_id, captured_at)
+                                                       ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- migrations/000017_create_tracked_repositories.down.sql
+DROP TABLE IF EXISTS tracked_repositories;
@@ -0,0 +1,11 @@
+-- migrations/000017_create_tracked_repositories.up.sql
+-- The REPOS_TO_SYNC entries the syncer works through each cycle: repositories by name, and the
+-- org:, user: and wildcard entries repositories are discovered with. REPOS_TO_SYNC seeds it on
+-- startup; the admin API adds, pauses, resumes and removes entries at runtime.
+CREATE TABLE tracked_repositories (
+                                      entry TEXT PRIMARY KEY,
+                                      paused BOOLEAN NOT NULL DEFAULT FALSE,
+                                      added_by TEXT NOT NULL, -- 'config' or 'api'
+                                      created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                      updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
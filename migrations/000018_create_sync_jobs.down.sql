@@ -0,0 +1,2 @@
+-- migrations/000018_create_sync_jobs.down.sql
+DROP TABLE IF EXISTS sync_jobs;
@@ -0,0 +1,15 @@
+-- migrations/000018_create_sync_jobs.up.sql
+-- On-demand syncs of a single repository, requested through the API and run outside the cycle.
+CREATE TABLE sync_jobs (
+                           id BIGSERIAL PRIMARY KEY,
+                           provider TEXT NOT NULL,
+                           host TEXT NOT NULL,
+                           owner TEXT NOT NULL,
+                           name TEXT NOT NULL,
+                           state TEXT NOT NULL DEFAULT 'queued', -- 'queued', 'running', 'succeeded' or 'failed'
+                           commits_inserted BIGINT NOT NULL DEFAULT 0,
+                           error TEXT NOT NULL DEFAULT '',
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                           started_at TIMESTAMPTZ,
+                           finished_at TIMESTAMPTZ
+);
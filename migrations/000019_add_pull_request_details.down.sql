@@ -0,0 +1,2 @@
+-- migrations/000019_add_pull_request_details.down.sql
+ALTER TABLE pull_requests DROP COLUMN details_updated_at;
@@ -0,0 +1,6 @@
+-- migrations/000019_add_pull_request_details.up.sql
+-- details_updated_at is the pr_updated_at at which line stats, who merged the pull request and its
+-- reviews were last fetched; they are fetched again once the pull request is updated after it.
+-- Pull requests stored so far were fetched with their details.
+ALTER TABLE pull_requests ADD COLUMN details_updated_at TIMESTAMPTZ;
+UPDATE pull_requests SET details_updated_at = pr_updated_at;
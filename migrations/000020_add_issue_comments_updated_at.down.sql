@@ -0,0 +1,2 @@
+-- migrations/000020_add_issue_comments_updated_at.down.sql
+ALTER TABLE issues DROP COLUMN comments_updated_at;
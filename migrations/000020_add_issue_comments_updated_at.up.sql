@@ -0,0 +1,6 @@
+-- migrations/000020_add_issue_comments_updated_at.up.sql
+-- comments_updated_at is the issue_updated_at at which the comments of the issue were last listed;
+-- they are listed again once the issue is updated after it. Issues stored so far were listed with
+-- their comments.
+ALTER TABLE issues ADD COLUMN comments_updated_at TIMESTAMPTZ;
+UPDATE issues SET comments_updated_at = issue_updated_at;